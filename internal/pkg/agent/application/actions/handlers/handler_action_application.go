@@ -136,8 +136,16 @@ var (
 //		   }
 //	 }
 func appendActionResponse(action *fleetapi.ActionApp, inputType string, res map[string]interface{}) {
+	if m := actionResponse(inputType, res); m != nil {
+		action.Response = m
+	}
+}
+
+// actionResponse returns the action response property for the action response values
+// excluding the ones specified in excludeActionResponseFields, nil if there are none.
+func actionResponse(key string, res map[string]interface{}) map[string]interface{} {
 	if len(res) == 0 {
-		return
+		return nil
 	}
 
 	m := make(map[string]interface{}, len(res))
@@ -148,12 +156,10 @@ func appendActionResponse(action *fleetapi.ActionApp, inputType string, res map[
 		}
 	}
 
-	if len(m) > 0 {
-		mt := make(map[string]interface{}, 1)
-		mt[inputType] = m
-
-		action.Response = mt
+	if len(m) == 0 {
+		return nil
 	}
+	return map[string]interface{}{key: m}
 }
 
 func readMapString(m map[string]interface{}, key string, def string) string {
@@ -0,0 +1,108 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/elastic/elastic-agent-client/v7/pkg/client"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/coordinator"
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi/acker"
+	"github.com/elastic/elastic-agent/pkg/component"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+)
+
+// CustomAction is a handler for the actions of a type declared in the action_types of a
+// component specification. The actions are performed by the component and acked with its result.
+type CustomAction struct {
+	log   *logger.Logger
+	coord actionCoordinator
+}
+
+// NewCustomAction creates a new CustomAction handler.
+func NewCustomAction(log *logger.Logger, coord actionCoordinator) *CustomAction {
+	return &CustomAction{
+		log:   log,
+		coord: coord,
+	}
+}
+
+// Handle handles custom actions.
+func (h *CustomAction) Handle(ctx context.Context, a fleetapi.Action, acker acker.Acker) error {
+	action, ok := a.(*fleetapi.ActionCustom)
+	if !ok {
+		return fmt.Errorf("invalid type, expected ActionCustom and received %T", a)
+	}
+
+	comp, unit, ok := findUnitFromActionType(h.coord.State(), action.ActionType)
+	if !ok {
+		h.log.Errorf("handlerCustomAction: no running component performs action '%v'", action)
+		now := time.Now().UTC().Format(time.RFC3339Nano)
+		action.StartedAt = now
+		action.CompletedAt = now
+		action.Error = fmt.Sprintf("no running component performs action type %q", action.ActionType)
+		return acker.Ack(ctx, action)
+	}
+	h.log.Debugf("handlerCustomAction: action '%v' routed to unit '%s' of component '%s'", action, unit.ID, comp.ID)
+
+	params, err := action.MarshalMap()
+	if err != nil {
+		return err
+	}
+
+	start := time.Now().UTC()
+	timeout := defaultActionTimeout
+	if action.Timeout > 0 {
+		timeout = time.Duration(action.Timeout) * time.Second
+		if timeout > maxActionTimeout {
+			h.log.Debugf("handlerCustomAction: action '%v' timeout exceeds maximum allowed %v", action.ActionType, maxActionTimeout)
+			err = errActionTimeoutInvalid
+		}
+	}
+
+	var res map[string]interface{}
+	if err == nil {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		res, err = h.coord.PerformAction(ctx, comp, unit, action.ActionType, params)
+	}
+	end := time.Now().UTC()
+
+	startFormatted := start.Format(time.RFC3339Nano)
+	endFormatted := end.Format(time.RFC3339Nano)
+	h.log.Debugf("handlerCustomAction: action '%v' finished, startFormatted: %v, endFormatted: %v, err: %v", action.ActionType, startFormatted, endFormatted, err)
+	if err != nil {
+		action.StartedAt = startFormatted
+		action.CompletedAt = endFormatted
+		action.Error = err.Error()
+	} else {
+		action.StartedAt = readMapString(res, "started_at", startFormatted)
+		action.CompletedAt = readMapString(res, "completed_at", endFormatted)
+		action.Error = readMapString(res, "error", "")
+		action.Response = actionResponse(action.ActionType, res)
+	}
+
+	return acker.Ack(ctx, action)
+}
+
+// findUnitFromActionType returns the first input unit of a component declaring actionType
+// in the action_types of its specification.
+func findUnitFromActionType(state coordinator.State, actionType string) (component.Component, component.Unit, bool) {
+	for _, comp := range state.Components {
+		if comp.Component.InputSpec == nil || !contains(comp.Component.InputSpec.Spec.ActionTypes, actionType) {
+			continue
+		}
+		for _, unit := range comp.Component.Units {
+			if unit.Type == client.UnitTypeInput && unit.Config != nil {
+				return comp.Component, unit, true
+			}
+		}
+	}
+	return component.Component{}, component.Unit{}, false
+}
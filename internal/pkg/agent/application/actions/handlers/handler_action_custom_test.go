@@ -0,0 +1,138 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-client/v7/pkg/client"
+	"github.com/elastic/elastic-agent-client/v7/pkg/proto"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/actions/handlers/mocks"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/coordinator"
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
+	"github.com/elastic/elastic-agent/pkg/component"
+	"github.com/elastic/elastic-agent/pkg/component/runtime"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+)
+
+type fakeActionCoordinator struct {
+	state coordinator.State
+
+	performed []string
+	params    map[string]interface{}
+	result    map[string]interface{}
+	err       error
+}
+
+func (c *fakeActionCoordinator) State() coordinator.State {
+	return c.state
+}
+
+func (c *fakeActionCoordinator) PerformAction(_ context.Context, comp component.Component, unit component.Unit, name string, params map[string]interface{}) (map[string]interface{}, error) {
+	c.performed = append(c.performed, comp.ID+"/"+unit.ID+"/"+name)
+	c.params = params
+	return c.result, c.err
+}
+
+func TestCustomActionHandler(t *testing.T) {
+	state := coordinator.State{
+		Components: []runtime.ComponentComponentState{
+			{
+				Component: component.Component{
+					ID:        "log-default",
+					InputType: "log",
+					InputSpec: &component.InputRuntimeSpec{InputType: "log"},
+					Units: []component.Unit{
+						{ID: "log-default-logfile", Type: client.UnitTypeInput, Config: &proto.UnitExpectedConfig{Type: "log"}},
+					},
+				},
+			},
+			{
+				Component: component.Component{
+					ID:        "custom-default",
+					InputType: "custom",
+					InputSpec: &component.InputRuntimeSpec{
+						InputType: "custom",
+						Spec:      component.InputSpec{ActionTypes: []string{"CUSTOM_RESTART"}},
+					},
+					Units: []component.Unit{
+						{ID: "custom-default", Type: client.UnitTypeOutput, Config: &proto.UnitExpectedConfig{Type: "elasticsearch"}},
+						{ID: "custom-default-custom", Type: client.UnitTypeInput, Config: &proto.UnitExpectedConfig{Type: "custom"}},
+					},
+				},
+			},
+		},
+	}
+
+	parse := func(t *testing.T, raw string) *fleetapi.ActionCustom {
+		var actions fleetapi.Actions
+		require.NoError(t, json.Unmarshal([]byte(raw), &actions))
+		require.Len(t, actions, 1)
+		unknown, ok := actions[0].(*fleetapi.ActionUnknown)
+		require.True(t, ok, "expected an unknown action, got %T", actions[0])
+		return unknown.AsCustom()
+	}
+
+	t.Run("declared action type is performed by the component", func(t *testing.T) {
+		coord := &fakeActionCoordinator{
+			state:  state,
+			result: map[string]interface{}{"restarted": true, "completed_at": "2023-01-01T00:00:00Z"},
+		}
+		acker := mocks.NewAcker(t)
+		acker.EXPECT().Ack(mock.Anything, mock.Anything).Run(func(_ context.Context, a fleetapi.Action) {
+			action, ok := a.(*fleetapi.ActionCustom)
+			require.True(t, ok, "expected a custom action to be acked, got %T", a)
+			ack := action.AckEvent()
+			assert.Equal(t, "action-1", ack.ActionID)
+			assert.Empty(t, ack.Error)
+			assert.Equal(t, "2023-01-01T00:00:00Z", ack.CompletedAt)
+			assert.Equal(t, map[string]interface{}{"CUSTOM_RESTART": map[string]interface{}{"restarted": true}}, ack.ActionResponse)
+		}).Return(nil).Once()
+
+		log, _ := logger.NewTesting("testing")
+		h := NewCustomAction(log, coord)
+		err := h.Handle(context.Background(), parse(t, `[{"id": "action-1", "type": "CUSTOM_RESTART", "data": {"service": "foo"}}]`), acker)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"custom-default/custom-default-custom/CUSTOM_RESTART"}, coord.performed)
+		assert.Equal(t, "action-1", coord.params["id"])
+		assert.JSONEq(t, `{"service": "foo"}`, string(coord.params["data"].(json.RawMessage)))
+	})
+
+	t.Run("component error is acked", func(t *testing.T) {
+		coord := &fakeActionCoordinator{state: state, err: errors.New("unit failed")}
+		acker := mocks.NewAcker(t)
+		acker.EXPECT().Ack(mock.Anything, mock.Anything).Run(func(_ context.Context, a fleetapi.Action) {
+			assert.Equal(t, "unit failed", a.AckEvent().Error)
+		}).Return(nil).Once()
+
+		log, _ := logger.NewTesting("testing")
+		h := NewCustomAction(log, coord)
+		err := h.Handle(context.Background(), parse(t, `[{"id": "action-2", "type": "CUSTOM_RESTART"}]`), acker)
+		require.NoError(t, err)
+	})
+
+	t.Run("action type without a running component is acked with an error", func(t *testing.T) {
+		coord := &fakeActionCoordinator{state: state}
+		acker := mocks.NewAcker(t)
+		acker.EXPECT().Ack(mock.Anything, mock.Anything).Run(func(_ context.Context, a fleetapi.Action) {
+			assert.Equal(t, `no running component performs action type "CUSTOM_STOP"`, a.AckEvent().Error)
+		}).Return(nil).Once()
+
+		log, _ := logger.NewTesting("testing")
+		h := NewCustomAction(log, coord)
+		err := h.Handle(context.Background(), parse(t, `[{"id": "action-3", "type": "CUSTOM_STOP"}]`), acker)
+		require.NoError(t, err)
+		assert.Empty(t, coord.performed)
+	})
+}
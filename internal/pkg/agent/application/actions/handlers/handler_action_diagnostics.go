@@ -7,9 +7,11 @@ package handlers
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/elastic/elastic-agent/pkg/component/runtime"
@@ -41,6 +43,13 @@ type Uploader interface {
 	UploadDiagnostics(context.Context, string, string, int64, io.Reader) (string, error)
 }
 
+// resumableUploader is implemented by the Uploader able to resume an interrupted upload.
+type resumableUploader interface {
+	// BundleFile returns the file the diagnostics bundle of the action is kept in until
+	// uploaded, and whether it holds the bundle of an interrupted upload to resume.
+	BundleFile(actionID string) (string, bool)
+}
+
 // diagnosticsProvider abstracts the source of the diagnostic data
 type diagnosticsProvider interface {
 	DiagnosticHooks() diagnostics.Hooks
@@ -66,15 +75,18 @@ type Diagnostics struct {
 	log          abstractLogger
 	diagProvider diagnosticsProvider
 	limiter      *rate.Limiter
+	redaction    func() config.Redaction
 	uploader     Uploader
 }
 
-// NewDiagnostics returns a new Diagnostics handler.
-func NewDiagnostics(log abstractLogger, coord diagnosticsProvider, cfg config.Limit, uploader Uploader) *Diagnostics {
+// NewDiagnostics returns a new Diagnostics handler. The bundles are redacted with the
+// redaction policy returned by redaction when they are collected.
+func NewDiagnostics(log abstractLogger, coord diagnosticsProvider, cfg config.Limit, redaction func() config.Redaction, uploader Uploader) *Diagnostics {
 	return &Diagnostics{
 		log:          log,
 		diagProvider: coord,
 		limiter:      rate.NewLimiter(rate.Every(cfg.Interval), cfg.Burst),
+		redaction:    redaction,
 		uploader:     uploader,
 	}
 }
@@ -126,53 +138,72 @@ func (h *Diagnostics) collectDiag(ctx context.Context, action *fleetapi.ActionDi
 		return
 	}
 
-	h.log.Debug("Gathering agent diagnostics.")
-	aDiag, err := h.runHooks(ctx, action)
-	if err != nil {
-		action.Err = err
-		h.log.Errorw("diagnostics action handler failed to run diagnostics hooks",
-			"error.message", err,
-			"action", action)
-		return
+	// the bundle of an upload interrupted by a restart of the agent is kept to be resumed
+	var bundlePath string
+	var resume bool
+	if ru, ok := h.uploader.(resumableUploader); ok {
+		bundlePath, resume = ru.BundleFile(action.ActionID)
 	}
-	h.log.Debug("Gathering unit diagnostics.")
-	uDiag := h.diagUnits(ctx)
-
-	h.log.Debug("Gathering component diagnostics.")
-	cDiag := h.diagComponents(ctx, action)
 
 	var r io.Reader
-	// attempt to create the a temporary diagnostics file on disk in order to avoid loading a
-	// potentially large file in memory.
-	// if on-disk creation fails an in-memory buffer is used.
-	f, s, err := h.diagFile(aDiag, uDiag, cDiag)
-	if err != nil {
-		var b bytes.Buffer
-		h.log.Warnw("Diagnostics action unable to use temporary file, using buffer instead.", "error.message", err)
-		var wBuf bytes.Buffer
-		defer func() {
-			if str := wBuf.String(); str != "" {
-				h.log.Warn(str)
-			}
-		}()
-		err := diagnostics.ZipArchive(&wBuf, &b, aDiag, uDiag, cDiag)
+	var s int64
+	if resume {
+		f, size, err := openBundle(bundlePath)
+		if err != nil {
+			h.log.Warnw("Diagnostics action unable to resume the upload of the diagnostics bundle.", "error.message", err)
+			resume = false
+		} else {
+			h.log.Infof("Resuming the upload of the diagnostics bundle of action %s.", action.ActionID)
+			r, s = f, size
+			defer closeBundle(f, bundlePath != "", &action.Err)
+		}
+	}
+
+	if !resume {
+		h.log.Debug("Gathering agent diagnostics.")
+		aDiag, err := h.runHooks(ctx, action)
 		if err != nil {
-			h.log.Errorw(
-				"diagnostics action handler failed generate zip archive",
-				"error.message", err,
-				"action", action,
-			)
 			action.Err = err
+			h.log.Errorw("diagnostics action handler failed to run diagnostics hooks",
+				"error.message", err,
+				"action", action)
 			return
 		}
-		r = &b
-		s = int64(b.Len())
-	} else {
-		defer func() {
-			f.Close()
-			os.Remove(f.Name())
-		}()
-		r = f
+		h.log.Debug("Gathering unit diagnostics.")
+		uDiag := h.diagUnits(ctx)
+
+		h.log.Debug("Gathering component diagnostics.")
+		cDiag := h.diagComponents(ctx, action)
+
+		// attempt to create the a temporary diagnostics file on disk in order to avoid loading a
+		// potentially large file in memory.
+		// if on-disk creation fails an in-memory buffer is used.
+		f, size, err := h.diagFile(bundlePath, aDiag, uDiag, cDiag)
+		if err != nil {
+			var b bytes.Buffer
+			h.log.Warnw("Diagnostics action unable to use temporary file, using buffer instead.", "error.message", err)
+			var wBuf bytes.Buffer
+			defer func() {
+				if str := wBuf.String(); str != "" {
+					h.log.Warn(str)
+				}
+			}()
+			err := diagnostics.ZipArchive(&wBuf, &b, h.redaction(), aDiag, uDiag, cDiag)
+			if err != nil {
+				h.log.Errorw(
+					"diagnostics action handler failed generate zip archive",
+					"error.message", err,
+					"action", action,
+				)
+				action.Err = err
+				return
+			}
+			r = &b
+			s = int64(b.Len())
+		} else {
+			defer closeBundle(f, bundlePath != "", &action.Err)
+			r, s = f, size
+		}
 	}
 	h.log.Debug("Sending diagnostics archive.")
 	uploadID, err := h.uploader.UploadDiagnostics(ctx, action.ActionID, ts.Format("2006-01-02T15-04-05Z07-00"), s, r) // RFC3339 format that uses - instead of : so it works on Windows
@@ -189,6 +220,21 @@ func (h *Diagnostics) collectDiag(ctx context.Context, action *fleetapi.ActionDi
 	h.log.Debugw(fmt.Sprintf("Diagnostics action complete. Took %s", elapsed), "action", action, "elapsed", elapsed)
 }
 
+// WriteBundle collects a diagnostics bundle with the additional metrics, as requested by a
+// REQUEST_DIAGNOSTICS action, and the agent files, then writes it to path. The returned file
+// is ready to be read.
+func (h *Diagnostics) WriteBundle(ctx context.Context, path string, additionalMetrics []string, files ...client.DiagnosticFileResult) (*os.File, int64, error) {
+	action := &fleetapi.ActionDiagnostics{AdditionalMetrics: additionalMetrics}
+	aDiag, err := h.runHooks(ctx, action)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to run diagnostics hooks: %w", err)
+	}
+	aDiag = append(aDiag, files...)
+	uDiag := h.diagUnits(ctx)
+	cDiag := h.diagComponents(ctx, action)
+	return h.diagFile(path, aDiag, uDiag, cDiag)
+}
+
 // runHooks runs the agent diagnostics hooks.
 func (h *Diagnostics) runHooks(ctx context.Context, action *fleetapi.ActionDiagnostics) ([]client.DiagnosticFileResult, error) {
 	hooks := append(h.diagProvider.DiagnosticHooks(), diagnostics.GlobalHooks()...)
@@ -230,16 +276,18 @@ func (h *Diagnostics) runHooks(ctx context.Context, action *fleetapi.ActionDiagn
 	if collectCPU {
 		p, err := getCPUDiag(ctx, diagnostics.DiagCPUDuration)
 		if err != nil {
-			return diags, fmt.Errorf("unable to gather CPU profile: %w", err)
+			// the bundle is still useful without the CPU profile
+			h.log.Warnw("Diagnostics collected without CPU profile, unable to gather it", "error.message", err)
+		} else {
+			diags = append(diags, client.DiagnosticFileResult{
+				Name:        diagnostics.DiagCPUName,
+				Filename:    diagnostics.DiagCPUFilename,
+				Description: diagnostics.DiagCPUDescription,
+				ContentType: diagnostics.DiagCPUContentType,
+				Content:     p,
+				Generated:   time.Now().UTC(),
+			})
 		}
-		diags = append(diags, client.DiagnosticFileResult{
-			Name:        diagnostics.DiagCPUName,
-			Filename:    diagnostics.DiagCPUFilename,
-			Description: diagnostics.DiagCPUDescription,
-			ContentType: diagnostics.DiagCPUContentType,
-			Content:     p,
-			Generated:   time.Now().UTC(),
-		})
 	}
 	return diags, nil
 }
@@ -325,9 +373,43 @@ func (h *Diagnostics) diagComponents(ctx context.Context, action *fleetapi.Actio
 	return cDiag
 }
 
+// openBundle opens the diagnostics bundle of an interrupted upload.
+func openBundle(path string) (*os.File, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	return f, fi.Size(), nil
+}
+
+// closeBundle closes and removes the diagnostics bundle, unless resumable and its upload was
+// interrupted by the cancellation of the context, then it's kept for the next attempt.
+func closeBundle(f *os.File, resumable bool, errp *error) {
+	f.Close()
+	if resumable && (errors.Is(*errp, context.Canceled) || errors.Is(*errp, context.DeadlineExceeded)) {
+		return
+	}
+	os.Remove(f.Name())
+}
+
 // diagFile will write the diagnostics to a temporary file and return the file ready to be read
-func (h *Diagnostics) diagFile(aDiag []client.DiagnosticFileResult, uDiag []client.DiagnosticUnitResult, cDiag []client.DiagnosticComponentResult) (*os.File, int64, error) {
-	f, err := os.CreateTemp("", "elastic-agent-diagnostics")
+//
+// The bundle is written to path, or to a temporary file when path is empty.
+func (h *Diagnostics) diagFile(path string, aDiag []client.DiagnosticFileResult, uDiag []client.DiagnosticUnitResult, cDiag []client.DiagnosticComponentResult) (*os.File, int64, error) {
+	var f *os.File
+	var err error
+	if path == "" {
+		f, err = os.CreateTemp("", "elastic-agent-diagnostics")
+	} else {
+		if err = os.MkdirAll(filepath.Dir(path), 0o700); err == nil {
+			f, err = os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0o600)
+		}
+	}
 	if err != nil {
 		return nil, 0, err
 	}
@@ -339,7 +421,7 @@ func (h *Diagnostics) diagFile(aDiag []client.DiagnosticFileResult, uDiag []clie
 			h.log.Warn(str)
 		}
 	}()
-	if err := diagnostics.ZipArchive(&wBuf, f, aDiag, uDiag, cDiag); err != nil {
+	if err := diagnostics.ZipArchive(&wBuf, f, h.redaction(), aDiag, uDiag, cDiag); err != nil {
 		os.Remove(name)
 		return nil, 0, err
 	}
@@ -6,8 +6,10 @@ package handlers
 
 import (
 	"context"
+	"io"
 	"os"
 	"path"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -40,6 +42,10 @@ var defaultRateLimit config.Limit = config.Limit{
 	Burst:    10,
 }
 
+var defaultRedaction = config.DefaultConfig().Diagnostics.Redaction
+
+func defaultRedactionFn() config.Redaction { return defaultRedaction }
+
 var hook1 diagnostics.Hook = diagnostics.Hook{
 	Name:        "hook1",
 	Filename:    "hook1.yaml",
@@ -87,7 +93,7 @@ func TestDiagnosticHandlerHappyPathWithLogs(t *testing.T) {
 	mockDiagProvider := mocks.NewDiagnosticsProvider(t)
 	mockUploader := mocks.NewUploader(t)
 	testLogger, observedLogs := logger.NewTesting("diagnostic-handler-test")
-	handler := NewDiagnostics(testLogger, mockDiagProvider, defaultRateLimit, mockUploader)
+	handler := NewDiagnostics(testLogger, mockDiagProvider, defaultRateLimit, defaultRedactionFn, mockUploader)
 
 	mockDiagProvider.EXPECT().DiagnosticHooks().Return([]diagnostics.Hook{hook1})
 	mockDiagProvider.EXPECT().PerformDiagnostics(mock.Anything, mock.Anything).Return([]runtime.ComponentUnitDiagnostic{mockUnitDiagnostic})
@@ -168,7 +174,7 @@ func TestDiagnosticHandlerUploaderErrorWithLogs(t *testing.T) {
 	mockDiagProvider := mocks.NewDiagnosticsProvider(t)
 	mockUploader := mocks.NewUploader(t)
 	testLogger, observedLogs := logger.NewTesting("diagnostic-handler-test")
-	handler := NewDiagnostics(testLogger, mockDiagProvider, defaultRateLimit, mockUploader)
+	handler := NewDiagnostics(testLogger, mockDiagProvider, defaultRateLimit, defaultRedactionFn, mockUploader)
 
 	mockDiagProvider.EXPECT().DiagnosticHooks().Return([]diagnostics.Hook{})
 	mockDiagProvider.EXPECT().PerformDiagnostics(mock.Anything, mock.Anything).Return([]runtime.ComponentUnitDiagnostic{})
@@ -209,7 +215,7 @@ func TestDiagnosticHandlerZipArchiveErrorWithLogs(t *testing.T) {
 	mockDiagProvider := mocks.NewDiagnosticsProvider(t)
 	mockUploader := mocks.NewUploader(t)
 	testLogger, observedLogs := logger.NewTesting("diagnostic-handler-test")
-	handler := NewDiagnostics(testLogger, mockDiagProvider, defaultRateLimit, mockUploader)
+	handler := NewDiagnostics(testLogger, mockDiagProvider, defaultRateLimit, defaultRedactionFn, mockUploader)
 
 	mockDiagProvider.EXPECT().DiagnosticHooks().Return([]diagnostics.Hook{})
 	mockDiagProvider.EXPECT().PerformDiagnostics(mock.Anything, mock.Anything).Return([]runtime.ComponentUnitDiagnostic{})
@@ -245,7 +251,7 @@ func TestDiagnosticHandlerAckErrorWithLogs(t *testing.T) {
 	mockDiagProvider := mocks.NewDiagnosticsProvider(t)
 	mockUploader := mocks.NewUploader(t)
 	testLogger, observedLogs := logger.NewTesting("diagnostic-handler-test")
-	handler := NewDiagnostics(testLogger, mockDiagProvider, defaultRateLimit, mockUploader)
+	handler := NewDiagnostics(testLogger, mockDiagProvider, defaultRateLimit, defaultRedactionFn, mockUploader)
 
 	mockDiagProvider.EXPECT().DiagnosticHooks().Return([]diagnostics.Hook{})
 	mockDiagProvider.EXPECT().PerformDiagnostics(mock.Anything, mock.Anything).Return([]runtime.ComponentUnitDiagnostic{})
@@ -284,7 +290,7 @@ func TestDiagnosticHandlerCommitErrorWithLogs(t *testing.T) {
 	mockDiagProvider := mocks.NewDiagnosticsProvider(t)
 	mockUploader := mocks.NewUploader(t)
 	testLogger, observedLogs := logger.NewTesting("diagnostic-handler-test")
-	handler := NewDiagnostics(testLogger, mockDiagProvider, defaultRateLimit, mockUploader)
+	handler := NewDiagnostics(testLogger, mockDiagProvider, defaultRateLimit, defaultRedactionFn, mockUploader)
 
 	mockDiagProvider.EXPECT().DiagnosticHooks().Return([]diagnostics.Hook{})
 	mockDiagProvider.EXPECT().PerformDiagnostics(mock.Anything, mock.Anything).Return([]runtime.ComponentUnitDiagnostic{})
@@ -324,7 +330,7 @@ func TestDiagnosticHandlerContexteExpiredErrorWithLogs(t *testing.T) {
 	mockDiagProvider := mocks.NewDiagnosticsProvider(t)
 	mockUploader := mocks.NewUploader(t)
 	testLogger, observedLogs := logger.NewTesting("diagnostic-handler-test")
-	handler := NewDiagnostics(testLogger, mockDiagProvider, defaultRateLimit, mockUploader)
+	handler := NewDiagnostics(testLogger, mockDiagProvider, defaultRateLimit, defaultRedactionFn, mockUploader)
 
 	mockDiagProvider.EXPECT().DiagnosticHooks().Return([]diagnostics.Hook{})
 
@@ -368,7 +374,7 @@ func TestDiagnosticHandlerWithCPUProfile(t *testing.T) {
 	mockDiagProvider := mocks.NewDiagnosticsProvider(t)
 	mockUploader := mocks.NewUploader(t)
 	testLogger, _ := logger.NewTesting("diagnostic-handler-test")
-	handler := NewDiagnostics(testLogger, mockDiagProvider, defaultRateLimit, mockUploader)
+	handler := NewDiagnostics(testLogger, mockDiagProvider, defaultRateLimit, defaultRedactionFn, mockUploader)
 
 	mockDiagProvider.EXPECT().DiagnosticHooks().Return([]diagnostics.Hook{hook1})
 	mockDiagProvider.EXPECT().PerformDiagnostics(mock.Anything, mock.Anything).Return([]runtime.ComponentUnitDiagnostic{mockUnitDiagnostic})
@@ -402,3 +408,94 @@ func TestDiagnosticHandlerWithCPUProfile(t *testing.T) {
 	assert.True(t, cpuCalled, "CPU profile collector was not called.")
 	mockDiagProvider.AssertExpectations(t)
 }
+
+func TestDiagnosticHandlerWithoutCPUProfile(t *testing.T) {
+	defaultGetCPUDiag := getCPUDiag
+	defer func() { getCPUDiag = defaultGetCPUDiag }()
+	getCPUDiag = func(_ context.Context, _ time.Duration) ([]byte, error) {
+		return nil, errors.New("cpu profiling already in use")
+	}
+
+	mockDiagProvider := mocks.NewDiagnosticsProvider(t)
+	testLogger, _ := logger.NewTesting("diagnostic-handler-test")
+	handler := NewDiagnostics(testLogger, mockDiagProvider, defaultRateLimit, defaultRedactionFn, mocks.NewUploader(t))
+	mockDiagProvider.EXPECT().DiagnosticHooks().Return([]diagnostics.Hook{hook1})
+
+	diags, err := handler.runHooks(context.Background(), &fleetapi.ActionDiagnostics{AdditionalMetrics: []string{"CPU"}})
+	require.NoError(t, err, "the diagnostics are collected without the CPU profile")
+	for _, d := range diags {
+		assert.NotEqual(t, diagnostics.DiagCPUFilename, d.Filename)
+	}
+	assert.NotEmpty(t, diags)
+}
+
+// resumableMockUploader is an Uploader keeping the diagnostics bundles to resume interrupted uploads.
+type resumableMockUploader struct {
+	*mocks.Uploader
+	bundlePath string
+	pending    bool
+}
+
+func (u *resumableMockUploader) BundleFile(string) (string, bool) {
+	return u.bundlePath, u.pending
+}
+
+func TestDiagnosticHandlerResumesInterruptedUpload(t *testing.T) {
+	bundle := []byte("interrupted diagnostics bundle")
+	bundlePath := filepath.Join(t.TempDir(), "diagnostics-upload.zip")
+	require.NoError(t, os.WriteFile(bundlePath, bundle, 0o600))
+
+	// the diagnostics are not collected again
+	mockDiagProvider := mocks.NewDiagnosticsProvider(t)
+	uploader := &resumableMockUploader{Uploader: mocks.NewUploader(t), bundlePath: bundlePath, pending: true}
+	testLogger, _ := logger.NewTesting("diagnostic-handler-test")
+	handler := NewDiagnostics(testLogger, mockDiagProvider, defaultRateLimit, defaultRedactionFn, uploader)
+
+	mockAcker := mocks.NewAcker(t)
+	mockAcker.EXPECT().Ack(mock.Anything, mock.Anything).Return(nil)
+	mockAcker.EXPECT().Commit(mock.Anything).Return(nil)
+
+	uploader.EXPECT().UploadDiagnostics(mock.Anything, "diagnostics-action", mock.Anything, int64(len(bundle)), mock.Anything).
+		RunAndReturn(func(_ context.Context, _ string, _ string, _ int64, r io.Reader) (string, error) {
+			b, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, bundle, b)
+			return "upload-id", nil
+		})
+
+	diagAction := &fleetapi.ActionDiagnostics{ActionID: "diagnostics-action"}
+	handler.collectDiag(context.Background(), diagAction, mockAcker)
+
+	assert.NoError(t, diagAction.Err)
+	assert.Equal(t, "upload-id", diagAction.UploadID)
+	assert.NoFileExists(t, bundlePath, "the bundle is removed once uploaded")
+}
+
+func TestDiagnosticHandlerKeepsBundleOfInterruptedUpload(t *testing.T) {
+	tempAgentRoot := t.TempDir()
+	paths.SetTop(tempAgentRoot)
+	err := os.MkdirAll(path.Join(tempAgentRoot, "data"), 0755)
+	require.NoError(t, err)
+	bundlePath := filepath.Join(t.TempDir(), "diagnostics-upload.zip")
+
+	mockDiagProvider := mocks.NewDiagnosticsProvider(t)
+	uploader := &resumableMockUploader{Uploader: mocks.NewUploader(t), bundlePath: bundlePath}
+	testLogger, _ := logger.NewTesting("diagnostic-handler-test")
+	handler := NewDiagnostics(testLogger, mockDiagProvider, defaultRateLimit, defaultRedactionFn, uploader)
+
+	mockDiagProvider.EXPECT().DiagnosticHooks().Return([]diagnostics.Hook{})
+	mockDiagProvider.EXPECT().PerformDiagnostics(mock.Anything, mock.Anything).Return([]runtime.ComponentUnitDiagnostic{})
+	mockDiagProvider.EXPECT().PerformComponentDiagnostics(mock.Anything, mock.Anything).Return([]runtime.ComponentDiagnostic{}, nil)
+
+	mockAcker := mocks.NewAcker(t)
+	mockAcker.EXPECT().Ack(mock.Anything, mock.Anything).Return(nil)
+	mockAcker.EXPECT().Commit(mock.Anything).Return(nil)
+
+	uploader.EXPECT().UploadDiagnostics(mock.Anything, "diagnostics-action", mock.Anything, mock.Anything, mock.Anything).Return("upload-id", context.Canceled)
+
+	diagAction := &fleetapi.ActionDiagnostics{ActionID: "diagnostics-action"}
+	handler.collectDiag(context.Background(), diagAction, mockAcker)
+
+	assert.ErrorIs(t, diagAction.Err, context.Canceled)
+	assert.FileExists(t, bundlePath, "the bundle is kept to resume the upload")
+}
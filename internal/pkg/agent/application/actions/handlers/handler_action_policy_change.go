@@ -7,6 +7,7 @@ package handlers
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"fmt"
 	"io"
 	"net/http"
@@ -21,6 +22,7 @@ import (
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/info"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/configuration"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/errors"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/protection"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/storage"
 	"github.com/elastic/elastic-agent/internal/pkg/config"
 	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
@@ -43,6 +45,9 @@ type PolicyChangeHandler struct {
 	ch        chan coordinator.ConfigChange
 	setters   []actions.ClientSetter
 
+	// clientConfig completes the configuration of the recreated Fleet client, e.g. with the client certificate
+	clientConfig func(remote.Config) remote.Config
+
 	// Disabled for 8.8.0 release in order to limit the surface
 	// https://github.com/elastic/security-team/issues/6501
 	// // Last known valid signature validation key
@@ -77,6 +82,12 @@ func (h *PolicyChangeHandler) AddSetter(cs actions.ClientSetter) {
 	h.setters = append(h.setters, cs)
 }
 
+// SetClientConfig sets the function completing the configuration of the Fleet client created
+// when the Fleet Server hosts change.
+func (h *PolicyChangeHandler) SetClientConfig(fn func(remote.Config) remote.Config) {
+	h.clientConfig = fn
+}
+
 // Handle handles policy change action.
 func (h *PolicyChangeHandler) Handle(ctx context.Context, a fleetapi.Action, acker acker.Acker) error {
 	h.log.Debugf("handlerPolicyChange: action '%+v' received", a)
@@ -98,6 +109,10 @@ func (h *PolicyChangeHandler) Handle(ctx context.Context, a fleetapi.Action, ack
 	// // Cache signature validation key for the next policy handling
 	// h.signatureValidationKey = signatureValidationKey
 
+	if err := h.validateFullPolicySignature(action.Policy); err != nil {
+		return errors.New(err, "could not validate the full policy signature", errors.TypeConfig)
+	}
+
 	c, err := config.NewConfigFrom(action.Policy)
 	if err != nil {
 		return errors.New(err, "could not parse the configuration from the policy", errors.TypeConfig)
@@ -113,6 +128,42 @@ func (h *PolicyChangeHandler) Handle(ctx context.Context, a fleetapi.Action, ack
 	return nil
 }
 
+// validateFullPolicySignature rejects the policy unless its signature covers the full policy
+// document, when required by the local configuration. The key rotated by the policy is persisted
+// with the Fleet configuration of the agent, so it validates the next policies after a restart.
+func (h *PolicyChangeHandler) validateFullPolicySignature(policy map[string]interface{}) error {
+	if h.config.Settings == nil || h.config.Settings.PolicySignature == nil || !h.config.Settings.PolicySignature.RequireFull {
+		return nil
+	}
+
+	signingKey := h.config.Settings.PolicySignature.SigningKey
+	key, err := base64.StdEncoding.DecodeString(signingKey)
+	if err != nil {
+		return fmt.Errorf("failed decoding the signing key: %w", err)
+	}
+
+	key, err = protection.ValidateFullPolicySignature(h.log, policy, key)
+	if err != nil {
+		return err
+	}
+
+	rotatedKey := base64.StdEncoding.EncodeToString(key)
+	if rotatedKey == signingKey {
+		return nil
+	}
+	h.config.Settings.PolicySignature.SigningKey = rotatedKey
+	reader, err := fleetToReader(h.agentInfo, h.config)
+	if err == nil {
+		err = h.store.Save(reader)
+	}
+	if err != nil {
+		h.config.Settings.PolicySignature.SigningKey = signingKey
+		return fmt.Errorf("failed persisting the rotated signing key: %w", err)
+	}
+	h.log.Info("Policy signing key rotated")
+	return nil
+}
+
 // Watch returns the channel for configuration change notifications.
 func (h *PolicyChangeHandler) Watch() <-chan coordinator.ConfigChange {
 	return h.ch
@@ -176,8 +227,12 @@ func (h *PolicyChangeHandler) handleFleetServerHosts(ctx context.Context, c *con
 		}
 	}()
 
+	clientCfg := h.config.Fleet.Client
+	if h.clientConfig != nil {
+		clientCfg = h.clientConfig(clientCfg)
+	}
 	client, err := client.NewAuthWithConfig(
-		h.log, h.config.Fleet.AccessAPIKey, h.config.Fleet.Client)
+		h.log, h.config.Fleet.AccessAPIKey, clientCfg)
 	if err != nil {
 		return errors.New(
 			err, "fail to create API client with updated config",
@@ -265,15 +320,20 @@ func clientEqual(k1 remote.Config, k2 remote.Config) bool {
 }
 
 func fleetToReader(agentInfo info.Agent, cfg *configuration.Configuration) (io.Reader, error) {
+	agentCfg := map[string]interface{}{
+		"id":               agentInfo.AgentID(),
+		"headers":          agentInfo.Headers(),
+		"logging.level":    cfg.Settings.LoggingConfig.Level,
+		"monitoring.http":  cfg.Settings.MonitoringConfig.HTTP,
+		"monitoring.pprof": cfg.Settings.MonitoringConfig.Pprof,
+	}
+	if ps := cfg.Settings.PolicySignature; ps != nil && ps.RequireFull {
+		// the signing key rotated by the fully signed policies
+		agentCfg["policy_signature.signing_key"] = ps.SigningKey
+	}
 	configToStore := map[string]interface{}{
 		"fleet": cfg.Fleet,
-		"agent": map[string]interface{}{
-			"id":               agentInfo.AgentID(),
-			"headers":          agentInfo.Headers(),
-			"logging.level":    cfg.Settings.LoggingConfig.Level,
-			"monitoring.http":  cfg.Settings.MonitoringConfig.HTTP,
-			"monitoring.pprof": cfg.Settings.MonitoringConfig.Pprof,
-		},
+		"agent": agentCfg,
 	}
 
 	data, err := yaml.Marshal(configToStore)
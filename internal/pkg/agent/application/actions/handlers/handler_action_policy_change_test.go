@@ -6,6 +6,13 @@ package handlers
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -21,6 +28,7 @@ import (
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/coordinator"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/info"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/configuration"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/protection"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/storage"
 	"github.com/elastic/elastic-agent/internal/pkg/config"
 	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
@@ -451,3 +459,125 @@ type testSetter struct {
 func (s *testSetter) SetClient(c client.Sender) {
 	s.SetClientFn(c)
 }
+
+func TestPolicyChangeFullSignature(t *testing.T) {
+	log, _ := logger.New("", false)
+	ack := noopacker.New()
+
+	agentInfo := &info.AgentInfo{}
+	nullStore := &storage.NullStore{}
+
+	pk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	pubK, err := x509.MarshalPKIXPublicKey(&pk.PublicKey)
+	require.NoError(t, err)
+
+	newPolicy := func(signingKey []byte) map[string]interface{} {
+		return map[string]interface{}{
+			"id": "policy-id",
+			"agent": map[string]interface{}{
+				"protection": map[string]interface{}{"signing_key": base64.StdEncoding.EncodeToString(signingKey)},
+			},
+			"inputs": []interface{}{map[string]interface{}{"id": "logfile-1", "type": "logfile"}},
+		}
+	}
+	signPolicy := func(policy map[string]interface{}, pk *ecdsa.PrivateKey) map[string]interface{} {
+		data, err := json.Marshal(policy)
+		require.NoError(t, err)
+		hash := sha256.Sum256(data)
+		signature, err := ecdsa.SignASN1(rand.Reader, pk, hash[:])
+		require.NoError(t, err)
+
+		signedPolicy := map[string]interface{}{
+			"signed": map[string]interface{}{
+				"data":      base64.StdEncoding.EncodeToString(data),
+				"signature": base64.StdEncoding.EncodeToString(signature),
+			},
+		}
+		for k, v := range policy {
+			signedPolicy[k] = v
+		}
+		return signedPolicy
+	}
+	policy := newPolicy(pubK)
+	signedPolicy := signPolicy(policy, pk)
+
+	newHandler := func(ch chan coordinator.ConfigChange) *PolicyChangeHandler {
+		cfg := configuration.DefaultConfiguration()
+		cfg.Settings.PolicySignature.RequireFull = true
+		cfg.Settings.PolicySignature.SigningKey = base64.StdEncoding.EncodeToString(pubK)
+		return NewPolicyChangeHandler(log, agentInfo, cfg, nullStore, ch)
+	}
+
+	t.Run("fully signed policy is emitted", func(t *testing.T) {
+		ch := make(chan coordinator.ConfigChange, 1)
+		handler := newHandler(ch)
+
+		err := handler.Handle(context.Background(), &fleetapi.ActionPolicyChange{ActionID: "abc123", ActionType: "POLICY_CHANGE", Policy: signedPolicy}, ack)
+		require.NoError(t, err)
+
+		change := <-ch
+		require.Equal(t, config.MustNewConfigFrom(signedPolicy), change.Config())
+	})
+
+	t.Run("unsigned policy is rejected", func(t *testing.T) {
+		ch := make(chan coordinator.ConfigChange, 1)
+		handler := newHandler(ch)
+
+		err := handler.Handle(context.Background(), &fleetapi.ActionPolicyChange{ActionID: "abc123", ActionType: "POLICY_CHANGE", Policy: policy}, ack)
+		assert.ErrorIs(t, err, protection.ErrPolicyNotSigned)
+		assert.Empty(t, ch)
+	})
+
+	t.Run("policy with an injected input is rejected", func(t *testing.T) {
+		ch := make(chan coordinator.ConfigChange, 1)
+		handler := newHandler(ch)
+
+		injected := make(map[string]interface{}, len(signedPolicy))
+		for k, v := range signedPolicy {
+			injected[k] = v
+		}
+		injected["inputs"] = []interface{}{
+			map[string]interface{}{"id": "logfile-1", "type": "logfile"},
+			map[string]interface{}{"id": "injected", "type": "osquery"},
+		}
+
+		err := handler.Handle(context.Background(), &fleetapi.ActionPolicyChange{ActionID: "abc123", ActionType: "POLICY_CHANGE", Policy: injected}, ack)
+		assert.ErrorIs(t, err, protection.ErrPolicyNotFullySigned)
+		assert.Empty(t, ch)
+	})
+
+	t.Run("rotated signing key is persisted", func(t *testing.T) {
+		otherPk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		otherPubK, err := x509.MarshalPKIXPublicKey(&otherPk.PublicKey)
+		require.NoError(t, err)
+
+		store := &testStore{}
+		cfg := configuration.DefaultConfiguration()
+		cfg.Settings.PolicySignature.RequireFull = true
+		cfg.Settings.PolicySignature.SigningKey = base64.StdEncoding.EncodeToString(pubK)
+		ch := make(chan coordinator.ConfigChange, 1)
+		handler := NewPolicyChangeHandler(log, agentInfo, cfg, store, ch)
+
+		// the policy signed with the known key rotates it
+		err = handler.Handle(context.Background(), &fleetapi.ActionPolicyChange{ActionID: "abc123", ActionType: "POLICY_CHANGE", Policy: signPolicy(newPolicy(otherPubK), pk)}, ack)
+		require.NoError(t, err)
+		<-ch
+		require.Len(t, store.saved, 1)
+
+		// the agent restarts with the persisted Fleet configuration
+		restarted, err := configuration.NewFromConfig(config.MustNewConfigFrom(store.saved[0]))
+		require.NoError(t, err)
+		assert.Equal(t, base64.StdEncoding.EncodeToString(otherPubK), restarted.Settings.PolicySignature.SigningKey)
+
+		restarted.Settings.PolicySignature.RequireFull = true
+		handler = NewPolicyChangeHandler(log, agentInfo, restarted, store, ch)
+		err = handler.Handle(context.Background(), &fleetapi.ActionPolicyChange{ActionID: "abc123", ActionType: "POLICY_CHANGE", Policy: signedPolicy}, ack)
+		assert.ErrorIs(t, err, protection.ErrInvalidSignature)
+		err = handler.Handle(context.Background(), &fleetapi.ActionPolicyChange{ActionID: "abc123", ActionType: "POLICY_CHANGE", Policy: signPolicy(newPolicy(otherPubK), otherPk)}, ack)
+		require.NoError(t, err)
+		<-ch
+		assert.Len(t, store.saved, 1, "the key didn't rotate")
+	})
+}
@@ -0,0 +1,169 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/actions"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/info"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/configuration"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/errors"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/storage"
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi/acker"
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi/client"
+	"github.com/elastic/elastic-agent/internal/pkg/remote"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+)
+
+const apiKeyVerifyTimeout = 30 * time.Second
+
+// RotateAPIKey is a handler for ROTATE_API_KEY action. It replaces the access API key
+// of the agent with a new one created by Fleet Server.
+type RotateAPIKey struct {
+	log       *logger.Logger
+	agentInfo info.Agent
+	config    *configuration.Configuration
+	store     storage.Store
+
+	// clientConfig completes the configuration of the Fleet client created with the new API key
+	clientConfig func(remote.Config) remote.Config
+	// verifier checks Fleet Server accepts the client created with the new API key
+	verifier func(context.Context, client.Sender) error
+
+	mx      sync.Mutex
+	client  client.Sender
+	setters []actions.ClientSetter
+}
+
+// NewRotateAPIKey creates a new RotateAPIKey handler.
+func NewRotateAPIKey(
+	log *logger.Logger,
+	agentInfo info.Agent,
+	config *configuration.Configuration,
+	store storage.Store,
+	client client.Sender,
+) *RotateAPIKey {
+	return &RotateAPIKey{
+		log:       log,
+		agentInfo: agentInfo,
+		config:    config,
+		store:     store,
+		client:    client,
+	}
+}
+
+// AddSetter adds a setter of the Fleet client created with the new API key.
+func (h *RotateAPIKey) AddSetter(cs actions.ClientSetter) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	h.setters = append(h.setters, cs)
+}
+
+// SetClient sets the client used to request the new API key.
+func (h *RotateAPIKey) SetClient(c client.Sender) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	h.client = c
+}
+
+// SetClientConfig sets the function completing the configuration of the Fleet client
+// created with the new API key.
+func (h *RotateAPIKey) SetClientConfig(fn func(remote.Config) remote.Config) {
+	h.clientConfig = fn
+}
+
+// SetVerifier sets the function checking Fleet Server accepts the client created with
+// the new API key.
+func (h *RotateAPIKey) SetVerifier(fn func(context.Context, client.Sender) error) {
+	h.verifier = fn
+}
+
+// Handle handles ROTATE_API_KEY action.
+//
+// The new API key is persisted before being verified, so a crash never leaves
+// the agent with an API key Fleet Server doesn't know about; the previous one is restored when
+// the verification fails. The action is acked with the new API key only once verified, then
+// Fleet Server invalidates the previous one.
+func (h *RotateAPIKey) Handle(ctx context.Context, a fleetapi.Action, acker acker.Acker) error {
+	h.log.Debugf("handlerRotateAPIKey: action '%+v' received", a)
+	action, ok := a.(*fleetapi.ActionRotateAPIKey)
+	if !ok {
+		return fmt.Errorf("invalid type, expected ActionRotateAPIKey and received %T", a)
+	}
+	if h.verifier == nil {
+		return errors.New("no verifier of the new API key", errors.TypeUnexpected)
+	}
+
+	h.mx.Lock()
+	c := h.client
+	h.mx.Unlock()
+
+	resp, err := fleetapi.NewAPIKeyCmd(h.agentInfo, c).Execute(ctx)
+	if err != nil {
+		return err
+	}
+
+	clientCfg := h.config.Fleet.Client
+	if h.clientConfig != nil {
+		clientCfg = h.clientConfig(clientCfg)
+	}
+	newClient, err := client.NewAuthWithConfig(h.log, resp.APIKey, clientCfg)
+	if err != nil {
+		return errors.New(err, "fail to create API client with the new API key", errors.TypeConfig)
+	}
+
+	prevAPIKey := h.config.Fleet.AccessAPIKey
+	if err := h.saveAPIKey(resp.APIKey); err != nil {
+		h.config.Fleet.AccessAPIKey = prevAPIKey
+		return err
+	}
+
+	verifyCtx, cancel := context.WithTimeout(ctx, apiKeyVerifyTimeout)
+	err = h.verifier(verifyCtx, newClient)
+	cancel()
+	if err != nil {
+		if rollbackErr := h.saveAPIKey(prevAPIKey); rollbackErr != nil {
+			h.log.Errorw("Failed to restore the previous API key", "error.message", rollbackErr)
+		}
+		return errors.New(err, "fail to verify the new API key", errors.TypeNetwork)
+	}
+
+	h.mx.Lock()
+	h.client = newClient
+	setters := h.setters
+	h.mx.Unlock()
+	for _, setter := range setters {
+		setter.SetClient(newClient)
+	}
+
+	action.APIKeyID = resp.APIKeyID
+	if err := acker.Ack(ctx, action); err != nil {
+		h.log.Errorf("failed to acknowledge ROTATE_API_KEY action with id '%s'", action.ActionID)
+	} else if err := acker.Commit(ctx); err != nil {
+		h.log.Errorf("failed to commit acker after acknowledging action with id '%s'", action.ActionID)
+	}
+
+	h.log.Infow("API key rotated", "api_key_id", resp.APIKeyID)
+	return nil
+}
+
+// saveAPIKey sets the access API key and persists the Fleet configuration.
+func (h *RotateAPIKey) saveAPIKey(apiKey string) error {
+	h.config.Fleet.AccessAPIKey = apiKey
+
+	reader, err := fleetToReader(h.agentInfo, h.config)
+	if err != nil {
+		return errors.New(err, "fail to persist the API key", errors.TypeUnexpected)
+	}
+	if err := h.store.Save(reader); err != nil {
+		return errors.New(err, "fail to persist the API key", errors.TypeFilesystem)
+	}
+	return nil
+}
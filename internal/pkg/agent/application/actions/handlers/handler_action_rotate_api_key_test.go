@@ -0,0 +1,137 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/info"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/configuration"
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi/client"
+	"github.com/elastic/elastic-agent/internal/pkg/remote"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+)
+
+type testStore struct {
+	saved []string
+}
+
+func (s *testStore) Save(r io.Reader) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.saved = append(s.saved, string(b))
+	return nil
+}
+
+// savedAPIKey returns the access API key of the last saved Fleet configuration.
+func (s *testStore) savedAPIKey(t *testing.T) string {
+	require.NotEmpty(t, s.saved, "the Fleet configuration was not saved")
+	var cfg struct {
+		Fleet struct {
+			AccessAPIKey string `yaml:"access_api_key"`
+		} `yaml:"fleet"`
+	}
+	require.NoError(t, yaml.Unmarshal([]byte(s.saved[len(s.saved)-1]), &cfg))
+	return cfg.Fleet.AccessAPIKey
+}
+
+func TestRotateAPIKey(t *testing.T) {
+	const (
+		oldAPIKey = "old-key"
+		newAPIKey = "new-key"
+	)
+
+	newFleetServer := func(t *testing.T, acceptNewKey bool) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			auth := strings.TrimPrefix(r.Header.Get("Authorization"), "ApiKey ")
+			switch {
+			case strings.HasSuffix(r.URL.Path, "/api_key"):
+				if auth != oldAPIKey {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				fmt.Fprintf(w, `{"api_key_id": "new-key-id", "api_key": %q}`, newAPIKey)
+			case strings.HasSuffix(r.URL.Path, "/checkin"):
+				if auth != newAPIKey || !acceptNewKey {
+					w.WriteHeader(http.StatusUnauthorized)
+					fmt.Fprint(w, `{"statusCode": 401, "error": "Unauthorized", "message": "invalid api key"}`)
+					return
+				}
+				fmt.Fprint(w, `{"action": "checkin", "actions": []}`)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+	}
+
+	newHandler := func(t *testing.T, srv *httptest.Server, store *testStore) (*RotateAPIKey, *configuration.Configuration) {
+		log, _ := logger.NewTesting("TestRotateAPIKey")
+		cfg := configuration.DefaultConfiguration()
+		cfg.Fleet.AccessAPIKey = oldAPIKey
+		cfg.Fleet.Client = remote.Config{Host: srv.URL}
+
+		c, err := client.NewAuthWithConfig(log, oldAPIKey, cfg.Fleet.Client)
+		require.NoError(t, err)
+		h := NewRotateAPIKey(log, &info.AgentInfo{}, cfg, store, c)
+		h.SetVerifier(func(ctx context.Context, c client.Sender) error {
+			_, _, err := fleetapi.NewCheckinCmd(&info.AgentInfo{}, c).Execute(ctx, &fleetapi.CheckinRequest{Status: "online"})
+			return err
+		})
+		return h, cfg
+	}
+
+	t.Run("API key is rotated", func(t *testing.T) {
+		srv := newFleetServer(t, true)
+		defer srv.Close()
+		store := &testStore{}
+		h, cfg := newHandler(t, srv, store)
+
+		var newClient client.Sender
+		h.AddSetter(&testSetter{SetClientFn: func(c client.Sender) { newClient = c }})
+
+		ack := &testAcker{}
+		action := &fleetapi.ActionRotateAPIKey{ActionID: "rotate-1", ActionType: fleetapi.ActionTypeRotateAPIKey}
+		require.NoError(t, h.Handle(context.Background(), action, ack))
+
+		assert.Equal(t, newAPIKey, cfg.Fleet.AccessAPIKey)
+		assert.Equal(t, newAPIKey, store.savedAPIKey(t))
+		assert.NotNil(t, newClient, "the setters must be given the client with the new API key")
+		assert.Equal(t, []string{"rotate-1"}, ack.Items())
+		assert.Equal(t, "new-key-id", action.APIKeyID)
+	})
+
+	t.Run("rollback when the new API key is rejected", func(t *testing.T) {
+		srv := newFleetServer(t, false)
+		defer srv.Close()
+		store := &testStore{}
+		h, cfg := newHandler(t, srv, store)
+
+		h.AddSetter(&testSetter{SetClientFn: func(c client.Sender) { t.Fatal("unexpected client change") }})
+
+		ack := &testAcker{}
+		action := &fleetapi.ActionRotateAPIKey{ActionID: "rotate-1", ActionType: fleetapi.ActionTypeRotateAPIKey}
+		err := h.Handle(context.Background(), action, ack)
+		require.ErrorContains(t, err, "fail to verify the new API key")
+
+		assert.Equal(t, oldAPIKey, cfg.Fleet.AccessAPIKey)
+		require.Len(t, store.saved, 2, "the new API key is saved then rolled back")
+		assert.Equal(t, oldAPIKey, store.savedAPIKey(t))
+		assert.Empty(t, ack.Items())
+		assert.Empty(t, action.APIKeyID)
+	})
+}
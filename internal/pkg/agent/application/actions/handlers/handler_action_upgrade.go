@@ -16,12 +16,19 @@ import (
 	"github.com/elastic/elastic-agent/pkg/features"
 )
 
+// upgradeScheduler is the persisted queue the switch to a prepared upgrade is scheduled in.
+type upgradeScheduler interface {
+	Add(fleetapi.ScheduledAction, int64)
+	Save() error
+}
+
 // Upgrade is a handler for UPGRADE action.
 // After running Upgrade agent should download its own version specified by action
 // from repository specified by fleet.
 type Upgrade struct {
 	log        *logger.Logger
 	coord      upgradeCoordinator
+	queue      upgradeScheduler
 	bkgActions []fleetapi.Action
 	bkgCancel  context.CancelFunc
 	bkgMutex   sync.Mutex
@@ -29,11 +36,13 @@ type Upgrade struct {
 	tamperProtectionFn func() bool // allows to inject the flag for tests, defaults to features.TamperProtection
 }
 
-// NewUpgrade creates a new Upgrade handler.
-func NewUpgrade(log *logger.Logger, coord upgradeCoordinator) *Upgrade {
+// NewUpgrade creates a new Upgrade handler. The switch to a prepared upgrade is scheduled
+// in queue, so it survives restarts.
+func NewUpgrade(log *logger.Logger, coord upgradeCoordinator, queue upgradeScheduler) *Upgrade {
 	return &Upgrade{
 		log:                log,
 		coord:              coord,
+		queue:              queue,
 		tamperProtectionFn: features.TamperProtection,
 	}
 }
@@ -83,11 +92,64 @@ func (h *Upgrade) Handle(ctx context.Context, a fleetapi.Action, ack acker.Acker
 				h.ackActions(asyncCtx, ack)
 				h.bkgMutex.Unlock()
 			}
+			return
+		}
+
+		if action.PrepareOnly {
+			h.handlePrepared(asyncCtx, action, ack)
 		}
 	}()
 	return nil
 }
 
+// handlePrepared is called once a prepare-only upgrade completed. If the action
+// defines a switch time the switch to the prepared version is added to the action
+// queue with the switch time as start time, the action is then dispatched again at
+// that time, even after a restart, and acked once the switch is done. Otherwise the
+// action is acked right away and the switch is left to a later upgrade action.
+func (h *Upgrade) handlePrepared(ctx context.Context, action *fleetapi.ActionUpgrade, ack acker.Acker) {
+	switchAt, err := action.SwitchTime()
+	if err != nil {
+		if !errors.Is(err, fleetapi.ErrNoSwitchTime) {
+			h.log.Errorf("invalid switch time %q for prepared upgrade to version %s: %v", action.SwitchAt, action.Version, err)
+		}
+		h.bkgMutex.Lock()
+		h.ackActions(ctx, ack)
+		h.bkgMutex.Unlock()
+		return
+	}
+
+	switchAction := *action
+	switchAction.PrepareOnly = false
+	switchAction.SwitchAt = ""
+	switchAction.SetStartTime(switchAt)
+
+	h.bkgMutex.Lock()
+	defer h.bkgMutex.Unlock()
+	if ctx.Err() != nil {
+		// cancelled by another upgrade action, acked in getAsyncContext
+		return
+	}
+	h.log.Infof("upgrade to version %s prepared, switching at %s", action.Version, switchAt)
+	h.queue.Add(&switchAction, switchAt.Unix())
+	if err := h.queue.Save(); err != nil {
+		h.log.Errorf("failed to persist the switch to prepared upgrade version %s: %v", action.Version, err)
+	}
+
+	// the scheduled action is acked once switched, only the duplicates are acked now
+	var duplicates []fleetapi.Action
+	for _, a := range h.bkgActions {
+		if a.ID() != action.ID() {
+			duplicates = append(duplicates, a)
+		}
+	}
+	if len(duplicates) > 0 {
+		h.bkgActions = duplicates
+		h.ackActions(ctx, ack)
+	}
+	h.bkgActions = nil
+}
+
 // ackActions Acks all the actions in bkgActions, and deletes entries from bkgActions.
 // User is responsible for obtaining and releasing bkgMutex lock
 func (h *Upgrade) ackActions(ctx context.Context, ack acker.Acker) {
@@ -125,13 +187,13 @@ func (h *Upgrade) getAsyncContext(ctx context.Context, action fleetapi.Action, a
 		h.log.Errorf("invalid type, expected ActionUpgrade and received %T", action)
 		return nil, false
 	}
-	if (upgradeAction.Version == bkgAction.Version) && (upgradeAction.SourceURI == bkgAction.SourceURI) {
+	if (upgradeAction.Version == bkgAction.Version) && (upgradeAction.SourceURI == bkgAction.SourceURI) && (upgradeAction.PrepareOnly == bkgAction.PrepareOnly) {
 		h.log.Infof("Duplicate upgrade to version %s received", bkgAction.Version)
 		h.bkgActions = append(h.bkgActions, action)
 		return nil, false
 	}
 
-	// Versions must be different, cancel the first upgrade and run the new one
+	// Versions or upgrade modes must be different, cancel the first upgrade and run the new one
 	h.log.Infof("Canceling upgrade to version %s received", bkgAction.Version)
 	h.bkgCancel()
 
@@ -9,8 +9,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/actions/handlers/mocks"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/coordinator"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/info"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/reexec"
@@ -48,6 +50,10 @@ func (u *mockUpgradeManager) Upgrade(ctx context.Context, version string, source
 	}
 }
 
+func (u *mockUpgradeManager) Rollback(ctx context.Context, version string, details *details.Details) (_ string, _ reexec.ShutdownCallbackFn, err error) {
+	return version, nil, nil
+}
+
 func (u *mockUpgradeManager) Ack(ctx context.Context, acker acker.Acker) error {
 	return nil
 }
@@ -80,7 +86,7 @@ func TestUpgradeHandler(t *testing.T) {
 	//nolint:errcheck // We don't need the termination state of the Coordinator
 	go c.Run(ctx)
 
-	u := NewUpgrade(log, c)
+	u := NewUpgrade(log, c, nil)
 	a := fleetapi.ActionUpgrade{Version: "8.3.0", SourceURI: "http://localhost"}
 	ack := noopacker.New()
 	err := u.Handle(ctx, &a, ack)
@@ -113,7 +119,7 @@ func TestUpgradeHandlerSameVersion(t *testing.T) {
 	//nolint:errcheck // We don't need the termination state of the Coordinator
 	go c.Run(ctx)
 
-	u := NewUpgrade(log, c)
+	u := NewUpgrade(log, c, nil)
 	a := fleetapi.ActionUpgrade{Version: "8.3.0", SourceURI: "http://localhost"}
 	ack := noopacker.New()
 	err1 := u.Handle(ctx, &a, ack)
@@ -148,7 +154,7 @@ func TestUpgradeHandlerNewVersion(t *testing.T) {
 	//nolint:errcheck // We don't need the termination state of the Coordinator
 	go c.Run(ctx)
 
-	u := NewUpgrade(log, c)
+	u := NewUpgrade(log, c, nil)
 	a1 := fleetapi.ActionUpgrade{Version: "8.2.0", SourceURI: "http://localhost"}
 	a2 := fleetapi.ActionUpgrade{Version: "8.5.0", SourceURI: "http://localhost"}
 	ack := noopacker.New()
@@ -162,3 +168,117 @@ func TestUpgradeHandlerNewVersion(t *testing.T) {
 	msg2 := <-msgChan
 	require.Equal(t, "completed 8.5.0", msg2)
 }
+
+func TestUpgradeHandlerPrepareOnly(t *testing.T) {
+	// Create a cancellable context that will shut down the coordinator after
+	// the test.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	log, _ := logger.New("", false)
+
+	agentInfo := &info.AgentInfo{}
+	msgChan := make(chan string)
+
+	// Create and start the Coordinator
+	c := coordinator.New(
+		log,
+		configuration.DefaultConfiguration(),
+		logger.DefaultLogLevel,
+		agentInfo,
+		component.RuntimeSpecs{},
+		nil,
+		&mockUpgradeManager{msgChan: msgChan},
+		nil, nil, nil, nil, nil, false)
+	//nolint:errcheck // We don't need the termination state of the Coordinator
+	go c.Run(ctx)
+
+	u := NewUpgrade(log, c, nil)
+	a := fleetapi.ActionUpgrade{Version: "8.3.0", SourceURI: "http://localhost", PrepareOnly: true}
+
+	acked := make(chan struct{})
+	ack := mocks.NewAcker(t)
+	ack.EXPECT().Ack(mock.Anything, &a).Return(nil).Once()
+	ack.EXPECT().Commit(mock.Anything).Run(func(_ context.Context) { close(acked) }).Return(nil).Once()
+
+	err := u.Handle(ctx, &a, ack)
+	require.NoError(t, err)
+	msg := <-msgChan
+	require.Equal(t, "completed 8.3.0", msg)
+
+	select {
+	case <-acked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("prepared upgrade action was not acked")
+	}
+}
+
+type testUpgradeQueue struct {
+	added chan fleetapi.ScheduledAction
+}
+
+func (q *testUpgradeQueue) Add(a fleetapi.ScheduledAction, _ int64) {
+	q.added <- a
+}
+
+func (q *testUpgradeQueue) Save() error {
+	return nil
+}
+
+func TestUpgradeHandlerPrepareOnlySwitchAt(t *testing.T) {
+	// Create a cancellable context that will shut down the coordinator after
+	// the test.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	log, _ := logger.New("", false)
+
+	agentInfo := &info.AgentInfo{}
+	msgChan := make(chan string)
+
+	// Create and start the Coordinator
+	c := coordinator.New(
+		log,
+		configuration.DefaultConfiguration(),
+		logger.DefaultLogLevel,
+		agentInfo,
+		component.RuntimeSpecs{},
+		nil,
+		&mockUpgradeManager{msgChan: msgChan},
+		nil, nil, nil, nil, nil, false)
+	//nolint:errcheck // We don't need the termination state of the Coordinator
+	go c.Run(ctx)
+
+	queue := &testUpgradeQueue{added: make(chan fleetapi.ScheduledAction, 1)}
+	u := NewUpgrade(log, c, queue)
+	switchAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	a := fleetapi.ActionUpgrade{ActionID: "upgrade-1", Version: "8.3.0", SourceURI: "http://localhost", PrepareOnly: true, SwitchAt: switchAt.Format(time.RFC3339)}
+
+	// the action is acked once switched, not when prepared
+	ack := mocks.NewAcker(t)
+
+	err := u.Handle(ctx, &a, ack)
+	require.NoError(t, err)
+	msg := <-msgChan
+	require.Equal(t, "completed 8.3.0", msg)
+
+	var scheduled fleetapi.ScheduledAction
+	select {
+	case scheduled = <-queue.added:
+	case <-time.After(5 * time.Second):
+		t.Fatal("switch to the prepared upgrade was not scheduled")
+	}
+	switchAction, ok := scheduled.(*fleetapi.ActionUpgrade)
+	require.True(t, ok)
+	require.Equal(t, "upgrade-1", switchAction.ID())
+	require.False(t, switchAction.PrepareOnly, "the scheduled action switches to the prepared upgrade")
+	require.Empty(t, switchAction.SwitchAt)
+	start, err := switchAction.StartTime()
+	require.NoError(t, err)
+	require.Equal(t, switchAt, start)
+	require.Eventually(t, func() bool {
+		u.bkgMutex.Lock()
+		defer u.bkgMutex.Unlock()
+		return len(u.bkgActions) == 0
+	}, 5*time.Second, 10*time.Millisecond, "the scheduled action must be handled again when dispatched")
+}
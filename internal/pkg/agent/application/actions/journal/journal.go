@@ -0,0 +1,287 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+)
+
+// defaultMaxSize is the size a journal file can reach before being rotated.
+const defaultMaxSize = 1024 * 1024
+
+// rotatedSuffix is appended to the journal path to name the rotated journal file.
+const rotatedSuffix = ".1"
+
+// Status is the step of the lifecycle of a Fleet action recorded in the journal.
+type Status string
+
+const (
+	// StatusReceived is recorded when the action is received by the dispatcher.
+	StatusReceived Status = "received"
+	// StatusQueued is recorded when the action is added to the action queue until its start time.
+	StatusQueued Status = "queued"
+	// StatusDispatched is recorded when the handler of the action succeeded.
+	StatusDispatched Status = "dispatched"
+	// StatusFailed is recorded when the handler of the action failed.
+	StatusFailed Status = "failed"
+	// StatusExpired is recorded when the action expired before being dispatched.
+	StatusExpired Status = "expired"
+	// StatusCancelled is recorded when the queued action is cancelled locally.
+	StatusCancelled Status = "cancelled"
+	// StatusAcked is recorded when the ack of the action is accepted by Fleet Server.
+	StatusAcked Status = "acked"
+	// StatusAckFailed is recorded when the ack of the action could not be sent to Fleet Server.
+	StatusAckFailed Status = "ack_failed"
+)
+
+// Entry is a step of the lifecycle of a Fleet action.
+type Entry struct {
+	Timestamp    time.Time `json:"@timestamp" yaml:"timestamp"`
+	ActionID     string    `json:"action_id" yaml:"action_id"`
+	ActionType   string    `json:"action_type" yaml:"action_type"`
+	Status       Status    `json:"status" yaml:"status"`
+	Error        string    `json:"error,omitempty" yaml:"error,omitempty"`
+	RetryAttempt int       `json:"retry_attempt,omitempty" yaml:"retry_attempt,omitempty"`
+}
+
+// Filter selects the journal entries returned by Query, the zero value selects all entries.
+type Filter struct {
+	ActionID   string
+	ActionType string
+	Status     Status
+	Since      time.Time
+	Until      time.Time
+	// Limit is the maximum number of the most recent entries returned, 0 means no limit.
+	Limit int
+}
+
+// Match returns true if the entry is selected by the filter.
+func (f Filter) Match(e Entry) bool {
+	if f.ActionID != "" && f.ActionID != e.ActionID {
+		return false
+	}
+	if f.ActionType != "" && f.ActionType != e.ActionType {
+		return false
+	}
+	if f.Status != "" && f.Status != e.Status {
+		return false
+	}
+	if !f.Since.IsZero() && e.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && e.Timestamp.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// Option is a Journal option function.
+type Option func(*Journal)
+
+// WithMaxSize sets the size a journal file can reach before being rotated.
+func WithMaxSize(size int64) Option {
+	return func(j *Journal) {
+		j.maxSize = size
+	}
+}
+
+// Journal is an append-only record of the lifecycle of the Fleet actions, persisted as
+// newline-delimited JSON. When the journal file reaches its maximum size it replaces the
+// previously rotated file, the journal never uses more than twice its maximum size on disk.
+//
+// A nil Journal is valid and records nothing.
+type Journal struct {
+	log     *logger.Logger
+	path    string
+	maxSize int64
+
+	mx   sync.Mutex
+	size int64 // size of the journal file, -1 until read from disk
+	now  func() time.Time
+}
+
+// New creates a new journal persisted at path.
+func New(log *logger.Logger, path string, opts ...Option) *Journal {
+	j := &Journal{
+		log:     log,
+		path:    path,
+		maxSize: defaultMaxSize,
+		size:    -1,
+		now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(j)
+	}
+	return j
+}
+
+// Record appends an entry with status for each action to the journal. Failing to
+// write the journal doesn't affect the handling of the actions, it is only logged.
+func (j *Journal) Record(status Status, err error, actions ...fleetapi.Action) {
+	if j == nil || len(actions) == 0 {
+		return
+	}
+
+	ts := j.now().UTC()
+	entries := make([]Entry, 0, len(actions))
+	for _, a := range actions {
+		e := Entry{
+			Timestamp:  ts,
+			ActionID:   a.ID(),
+			ActionType: actionType(a),
+			Status:     status,
+		}
+		if err != nil {
+			e.Error = err.Error()
+		}
+		if r, ok := a.(fleetapi.RetryableAction); ok && r.RetryAttempt() > 0 {
+			e.RetryAttempt = r.RetryAttempt()
+		}
+		entries = append(entries, e)
+	}
+
+	if err := j.append(entries); err != nil {
+		j.log.Warnw("Failed to write the action journal", "error.message", err, "path", j.path)
+	}
+}
+
+// RecordAckResponse records the actions acked in a batch as acked, or as ack_failed when
+// their item of the Fleet Server response reports an error.
+func (j *Journal) RecordAckResponse(actions []fleetapi.Action, resp *fleetapi.AckResponse) {
+	if j == nil {
+		return
+	}
+
+	for i, a := range actions {
+		if resp != nil && resp.Errors && i < len(resp.Items) && resp.Items[i].Status >= http.StatusBadRequest {
+			item := resp.Items[i]
+			j.Record(StatusAckFailed, fmt.Errorf("fleet-server returned status %d: %s", item.Status, item.Message), a)
+			continue
+		}
+		j.Record(StatusAcked, nil, a)
+	}
+}
+
+// RecordAckFailure records the actions which ack failed with err as ack_failed.
+func (j *Journal) RecordAckFailure(err error, actions ...fleetapi.Action) {
+	j.Record(StatusAckFailed, err, actions...)
+}
+
+// Query returns the entries selected by filter, from the oldest to the most recent.
+func (j *Journal) Query(filter Filter) ([]Entry, error) {
+	if j == nil {
+		return nil, nil
+	}
+
+	j.mx.Lock()
+	defer j.mx.Unlock()
+
+	var entries []Entry
+	for _, path := range []string{j.path + rotatedSuffix, j.path} {
+		read, err := readEntries(path, filter)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, read...)
+	}
+
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[len(entries)-filter.Limit:]
+	}
+	return entries, nil
+}
+
+func (j *Journal) append(entries []Entry) error {
+	var buf []byte
+	for _, e := range entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("failed to encode journal entry: %w", err)
+		}
+		buf = append(buf, line...)
+		buf = append(buf, '\n')
+	}
+
+	j.mx.Lock()
+	defer j.mx.Unlock()
+
+	if j.size < 0 {
+		info, err := os.Stat(j.path)
+		switch {
+		case err == nil:
+			j.size = info.Size()
+		case errors.Is(err, fs.ErrNotExist):
+			j.size = 0
+		default:
+			return err
+		}
+	}
+
+	if j.size > 0 && j.size+int64(len(buf)) > j.maxSize {
+		if err := os.Rename(j.path, j.path+rotatedSuffix); err != nil {
+			return fmt.Errorf("failed to rotate journal: %w", err)
+		}
+		j.size = 0
+	}
+
+	f, err := os.OpenFile(j.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	n, err := f.Write(buf)
+	j.size += int64(n)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func readEntries(path string, filter Filter) ([]Entry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Entry
+		// a partially written line is skipped, it's at most the last one
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		if filter.Match(e) {
+			entries = append(entries, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read journal %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// actionType returns the type of the action as received from Fleet.
+func actionType(a fleetapi.Action) string {
+	if u, ok := a.(*fleetapi.ActionUnknown); ok {
+		return u.OriginalType()
+	}
+	return a.Type()
+}
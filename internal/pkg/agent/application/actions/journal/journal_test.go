@@ -0,0 +1,134 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package journal
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+)
+
+func TestJournal(t *testing.T) {
+	log, _ := logger.NewTesting("journal")
+	path := filepath.Join(t.TempDir(), "action_journal.ndjson")
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	j := New(log, path)
+	j.now = func() time.Time { return now }
+
+	upgrade := &fleetapi.ActionUpgrade{ActionID: "upgrade-1", ActionType: fleetapi.ActionTypeUpgrade}
+	settings := &fleetapi.ActionSettings{ActionID: "settings-1", ActionType: fleetapi.ActionTypeSettings}
+
+	j.Record(StatusReceived, nil, upgrade, settings)
+	now = now.Add(time.Minute)
+	j.Record(StatusFailed, errors.New("download failed"), upgrade)
+	now = now.Add(time.Minute)
+	j.Record(StatusDispatched, nil, settings)
+
+	t.Run("all entries", func(t *testing.T) {
+		entries, err := j.Query(Filter{})
+		require.NoError(t, err)
+		require.Len(t, entries, 4)
+		assert.Equal(t, Entry{
+			Timestamp:  now.Add(-time.Minute),
+			ActionID:   "upgrade-1",
+			ActionType: fleetapi.ActionTypeUpgrade,
+			Status:     StatusFailed,
+			Error:      "download failed",
+		}, entries[2])
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		entries, err := j.Query(Filter{ActionID: "settings-1"})
+		require.NoError(t, err)
+		assert.Len(t, entries, 2)
+
+		entries, err = j.Query(Filter{Status: StatusFailed})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "upgrade-1", entries[0].ActionID)
+
+		entries, err = j.Query(Filter{Since: now.Add(-time.Minute)})
+		require.NoError(t, err)
+		assert.Len(t, entries, 2)
+
+		entries, err = j.Query(Filter{Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, StatusDispatched, entries[0].Status)
+	})
+
+	t.Run("reopened journal", func(t *testing.T) {
+		entries, err := New(log, path).Query(Filter{})
+		require.NoError(t, err)
+		assert.Len(t, entries, 4)
+	})
+}
+
+func TestJournalRotation(t *testing.T) {
+	log, _ := logger.NewTesting("journal")
+	path := filepath.Join(t.TempDir(), "action_journal.ndjson")
+
+	j := New(log, path, WithMaxSize(512))
+	for i := 0; i < 20; i++ {
+		j.Record(StatusReceived, nil, &fleetapi.ActionSettings{ActionID: "settings", ActionType: fleetapi.ActionTypeSettings})
+	}
+
+	for _, p := range []string{path, path + rotatedSuffix} {
+		info, err := os.Stat(p)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, info.Size(), int64(512))
+	}
+
+	entries, err := j.Query(Filter{})
+	require.NoError(t, err)
+	assert.NotEmpty(t, entries)
+	assert.Less(t, len(entries), 20)
+}
+
+func TestJournalRecordAcks(t *testing.T) {
+	log, _ := logger.NewTesting("journal")
+	j := New(log, filepath.Join(t.TempDir(), "action_journal.ndjson"))
+
+	actions := []fleetapi.Action{
+		&fleetapi.ActionSettings{ActionID: "settings-1", ActionType: fleetapi.ActionTypeSettings},
+		&fleetapi.ActionSettings{ActionID: "settings-2", ActionType: fleetapi.ActionTypeSettings},
+	}
+	j.RecordAckResponse(actions, &fleetapi.AckResponse{
+		Errors: true,
+		Items: []fleetapi.AckResponseItem{
+			{Status: http.StatusOK},
+			{Status: http.StatusNotFound, Message: "action not found"},
+		},
+	})
+	j.RecordAckFailure(errors.New("connection refused"), actions[1])
+
+	entries, err := j.Query(Filter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, StatusAcked, entries[0].Status)
+	assert.Equal(t, "settings-1", entries[0].ActionID)
+	assert.Equal(t, StatusAckFailed, entries[1].Status)
+	assert.Equal(t, "fleet-server returned status 404: action not found", entries[1].Error)
+	assert.Equal(t, StatusAckFailed, entries[2].Status)
+	assert.Equal(t, "connection refused", entries[2].Error)
+}
+
+func TestNilJournal(t *testing.T) {
+	var j *Journal
+	j.Record(StatusReceived, nil, &fleetapi.ActionSettings{ActionID: "settings"})
+	entries, err := j.Query(Filter{})
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
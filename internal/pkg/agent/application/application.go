@@ -17,6 +17,7 @@ import (
 
 	"github.com/elastic/elastic-agent-libs/logp"
 
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/autocapture"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/coordinator"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/info"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/monitoring"
@@ -169,9 +170,10 @@ func New(
 			compModifiers = append(compModifiers, FleetServerComponentModifier(cfg.Fleet.Server),
 				InjectFleetConfigComponentModifier(cfg.Fleet, agentInfo),
 				EndpointSignedComponentModifier(),
+				PolicySecretsComponentModifier(agentSecretsKey(ctx)),
 			)
 
-			managed, err = newManagedConfigManager(ctx, log, agentInfo, cfg, store, runtime, fleetInitTimeout, upgrader)
+			managed, err = newManagedConfigManager(ctx, log, agentInfo, cfg, store, runtime, fleetInitTimeout, specs.ActionTypes(), upgrader)
 			if err != nil {
 				return nil, nil, nil, err
 			}
@@ -191,6 +193,17 @@ func New(
 		managed.coord = coord
 	}
 
+	// capture the diagnostics automatically when problems are detected, not in testing mode or as an OTel collector
+	autoCaptureCfg := cfg.Settings.MonitoringConfig.Diagnostics.AutoCapture
+	if autoCaptureCfg.Enabled && !testingMode && !runAsOtel {
+		autoCapture := autocapture.New(log.Named("diagnostics"), coord, autoCaptureCfg, coord.DiagnosticsRedaction)
+		coord.RegisterBackgroundTask(autoCapture)
+		if managed != nil {
+			// in managed-mode the bundles can be uploaded with the uploader of the diagnostics action handler
+			managed.autoCapture = autoCapture
+		}
+	}
+
 	// every time we change the limits we'll see the log message
 	limits.AddLimitsOnChangeCallback(func(new, old limits.LimitsConfig) {
 		log.Debugf("agent limits have changed: %+v -> %+v", old, new)
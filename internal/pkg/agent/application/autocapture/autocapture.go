@@ -0,0 +1,421 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// Package autocapture captures diagnostics bundles automatically when problems are
+// detected in the state of the agent.
+package autocapture
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/elastic/elastic-agent-client/v7/pkg/client"
+	"github.com/elastic/go-sysinfo"
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/actions/handlers"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/coordinator"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
+	"github.com/elastic/elastic-agent/internal/pkg/core/monitoring/config"
+	"github.com/elastic/elastic-agent/internal/pkg/diagnostics"
+	"github.com/elastic/elastic-agent/pkg/component"
+	"github.com/elastic/elastic-agent/pkg/component/runtime"
+	agentclient "github.com/elastic/elastic-agent/pkg/control/v2/client"
+	"github.com/elastic/elastic-agent/pkg/control/v2/cproto"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+)
+
+const (
+	// captureFilePrefix is the prefix of the names of the bundles captured automatically.
+	captureFilePrefix = "elastic-agent-diagnostics-"
+	// captureTriggerFilename is the file of the bundles describing the problems that triggered the capture.
+	captureTriggerFilename = "capture-trigger.yaml"
+	// agentProcessName is the name the agent process is reported with by the memory trigger.
+	agentProcessName = "elastic-agent"
+	// uploadActionIDPrefix is the prefix of the action ID the bundles are uploaded to Fleet with,
+	// as no REQUEST_DIAGNOSTICS action requested them.
+	uploadActionIDPrefix = "auto-capture-"
+)
+
+// Reasons of an automatic capture.
+const (
+	ReasonFailed    = "failed"
+	ReasonCrashLoop = "crash_loop"
+	ReasonMemory    = "memory"
+	ReasonPeriodic  = "periodic"
+)
+
+// getProcessMemory returns the resident memory of a process, it can be replaced in unit-tests.
+var getProcessMemory = func(pid int) (uint64, error) {
+	p, err := sysinfo.Process(pid)
+	if err != nil {
+		return 0, err
+	}
+	m, err := p.Memory()
+	if err != nil {
+		return 0, err
+	}
+	return m.Resident, nil
+}
+
+// stateProvider is the source of the state watched and of the diagnostics captured by the AutoCapture.
+type stateProvider interface {
+	DiagnosticHooks() diagnostics.Hooks
+	PerformDiagnostics(ctx context.Context, req ...runtime.ComponentUnitDiagnosticRequest) []runtime.ComponentUnitDiagnostic
+	PerformComponentDiagnostics(ctx context.Context, additionalMetrics []cproto.AdditionalDiagnosticRequest, req ...component.Component) ([]runtime.ComponentDiagnostic, error)
+	StateSubscribe(ctx context.Context, bufferLen int) chan coordinator.State
+}
+
+// Trigger describes a problem that triggered the capture of a diagnostics bundle.
+type Trigger struct {
+	Reason      string    `yaml:"reason"`
+	ComponentID string    `yaml:"component_id,omitempty"`
+	Message     string    `yaml:"message"`
+	Timestamp   time.Time `yaml:"timestamp"`
+}
+
+// componentHistory is what is known of a component from the previous states.
+type componentHistory struct {
+	failed   bool
+	restarts int
+	// restartTimes are the times the restarts were observed, within the crash-loop window.
+	restartTimes []time.Time
+	pid          int
+}
+
+// AutoCapture captures diagnostics bundles locally, without waiting for them to be requested,
+// when a component fails, crash-loops or a process exceeds a memory threshold, and periodically.
+//
+// The bundles are kept in a directory rotated to stay under a size limit and optionally uploaded to Fleet.
+// Fleet lists the diagnostics bundles of the REQUEST_DIAGNOSTICS actions only, the bundles are uploaded
+// with an action ID of their own, prefixed with auto-capture-, and are retrieved from the Fleet file
+// storage by that ID.
+type AutoCapture struct {
+	log   *logger.Logger
+	cfg   config.AutoCapture
+	state stateProvider
+	diag  *handlers.Diagnostics
+	dir   string
+
+	uploaderMx sync.Mutex
+	uploader   handlers.Uploader
+
+	components  map[string]*componentHistory
+	overMemory  map[string]bool
+	lastCapture time.Time
+	now         func() time.Time
+}
+
+// New returns a new AutoCapture capturing bundles with the redaction policy returned by redaction.
+func New(log *logger.Logger, coord stateProvider, cfg config.AutoCapture, redaction func() config.Redaction) *AutoCapture {
+	dir := cfg.Dir
+	if dir == "" {
+		dir = paths.DiagnosticsCapturesDir()
+	}
+	return &AutoCapture{
+		log:   log,
+		cfg:   cfg,
+		state: coord,
+		// the bundles are written and uploaded by the AutoCapture, the limit and uploader of the
+		// action handler are not used.
+		diag:       handlers.NewDiagnostics(log, coord, config.Limit{}, redaction, nil),
+		dir:        dir,
+		components: make(map[string]*componentHistory),
+		overMemory: make(map[string]bool),
+		now:        time.Now,
+	}
+}
+
+// SetUploader sets the uploader the bundles are uploaded to Fleet with, when enabled.
+func (a *AutoCapture) SetUploader(u handlers.Uploader) {
+	a.uploaderMx.Lock()
+	defer a.uploaderMx.Unlock()
+	a.uploader = u
+}
+
+func (a *AutoCapture) getUploader() handlers.Uploader {
+	a.uploaderMx.Lock()
+	defer a.uploaderMx.Unlock()
+	return a.uploader
+}
+
+// Run watches the state of the agent and captures the bundles until the context is cancelled.
+//
+// The bundles are captured one at a time, the problems detected while capturing are detected again
+// from the following states, unless they are fixed by then.
+func (a *AutoCapture) Run(ctx context.Context) {
+	if !a.cfg.Enabled {
+		return
+	}
+	a.log.Infof("Diagnostics auto-capture enabled, bundles are kept in %s", a.dir)
+
+	stateCh := a.state.StateSubscribe(ctx, 32)
+
+	var periodic <-chan time.Time
+	if a.cfg.Interval > 0 {
+		t := time.NewTicker(a.cfg.Interval)
+		defer t.Stop()
+		periodic = t.C
+	}
+	var memory <-chan time.Time
+	if a.cfg.Memory.Threshold > 0 && a.cfg.Memory.Period > 0 {
+		t := time.NewTicker(a.cfg.Memory.Period)
+		defer t.Stop()
+		memory = t.C
+	}
+
+	for {
+		var triggers []Trigger
+		select {
+		case <-ctx.Done():
+			return
+		case state, ok := <-stateCh:
+			if !ok {
+				return
+			}
+			triggers = a.observe(state)
+		case <-periodic:
+			triggers = []Trigger{{
+				Reason:    ReasonPeriodic,
+				Message:   fmt.Sprintf("periodic capture every %s", a.cfg.Interval),
+				Timestamp: a.now().UTC(),
+			}}
+		case <-memory:
+			triggers = a.checkMemory()
+		}
+		if len(triggers) > 0 {
+			a.capture(ctx, triggers)
+		}
+	}
+}
+
+// observe updates the history of the components from the state and returns the problems detected.
+func (a *AutoCapture) observe(state coordinator.State) []Trigger {
+	now := a.now().UTC()
+	var triggers []Trigger
+	seen := make(map[string]bool, len(state.Components))
+	for _, comp := range state.Components {
+		id := comp.Component.ID
+		seen[id] = true
+		h, ok := a.components[id]
+		if !ok {
+			h = &componentHistory{}
+			a.components[id] = h
+		}
+		h.pid = comp.State.PID
+
+		failed, msg := componentFailure(comp.State)
+		if failed && !h.failed && a.cfg.OnFailed {
+			triggers = append(triggers, Trigger{
+				Reason:      ReasonFailed,
+				ComponentID: id,
+				Message:     msg,
+				Timestamp:   now,
+			})
+		}
+		h.failed = failed
+
+		if comp.State.Restarts < h.restarts {
+			// the component was re-created
+			h.restartTimes = nil
+		}
+		for i := h.restarts; i < comp.State.Restarts; i++ {
+			h.restartTimes = append(h.restartTimes, now)
+		}
+		h.restarts = comp.State.Restarts
+		if a.cfg.CrashLoop.Restarts > 0 {
+			kept := h.restartTimes[:0]
+			for _, t := range h.restartTimes {
+				if now.Sub(t) <= a.cfg.CrashLoop.Window {
+					kept = append(kept, t)
+				}
+			}
+			h.restartTimes = kept
+			if len(h.restartTimes) >= a.cfg.CrashLoop.Restarts {
+				triggers = append(triggers, Trigger{
+					Reason:      ReasonCrashLoop,
+					ComponentID: id,
+					Message:     fmt.Sprintf("restarted %d times within %s: %s", len(h.restartTimes), a.cfg.CrashLoop.Window, comp.State.Message),
+					Timestamp:   now,
+				})
+				// the following restarts are counted for the next detection
+				h.restartTimes = nil
+			}
+		}
+	}
+	for id := range a.components {
+		if !seen[id] {
+			delete(a.components, id)
+			delete(a.overMemory, id)
+		}
+	}
+	return triggers
+}
+
+// componentFailure returns whether the component or one of its units is failed, with the message of the failure.
+func componentFailure(state runtime.ComponentState) (bool, string) {
+	if state.State == client.UnitStateFailed {
+		return true, state.Message
+	}
+	keys := make([]runtime.ComponentUnitKey, 0, len(state.Units))
+	for k, u := range state.Units {
+		if u.State == client.UnitStateFailed {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return false, ""
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].UnitID < keys[j].UnitID })
+	return true, fmt.Sprintf("unit %s: %s", keys[0].UnitID, state.Units[keys[0]].Message)
+}
+
+// checkMemory returns the agent and component processes exceeding the memory threshold. A process is
+// detected again only once back under the threshold.
+func (a *AutoCapture) checkMemory() []Trigger {
+	now := a.now().UTC()
+	processes := map[string]int{agentProcessName: os.Getpid()}
+	for id, h := range a.components {
+		if h.pid > 0 {
+			processes[id] = h.pid
+		}
+	}
+	names := make([]string, 0, len(processes))
+	for name := range processes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var triggers []Trigger
+	for _, name := range names {
+		rss, err := getProcessMemory(processes[name])
+		if err != nil {
+			a.log.Debugf("Diagnostics auto-capture failed to read the memory of %s: %v", name, err)
+			continue
+		}
+		over := rss >= uint64(a.cfg.Memory.Threshold)
+		if over && !a.overMemory[name] {
+			trigger := Trigger{
+				Reason:    ReasonMemory,
+				Message:   fmt.Sprintf("%s uses %s of resident memory, over the threshold of %s", name, units.BytesSize(float64(rss)), units.BytesSize(float64(a.cfg.Memory.Threshold))),
+				Timestamp: now,
+			}
+			if name != agentProcessName {
+				trigger.ComponentID = name
+			}
+			triggers = append(triggers, trigger)
+		}
+		a.overMemory[name] = over
+	}
+	return triggers
+}
+
+// capture captures a bundle for the triggers, unless a bundle was captured less than the minimum
+// interval ago, keeps it in the directory and uploads it when enabled.
+func (a *AutoCapture) capture(ctx context.Context, triggers []Trigger) {
+	ts := a.now().UTC()
+	for _, t := range triggers {
+		a.log.Infof("Diagnostics auto-capture detected a problem (%s): %s", t.Reason, t.Message)
+	}
+	if !a.lastCapture.IsZero() && ts.Sub(a.lastCapture) < a.cfg.MinInterval {
+		a.log.Infof("Diagnostics auto-capture skipped, the last bundle was captured %s ago", ts.Sub(a.lastCapture).Round(time.Second))
+		return
+	}
+	a.lastCapture = ts
+
+	name := ts.Format("2006-01-02T15-04-05Z07-00") // RFC3339 format that uses - instead of : so it works on Windows
+	var additionalMetrics []string
+	if a.cfg.CPUProfile {
+		additionalMetrics = []string{"CPU"}
+	}
+
+	content, err := yaml.Marshal(map[string][]Trigger{"triggers": triggers})
+	if err != nil {
+		a.log.Errorw("Diagnostics auto-capture failed to describe the triggers", "error.message", err)
+		return
+	}
+	trigger := agentclient.DiagnosticFileResult{
+		Name:        "capture trigger",
+		Filename:    captureTriggerFilename,
+		Description: "Problems that triggered the automatic capture of the diagnostics",
+		ContentType: "application/yaml",
+		Content:     content,
+		Generated:   ts,
+	}
+
+	path := filepath.Join(a.dir, captureFilePrefix+name+"-"+triggers[0].Reason+".zip")
+	f, size, err := a.diag.WriteBundle(ctx, path, additionalMetrics, trigger)
+	if err != nil {
+		a.log.Errorw("Diagnostics auto-capture failed to capture the diagnostics bundle", "error.message", err, "path", path)
+		return
+	}
+	a.log.Infof("Diagnostics auto-capture wrote the diagnostics bundle %s", path)
+
+	if u := a.getUploader(); a.cfg.Upload && u != nil {
+		uploadID, err := u.UploadDiagnostics(ctx, uploadActionIDPrefix+name, name, size, f)
+		if err != nil {
+			a.log.Errorw("Diagnostics auto-capture failed to upload the diagnostics bundle", "error.message", err, "path", path)
+		} else {
+			a.log.Infof("Diagnostics auto-capture uploaded the diagnostics bundle %s with upload ID %s", path, uploadID)
+		}
+	}
+	f.Close()
+
+	a.rotate(path)
+}
+
+// rotate removes the oldest bundles of the directory until their total size is under the limit,
+// the bundle just captured is always kept.
+func (a *AutoCapture) rotate(keep string) {
+	entries, err := os.ReadDir(a.dir)
+	if err != nil {
+		a.log.Warnw("Diagnostics auto-capture failed to list the diagnostics bundles", "error.message", err)
+		return
+	}
+	type bundle struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var bundles []bundle
+	var total int64
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasPrefix(e.Name(), captureFilePrefix) || filepath.Ext(e.Name()) != ".zip" {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			continue
+		}
+		bundles = append(bundles, bundle{path: filepath.Join(a.dir, e.Name()), size: fi.Size(), modTime: fi.ModTime()})
+		total += fi.Size()
+	}
+	sort.Slice(bundles, func(i, j int) bool {
+		if bundles[i].modTime.Equal(bundles[j].modTime) {
+			return bundles[i].path < bundles[j].path
+		}
+		return bundles[i].modTime.Before(bundles[j].modTime)
+	})
+	for _, b := range bundles {
+		if total <= int64(a.cfg.MaxSize) {
+			break
+		}
+		if b.path == keep {
+			continue
+		}
+		if err := os.Remove(b.path); err != nil {
+			a.log.Warnw("Diagnostics auto-capture failed to remove a diagnostics bundle", "error.message", err, "path", b.path)
+			continue
+		}
+		a.log.Debugf("Diagnostics auto-capture removed the diagnostics bundle %s to stay under %s", b.path, units.BytesSize(float64(a.cfg.MaxSize)))
+		total -= b.size
+	}
+}
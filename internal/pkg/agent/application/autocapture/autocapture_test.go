@@ -0,0 +1,274 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package autocapture
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-agent-client/v7/pkg/client"
+	"github.com/elastic/elastic-agent-client/v7/pkg/proto"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/actions/handlers/mocks"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/coordinator"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
+	"github.com/elastic/elastic-agent/internal/pkg/core/monitoring/config"
+	"github.com/elastic/elastic-agent/internal/pkg/diagnostics"
+	"github.com/elastic/elastic-agent/pkg/component"
+	"github.com/elastic/elastic-agent/pkg/component/runtime"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+)
+
+var defaultRedaction = config.DefaultConfig().Diagnostics.Redaction
+
+func defaultRedactionFn() config.Redaction { return defaultRedaction }
+
+var testHook = diagnostics.Hook{
+	Name:        "hook1",
+	Filename:    "hook1.yaml",
+	ContentType: "application/yaml",
+	Hook: func(ctx context.Context) []byte {
+		return []byte(`hook: 1`)
+	},
+}
+
+var testUnitDiagnostic = runtime.ComponentUnitDiagnostic{
+	Component: component.Component{ID: "filestream-default"},
+	Unit:      component.Unit{ID: "filestream-default-logs", Type: client.UnitTypeInput},
+	Results: []*proto.ActionDiagnosticUnitResult{
+		{
+			Name:        "unit diagnostic result",
+			Filename:    "unit_diag_file.yaml",
+			ContentType: "application/yaml",
+			Content:     []byte("hello: there"),
+		},
+	},
+}
+
+type testStateProvider struct {
+	*mocks.DiagnosticsProvider
+	ch chan coordinator.State
+}
+
+func (p *testStateProvider) StateSubscribe(context.Context, int) chan coordinator.State {
+	return p.ch
+}
+
+func autoCaptureState(comps ...runtime.ComponentComponentState) coordinator.State {
+	return coordinator.State{Components: comps}
+}
+
+func autoCaptureComponent(id string, state runtime.ComponentState) runtime.ComponentComponentState {
+	return runtime.ComponentComponentState{Component: component.Component{ID: id}, State: state}
+}
+
+func newTestAutoCapture(t *testing.T, cfg config.AutoCapture) (*AutoCapture, *mocks.DiagnosticsProvider, *time.Time) {
+	t.Helper()
+	paths.SetTop(t.TempDir())
+	provider := mocks.NewDiagnosticsProvider(t)
+	testLogger, _ := logger.NewTesting("diagnostics-auto-capture-test")
+	a := New(testLogger, &testStateProvider{DiagnosticsProvider: provider}, cfg, defaultRedactionFn)
+	now := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	a.now = func() time.Time { return now }
+	return a, provider, &now
+}
+
+func TestDiagnosticsAutoTriggers(t *testing.T) {
+	cfg := config.DefaultConfig().Diagnostics.AutoCapture
+	cfg.Enabled = true
+	cfg.Memory.Threshold = 100
+
+	t.Run("failed", func(t *testing.T) {
+		a, _, _ := newTestAutoCapture(t, cfg)
+		healthy := runtime.ComponentState{State: client.UnitStateHealthy, Message: "Healthy"}
+		assert.Empty(t, a.observe(autoCaptureState(autoCaptureComponent("filestream-default", healthy))))
+
+		failed := runtime.ComponentState{State: client.UnitStateFailed, Message: "Failed: pid '42' exited with code '2'"}
+		triggers := a.observe(autoCaptureState(autoCaptureComponent("filestream-default", failed)))
+		require.Len(t, triggers, 1)
+		assert.Equal(t, ReasonFailed, triggers[0].Reason)
+		assert.Equal(t, "filestream-default", triggers[0].ComponentID)
+		assert.Equal(t, failed.Message, triggers[0].Message)
+
+		assert.Empty(t, a.observe(autoCaptureState(autoCaptureComponent("filestream-default", failed))), "a component staying failed is detected once")
+
+		unitFailed := runtime.ComponentState{
+			State: client.UnitStateHealthy,
+			Units: map[runtime.ComponentUnitKey]runtime.ComponentUnitState{
+				{UnitType: client.UnitTypeInput, UnitID: "filestream-default-logs"}: {State: client.UnitStateFailed, Message: "invalid configuration"},
+			},
+		}
+		assert.Empty(t, a.observe(autoCaptureState(autoCaptureComponent("filestream-default", healthy))))
+		triggers = a.observe(autoCaptureState(autoCaptureComponent("filestream-default", unitFailed)))
+		require.Len(t, triggers, 1)
+		assert.Equal(t, "unit filestream-default-logs: invalid configuration", triggers[0].Message)
+	})
+
+	t.Run("crash loop", func(t *testing.T) {
+		a, _, now := newTestAutoCapture(t, cfg)
+		restarts := func(n int) coordinator.State {
+			return autoCaptureState(autoCaptureComponent("filestream-default", runtime.ComponentState{State: client.UnitStateStarting, Message: "Starting", Restarts: n}))
+		}
+
+		assert.Empty(t, a.observe(restarts(1)))
+		*now = now.Add(10 * time.Minute)
+		assert.Empty(t, a.observe(restarts(2)), "restarts outside of the window are not counted")
+		*now = now.Add(time.Minute)
+		assert.Empty(t, a.observe(restarts(3)))
+		*now = now.Add(time.Minute)
+		triggers := a.observe(restarts(4))
+		require.Len(t, triggers, 1)
+		assert.Equal(t, ReasonCrashLoop, triggers[0].Reason)
+		assert.Equal(t, "restarted 3 times within 5m0s: Starting", triggers[0].Message)
+
+		assert.Empty(t, a.observe(restarts(5)), "the restarts are counted again after a detection")
+	})
+
+	t.Run("memory", func(t *testing.T) {
+		a, _, _ := newTestAutoCapture(t, cfg)
+		memory := map[int]uint64{os.Getpid(): 50, 42: 150}
+		getProcessMemory = func(pid int) (uint64, error) {
+			m, ok := memory[pid]
+			if !ok {
+				return 0, errors.New("no such process")
+			}
+			return m, nil
+		}
+		defer func() { getProcessMemory = defaultGetProcessMemory }()
+
+		a.observe(autoCaptureState(
+			autoCaptureComponent("filestream-default", runtime.ComponentState{State: client.UnitStateHealthy, PID: 42}),
+			autoCaptureComponent("endpoint-default", runtime.ComponentState{State: client.UnitStateHealthy, PID: 43}),
+		))
+		triggers := a.checkMemory()
+		require.Len(t, triggers, 1)
+		assert.Equal(t, ReasonMemory, triggers[0].Reason)
+		assert.Equal(t, "filestream-default", triggers[0].ComponentID)
+		assert.Equal(t, "filestream-default uses 150B of resident memory, over the threshold of 100B", triggers[0].Message)
+
+		assert.Empty(t, a.checkMemory(), "a process staying over the threshold is detected once")
+
+		memory[os.Getpid()] = 200
+		triggers = a.checkMemory()
+		require.Len(t, triggers, 1)
+		assert.Empty(t, triggers[0].ComponentID, "the agent process has no component ID")
+		assert.Contains(t, triggers[0].Message, "elastic-agent uses 200B")
+	})
+}
+
+var defaultGetProcessMemory = getProcessMemory
+
+func TestAutoCaptureCapture(t *testing.T) {
+	cfg := config.DefaultConfig().Diagnostics.AutoCapture
+	cfg.Enabled = true
+	cfg.Upload = true
+	a, provider, now := newTestAutoCapture(t, cfg)
+	provider.EXPECT().DiagnosticHooks().Return([]diagnostics.Hook{testHook})
+	provider.EXPECT().PerformDiagnostics(mock.Anything).Return([]runtime.ComponentUnitDiagnostic{testUnitDiagnostic})
+	provider.EXPECT().PerformComponentDiagnostics(mock.Anything, mock.Anything).Return([]runtime.ComponentDiagnostic{}, nil)
+
+	uploader := mocks.NewUploader(t)
+	// Fleet doesn't list the bundles not requested by an action, they are uploaded with an action ID of their own
+	uploader.EXPECT().UploadDiagnostics(mock.Anything, "auto-capture-2024-01-02T10-00-00Z-00", "2024-01-02T10-00-00Z-00", mock.Anything, mock.Anything).
+		RunAndReturn(func(_ context.Context, _ string, _ string, size int64, r io.Reader) (string, error) {
+			content, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.EqualValues(t, size, len(content), "the whole bundle must be uploaded")
+			return "upload-id", nil
+		}).Once()
+	a.SetUploader(uploader)
+
+	trigger := Trigger{Reason: ReasonFailed, ComponentID: "filestream-default", Message: "Failed", Timestamp: *now}
+	a.capture(context.Background(), []Trigger{trigger})
+
+	bundle := filepath.Join(paths.DiagnosticsCapturesDir(), "elastic-agent-diagnostics-2024-01-02T10-00-00Z-00-failed.zip")
+	zr, err := zip.OpenReader(bundle)
+	require.NoError(t, err)
+	defer zr.Close()
+	f, err := zr.Open(captureTriggerFilename)
+	require.NoError(t, err)
+	content, err := io.ReadAll(f)
+	require.NoError(t, err)
+	f.Close()
+	var described map[string][]Trigger
+	require.NoError(t, yaml.Unmarshal(content, &described))
+	assert.Equal(t, map[string][]Trigger{"triggers": {trigger}}, described)
+
+	*now = now.Add(time.Minute)
+	a.capture(context.Background(), []Trigger{trigger})
+	entries, err := os.ReadDir(paths.DiagnosticsCapturesDir())
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no bundle is captured within the minimum interval")
+}
+
+func TestAutoCaptureRotate(t *testing.T) {
+	cfg := config.DefaultConfig().Diagnostics.AutoCapture
+	cfg.MaxSize = 250
+	a, _, _ := newTestAutoCapture(t, cfg)
+	require.NoError(t, os.MkdirAll(a.dir, 0o700))
+
+	write := func(name string, size int, age time.Duration) string {
+		p := filepath.Join(a.dir, name)
+		require.NoError(t, os.WriteFile(p, make([]byte, size), 0o600))
+		mt := time.Now().Add(-age)
+		require.NoError(t, os.Chtimes(p, mt, mt))
+		return p
+	}
+	oldest := write("elastic-agent-diagnostics-1-failed.zip", 100, 3*time.Hour)
+	older := write("elastic-agent-diagnostics-2-memory.zip", 100, 2*time.Hour)
+	other := write("notes.txt", 1000, 4*time.Hour)
+	newest := write("elastic-agent-diagnostics-3-periodic.zip", 100, time.Hour)
+
+	a.rotate(newest)
+	assert.NoFileExists(t, oldest)
+	assert.FileExists(t, older)
+	assert.FileExists(t, newest)
+	assert.FileExists(t, other, "only the bundles are rotated")
+
+	huge := write("elastic-agent-diagnostics-4-periodic.zip", 1000, 0)
+	a.rotate(huge)
+	assert.NoFileExists(t, older)
+	assert.NoFileExists(t, newest)
+	assert.FileExists(t, huge, "the bundle just captured is kept")
+}
+
+func TestAutoCaptureRun(t *testing.T) {
+	cfg := config.DefaultConfig().Diagnostics.AutoCapture
+	cfg.Enabled = true
+	paths.SetTop(t.TempDir())
+	provider := mocks.NewDiagnosticsProvider(t)
+	provider.EXPECT().DiagnosticHooks().Return([]diagnostics.Hook{testHook})
+	provider.EXPECT().PerformDiagnostics(mock.Anything).Return(nil)
+	provider.EXPECT().PerformComponentDiagnostics(mock.Anything, mock.Anything).Return(nil, nil)
+	ch := make(chan coordinator.State, 1)
+	testLogger, _ := logger.NewTesting("diagnostics-auto-capture-test")
+	a := New(testLogger, &testStateProvider{DiagnosticsProvider: provider, ch: ch}, cfg, defaultRedactionFn)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		a.Run(ctx)
+		close(done)
+	}()
+	ch <- autoCaptureState(autoCaptureComponent("filestream-default", runtime.ComponentState{State: client.UnitStateFailed, Message: "Failed"}))
+
+	require.Eventually(t, func() bool {
+		matches, _ := filepath.Glob(filepath.Join(paths.DiagnosticsCapturesDir(), "elastic-agent-diagnostics-*-failed.zip"))
+		return len(matches) == 1
+	}, 10*time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/go-multierror"
@@ -20,6 +21,7 @@ import (
 	"github.com/elastic/elastic-agent-client/v7/pkg/client"
 	"github.com/elastic/elastic-agent-libs/logp"
 
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/actions/journal"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/info"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/reexec"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade"
@@ -28,6 +30,7 @@ import (
 	"github.com/elastic/elastic-agent/internal/pkg/agent/transpiler"
 	"github.com/elastic/elastic-agent/internal/pkg/capabilities"
 	"github.com/elastic/elastic-agent/internal/pkg/config"
+	monitoringCfg "github.com/elastic/elastic-agent/internal/pkg/core/monitoring/config"
 	"github.com/elastic/elastic-agent/internal/pkg/diagnostics"
 	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
 	"github.com/elastic/elastic-agent/internal/pkg/fleetapi/acker"
@@ -50,6 +53,14 @@ var ErrNotUpgradable = errors.New(
 // attempted at the same time.
 var ErrUpgradeInProgress = errors.New("upgrade already in progress")
 
+// ErrNoActionQueue error is returned when cancelling a queued action of an agent
+// not managed by Fleet, thus without action queue.
+var ErrNoActionQueue = errors.New("agent is not managed by Fleet, it has no action queue")
+
+// ErrNoActionJournal error is returned when querying the action journal of an agent
+// not managed by Fleet, thus without Fleet actions.
+var ErrNoActionJournal = errors.New("agent is not managed by Fleet, it has no action journal")
+
 // ReExecManager provides an interface to perform re-execution of the entire agent.
 type ReExecManager interface {
 	ReExec(callback reexec.ShutdownCallbackFn, argOverrides ...string)
@@ -66,6 +77,9 @@ type UpgradeManager interface {
 	// Upgrade upgrades running agent.
 	Upgrade(ctx context.Context, version string, sourceURI string, action *fleetapi.ActionUpgrade, details *details.Details, skipVerifyOverride bool, skipDefaultPgp bool, pgpBytes ...string) (_ reexec.ShutdownCallbackFn, err error)
 
+	// Rollback switches the running agent back to a previously installed version.
+	Rollback(ctx context.Context, version string, details *details.Details) (_ string, _ reexec.ShutdownCallbackFn, err error)
+
 	// Ack is used on startup to check if the agent has upgraded and needs to send an ack for the action
 	Ack(ctx context.Context, acker acker.Acker) error
 
@@ -148,6 +162,21 @@ type ConfigManager interface {
 	Watch() <-chan ConfigChange
 }
 
+// ActionQueueManager is implemented by the ConfigManager holding the queue of the
+// Fleet actions waiting for their start time.
+type ActionQueueManager interface {
+	// CancelQueuedAction removes the action with actionID from the action queue and
+	// acks it to Fleet as failed.
+	CancelQueuedAction(ctx context.Context, actionID string) error
+}
+
+// ActionHistoryProvider is implemented by the ConfigManager recording the lifecycle of
+// the Fleet actions in the action journal.
+type ActionHistoryProvider interface {
+	// ActionHistory returns the entries of the action journal selected by filter.
+	ActionHistory(filter journal.Filter) ([]journal.Entry, error)
+}
+
 // VarsManager provides an interface to run and watch for variable changes.
 type VarsManager interface {
 	Runner
@@ -168,6 +197,11 @@ type configReloader interface {
 	Reload(*config.Config) error
 }
 
+// BackgroundTask is run alongside the Coordinator until it stops.
+type BackgroundTask interface {
+	Run(ctx context.Context)
+}
+
 // Coordinator manages the entire state of the Elastic Agent.
 //
 // All configuration changes, update variables, and upgrade actions are managed and controlled by the coordinator.
@@ -185,6 +219,9 @@ type Coordinator struct {
 
 	monitoringServerReloader configReloader
 
+	// backgroundTasks are the tasks registered with RegisterBackgroundTask.
+	backgroundTasks []BackgroundTask
+
 	runtimeMgr RuntimeManager
 	configMgr  ConfigManager
 	varsMgr    VarsManager
@@ -224,6 +261,10 @@ type Coordinator struct {
 	// SetUpgradeDetails helper to the Coordinator goroutine.
 	upgradeDetailsChan chan *details.Details
 
+	// queuedActionsChan forwards the queued actions from the publicly accessible
+	// SetQueuedActions helper to the Coordinator goroutine.
+	queuedActionsChan chan []QueuedAction
+
 	// loglevelCh forwards log level changes from the public API (SetLogLevel)
 	// to the run loop in Coordinator's main goroutine.
 	logLevelCh chan logp.Level
@@ -273,6 +314,17 @@ type Coordinator struct {
 	// The final component model generated from ast and vars (this is the same
 	// value that is sent to the runtime manager).
 	componentModel []component.Component
+	// The components of the policy blocked by the capabilities, not in componentModel.
+	blockedComponents []component.Component
+	// componentModelMx guards componentModel and blockedComponents for the
+	// external goroutines reading them through ComponentModel.
+	componentModelMx sync.RWMutex
+
+	// The redaction policy of the diagnostics bundles from the current configuration.
+	diagnosticsRedaction monitoringCfg.Redaction
+	// diagnosticsRedactionMx guards diagnosticsRedaction for the external goroutines
+	// reading it through DiagnosticsRedaction.
+	diagnosticsRedactionMx sync.RWMutex
 
 	// Disabled for 8.8.0 release in order to limit the surface
 	// https://github.com/elastic/security-team/issues/6501
@@ -372,6 +424,7 @@ func New(logger *logger.Logger, cfg *configuration.Configuration, logLevel logp.
 		logLevelCh:         make(chan logp.Level),
 		overrideStateChan:  make(chan *coordinatorOverrideState),
 		upgradeDetailsChan: make(chan *details.Details),
+		queuedActionsChan:  make(chan []QueuedAction),
 	}
 	// Setup communication channels for any non-nil components. This pattern
 	// lets us transparently accept nil managers / simulated events during
@@ -400,6 +453,11 @@ func New(logger *logger.Logger, cfg *configuration.Configuration, logLevel logp.
 		c.managerChans.varsManagerUpdate = varsMgr.Watch()
 		c.managerChans.varsManagerError = varsMgr.Errors()
 	}
+	if cfg != nil && cfg.Settings != nil && cfg.Settings.MonitoringConfig != nil {
+		c.diagnosticsRedaction = cfg.Settings.MonitoringConfig.Diagnostics.Redaction
+	} else {
+		c.diagnosticsRedaction = monitoringCfg.DefaultConfig().Diagnostics.Redaction
+	}
 	if upgradeMgr != nil && upgradeMgr.MarkerWatcher() != nil {
 		c.managerChans.upgradeMarkerUpdate = upgradeMgr.MarkerWatcher().Watch()
 	}
@@ -416,6 +474,12 @@ func (c *Coordinator) RegisterMonitoringServer(s configReloader) {
 	c.monitoringServerReloader = s
 }
 
+// RegisterBackgroundTask registers a task run by the Coordinator until it stops.
+// It must be called before Run.
+func (c *Coordinator) RegisterBackgroundTask(t BackgroundTask) {
+	c.backgroundTasks = append(c.backgroundTasks, t)
+}
+
 // StateSubscribe returns a channel that reports changes in Coordinator state.
 //
 // bufferLen specifies how many state changes should be queued in addition to
@@ -511,13 +575,94 @@ func (c *Coordinator) Upgrade(ctx context.Context, version string, sourceURI str
 		det.Fail(err)
 		return err
 	}
-	if cb != nil {
-		det.SetState(details.StateRestarting)
-		c.ReExec(cb)
+	if cb == nil {
+		// upgrade was only prepared, the agent keeps running the current version
+		c.ClearOverrideState()
+		return nil
 	}
+	det.SetState(details.StateRestarting)
+	c.ReExec(cb)
 	return nil
 }
 
+// Rollback switches the agent back to a previously installed version and restarts it.
+// It returns the version being rolled back to.
+// Called from external goroutines.
+func (c *Coordinator) Rollback(ctx context.Context, version string) (string, error) {
+	// early check outside of upgrader before overriding the state
+	if !c.upgradeMgr.Upgradeable() {
+		return "", ErrNotUpgradable
+	}
+
+	if c.State().State == agentclient.Upgrading {
+		return "", ErrUpgradeInProgress
+	}
+
+	// override the overall state to upgrading until the re-execution is complete
+	c.SetOverrideState(agentclient.Upgrading, "Rolling back to a previous version")
+
+	det := details.NewDetails(version, details.StateRollback, "")
+	det.RegisterObserver(c.SetUpgradeDetails)
+
+	rolledBackVersion, cb, err := c.upgradeMgr.Rollback(ctx, version, det)
+	if err != nil {
+		c.ClearOverrideState()
+		det.Fail(err)
+		return "", err
+	}
+
+	det.SetState(details.StateRestarting)
+	c.ReExec(cb)
+	return rolledBackVersion, nil
+}
+
+// CancelQueuedAction cancels the Fleet action with actionID waiting in the action queue.
+// Called from external goroutines.
+func (c *Coordinator) CancelQueuedAction(ctx context.Context, actionID string) error {
+	m, ok := c.baseConfigManager().(ActionQueueManager)
+	if !ok {
+		return ErrNoActionQueue
+	}
+	return m.CancelQueuedAction(ctx, actionID)
+}
+
+// baseConfigManager returns the config manager without the ConfigPatchManager
+// decorating it in Fleet mode.
+func (c *Coordinator) baseConfigManager() ConfigManager {
+	if p, ok := c.configMgr.(*ConfigPatchManager); ok {
+		return p.inner
+	}
+	return c.configMgr
+}
+
+// ComponentModel returns the current component model, and the components of the
+// policy blocked by the capabilities.
+// Called from external goroutines.
+func (c *Coordinator) ComponentModel() (components []component.Component, blocked []component.Component) {
+	c.componentModelMx.RLock()
+	defer c.componentModelMx.RUnlock()
+	return c.componentModel, c.blockedComponents
+}
+
+// DiagnosticsRedaction returns the redaction policy of the diagnostics bundles from the
+// current configuration.
+// Called from external goroutines.
+func (c *Coordinator) DiagnosticsRedaction() monitoringCfg.Redaction {
+	c.diagnosticsRedactionMx.RLock()
+	defer c.diagnosticsRedactionMx.RUnlock()
+	return c.diagnosticsRedaction
+}
+
+// ActionHistory returns the entries of the action journal selected by filter.
+// Called from external goroutines.
+func (c *Coordinator) ActionHistory(filter journal.Filter) ([]journal.Entry, error) {
+	p, ok := c.baseConfigManager().(ActionHistoryProvider)
+	if !ok {
+		return nil, ErrNoActionJournal
+	}
+	return p.ActionHistory(filter)
+}
+
 func (c *Coordinator) logUpgradeDetails(details *details.Details) {
 	c.logger.Infow("updated upgrade details", "upgrade_details", details)
 }
@@ -691,7 +836,7 @@ func (c *Coordinator) Run(ctx context.Context) error {
 // information about the state of the Elastic Agent.
 // Called by external goroutines.
 func (c *Coordinator) DiagnosticHooks() diagnostics.Hooks {
-	return diagnostics.Hooks{
+	hooks := diagnostics.Hooks{
 		{
 			Name:        "local-config",
 			Filename:    "local-config.yaml",
@@ -776,7 +921,7 @@ func (c *Coordinator) DiagnosticHooks() diagnostics.Hooks {
 			Description: "current expected components model of the running Elastic Agent",
 			ContentType: "application/yaml",
 			Hook: func(_ context.Context) []byte {
-				comps := c.componentModel
+				comps, _ := c.ComponentModel()
 				o, err := yaml.Marshal(struct {
 					Components []component.Component `yaml:"components"`
 				}{
@@ -829,6 +974,7 @@ func (c *Coordinator) DiagnosticHooks() diagnostics.Hooks {
 					LogLevel       logp.Level             `yaml:"log_level"`
 					Components     []StateComponentOutput `yaml:"components"`
 					UpgradeDetails *details.Details       `yaml:"upgrade_details,omitempty"`
+					QueuedActions  []QueuedAction         `yaml:"queued_actions,omitempty"`
 				}
 
 				s := c.State()
@@ -848,6 +994,7 @@ func (c *Coordinator) DiagnosticHooks() diagnostics.Hooks {
 					LogLevel:       s.LogLevel,
 					Components:     compStates,
 					UpgradeDetails: s.UpgradeDetails,
+					QueuedActions:  s.QueuedActions,
 				}
 				o, err := yaml.Marshal(output)
 				if err != nil {
@@ -857,6 +1004,32 @@ func (c *Coordinator) DiagnosticHooks() diagnostics.Hooks {
 			},
 		},
 	}
+
+	// standalone agents receive no Fleet action, their bundles have no action journal
+	if _, ok := c.baseConfigManager().(ActionHistoryProvider); ok {
+		hooks = append(hooks, diagnostics.Hook{
+			Name:        "action-journal",
+			Filename:    "action-journal.yaml",
+			Description: "lifecycle of the Fleet actions received by the Elastic Agent",
+			ContentType: "application/yaml",
+			Hook: func(_ context.Context) []byte {
+				entries, err := c.ActionHistory(journal.Filter{})
+				if err != nil {
+					return []byte(fmt.Sprintf("error: %q", err))
+				}
+				o, err := yaml.Marshal(struct {
+					Entries []journal.Entry `yaml:"entries"`
+				}{
+					Entries: entries,
+				})
+				if err != nil {
+					return []byte(fmt.Sprintf("error: %q", err))
+				}
+				return o
+			},
+		})
+	}
+	return hooks
 }
 
 // runner performs the actual work of running all the managers.
@@ -918,6 +1091,11 @@ func (c *Coordinator) runner(ctx context.Context) error {
 		upgradeMarkerWatcherErrCh <- nil
 	}
 
+	// the background tasks stop with the context, they aren't waited for on shutdown
+	for _, t := range c.backgroundTasks {
+		go t.Run(ctx)
+	}
+
 	// Keep looping until the context ends.
 	for ctx.Err() == nil {
 		c.runLoopIteration(ctx)
@@ -954,9 +1132,11 @@ func (c *Coordinator) runLoopIteration(ctx context.Context) {
 			var wErr *WarningError
 			if configErr == nil {
 				c.setFleetState(agentclient.Healthy, "Connected")
+				c.setFleetLastCheckin(time.Now().UTC())
 			} else if errors.As(configErr, &wErr) {
 				// we received a warning from Fleet, set state to degraded and the warning as state string
 				c.setFleetState(agentclient.Degraded, wErr.Error())
+				c.setFleetLastCheckin(time.Now().UTC())
 			} else {
 				c.setFleetState(agentclient.Failed, configErr.Error())
 			}
@@ -977,6 +1157,9 @@ func (c *Coordinator) runLoopIteration(ctx context.Context) {
 	case upgradeDetails := <-c.upgradeDetailsChan:
 		c.setUpgradeDetails(upgradeDetails)
 
+	case queuedActions := <-c.queuedActionsChan:
+		c.setQueuedActions(queuedActions)
+
 	case componentState := <-c.managerChans.runtimeManagerUpdate:
 		// New component change reported by the runtime manager via
 		// Coordinator.watchRuntimeComponents(), merge it with the
@@ -1118,6 +1301,14 @@ func (c *Coordinator) generateAST(cfg *config.Config) (err error) {
 		}
 	}
 
+	newConfig := configuration.DefaultConfiguration()
+	if err := cfg.Unpack(&newConfig); err != nil {
+		return fmt.Errorf("failed to unpack the diagnostics configuration: %w", err)
+	}
+	c.diagnosticsRedactionMx.Lock()
+	c.diagnosticsRedaction = newConfig.Settings.MonitoringConfig.Diagnostics.Redaction
+	c.diagnosticsRedactionMx.Unlock()
+
 	c.ast = rawAst
 	return nil
 }
@@ -1227,7 +1418,7 @@ func (c *Coordinator) generateComponentModel() (err error) {
 	}
 
 	// Filter any disallowed inputs/outputs from the components
-	comps = c.filterByCapabilities(comps)
+	comps, blocked := c.filterByCapabilities(comps)
 
 	for _, modifier := range c.modifiers {
 		comps, err = modifier(comps, cfg)
@@ -1241,7 +1432,10 @@ func (c *Coordinator) generateComponentModel() (err error) {
 	c.derivedConfig = cfg
 
 	lastComponentModel := c.componentModel
+	c.componentModelMx.Lock()
 	c.componentModel = comps
+	c.blockedComponents = blocked
+	c.componentModelMx.Unlock()
 
 	c.checkAndLogUpdate(lastComponentModel)
 
@@ -1384,25 +1578,28 @@ func (c *Coordinator) checkAndLogUpdate(lastComponentModel []component.Component
 
 // Filter any inputs and outputs in the generated component model
 // based on whether they're excluded by the capabilities config
-func (c *Coordinator) filterByCapabilities(comps []component.Component) []component.Component {
+func (c *Coordinator) filterByCapabilities(comps []component.Component) ([]component.Component, []component.Component) {
 	if c.caps == nil {
 		// No active filters, return unchanged
-		return comps
+		return comps, nil
 	}
 	result := []component.Component{}
+	var blocked []component.Component
 	for _, component := range comps {
 		// If this is an input component (not a shipper), make sure its type is allowed
 		if component.InputSpec != nil && !c.caps.AllowInput(component.InputType) {
 			c.logger.Infof("Component '%v' with input type '%v' filtered by capabilities.yml", component.ID, component.InputType)
+			blocked = append(blocked, component)
 			continue
 		}
 		if !c.caps.AllowOutput(component.OutputType) {
 			c.logger.Infof("Component '%v' with output type '%v' filtered by capabilities.yml", component.ID, component.OutputType)
+			blocked = append(blocked, component)
 			continue
 		}
 		result = append(result, component)
 	}
-	return result
+	return result, blocked
 }
 
 // helpers for checkAndLogUpdate
@@ -6,6 +6,7 @@ package coordinator
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/elastic/elastic-agent-client/v7/pkg/client"
 
@@ -29,11 +30,24 @@ type State struct {
 	// The state of the
 	FleetState   agentclient.State `yaml:"fleet_state"`
 	FleetMessage string            `yaml:"fleet_message"`
+	// FleetLastCheckin is the time of the last successful checkin with Fleet Server.
+	FleetLastCheckin time.Time `yaml:"fleet_last_checkin,omitempty"`
 
 	Components []runtime.ComponentComponentState `yaml:"components"`
 	LogLevel   logp.Level                        `yaml:"log_level"`
 
 	UpgradeDetails *details.Details `yaml:"upgrade_details,omitempty"`
+
+	QueuedActions []QueuedAction `yaml:"queued_actions,omitempty"`
+}
+
+// QueuedAction is a Fleet action waiting in the action queue for its start time.
+type QueuedAction struct {
+	ID           string    `yaml:"id"`
+	Type         string    `yaml:"type"`
+	StartTime    time.Time `yaml:"start_time"`
+	Expiration   time.Time `yaml:"expiration,omitempty"`
+	RetryAttempt int       `yaml:"retry_attempt,omitempty"`
 }
 
 type coordinatorOverrideState struct {
@@ -63,6 +77,11 @@ func (c *Coordinator) SetUpgradeDetails(upgradeDetails *details.Details) {
 	c.upgradeDetailsChan <- upgradeDetails
 }
 
+// SetQueuedActions sets the actions waiting in the action queue.
+func (c *Coordinator) SetQueuedActions(actions []QueuedAction) {
+	c.queuedActionsChan <- actions
+}
+
 // setRuntimeUpdateError reports a failed policy update in the runtime manager.
 // Called on the main Coordinator goroutine.
 func (c *Coordinator) setRuntimeUpdateError(err error) {
@@ -124,6 +143,13 @@ func (c *Coordinator) setUpgradeDetails(upgradeDetails *details.Details) {
 	c.logUpgradeDetails(upgradeDetails)
 }
 
+// setQueuedActions is the internal helper to set the queued actions and set stateNeedsRefresh.
+// Must be called on the main Coordinator goroutine.
+func (c *Coordinator) setQueuedActions(actions []QueuedAction) {
+	c.state.QueuedActions = actions
+	c.stateNeedsRefresh = true
+}
+
 // Forward the current state to the broadcaster and clear the stateNeedsRefresh
 // flag. Must be called on the main Coordinator goroutine.
 func (c *Coordinator) refreshState() {
@@ -172,8 +198,10 @@ func (c *Coordinator) generateReportableState() (s State) {
 	s.CoordinatorMessage = c.state.CoordinatorMessage
 	s.FleetState = c.state.FleetState
 	s.FleetMessage = c.state.FleetMessage
+	s.FleetLastCheckin = c.state.FleetLastCheckin
 	s.LogLevel = c.state.LogLevel
 	s.UpgradeDetails = c.state.UpgradeDetails
+	s.QueuedActions = c.state.QueuedActions
 	s.Components = make([]runtime.ComponentComponentState, len(c.state.Components))
 	copy(s.Components, c.state.Components)
 
@@ -235,6 +263,13 @@ func (c *Coordinator) setFleetState(state agentclient.State, message string) {
 	c.stateNeedsRefresh = true
 }
 
+// setFleetLastCheckin records the time of the last successful checkin with Fleet Server.
+// Must be called on the main Coordinator goroutine.
+func (c *Coordinator) setFleetLastCheckin(t time.Time) {
+	c.state.FleetLastCheckin = t
+	c.stateNeedsRefresh = true
+}
+
 // setLogLevel changes the log level state of the coordinator.
 // Must be called on the main Coordinator goroutine.
 func (c *Coordinator) setLogLevel(logLevel logp.Level) {
@@ -877,6 +877,10 @@ func (f *fakeUpgradeManager) Upgrade(ctx context.Context, version string, source
 	return func() error { return nil }, nil
 }
 
+func (f *fakeUpgradeManager) Rollback(ctx context.Context, version string, details *details.Details) (_ string, _ reexec.ShutdownCallbackFn, err error) {
+	return version, func() error { return nil }, nil
+}
+
 func (f *fakeUpgradeManager) Ack(ctx context.Context, acker acker.Acker) error {
 	return nil
 }
@@ -1085,3 +1089,71 @@ func testBinary(t *testing.T, name string) string {
 	}
 	return binaryPath
 }
+
+type denyCapabilities struct {
+	inputs  []string
+	outputs []string
+}
+
+func (c denyCapabilities) AllowUpgrade(string, string) bool { return true }
+
+func (c denyCapabilities) AllowInput(name string) bool {
+	for _, i := range c.inputs {
+		if i == name {
+			return false
+		}
+	}
+	return true
+}
+
+func (c denyCapabilities) AllowOutput(name string) bool {
+	for _, o := range c.outputs {
+		if o == name {
+			return false
+		}
+	}
+	return true
+}
+
+type fakeActionQueueManager struct {
+	*fakeConfigManager
+	cancelled []string
+}
+
+func (f *fakeActionQueueManager) CancelQueuedAction(_ context.Context, actionID string) error {
+	f.cancelled = append(f.cancelled, actionID)
+	return nil
+}
+
+func TestCoordinatorCancelQueuedAction(t *testing.T) {
+	t.Run("managed agent", func(t *testing.T) {
+		mgr := &fakeActionQueueManager{fakeConfigManager: newFakeConfigManager()}
+		coord := &Coordinator{configMgr: NewConfigPatchManager(mgr, func(change ConfigChange) ConfigChange { return change })}
+
+		require.NoError(t, coord.CancelQueuedAction(context.Background(), "action-1"))
+		assert.Equal(t, []string{"action-1"}, mgr.cancelled, "the action must be cancelled by the config manager decorated in Fleet mode")
+	})
+
+	t.Run("standalone agent", func(t *testing.T) {
+		coord := &Coordinator{configMgr: newFakeConfigManager()}
+		assert.ErrorIs(t, coord.CancelQueuedAction(context.Background(), "action-1"), ErrNoActionQueue)
+	})
+}
+
+func TestFilterByCapabilitiesReturnsBlocked(t *testing.T) {
+	comps := []component.Component{
+		{ID: "filestream-default", InputType: "filestream", OutputType: "elasticsearch", InputSpec: &component.InputRuntimeSpec{}},
+		{ID: "system/metrics-default", InputType: "system/metrics", OutputType: "elasticsearch", InputSpec: &component.InputRuntimeSpec{}},
+		{ID: "filestream-kafka", InputType: "filestream", OutputType: "kafka", InputSpec: &component.InputRuntimeSpec{}},
+	}
+
+	coord := &Coordinator{logger: newErrorLogger(t)}
+	allowed, blocked := coord.filterByCapabilities(comps)
+	assert.Equal(t, comps, allowed, "without capabilities all the components are allowed")
+	assert.Empty(t, blocked)
+
+	coord.caps = denyCapabilities{inputs: []string{"system/metrics"}, outputs: []string{"kafka"}}
+	allowed, blocked = coord.filterByCapabilities(comps)
+	assert.Equal(t, []component.Component{comps[0]}, allowed)
+	assert.Equal(t, []component.Component{comps[1], comps[2]}, blocked)
+}
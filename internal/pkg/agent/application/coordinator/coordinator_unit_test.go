@@ -667,6 +667,88 @@ inputs:
 	assert.True(t, monitoringServer.isRunning)
 }
 
+type testBackgroundTask struct {
+	started chan struct{}
+	stopped chan struct{}
+}
+
+func (t *testBackgroundTask) Run(ctx context.Context) {
+	close(t.started)
+	<-ctx.Done()
+	close(t.stopped)
+}
+
+func TestCoordinatorRunsBackgroundTasks(t *testing.T) {
+	coord := &Coordinator{
+		logger:           logp.NewLogger("testing"),
+		agentInfo:        &info.AgentInfo{},
+		stateBroadcaster: broadcaster.New(State{}, 0, 0),
+	}
+	task := &testBackgroundTask{started: make(chan struct{}), stopped: make(chan struct{})}
+	coord.RegisterBackgroundTask(task)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		_ = coord.runner(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-task.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the background task was not started")
+	}
+	cancel()
+	select {
+	case <-task.stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the background task was not stopped with the Coordinator")
+	}
+	<-done
+}
+
+func TestCoordinatorPolicyChangeUpdatesDiagnosticsRedaction(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	logger := logp.NewLogger("testing")
+
+	configChan := make(chan ConfigChange, 1)
+	coord := &Coordinator{
+		logger:           logger,
+		agentInfo:        &info.AgentInfo{},
+		stateBroadcaster: broadcaster.New(State{}, 0, 0),
+		managerChans: managerChans{
+			configManagerUpdate: configChan,
+		},
+		runtimeMgr: &fakeRuntimeManager{},
+		vars:       emptyVars(t),
+	}
+
+	cfg := config.MustNewConfigFrom(`
+agent.monitoring.diagnostics.redaction.mode: hash
+outputs:
+  default:
+    type: elasticsearch
+`)
+	cfgChange := &configChange{cfg: cfg}
+	configChan <- cfgChange
+	coord.runLoopIteration(ctx)
+	assert.True(t, cfgChange.acked, "Coordinator should ACK a successful policy change")
+	assert.Equal(t, "hash", coord.DiagnosticsRedaction().Mode)
+
+	// the default policy is restored when the policy no longer sets it
+	cfgChange = &configChange{cfg: config.MustNewConfigFrom(`
+outputs:
+  default:
+    type: elasticsearch
+`)}
+	configChan <- cfgChange
+	coord.runLoopIteration(ctx)
+	assert.True(t, cfgChange.acked, "Coordinator should ACK a successful policy change")
+	assert.Equal(t, monitoringCfg.DefaultConfig().Diagnostics.Redaction, coord.DiagnosticsRedaction())
+}
+
 func TestCoordinatorPolicyChangeUpdatesRuntimeManager(t *testing.T) {
 	// Send a test policy to the Coordinator as a Config Manager update,
 	// verify it generates the right component model and sends it to the
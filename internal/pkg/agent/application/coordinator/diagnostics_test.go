@@ -18,6 +18,7 @@ import (
 	"github.com/elastic/elastic-agent-client/v7/pkg/client"
 	"github.com/elastic/elastic-agent-client/v7/pkg/proto"
 
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/actions/journal"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/details"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/configuration"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/transpiler"
@@ -87,6 +88,7 @@ agent:
   grpc: null
   id: ""
   path: ""
+  policy_signature: null
   process: null
   reload: null
   upgrade: null
@@ -572,6 +574,60 @@ components:
 	assert.YAMLEq(t, expected, string(result), "state diagnostic returned unexpected value")
 }
 
+type fakeActionHistoryManager struct {
+	*fakeConfigManager
+	entries []journal.Entry
+}
+
+func (f *fakeActionHistoryManager) ActionHistory(filter journal.Filter) ([]journal.Entry, error) {
+	var entries []journal.Entry
+	for _, e := range f.entries {
+		if filter.Match(e) {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+func TestDiagnosticActionJournal(t *testing.T) {
+	t.Run("managed agent", func(t *testing.T) {
+		// the config manager is decorated in Fleet mode
+		coord := &Coordinator{
+			configMgr: NewConfigPatchManager(&fakeActionHistoryManager{
+				fakeConfigManager: newFakeConfigManager(),
+				entries: []journal.Entry{
+					{
+						Timestamp:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+						ActionID:   "action-1",
+						ActionType: "SETTINGS",
+						Status:     journal.StatusFailed,
+						Error:      "invalid log level",
+					},
+				},
+			}, func(change ConfigChange) ConfigChange { return change }),
+		}
+
+		expected := `
+entries:
+  - timestamp: 2024-01-01T00:00:00Z
+    action_id: action-1
+    action_type: SETTINGS
+    status: failed
+    error: invalid log level
+`
+		hook, ok := diagnosticHooksMap(coord)["action-journal"]
+		require.True(t, ok, "diagnostic hooks should have an entry for action-journal")
+		assert.YAMLEq(t, expected, string(hook.Hook(context.Background())))
+	})
+
+	t.Run("standalone agent", func(t *testing.T) {
+		coord := &Coordinator{configMgr: newFakeConfigManager()}
+
+		_, ok := diagnosticHooksMap(coord)["action-journal"]
+		assert.False(t, ok, "standalone agents have no action journal in their diagnostics")
+	})
+}
+
 // Fetch the diagnostic hooks and add them to a lookup table for
 // easier verification
 func diagnosticHooksMap(coord *Coordinator) map[string]diagnostics.Hook {
@@ -14,6 +14,7 @@ import (
 	"go.elastic.co/apm"
 
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/actions"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/actions/journal"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/details"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/errors"
 	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
@@ -44,12 +45,37 @@ type ActionDispatcher struct {
 	queue    priorityQueue
 	rt       *retryConfig
 	errCh    chan error
+	journal  *journal.Journal
+	// customTypes are the action types declared by the components
+	customTypes map[string]struct{}
 
 	lastUpgradeDetails *details.Details
 }
 
+// Option is an ActionDispatcher option function.
+type Option func(*ActionDispatcher)
+
+// WithJournal records the lifecycle of the dispatched actions in j.
+func WithJournal(j *journal.Journal) Option {
+	return func(ad *ActionDispatcher) {
+		ad.journal = j
+	}
+}
+
+// WithCustomActionTypes dispatches the actions of the types declared in the action_types of the
+// component specifications as fleetapi.ActionCustom, the actions of the other unknown types are
+// dispatched as fleetapi.ActionUnknown.
+func WithCustomActionTypes(actionTypes ...string) Option {
+	return func(ad *ActionDispatcher) {
+		ad.customTypes = make(map[string]struct{}, len(actionTypes))
+		for _, t := range actionTypes {
+			ad.customTypes[t] = struct{}{}
+		}
+	}
+}
+
 // New creates a new action dispatcher.
-func New(log *logger.Logger, def actions.Handler, queue priorityQueue) (*ActionDispatcher, error) {
+func New(log *logger.Logger, def actions.Handler, queue priorityQueue, opts ...Option) (*ActionDispatcher, error) {
 	var err error
 	if log == nil {
 		log, err = logger.New("action_dispatcher", false)
@@ -62,14 +88,18 @@ func New(log *logger.Logger, def actions.Handler, queue priorityQueue) (*ActionD
 		return nil, errors.New("missing default handler")
 	}
 
-	return &ActionDispatcher{
+	ad := &ActionDispatcher{
 		log:      log,
 		handlers: make(actionHandlers),
 		def:      def,
 		queue:    queue,
 		rt:       defaultRetryConfig(),
 		errCh:    make(chan error),
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(ad)
+	}
+	return ad, nil
 }
 
 func (ad *ActionDispatcher) Errors() <-chan error {
@@ -112,19 +142,24 @@ func (ad *ActionDispatcher) Dispatch(ctx context.Context, detailsSetter details.
 		span.End()
 	}()
 
+	actions = ad.customActions(actions)
+	ad.journal.Record(journal.StatusReceived, nil, actions...)
 	ad.removeQueuedUpgrades(actions)
 
 	// set scheduled action as soon as it's received
 	// report it before the scheduled actions go to the queue
 	ad.reportNextScheduledUpgrade(actions, detailsSetter, ad.log)
 
+	now := time.Now().UTC()
 	actions = ad.queueScheduledActions(actions)
 	actions = ad.dispatchCancelActions(ctx, actions, acker)
-	queued, expired := ad.gatherQueuedActions(time.Now().UTC())
+	actions, expired := separateExpiredActions(actions, now)
+	queued, expiredQueued := ad.gatherQueuedActions(now)
+	expired = append(expired, expiredQueued...)
 	ad.log.Debugf("Gathered %d actions from queue, %d actions expired", len(queued), len(expired))
 	ad.log.Debugf("Expired actions: %v", expired)
 
-	ad.handleExpired(expired, detailsSetter)
+	ad.handleExpired(ctx, expired, detailsSetter, acker)
 	actions = append(actions, queued...)
 
 	if err := ad.queue.Save(); err != nil {
@@ -150,6 +185,7 @@ func (ad *ActionDispatcher) Dispatch(ctx context.Context, detailsSetter details.
 		}
 
 		if err := ad.dispatchAction(ctx, action, acker); err != nil {
+			ad.journal.Record(journal.StatusFailed, err, action)
 			rAction, ok := action.(fleetapi.RetryableAction)
 			if ok {
 				rAction.SetError(err) // set the retryable action error to what the dispatcher returned
@@ -160,6 +196,7 @@ func (ad *ActionDispatcher) Dispatch(ctx context.Context, detailsSetter details.
 			reportedErr = err
 			continue
 		}
+		ad.journal.Record(journal.StatusDispatched, nil, action)
 		ad.log.Debugf("Successfully dispatched action: '%+v'", action)
 	}
 
@@ -181,6 +218,24 @@ func (ad *ActionDispatcher) dispatchAction(ctx context.Context, a fleetapi.Actio
 	return handler.Handle(ctx, a, acker)
 }
 
+// customActions replaces the unknown actions of a type declared by a component by the
+// corresponding custom action.
+func (ad *ActionDispatcher) customActions(actions []fleetapi.Action) []fleetapi.Action {
+	if len(ad.customTypes) == 0 {
+		return actions
+	}
+	for i, a := range actions {
+		unknown, ok := a.(*fleetapi.ActionUnknown)
+		if !ok {
+			continue
+		}
+		if _, ok := ad.customTypes[unknown.OriginalType()]; ok {
+			actions[i] = unknown.AsCustom()
+		}
+	}
+	return actions
+}
+
 func detectTypes(actions []fleetapi.Action) []string {
 	str := make([]string, len(actions))
 	for idx, action := range actions {
@@ -197,6 +252,10 @@ func (ad *ActionDispatcher) queueScheduledActions(input []fleetapi.Action) []fle
 		sAction, ok := action.(fleetapi.ScheduledAction)
 		if ok {
 			start, err := sAction.StartTime()
+			if errors.Is(err, fleetapi.ErrNoStartTime) {
+				actions = append(actions, action)
+				continue
+			}
 			if err != nil {
 				ad.log.Warnf("Skipping addition to action-queue, issue gathering start time from action id %s: %v", sAction.ID(), err)
 				actions = append(actions, action)
@@ -204,6 +263,7 @@ func (ad *ActionDispatcher) queueScheduledActions(input []fleetapi.Action) []fle
 			}
 			ad.log.Debugf("Adding action id: %s to queue.", sAction.ID())
 			ad.queue.Add(sAction, start.Unix())
+			ad.journal.Record(journal.StatusQueued, nil, sAction)
 			continue
 		}
 		actions = append(actions, action)
@@ -220,8 +280,11 @@ func (ad *ActionDispatcher) dispatchCancelActions(ctx context.Context, actions [
 		if action.Type() == fleetapi.ActionTypeCancel {
 			actions = append(actions[:i], actions[i+1:]...)
 			if err := ad.dispatchAction(ctx, action, acker); err != nil {
+				ad.journal.Record(journal.StatusFailed, err, action)
 				ad.log.Errorf("Unable to dispatch cancel action id %s: %v", action.ID(), err)
+				continue
 			}
+			ad.journal.Record(journal.StatusDispatched, nil, action)
 		}
 	}
 	return actions
@@ -231,8 +294,7 @@ func (ad *ActionDispatcher) dispatchCancelActions(ctx context.Context, actions [
 func (ad *ActionDispatcher) gatherQueuedActions(ts time.Time) (queued, expired []fleetapi.Action) {
 	actions := ad.queue.DequeueActions()
 	for _, action := range actions {
-		exp, _ := action.Expiration()
-		if ts.After(exp) {
+		if isExpired(action, ts) {
 			expired = append(expired, action)
 			continue
 		}
@@ -241,6 +303,29 @@ func (ad *ActionDispatcher) gatherQueuedActions(ts time.Time) (queued, expired [
 	return queued, expired
 }
 
+// separateExpiredActions separates the scheduled actions in input that already expired, they
+// are received expired when fleet-server delivers them after the agent was offline.
+func separateExpiredActions(input []fleetapi.Action, ts time.Time) (actions, expired []fleetapi.Action) {
+	actions = make([]fleetapi.Action, 0, len(input))
+	for _, action := range input {
+		if sAction, ok := action.(fleetapi.ScheduledAction); ok && isExpired(sAction, ts) {
+			expired = append(expired, action)
+			continue
+		}
+		actions = append(actions, action)
+	}
+	return actions, expired
+}
+
+// isExpired returns true if the action has an expiration before ts.
+func isExpired(action fleetapi.ScheduledAction, ts time.Time) bool {
+	exp, err := action.Expiration()
+	if err != nil {
+		return false
+	}
+	return ts.After(exp)
+}
+
 // removeQueuedUpgrades will scan the passed actions and if there is an upgrade action it will remove all upgrade actions in the queue but not alter the passed list.
 // this is done to try to only have the most recent upgrade action executed. However it does not eliminate duplicates in retrieved directly from the gateway
 func (ad *ActionDispatcher) removeQueuedUpgrades(actions []fleetapi.Action) {
@@ -275,6 +360,7 @@ func (ad *ActionDispatcher) scheduleRetry(ctx context.Context, action fleetapi.R
 	action.SetStartTime(startTime)
 	ad.log.Debugf("Adding action id: %s to queue.", action.ID())
 	ad.queue.Add(action, startTime.Unix())
+	ad.journal.Record(journal.StatusQueued, nil, action)
 	err = ad.queue.Save()
 	if err != nil {
 		ad.log.Errorf("retry action id %s attempt %d failed to persist action_queue: %v", action.ID(), attempt, err)
@@ -288,11 +374,39 @@ func (ad *ActionDispatcher) scheduleRetry(ctx context.Context, action fleetapi.R
 	}
 }
 
+// handleExpired acks the expired actions as failed without retry, the expired upgrade
+// actions are also reported as failed in the upgrade details.
 func (ad *ActionDispatcher) handleExpired(
+	ctx context.Context,
 	expired []fleetapi.Action,
-	upgradeDetailsSetter details.Observer) {
+	upgradeDetailsSetter details.Observer,
+	acker acker.Acker) {
+
+	if len(expired) == 0 {
+		return
+	}
+	defer func() {
+		if err := acker.Commit(ctx); err != nil {
+			ad.log.Errorf("Unable to commit expired actions to fleet-server: %v", err)
+		}
+	}()
 
 	for _, e := range expired {
+		if rAction, ok := e.(fleetapi.RetryableAction); ok {
+			rAction.SetRetryAttempt(-1)
+		}
+		if fAction, ok := e.(fleetapi.FailableAction); ok {
+			var exp time.Time
+			if sAction, ok := e.(fleetapi.ScheduledAction); ok {
+				exp, _ = sAction.Expiration()
+			}
+			fAction.SetError(fmt.Errorf("action %q expired on %s", e.ID(), exp.Format(time.RFC3339)))
+			ad.journal.Record(journal.StatusExpired, fAction.GetError(), fAction)
+			if err := acker.Ack(ctx, fAction); err != nil {
+				ad.log.Errorf("Unable to ack expired action (id %s) to fleet-server: %v", e.ID(), err)
+			}
+		}
+
 		if e.Type() == fleetapi.ActionTypeUpgrade {
 			// there is a scheduled upgrade set, if it isn't the same actions as
 			// the expired, the current status take precedence
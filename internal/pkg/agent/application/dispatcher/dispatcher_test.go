@@ -7,6 +7,7 @@ package dispatcher
 import (
 	"context"
 	"errors"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -16,6 +17,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/actions/journal"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/details"
 	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
 	"github.com/elastic/elastic-agent/internal/pkg/fleetapi/acker"
@@ -181,6 +183,40 @@ func TestActionDispatcher(t *testing.T) {
 		queue.AssertExpectations(t)
 	})
 
+	t.Run("Unknown action of a custom type is dispatched as a custom action", func(t *testing.T) {
+		def := &mockHandler{}
+		def.On("Handle", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+		ctx := context.Background()
+		queue := &mockQueue{}
+		queue.On("Save").Return(nil).Once()
+		queue.On("DequeueActions").Return([]fleetapi.ScheduledAction{}).Once()
+		d, err := New(nil, def, queue, WithCustomActionTypes("CUSTOM_RESTART"))
+		require.NoError(t, err)
+
+		custom := &mockHandler{}
+		custom.On("Handle", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+		require.NoError(t, d.Register(&fleetapi.ActionCustom{}, custom))
+
+		var actions fleetapi.Actions
+		require.NoError(t, actions.UnmarshalJSON([]byte(`[{"id":"custom","type":"CUSTOM_RESTART","timeout":30,"data":{"service":"foo"}},{"id":"other","type":"CUSTOM_STOP","data":{"service":"foo"}}]`)))
+
+		dispatchCtx, cancelFn := context.WithCancel(ctx)
+		defer cancelFn()
+		go d.Dispatch(dispatchCtx, detailsSetter, ack, actions...)
+		if err := <-d.Errors(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		custom.AssertCalled(t, "Handle", mock.Anything, &fleetapi.ActionCustom{
+			ActionID:   "custom",
+			ActionType: "CUSTOM_RESTART",
+			Timeout:    30,
+			Data:       []byte(`{"service":"foo"}`),
+		}, mock.Anything)
+		def.AssertCalled(t, "Handle", mock.Anything, mock.AnythingOfType("*fleetapi.ActionUnknown"), mock.Anything)
+		queue.AssertExpectations(t)
+	})
+
 	t.Run("Could not register two handlers on the same action", func(t *testing.T) {
 		success1 := &mockHandler{}
 		success2 := &mockHandler{}
@@ -344,6 +380,7 @@ func TestActionDispatcher(t *testing.T) {
 		action.On("Type").Return("action")
 		action.On("ID").Return("id")
 		action.On("StartTime").Return(time.Time{}, fleetapi.ErrNoStartTime).Once()
+		action.On("Expiration").Return(time.Time{}, fleetapi.ErrNoExpiration).Once()
 		action.On("SetError", mock.Anything).Once()
 		action.On("RetryAttempt").Return(0).Once()
 		action.On("SetRetryAttempt", 1).Once()
@@ -760,3 +797,136 @@ func TestReportNextScheduledUpgrade(t *testing.T) {
 		})
 	}
 }
+
+type recordingAcker struct {
+	acked     []fleetapi.Action
+	committed int
+}
+
+func (a *recordingAcker) Ack(_ context.Context, action fleetapi.Action) error {
+	a.acked = append(a.acked, action)
+	return nil
+}
+
+func (a *recordingAcker) Commit(context.Context) error {
+	a.committed++
+	return nil
+}
+
+func TestActionDispatcherScheduledActions(t *testing.T) {
+	detailsSetter := func(upgradeDetails *details.Details) {}
+
+	t.Run("scheduled diagnostics action is queued", func(t *testing.T) {
+		def := &mockHandler{}
+		start := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+		action := &fleetapi.ActionDiagnostics{
+			ActionID:   "diagnostics",
+			ActionType: fleetapi.ActionTypeDiagnostics,
+			ActionSchedule: fleetapi.ActionSchedule{
+				ActionStartTime: start.Format(time.RFC3339),
+			},
+		}
+
+		queue := &mockQueue{}
+		queue.On("Add", action, start.Unix()).Once()
+		queue.On("DequeueActions").Return([]fleetapi.ScheduledAction{}).Once()
+		queue.On("Save").Return(nil).Once()
+
+		d, err := New(nil, def, queue)
+		require.NoError(t, err)
+
+		d.Dispatch(context.Background(), detailsSetter, &recordingAcker{}, action)
+		def.AssertNotCalled(t, "Handle", mock.Anything, mock.Anything, mock.Anything)
+		queue.AssertExpectations(t)
+	})
+
+	t.Run("expired actions are acked as failed and not dispatched", func(t *testing.T) {
+		def := &mockHandler{}
+		expiration := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+		expired := &fleetapi.ActionSettings{
+			ActionID:   "settings",
+			ActionType: fleetapi.ActionTypeSettings,
+			LogLevel:   "debug",
+			ActionSchedule: fleetapi.ActionSchedule{
+				ActionExpiration: expiration.Format(time.RFC3339),
+			},
+		}
+		expiredQueued := &fleetapi.ActionDiagnostics{
+			ActionID:   "diagnostics",
+			ActionType: fleetapi.ActionTypeDiagnostics,
+			ActionSchedule: fleetapi.ActionSchedule{
+				ActionStartTime:  expiration.Add(-time.Hour).Format(time.RFC3339),
+				ActionExpiration: expiration.Format(time.RFC3339),
+			},
+		}
+
+		queue := &mockQueue{}
+		queue.On("DequeueActions").Return([]fleetapi.ScheduledAction{expiredQueued}).Once()
+		queue.On("Save").Return(nil).Once()
+
+		d, err := New(nil, def, queue)
+		require.NoError(t, err)
+
+		acker := &recordingAcker{}
+		d.Dispatch(context.Background(), detailsSetter, acker, expired)
+		def.AssertNotCalled(t, "Handle", mock.Anything, mock.Anything, mock.Anything)
+		queue.AssertExpectations(t)
+
+		require.Len(t, acker.acked, 2)
+		assert.Equal(t, 1, acker.committed)
+		for _, a := range acker.acked {
+			event := a.AckEvent()
+			assert.Contains(t, event.Error, "expired on "+expiration.Format(time.RFC3339))
+			assert.Empty(t, event.Payload, "only upgrade acks carry a retry payload")
+		}
+	})
+	t.Run("dispatched actions are recorded in the journal", func(t *testing.T) {
+		log, _ := logger.NewTesting("dispatcher")
+		j := journal.New(log, filepath.Join(t.TempDir(), "action_journal.ndjson"))
+
+		start := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+		scheduled := &fleetapi.ActionDiagnostics{
+			ActionID:   "diagnostics",
+			ActionType: fleetapi.ActionTypeDiagnostics,
+			ActionSchedule: fleetapi.ActionSchedule{
+				ActionStartTime: start.Format(time.RFC3339),
+			},
+		}
+		settings := &fleetapi.ActionSettings{
+			ActionID:   "settings",
+			ActionType: fleetapi.ActionTypeSettings,
+			LogLevel:   "debug",
+		}
+
+		queue := &mockQueue{}
+		queue.On("Add", scheduled, start.Unix()).Once()
+		queue.On("DequeueActions").Return([]fleetapi.ScheduledAction{}).Once()
+		queue.On("Save").Return(nil).Once()
+
+		d, err := New(nil, &mockHandler{}, queue, WithJournal(j))
+		require.NoError(t, err)
+		handler := &mockHandler{}
+		handler.On("Handle", mock.Anything, settings, mock.Anything).Return(errors.New("handler failed")).Once()
+		require.NoError(t, d.Register(&fleetapi.ActionSettings{}, handler))
+
+		acker := &recordingAcker{}
+		go d.Dispatch(context.Background(), detailsSetter, acker, scheduled, settings)
+		// only upgrades are retried, the failure of other actions is reported
+		require.EqualError(t, <-d.Errors(), "handler failed")
+		assert.Empty(t, acker.acked)
+		queue.AssertExpectations(t)
+
+		entries, err := j.Query(journal.Filter{})
+		require.NoError(t, err)
+		statuses := make([]string, 0, len(entries))
+		for _, e := range entries {
+			statuses = append(statuses, e.ActionID+":"+string(e.Status))
+		}
+		assert.Equal(t, []string{
+			"diagnostics:received",
+			"settings:received",
+			"diagnostics:queued",
+			"settings:failed",
+		}, statuses)
+	})
+}
@@ -6,6 +6,8 @@ package fleet
 
 import (
 	"context"
+	"sort"
+	"sync"
 	"time"
 
 	agentclient "github.com/elastic/elastic-agent/pkg/control/v2/client"
@@ -26,6 +28,12 @@ import (
 // Max number of times an invalid API Key is checked
 const maxUnauthCounter int = 6
 
+// Interval at which the state transitions recorded while fleet-server is unreachable are saved
+const stateHistorySaveInterval = 10 * time.Second
+
+// Poll timeout of the checkin verifying a client, short for fleet-server to answer it right away
+const verifyPollTimeout = time.Second
+
 // Consts for states at fleet checkin
 const fleetStateDegraded = "DEGRADED"
 const fleetStateOnline = "online"
@@ -61,6 +69,9 @@ type stateStore interface {
 	Add(fleetapi.Action)
 	AckToken() string
 	SetAckToken(ackToken string)
+	AddStateHistory(transitions ...fleetapi.CheckinStateTransition)
+	StateHistory() ([]fleetapi.CheckinStateTransition, uint64)
+	TrimStateHistory(end uint64)
 	Save() error
 	Actions() []fleetapi.Action
 }
@@ -75,9 +86,26 @@ type FleetGateway struct {
 	unauthCounter      int
 	checkinFailCounter int
 	stateFetcher       func() coordinator.State
+	stateSubscriber    func(ctx context.Context, bufferLen int) chan coordinator.State
 	stateStore         stateStore
 	errCh              chan error
 	actionCh           chan []fleetapi.Action
+
+	// checkinStateMx guards checkinState and checkinStateHash, also updated by VerifyClient.
+	checkinStateMx sync.Mutex
+	// checkinState is the last full checkin state fleet-server acknowledged
+	// holding, with its hash. When set, checkins only send the changes since it.
+	checkinState     *fleetapi.CheckinRequest
+	checkinStateHash string
+
+	// historyMx guards offline and lastReported.
+	historyMx sync.Mutex
+	// offline is set while checkins fail, meanwhile state transitions are
+	// recorded in the state history sent on the next successful checkin.
+	offline bool
+	// lastReported holds the agent, components and units status last sent to
+	// fleet-server or recorded in the state history.
+	lastReported map[string]fleetapi.CheckinStateTransition
 }
 
 // New creates a new fleet gateway
@@ -87,6 +115,7 @@ func New(
 	client client.Sender,
 	acker acker.Acker,
 	stateFetcher func() coordinator.State,
+	stateSubscriber func(ctx context.Context, bufferLen int) chan coordinator.State,
 	stateStore stateStore,
 ) (*FleetGateway, error) {
 
@@ -99,6 +128,7 @@ func New(
 		scheduler,
 		acker,
 		stateFetcher,
+		stateSubscriber,
 		stateStore,
 	)
 }
@@ -111,19 +141,21 @@ func newFleetGatewayWithScheduler(
 	scheduler scheduler.Scheduler,
 	acker acker.Acker,
 	stateFetcher func() coordinator.State,
+	stateSubscriber func(ctx context.Context, bufferLen int) chan coordinator.State,
 	stateStore stateStore,
 ) (*FleetGateway, error) {
 	return &FleetGateway{
-		log:          log,
-		client:       client,
-		settings:     settings,
-		agentInfo:    agentInfo,
-		scheduler:    scheduler,
-		acker:        acker,
-		stateFetcher: stateFetcher,
-		stateStore:   stateStore,
-		errCh:        make(chan error),
-		actionCh:     make(chan []fleetapi.Action, 1),
+		log:             log,
+		client:          client,
+		settings:        settings,
+		agentInfo:       agentInfo,
+		scheduler:       scheduler,
+		acker:           acker,
+		stateFetcher:    stateFetcher,
+		stateSubscriber: stateSubscriber,
+		stateStore:      stateStore,
+		errCh:           make(chan error),
+		actionCh:        make(chan []fleetapi.Action, 1),
 	}, nil
 }
 
@@ -145,6 +177,8 @@ func (f *FleetGateway) Run(ctx context.Context) error {
 		close(done)
 	}()
 
+	go f.recordStateHistoryLoop(ctx)
+
 	f.log.Info("Fleet gateway started")
 	for {
 		select {
@@ -311,7 +345,8 @@ func (f *FleetGateway) convertToCheckinComponents(components []runtime.Component
 	return checkinComponents
 }
 
-func (f *FleetGateway) execute(ctx context.Context) (*fleetapi.CheckinResponse, time.Duration, error) {
+// checkinRequest returns the full checkin request holding the current agent state.
+func (f *FleetGateway) checkinRequest(ctx context.Context) *fleetapi.CheckinRequest {
 	ecsMeta, err := info.Metadata(ctx, f.log)
 	if err != nil {
 		f.log.Error(errors.New("failed to load metadata", err))
@@ -329,9 +364,7 @@ func (f *FleetGateway) execute(ctx context.Context) (*fleetapi.CheckinResponse,
 	// convert components into checkin components structure
 	components := f.convertToCheckinComponents(state.Components)
 
-	// checkin
-	cmd := fleetapi.NewCheckinCmd(f.agentInfo, f.client)
-	req := &fleetapi.CheckinRequest{
+	return &fleetapi.CheckinRequest{
 		AckToken:       ackToken,
 		Metadata:       ecsMeta,
 		Status:         agentStateToString(state.State),
@@ -339,8 +372,44 @@ func (f *FleetGateway) execute(ctx context.Context) (*fleetapi.CheckinResponse,
 		Components:     components,
 		UpgradeDetails: state.UpgradeDetails,
 	}
+}
+
+func (f *FleetGateway) execute(ctx context.Context) (*fleetapi.CheckinResponse, time.Duration, error) {
+	// checkin
+	cmd := fleetapi.NewCheckinCmd(f.agentInfo, f.client)
+	req := f.checkinRequest(ctx)
+
+	stateHash, err := fleetapi.CheckinStateHash(req)
+	if err != nil {
+		f.log.Warnw("Failed to compute checkin state hash, sending full state", "error.message", err)
+	}
 
-	resp, took, err := cmd.Execute(ctx, req)
+	sent := req
+	f.checkinStateMx.Lock()
+	if err != nil {
+		f.checkinState, f.checkinStateHash = nil, ""
+	}
+	if f.checkinState != nil && stateHash != "" {
+		sent = fleetapi.NewCheckinDelta(f.checkinState, req)
+		sent.BaseStateHash = f.checkinStateHash
+	}
+	f.checkinStateMx.Unlock()
+	sent.StateHash = stateHash
+	var historyEnd uint64
+	sent.StateHistory, historyEnd = f.stateStore.StateHistory()
+
+	resp, took, err := cmd.Execute(ctx, sent)
+	switch {
+	case err == nil:
+		f.clearStateHistory(req, len(sent.StateHistory) > 0, historyEnd, time.Now())
+	case isUnauth(err):
+		// fleet-server is reachable but rejects the agent, the state transitions are not recorded
+		f.stopStateHistory()
+	default:
+		if f.recordStateHistory(req, time.Now()) {
+			f.saveStateHistory()
+		}
+	}
 	if isUnauth(err) {
 		f.unauthCounter++
 
@@ -359,6 +428,8 @@ func (f *FleetGateway) execute(ctx context.Context) (*fleetapi.CheckinResponse,
 		return nil, took, err
 	}
 
+	f.updateCheckinState(req, stateHash, resp)
+
 	// Save the latest ackToken
 	if resp.AckToken != "" {
 		f.stateStore.SetAckToken(resp.AckToken)
@@ -371,6 +442,223 @@ func (f *FleetGateway) execute(ctx context.Context) (*fleetapi.CheckinResponse,
 	return resp, took, nil
 }
 
+// VerifyClient checks fleet-server accepts the client with a checkin carrying the
+// full agent state, answered without being held. Neither its actions nor its ack
+// token are kept, so the actions are delivered again to the gateway; the state it
+// sent becomes the base of the next delta checkin.
+func (f *FleetGateway) VerifyClient(ctx context.Context, c client.Sender) error {
+	req := f.checkinRequest(ctx)
+	stateHash, err := fleetapi.CheckinStateHash(req)
+	if err != nil {
+		f.log.Warnw("Failed to compute checkin state hash", "error.message", err)
+	}
+
+	sent := *req
+	sent.StateHash = stateHash
+	sent.PollTimeout = verifyPollTimeout.String()
+	resp, _, err := fleetapi.NewCheckinCmd(f.agentInfo, c).Execute(ctx, &sent)
+	if err != nil {
+		return err
+	}
+
+	f.updateCheckinState(req, stateHash, resp)
+	return nil
+}
+
+// updateCheckinState keeps the full state sent on checkin as the base for the
+// next delta checkin if fleet-server acknowledged holding it. Otherwise, as with
+// fleet-servers not supporting delta checkins, the next checkin sends the full state.
+func (f *FleetGateway) updateCheckinState(req *fleetapi.CheckinRequest, stateHash string, resp *fleetapi.CheckinResponse) {
+	f.checkinStateMx.Lock()
+	defer f.checkinStateMx.Unlock()
+
+	if stateHash != "" && resp.StateHash == stateHash && !resp.Resync {
+		f.checkinState, f.checkinStateHash = req, stateHash
+		return
+	}
+
+	if resp.Resync {
+		f.log.Debug("Fleet-server requested a full checkin state resync")
+	} else if resp.StateHash != "" {
+		f.log.Debugw("Fleet-server checkin state hash mismatch, resyncing full state",
+			"state_hash", stateHash, "fleet_state_hash", resp.StateHash)
+	}
+	f.checkinState, f.checkinStateHash = nil, ""
+}
+
+// recordStateHistoryLoop records the state transitions while fleet-server is
+// unreachable, as between checkin retries the state isn't sent. The recorded
+// transitions are saved every stateHistorySaveInterval.
+func (f *FleetGateway) recordStateHistoryLoop(ctx context.Context) {
+	if f.stateSubscriber == nil {
+		return
+	}
+	states := f.stateSubscriber(ctx, 32)
+	t := time.NewTicker(stateHistorySaveInterval)
+	defer t.Stop()
+
+	unsaved := false
+	for {
+		select {
+		case <-ctx.Done():
+			if unsaved {
+				f.saveStateHistory()
+			}
+			return
+		case state := <-states:
+			if f.recordOfflineStateHistory(&fleetapi.CheckinRequest{
+				Status:     agentStateToString(state.State),
+				Message:    state.Message,
+				Components: f.convertToCheckinComponents(state.Components),
+			}, time.Now()) {
+				unsaved = true
+			}
+		case <-t.C:
+			if unsaved {
+				f.saveStateHistory()
+				unsaved = false
+			}
+		}
+	}
+}
+
+// recordStateHistory marks fleet-server as unreachable and adds the state
+// transitions since the state last reported or recorded to the state history.
+// It returns true when transitions were added and must be saved.
+func (f *FleetGateway) recordStateHistory(req *fleetapi.CheckinRequest, now time.Time) bool {
+	f.historyMx.Lock()
+	defer f.historyMx.Unlock()
+
+	f.offline = true
+	return f.addStateHistory(req, now)
+}
+
+// recordOfflineStateHistory adds the state transitions to the state history
+// only while fleet-server is unreachable.
+func (f *FleetGateway) recordOfflineStateHistory(req *fleetapi.CheckinRequest, now time.Time) bool {
+	f.historyMx.Lock()
+	defer f.historyMx.Unlock()
+
+	if !f.offline {
+		return false
+	}
+	return f.addStateHistory(req, now)
+}
+
+// addStateHistory adds the state transitions since the state last reported or
+// recorded to the state history. The caller must hold historyMx.
+func (f *FleetGateway) addStateHistory(req *fleetapi.CheckinRequest, now time.Time) bool {
+	current := stateSnapshot(req, now)
+	if f.lastReported == nil {
+		// nothing known about what was reported before the agent started,
+		// the state history persisted until then is kept as is.
+		f.lastReported = current
+		return false
+	}
+
+	transitions := stateTransitions(f.lastReported, current, now)
+	f.lastReported = current
+	if len(transitions) == 0 {
+		return false
+	}
+
+	f.stateStore.AddStateHistory(transitions...)
+	return true
+}
+
+// saveStateHistory persists the recorded state transitions.
+func (f *FleetGateway) saveStateHistory() {
+	if err := f.stateStore.Save(); err != nil {
+		f.log.Errorf("failed to save the state history, err: %v", err)
+	}
+}
+
+// stopStateHistory stops recording state transitions, fleet-server is reachable.
+func (f *FleetGateway) stopStateHistory() {
+	f.historyMx.Lock()
+	defer f.historyMx.Unlock()
+	f.offline = false
+}
+
+// clearStateHistory removes the sent transitions, up to the sequence number
+// end, from the state history after a successful checkin and stops recording
+// state transitions.
+func (f *FleetGateway) clearStateHistory(req *fleetapi.CheckinRequest, sent bool, end uint64, now time.Time) {
+	f.historyMx.Lock()
+	defer f.historyMx.Unlock()
+
+	f.offline = false
+	f.lastReported = stateSnapshot(req, now)
+	if !sent {
+		return
+	}
+
+	f.stateStore.TrimStateHistory(end)
+	if err := f.stateStore.Save(); err != nil {
+		f.log.Errorf("failed to save the state history, err: %v", err)
+	}
+}
+
+// stateSnapshot returns the agent, components and units status reported by
+// the full checkin request req, keyed by their identifiers.
+func stateSnapshot(req *fleetapi.CheckinRequest, now time.Time) map[string]fleetapi.CheckinStateTransition {
+	snapshot := map[string]fleetapi.CheckinStateTransition{
+		"": {Timestamp: now, Status: req.Status, Message: req.Message},
+	}
+	for _, c := range req.Components {
+		snapshot[c.ID] = fleetapi.CheckinStateTransition{
+			Timestamp:   now,
+			ComponentID: c.ID,
+			Status:      c.Status,
+			Message:     c.Message,
+		}
+		for _, u := range c.Units {
+			snapshot[c.ID+"/"+u.Type+"/"+u.ID] = fleetapi.CheckinStateTransition{
+				Timestamp:   now,
+				ComponentID: c.ID,
+				UnitID:      u.ID,
+				UnitType:    u.Type,
+				Status:      u.Status,
+				Message:     u.Message,
+			}
+		}
+	}
+	return snapshot
+}
+
+// stateTransitions returns the transitions from the previous to the current
+// snapshot, sorted by key. Components and units no longer present are
+// reported as stopped at now.
+func stateTransitions(previous, current map[string]fleetapi.CheckinStateTransition, now time.Time) []fleetapi.CheckinStateTransition {
+	keys := make([]string, 0, len(current))
+	for key := range current {
+		keys = append(keys, key)
+	}
+	for key := range previous {
+		if _, ok := current[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var transitions []fleetapi.CheckinStateTransition
+	for _, key := range keys {
+		prev, hadPrev := previous[key]
+		cur, ok := current[key]
+		switch {
+		case !ok:
+			cur = prev
+			cur.Timestamp = now
+			cur.Status = "STOPPED"
+			cur.Message = "Removed"
+		case hadPrev && prev.Status == cur.Status && prev.Message == cur.Message:
+			continue
+		}
+		transitions = append(transitions, cur)
+	}
+	return transitions
+}
+
 // shouldUnenroll checks if the max number of trying an invalid key is reached
 func (f *FleetGateway) shouldUnenroll() bool {
 	return f.unauthCounter > maxUnauthCounter
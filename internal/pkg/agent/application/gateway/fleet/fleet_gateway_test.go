@@ -22,6 +22,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"gotest.tools/assert"
 
+	eaclient "github.com/elastic/elastic-agent-client/v7/pkg/client"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/coordinator"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/details"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/errors"
@@ -29,9 +30,13 @@ import (
 	"github.com/elastic/elastic-agent/internal/pkg/agent/storage/store"
 	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
 	"github.com/elastic/elastic-agent/internal/pkg/fleetapi/acker/noop"
+	fleetclient "github.com/elastic/elastic-agent/internal/pkg/fleetapi/client"
 	"github.com/elastic/elastic-agent/internal/pkg/scheduler"
+	"github.com/elastic/elastic-agent/pkg/component"
+	"github.com/elastic/elastic-agent/pkg/component/runtime"
 	agentclient "github.com/elastic/elastic-agent/pkg/control/v2/client"
 	"github.com/elastic/elastic-agent/pkg/core/logger"
+	"github.com/elastic/elastic-agent/testing/fleetservertest"
 )
 
 type clientCallbackFunc func(headers http.Header, body io.Reader) (*http.Response, error)
@@ -90,6 +95,7 @@ func withGateway(agentInfo agentInfo, settings *fleetGatewaySettings, fn withGat
 			scheduler,
 			noop.New(),
 			emptyStateFetcher,
+			nil,
 			stateStore,
 		)
 
@@ -229,6 +235,7 @@ func TestFleetGateway(t *testing.T) {
 			scheduler,
 			noop.New(),
 			emptyStateFetcher,
+			nil,
 			stateStore,
 		)
 		require.NoError(t, err)
@@ -281,6 +288,7 @@ func TestFleetGateway(t *testing.T) {
 			scheduler,
 			noop.New(),
 			emptyStateFetcher,
+			nil,
 			stateStore,
 		)
 		require.NoError(t, err)
@@ -340,6 +348,7 @@ func TestFleetGateway(t *testing.T) {
 			scheduler,
 			noop.New(),
 			stateFetcher,
+			nil,
 			stateStore,
 		)
 
@@ -497,6 +506,12 @@ func runFleetGateway(ctx context.Context, g coordinator.FleetGateway) <-chan err
 	return errCh
 }
 
+// stateHistory returns the state history of the state store without its sequence number.
+func stateHistory(s *store.StateStore) []fleetapi.CheckinStateTransition {
+	history, _ := s.StateHistory()
+	return history
+}
+
 func newStateStore(t *testing.T, log *logger.Logger) *store.StateStore {
 	dir, err := os.MkdirTemp("", "fleet-gateway-unit-test")
 	require.NoError(t, err)
@@ -570,3 +585,335 @@ func TestAgentStateToString(t *testing.T) {
 		})
 	}
 }
+
+// checkinStateClient answers checkins keeping the agent state as fleet-server
+// does, optionally without delta checkin support.
+type checkinStateClient struct {
+	t        *testing.T
+	state    *fleetservertest.AgentState
+	legacy   bool
+	err      error
+	requests []fleetapi.CheckinRequest
+}
+
+func (c *checkinStateClient) Send(
+	_ context.Context,
+	_ string,
+	_ string,
+	_ url.Values,
+	_ http.Header,
+	body io.Reader,
+) (*http.Response, error) {
+	data, err := io.ReadAll(body)
+	require.NoError(c.t, err)
+
+	var req fleetapi.CheckinRequest
+	require.NoError(c.t, json.Unmarshal(data, &req))
+	c.requests = append(c.requests, req)
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	resp := fleetservertest.CheckinResponse{Action: "checkin"}
+	if !c.legacy {
+		var checkinRequest fleetservertest.CheckinRequest
+		require.NoError(c.t, json.Unmarshal(data, &checkinRequest))
+		hash, resync, hErr := c.state.Checkin(checkinRequest)
+		require.Nil(c.t, hErr)
+		resp.StateHash, resp.Resync = hash, resync
+	}
+
+	b, err := json.Marshal(resp)
+	require.NoError(c.t, err)
+	return wrapStrToResp(http.StatusOK, string(b)), nil
+}
+
+func (c *checkinStateClient) URI() string {
+	return "http://localhost"
+}
+
+func (c *checkinStateClient) last() fleetapi.CheckinRequest {
+	return c.requests[len(c.requests)-1]
+}
+
+func TestFleetGatewayDeltaCheckin(t *testing.T) {
+	unitKey := func(id string) runtime.ComponentUnitKey {
+		return runtime.ComponentUnitKey{UnitType: eaclient.UnitTypeInput, UnitID: id}
+	}
+	var components []runtime.ComponentComponentState
+	for _, id := range []string{"filestream-default", "system/metrics-default"} {
+		components = append(components, runtime.ComponentComponentState{
+			Component: component.Component{ID: id},
+			State: runtime.ComponentState{
+				State:   eaclient.UnitStateHealthy,
+				Message: "Healthy",
+				Units: map[runtime.ComponentUnitKey]runtime.ComponentUnitState{
+					unitKey(id + "-unit-1"): {State: eaclient.UnitStateHealthy, Message: "Healthy"},
+					unitKey(id + "-unit-2"): {State: eaclient.UnitStateHealthy, Message: "Healthy"},
+				},
+			},
+		})
+	}
+	stateFetcher := func() coordinator.State {
+		return coordinator.State{State: agentclient.Healthy, Message: "Running", Components: components}
+	}
+
+	newGateway := func(t *testing.T, client *checkinStateClient) *FleetGateway {
+		log, _ := logger.NewTesting("fleet_gateway")
+		gateway, err := newFleetGatewayWithScheduler(
+			log,
+			defaultGatewaySettings,
+			&testAgentInfo{},
+			client,
+			scheduler.NewStepper(),
+			noop.New(),
+			stateFetcher,
+			nil,
+			newStateStore(t, log),
+		)
+		require.NoError(t, err)
+		return gateway
+	}
+
+	t.Run("sends changes once fleet-server holds the state", func(t *testing.T) {
+		client := &checkinStateClient{t: t, state: fleetservertest.NewAgentState()}
+		gateway := newGateway(t, client)
+
+		_, _, err := gateway.execute(context.Background())
+		require.NoError(t, err)
+		require.False(t, client.last().Delta)
+		require.Len(t, client.last().Components, 2)
+
+		_, _, err = gateway.execute(context.Background())
+		require.NoError(t, err)
+		require.True(t, client.last().Delta)
+		require.Empty(t, client.last().Components)
+		require.Nil(t, client.last().Metadata)
+
+		components[1].State.Units[unitKey("system/metrics-default-unit-2")] = runtime.ComponentUnitState{
+			State: eaclient.UnitStateDegraded, Message: "Degraded"}
+		_, _, err = gateway.execute(context.Background())
+		require.NoError(t, err)
+		require.True(t, client.last().Delta)
+		require.Len(t, client.last().Components, 1)
+		require.Equal(t, "system/metrics-default", client.last().Components[0].ID)
+		require.Len(t, client.last().Components[0].Units, 1)
+		require.Equal(t, "system/metrics-default-unit-2", client.last().Components[0].Units[0].ID)
+
+		held, _ := client.state.State()
+		require.Len(t, held.Components, 2)
+		full, delta := client.state.Checkins()
+		require.Equal(t, 1, full)
+		require.Equal(t, 2, delta)
+	})
+
+	t.Run("sends full state on resync request", func(t *testing.T) {
+		client := &checkinStateClient{t: t, state: fleetservertest.NewAgentState()}
+		gateway := newGateway(t, client)
+
+		_, _, err := gateway.execute(context.Background())
+		require.NoError(t, err)
+
+		client.state.RequestResync()
+		_, _, err = gateway.execute(context.Background())
+		require.NoError(t, err)
+		require.True(t, client.last().Delta)
+
+		_, _, err = gateway.execute(context.Background())
+		require.NoError(t, err)
+		require.False(t, client.last().Delta)
+		require.Len(t, client.last().Components, 2)
+	})
+
+	t.Run("sends full state on hash mismatch", func(t *testing.T) {
+		client := &checkinStateClient{t: t, state: fleetservertest.NewAgentState()}
+		gateway := newGateway(t, client)
+
+		_, _, err := gateway.execute(context.Background())
+		require.NoError(t, err)
+
+		// fleet-server lost the state it acknowledged
+		client.state = fleetservertest.NewAgentState()
+		_, _, err = gateway.execute(context.Background())
+		require.NoError(t, err)
+		require.True(t, client.last().Delta)
+
+		_, _, err = gateway.execute(context.Background())
+		require.NoError(t, err)
+		require.False(t, client.last().Delta)
+	})
+
+	t.Run("verifying a client sends the full state the next checkin is based on", func(t *testing.T) {
+		client := &checkinStateClient{t: t, state: fleetservertest.NewAgentState()}
+		gateway := newGateway(t, client)
+
+		_, _, err := gateway.execute(context.Background())
+		require.NoError(t, err)
+
+		components[0].State.Message = "Verifying"
+		verifyClient := &checkinStateClient{t: t, state: client.state}
+		require.NoError(t, gateway.VerifyClient(context.Background(), verifyClient))
+		require.False(t, verifyClient.last().Delta)
+		require.Len(t, verifyClient.last().Components, 2)
+		require.Equal(t, verifyPollTimeout.String(), verifyClient.last().PollTimeout)
+		require.Len(t, client.requests, 1, "the gateway client must not be used")
+
+		_, _, err = gateway.execute(context.Background())
+		require.NoError(t, err)
+		require.True(t, client.last().Delta)
+		require.Empty(t, client.last().Components)
+		full, delta := client.state.Checkins()
+		require.Equal(t, 2, full)
+		require.Equal(t, 1, delta)
+	})
+
+	t.Run("always sends full state to fleet-server without delta support", func(t *testing.T) {
+		client := &checkinStateClient{t: t, legacy: true}
+		gateway := newGateway(t, client)
+
+		for i := 0; i < 3; i++ {
+			_, _, err := gateway.execute(context.Background())
+			require.NoError(t, err)
+			require.False(t, client.last().Delta)
+			require.Len(t, client.last().Components, 2)
+		}
+	})
+}
+
+func TestFleetGatewayStateHistory(t *testing.T) {
+	unitKey := runtime.ComponentUnitKey{UnitType: eaclient.UnitTypeInput, UnitID: "filestream-default-unit"}
+	state := coordinator.State{
+		State:   agentclient.Healthy,
+		Message: "Running",
+		Components: []runtime.ComponentComponentState{{
+			Component: component.Component{ID: "filestream-default"},
+			State: runtime.ComponentState{
+				State:   eaclient.UnitStateHealthy,
+				Message: "Healthy",
+				Units: map[runtime.ComponentUnitKey]runtime.ComponentUnitState{
+					unitKey: {State: eaclient.UnitStateHealthy, Message: "Healthy"},
+				},
+			},
+		}},
+	}
+	setUnitState := func(s eaclient.UnitState, msg string) {
+		state.Components[0].State.Units[unitKey] = runtime.ComponentUnitState{State: s, Message: msg}
+	}
+
+	log, _ := logger.NewTesting("fleet_gateway")
+	stateStore := newStateStore(t, log)
+	client := &checkinStateClient{t: t, state: fleetservertest.NewAgentState()}
+	gateway, err := newFleetGatewayWithScheduler(
+		log,
+		defaultGatewaySettings,
+		&testAgentInfo{},
+		client,
+		scheduler.NewStepper(),
+		noop.New(),
+		func() coordinator.State { return state },
+		nil,
+		stateStore,
+	)
+	require.NoError(t, err)
+
+	_, _, err = gateway.execute(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, client.last().StateHistory)
+
+	// fleet-server becomes unreachable, transitions are recorded
+	client.err = errors.New("connection refused")
+	setUnitState(eaclient.UnitStateFailed, "Output unreachable")
+	_, _, err = gateway.execute(context.Background())
+	require.Error(t, err)
+	setUnitState(eaclient.UnitStateHealthy, "Healthy")
+	state.Components = nil
+	_, _, err = gateway.execute(context.Background())
+	require.Error(t, err)
+
+	history := stateHistory(stateStore)
+	require.Len(t, history, 3)
+	require.Equal(t, "FAILED", history[0].Status)
+	require.Equal(t, "Output unreachable", history[0].Message)
+	require.Equal(t, "filestream-default", history[1].ComponentID)
+	require.Equal(t, "STOPPED", history[1].Status)
+	require.Equal(t, "filestream-default-unit", history[2].UnitID)
+	require.Equal(t, "STOPPED", history[2].Status)
+
+	// the history is sent on reconnect, then cleared
+	client.err = nil
+	_, _, err = gateway.execute(context.Background())
+	require.NoError(t, err)
+	require.Len(t, client.last().StateHistory, 3)
+	for i, transition := range client.last().StateHistory {
+		require.True(t, history[i].Timestamp.Equal(transition.Timestamp))
+		require.Equal(t, history[i].Status, transition.Status)
+		require.Equal(t, history[i].UnitID, transition.UnitID)
+	}
+	require.Empty(t, stateHistory(stateStore))
+
+	_, _, err = gateway.execute(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, client.last().StateHistory)
+
+	// fleet-server rejecting the agent is not being offline
+	client.err = fleetclient.ErrInvalidAPIKey
+	state.State, state.Message = agentclient.Degraded, "Degraded"
+	_, _, err = gateway.execute(context.Background())
+	require.Error(t, err)
+	require.Empty(t, stateHistory(stateStore))
+}
+
+func TestFleetGatewayStateHistoryFromStateChanges(t *testing.T) {
+	newState := func(s agentclient.State, msg string) coordinator.State {
+		return coordinator.State{State: s, Message: msg}
+	}
+	current := newState(agentclient.Healthy, "Running")
+
+	log, _ := logger.NewTesting("fleet_gateway")
+	stateStore := newStateStore(t, log)
+	client := &checkinStateClient{t: t, state: fleetservertest.NewAgentState()}
+	states := make(chan coordinator.State)
+	gateway, err := newFleetGatewayWithScheduler(
+		log,
+		defaultGatewaySettings,
+		&testAgentInfo{},
+		client,
+		scheduler.NewStepper(),
+		noop.New(),
+		func() coordinator.State { return current },
+		func(context.Context, int) chan coordinator.State { return states },
+		stateStore,
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		gateway.recordStateHistoryLoop(ctx)
+	}()
+
+	// state changes while fleet-server is reachable are reported by checkins
+	states <- newState(agentclient.Degraded, "Degraded")
+	_, _, err = gateway.execute(context.Background())
+	require.NoError(t, err)
+	states <- newState(agentclient.Failed, "Failed")
+	require.Empty(t, stateHistory(stateStore))
+
+	// fleet-server becomes unreachable, state changes are recorded as they happen
+	client.err = errors.New("connection refused")
+	_, _, err = gateway.execute(context.Background())
+	require.Error(t, err)
+	states <- newState(agentclient.Degraded, "Degraded")
+	states <- newState(agentclient.Healthy, "Running")
+	require.Eventually(t, func() bool {
+		return len(stateHistory(stateStore)) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	history := stateHistory(stateStore)
+	require.Equal(t, "DEGRADED", history[0].Status)
+	require.Equal(t, "online", history[1].Status)
+
+	cancel()
+	<-done
+}
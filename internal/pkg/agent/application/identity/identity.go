@@ -0,0 +1,188 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// Package identity manages the cryptographic identity of the agent: the key pair kept in the vault
+// and the client certificate, signed by Fleet, authenticating the agent to Fleet Server with mutual TLS.
+package identity
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/vault"
+	"github.com/elastic/elastic-agent/internal/pkg/remote"
+)
+
+// vaultKey is the key of the identity in the vault.
+const vaultKey = "identity"
+
+// ErrNoIdentity is returned when the agent has no identity, it was enrolled without client certificate.
+var ErrNoIdentity = errors.New("agent identity not found")
+
+// stored is the structure that is JSON serialized and stored in the vault.
+type stored struct {
+	Key         []byte `json:"k"` // PKCS #8 DER encoded private key
+	Certificate []byte `json:"c"` // PEM encoded client certificate
+}
+
+// Identity is the key pair of the agent and its client certificate.
+type Identity struct {
+	key            *ecdsa.PrivateKey
+	certificate    *x509.Certificate
+	certificatePEM []byte
+}
+
+// NewKey generates a new key pair for the agent.
+func NewKey() (*ecdsa.PrivateKey, error) {
+	return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+}
+
+// CertificateRequest returns the PEM encoded certificate signing request for the key.
+func CertificateRequest(key *ecdsa.PrivateKey, commonName string) (string, error) {
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: commonName},
+	}, key)
+	if err != nil {
+		return "", fmt.Errorf("could not create certificate signing request: %w", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})), nil
+}
+
+// New returns the identity made of the key and the PEM encoded certificate signed for it.
+func New(key *ecdsa.PrivateKey, certificatePEM string) (*Identity, error) {
+	block, _ := pem.Decode([]byte(certificatePEM))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("invalid client certificate: no PEM CERTIFICATE block")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid client certificate: %w", err)
+	}
+	if pub, ok := cert.PublicKey.(*ecdsa.PublicKey); !ok || !pub.Equal(&key.PublicKey) {
+		return nil, errors.New("invalid client certificate: not signed for the agent key")
+	}
+
+	return &Identity{
+		key:            key,
+		certificate:    cert,
+		certificatePEM: []byte(certificatePEM),
+	}, nil
+}
+
+// Certificate returns the client certificate.
+func (i *Identity) Certificate() *x509.Certificate {
+	return i.certificate
+}
+
+// RenewAt returns the time the client certificate should be renewed, once two thirds of its
+// validity elapsed.
+func (i *Identity) RenewAt() time.Time {
+	validity := i.certificate.NotAfter.Sub(i.certificate.NotBefore)
+	return i.certificate.NotBefore.Add(validity * 2 / 3)
+}
+
+// ClientConfig returns a copy of the configuration authenticating with the client certificate.
+// The key only lives in memory, the returned configuration must not be persisted. The configuration
+// is returned unchanged when it has a client certificate, provided by the operator on enroll.
+func (i *Identity) ClientConfig(cfg remote.Config) (remote.Config, error) {
+	if tls := cfg.Transport.TLS; tls != nil && (tls.Certificate.Certificate != "" || tls.Certificate.Key != "") {
+		return cfg, nil
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(i.key)
+	if err != nil {
+		return cfg, fmt.Errorf("could not encode the agent key: %w", err)
+	}
+
+	var tlsCfg tlscommon.Config
+	if cfg.Transport.TLS != nil {
+		tlsCfg = *cfg.Transport.TLS
+	}
+	tlsCfg.Certificate = tlscommon.CertificateConfig{
+		Certificate: string(i.certificatePEM),
+		Key:         string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})),
+	}
+	cfg.Transport.TLS = &tlsCfg
+	return cfg, nil
+}
+
+// Load reads the identity of the agent from the vault.
+func Load(ctx context.Context, opts ...vault.OptionFunc) (*Identity, error) {
+	v, err := vault.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("could not create new vault: %w", err)
+	}
+	defer v.Close()
+
+	exists, err := v.Exists(ctx, vaultKey)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNoIdentity
+	}
+
+	b, err := v.Get(ctx, vaultKey)
+	if err != nil {
+		return nil, err
+	}
+	var s stored
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("could not decode the agent identity: %w", err)
+	}
+	k, err := x509.ParsePKCS8PrivateKey(s.Key)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode the agent key: %w", err)
+	}
+	key, ok := k.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported agent key type %T", k)
+	}
+	return New(key, string(s.Certificate))
+}
+
+// Save stores the identity of the agent in the vault, replacing the previous one.
+func Save(ctx context.Context, id *Identity, opts ...vault.OptionFunc) error {
+	keyDER, err := x509.MarshalPKCS8PrivateKey(id.key)
+	if err != nil {
+		return fmt.Errorf("could not encode the agent key: %w", err)
+	}
+	b, err := json.Marshal(stored{Key: keyDER, Certificate: id.certificatePEM})
+	if err != nil {
+		return fmt.Errorf("could not encode the agent identity: %w", err)
+	}
+
+	v, err := vault.New(ctx, opts...)
+	if err != nil {
+		return fmt.Errorf("could not create new vault: %w", err)
+	}
+	defer v.Close()
+
+	return v.Set(ctx, vaultKey, b)
+}
+
+// Remove removes the identity of the agent from the vault.
+func Remove(ctx context.Context, opts ...vault.OptionFunc) error {
+	v, err := vault.New(ctx, opts...)
+	if err != nil {
+		return fmt.Errorf("could not create new vault: %w", err)
+	}
+	defer v.Close()
+
+	exists, err := v.Exists(ctx, vaultKey)
+	if err != nil || !exists {
+		return err
+	}
+	return v.Remove(ctx, vaultKey)
+}
@@ -0,0 +1,148 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package identity
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/vault"
+	fleetclient "github.com/elastic/elastic-agent/internal/pkg/fleetapi/client"
+	"github.com/elastic/elastic-agent/internal/pkg/remote"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+	"github.com/elastic/elastic-agent/testing/fleetservertest"
+)
+
+func newTestIdentity(t *testing.T, ca *fleetservertest.CA) *Identity {
+	key, err := NewKey()
+	require.NoError(t, err)
+	csr, err := CertificateRequest(key, "test-host")
+	require.NoError(t, err)
+	cert, err := ca.SignCSR(csr)
+	require.NoError(t, err)
+	id, err := New(key, cert)
+	require.NoError(t, err)
+	return id
+}
+
+func testVaultOpts(t *testing.T) []vault.OptionFunc {
+	return []vault.OptionFunc{vault.WithVaultPath(filepath.Join(t.TempDir(), "vault"))}
+}
+
+func TestNewCertificateOfOtherKey(t *testing.T) {
+	ca, err := fleetservertest.NewCA()
+	require.NoError(t, err)
+	id := newTestIdentity(t, ca)
+
+	otherKey, err := NewKey()
+	require.NoError(t, err)
+	_, err = New(otherKey, string(id.certificatePEM))
+	assert.ErrorContains(t, err, "not signed for the agent key")
+
+	_, err = New(otherKey, "not a certificate")
+	assert.ErrorContains(t, err, "no PEM CERTIFICATE block")
+}
+
+func TestSaveLoadRemove(t *testing.T) {
+	ctx := context.Background()
+	opts := testVaultOpts(t)
+
+	_, err := Load(ctx, opts...)
+	require.ErrorIs(t, err, ErrNoIdentity)
+
+	ca, err := fleetservertest.NewCA()
+	require.NoError(t, err)
+	id := newTestIdentity(t, ca)
+	require.NoError(t, Save(ctx, id, opts...))
+
+	loaded, err := Load(ctx, opts...)
+	require.NoError(t, err)
+	assert.True(t, id.key.Equal(loaded.key))
+	assert.Equal(t, id.Certificate().Raw, loaded.Certificate().Raw)
+	assert.Equal(t, "test-host", loaded.Certificate().Subject.CommonName)
+
+	require.NoError(t, Remove(ctx, opts...))
+	_, err = Load(ctx, opts...)
+	require.ErrorIs(t, err, ErrNoIdentity)
+	require.NoError(t, Remove(ctx, opts...), "removing a missing identity is not an error")
+}
+
+func TestRenewAt(t *testing.T) {
+	ca, err := fleetservertest.NewCA()
+	require.NoError(t, err)
+	id := newTestIdentity(t, ca)
+
+	cert := id.Certificate()
+	renewAt := id.RenewAt()
+	assert.True(t, renewAt.After(cert.NotBefore))
+	assert.True(t, renewAt.Before(cert.NotAfter))
+	assert.Equal(t, 2*cert.NotAfter.Sub(renewAt), renewAt.Sub(cert.NotBefore))
+}
+
+func TestClientConfigKeepsOperatorCertificate(t *testing.T) {
+	ca, err := fleetservertest.NewCA()
+	require.NoError(t, err)
+	id := newTestIdentity(t, ca)
+
+	operatorCert := tlscommon.CertificateConfig{Certificate: "/etc/agent/client.crt", Key: "/etc/agent/client.key"}
+	cfg := remote.Config{}
+	cfg.Transport.TLS = &tlscommon.Config{Certificate: operatorCert}
+
+	clientCfg, err := id.ClientConfig(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, operatorCert, clientCfg.Transport.TLS.Certificate)
+
+	// without operator certificate the identity authenticates the agent
+	cfg.Transport.TLS = &tlscommon.Config{CAs: []string{"/etc/agent/ca.crt"}}
+	clientCfg, err = id.ClientConfig(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, string(id.certificatePEM), clientCfg.Transport.TLS.Certificate.Certificate)
+	assert.Equal(t, []string{"/etc/agent/ca.crt"}, clientCfg.Transport.TLS.CAs)
+}
+
+func TestClientConfigMutualTLS(t *testing.T) {
+	ca, err := fleetservertest.NewCA()
+	require.NoError(t, err)
+
+	var clientCertCN string
+	srv := fleetservertest.NewServer(&fleetservertest.Handlers{
+		StatusFn: func(ctx context.Context, h *fleetservertest.Handlers) (*fleetservertest.StatusResponse, *fleetservertest.HTTPError) {
+			if cert := fleetservertest.ClientCertificateFromCtx(ctx); cert != nil {
+				clientCertCN = cert.Subject.CommonName
+			}
+			return &fleetservertest.StatusResponse{Name: "fleet-server", Status: "HEALTHY"}, nil
+		},
+	}, fleetservertest.WithMutualTLS(ca))
+	defer srv.Close()
+
+	cfg := remote.DefaultClientConfig()
+	cfg.Protocol = remote.ProtocolHTTPS
+	cfg.Host = srv.LocalhostURL
+	cfg.Transport.TLS = &tlscommon.Config{CAs: []string{string(ca.CertificatePEM)}}
+
+	id := newTestIdentity(t, ca)
+	clientCfg, err := id.ClientConfig(cfg)
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Transport.TLS.Certificate.Certificate, "the original configuration must not hold the client certificate")
+
+	log, _ := logger.New("identity", false)
+	client, err := fleetclient.NewWithConfig(log, clientCfg)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	resp, err := client.Send(ctx, http.MethodGet, "/api/status", nil, nil, nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "test-host", clientCertCN)
+}
@@ -0,0 +1,167 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package identity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/vault"
+	"github.com/elastic/elastic-agent/internal/pkg/core/backoff"
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi/client"
+	"github.com/elastic/elastic-agent/internal/pkg/remote"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+)
+
+const (
+	rotateBackoffInit = 30 * time.Second
+	rotateBackoffMax  = 30 * time.Minute
+)
+
+type agentInfo interface {
+	AgentID() string
+}
+
+// Manager holds the identity of the agent and rotates its client certificate before it expires.
+type Manager struct {
+	log       *logger.Logger
+	agentInfo agentInfo
+	vaultOpts []vault.OptionFunc
+
+	mx       sync.Mutex
+	identity *Identity
+	client   client.Sender
+}
+
+// NewManager creates a new identity manager storing the identity in the vault opened with vaultOpts.
+func NewManager(log *logger.Logger, agentInfo agentInfo, vaultOpts ...vault.OptionFunc) *Manager {
+	return &Manager{
+		log:       log,
+		agentInfo: agentInfo,
+		vaultOpts: vaultOpts,
+	}
+}
+
+// Load loads the identity from the vault, ErrNoIdentity is returned when the agent has none.
+func (m *Manager) Load(ctx context.Context) error {
+	id, err := Load(ctx, m.vaultOpts...)
+	if err != nil {
+		return err
+	}
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.identity = id
+	return nil
+}
+
+// Identity returns the current identity of the agent, nil if it has none.
+func (m *Manager) Identity() *Identity {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return m.identity
+}
+
+// ClientConfig returns the Fleet client configuration authenticating with the current client certificate.
+// The configuration is returned unchanged when the agent has no identity.
+func (m *Manager) ClientConfig(cfg remote.Config) remote.Config {
+	if m == nil {
+		return cfg
+	}
+	id := m.Identity()
+	if id == nil {
+		return cfg
+	}
+	clientCfg, err := id.ClientConfig(cfg)
+	if err != nil {
+		m.log.Errorw("Failed to configure the agent client certificate, falling back to API key authentication only", "error.message", err)
+		return cfg
+	}
+	return clientCfg
+}
+
+// SetClient sets the client used to renew the client certificate.
+func (m *Manager) SetClient(c client.Sender) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.client = c
+}
+
+// Run renews the client certificate once two thirds of its validity elapsed, until ctx is done.
+// onRotate is called after each rotation so the Fleet clients can be recreated with the new certificate.
+func (m *Manager) Run(ctx context.Context, onRotate func()) error {
+	if m.Identity() == nil {
+		return nil
+	}
+
+	backExp := backoff.NewExpBackoff(ctx.Done(), rotateBackoffInit, rotateBackoffMax)
+	for {
+		t := time.NewTimer(time.Until(m.Identity().RenewAt()))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+
+		for {
+			err := m.rotate(ctx)
+			if err == nil {
+				break
+			}
+			m.log.Errorw("Failed to renew the agent client certificate", "error.message", err)
+			if !backExp.Wait() {
+				return ctx.Err()
+			}
+		}
+		backExp.Reset()
+
+		m.log.Infow("Agent client certificate renewed", "not_after", m.Identity().Certificate().NotAfter)
+		onRotate()
+	}
+}
+
+func (m *Manager) rotate(ctx context.Context) error {
+	m.mx.Lock()
+	c := m.client
+	m.mx.Unlock()
+	if c == nil {
+		return errors.New("no Fleet client available")
+	}
+
+	key, err := NewKey()
+	if err != nil {
+		return fmt.Errorf("could not generate the agent key: %w", err)
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return fmt.Errorf("could not get the hostname: %w", err)
+	}
+	csr, err := CertificateRequest(key, hostname)
+	if err != nil {
+		return err
+	}
+
+	resp, err := fleetapi.NewCertificateCmd(m.agentInfo, c).Execute(ctx, &fleetapi.CertificateRequest{CSR: csr})
+	if err != nil {
+		return err
+	}
+	id, err := New(key, resp.Certificate)
+	if err != nil {
+		return err
+	}
+	if err := Save(ctx, id, m.vaultOpts...); err != nil {
+		return fmt.Errorf("could not save the agent identity: %w", err)
+	}
+
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.identity = id
+	return nil
+}
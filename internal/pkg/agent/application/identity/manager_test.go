@@ -0,0 +1,91 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package identity
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+	fleetclient "github.com/elastic/elastic-agent/internal/pkg/fleetapi/client"
+	"github.com/elastic/elastic-agent/internal/pkg/remote"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+	"github.com/elastic/elastic-agent/testing/fleetservertest"
+)
+
+type testAgentInfo struct{}
+
+func (testAgentInfo) AgentID() string { return "agent-id" }
+
+func TestManagerNoIdentity(t *testing.T) {
+	log, _ := logger.New("identity", false)
+	m := NewManager(log, testAgentInfo{}, testVaultOpts(t)...)
+	require.ErrorIs(t, m.Load(context.Background()), ErrNoIdentity)
+
+	cfg := remote.DefaultClientConfig()
+	assert.Equal(t, cfg, m.ClientConfig(cfg))
+	assert.NoError(t, m.Run(context.Background(), func() { t.Fatal("unexpected rotation") }))
+
+	var nilManager *Manager
+	assert.Equal(t, cfg, nilManager.ClientConfig(cfg))
+}
+
+func TestManagerRotation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	ca, err := fleetservertest.NewCA()
+	require.NoError(t, err)
+	// the certificates are valid one minute before being signed, a short validity
+	// makes them due for renewal right away.
+	ca.Validity = time.Second
+
+	var certificateRequests int
+	certificateFn := fleetservertest.NewHandlerCertificate()
+	srv := fleetservertest.NewServer(&fleetservertest.Handlers{
+		AgentID: "agent-id",
+		CertificateFn: func(ctx context.Context, h *fleetservertest.Handlers, agentID string, req fleetservertest.CertificateRequest) (*fleetservertest.CertificateResponse, *fleetservertest.HTTPError) {
+			certificateRequests++
+			if fleetservertest.ClientCertificateFromCtx(ctx) == nil {
+				return nil, &fleetservertest.HTTPError{StatusCode: 401, Message: "missing client certificate"}
+			}
+			return certificateFn(ctx, h, agentID, req)
+		},
+	}, fleetservertest.WithMutualTLS(ca))
+	defer srv.Close()
+
+	opts := testVaultOpts(t)
+	initial := newTestIdentity(t, ca)
+	require.NoError(t, Save(ctx, initial, opts...))
+
+	log, _ := logger.New("identity", false)
+	m := NewManager(log, testAgentInfo{}, opts...)
+	require.NoError(t, m.Load(ctx))
+
+	cfg := remote.DefaultClientConfig()
+	cfg.Protocol = remote.ProtocolHTTPS
+	cfg.Host = srv.LocalhostURL
+	cfg.Transport.TLS = &tlscommon.Config{CAs: []string{string(ca.CertificatePEM)}}
+	client, err := fleetclient.NewAuthWithConfig(log, "api-key", m.ClientConfig(cfg))
+	require.NoError(t, err)
+	m.SetClient(client)
+
+	runCtx, runCancel := context.WithCancel(ctx)
+	err = m.Run(runCtx, runCancel)
+	require.ErrorIs(t, err, context.Canceled)
+
+	assert.Equal(t, 1, certificateRequests)
+	rotated := m.Identity()
+	assert.False(t, rotated.key.Equal(initial.key), "a new key is generated on rotation")
+	assert.NotEqual(t, initial.Certificate().SerialNumber, rotated.Certificate().SerialNumber)
+
+	stored, err := Load(ctx, opts...)
+	require.NoError(t, err)
+	assert.Equal(t, rotated.Certificate().Raw, stored.Certificate().Raw)
+}
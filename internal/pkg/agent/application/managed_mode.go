@@ -7,14 +7,19 @@ package application
 import (
 	"context"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/elastic/elastic-agent-client/v7/pkg/client"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/actions"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/actions/handlers"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/actions/journal"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/autocapture"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/coordinator"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/dispatcher"
 	fleetgateway "github.com/elastic/elastic-agent/internal/pkg/agent/application/gateway/fleet"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/identity"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/info"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/details"
@@ -22,6 +27,7 @@ import (
 	"github.com/elastic/elastic-agent/internal/pkg/agent/errors"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/storage"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/storage/store"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/vault"
 	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
 	"github.com/elastic/elastic-agent/internal/pkg/fleetapi/acker"
 	"github.com/elastic/elastic-agent/internal/pkg/fleetapi/acker/fleet"
@@ -34,6 +40,7 @@ import (
 	"github.com/elastic/elastic-agent/internal/pkg/runner"
 	"github.com/elastic/elastic-agent/pkg/component/runtime"
 	"github.com/elastic/elastic-agent/pkg/core/logger"
+	"github.com/elastic/elastic-agent/pkg/utils"
 )
 
 // dispatchFlushInterval is the max time between calls to dispatcher.Dispatch
@@ -47,11 +54,18 @@ type managedConfigManager struct {
 	store                storage.Store
 	stateStore           *store.StateStore
 	actionQueue          *queue.ActionQueue
+	actionJournal        *journal.Journal
 	dispatcher           *dispatcher.ActionDispatcher
 	runtime              *runtime.Manager
 	coord                *coordinator.Coordinator
+	autoCapture          *autocapture.AutoCapture
 	fleetInitTimeout     time.Duration
 	initialClientSetters []actions.ClientSetter
+	identity             *identity.Manager
+
+	// actionAcker acks the queued actions cancelled locally, it's set once running.
+	actionAckerMx sync.Mutex
+	actionAcker   acker.Acker
 
 	ch    chan coordinator.ConfigChange
 	errCh chan error
@@ -65,9 +79,24 @@ func newManagedConfigManager(
 	storeSaver storage.Store,
 	runtime *runtime.Manager,
 	fleetInitTimeout time.Duration,
+	customActionTypes []string,
 	clientSetters ...actions.ClientSetter,
 ) (*managedConfigManager, error) {
-	client, err := fleetclient.NewAuthWithConfig(log, cfg.Fleet.AccessAPIKey, cfg.Fleet.Client)
+	hasRoot, err := utils.HasRoot()
+	if err != nil {
+		return nil, fmt.Errorf("checking if running with root/Administrator privileges: %w", err)
+	}
+
+	// The agent authenticates to Fleet Server with its client certificate when it was signed one on enroll.
+	identityManager := identity.NewManager(log, agentInfo, vault.WithUnprivileged(!hasRoot))
+	if err := identityManager.Load(ctx); err != nil {
+		if !errors.Is(err, identity.ErrNoIdentity) {
+			log.Warnw("Failed to load the agent identity, the agent authenticates with its API key only", "error.message", err)
+		}
+		identityManager = nil
+	}
+
+	client, err := fleetclient.NewAuthWithConfig(log, cfg.Fleet.AccessAPIKey, identityManager.ClientConfig(cfg.Fleet.Client))
 	if err != nil {
 		return nil, errors.New(err,
 			"fail to create API client",
@@ -75,6 +104,8 @@ func newManagedConfigManager(
 			errors.M(errors.MetaKeyURI, cfg.Fleet.Client.Host))
 	}
 
+	fleetclient.ReportHostsMetrics(client)
+
 	// Create the state store that will persist the last good policy change on disk.
 	stateStore, err := store.NewStateStoreWithMigration(ctx, log, paths.AgentActionStoreFile(), paths.AgentStateStoreFile())
 	if err != nil {
@@ -86,7 +117,13 @@ func newManagedConfigManager(
 		return nil, fmt.Errorf("unable to initialize action queue: %w", err)
 	}
 
-	actionDispatcher, err := dispatcher.New(log, handlers.NewDefault(log), actionQueue)
+	actionJournal := journal.New(log, paths.AgentActionJournalFile())
+
+	actionDispatcher, err := dispatcher.New(log, handlers.NewDefault(log), actionQueue,
+		dispatcher.WithJournal(actionJournal),
+		// the actions of the types declared by the components are handed to them
+		dispatcher.WithCustomActionTypes(customActionTypes...),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("unable to initialize action dispatcher: %w", err)
 	}
@@ -99,12 +136,14 @@ func newManagedConfigManager(
 		store:                storeSaver,
 		stateStore:           stateStore,
 		actionQueue:          actionQueue,
+		actionJournal:        actionJournal,
 		dispatcher:           actionDispatcher,
 		runtime:              runtime,
 		fleetInitTimeout:     fleetInitTimeout,
 		ch:                   make(chan coordinator.ConfigChange),
 		errCh:                make(chan error),
 		initialClientSetters: clientSetters,
+		identity:             identityManager,
 	}, nil
 }
 
@@ -130,16 +169,20 @@ func (m *managedConfigManager) Run(ctx context.Context) error {
 	defer gatewayCancel()
 
 	// Initialize the actionDispatcher.
-	policyChanger := m.initDispatcher(gatewayCancel)
+	policyChanger, apiKeyRotator, diagUploader := m.initDispatcher(gatewayCancel)
 
 	// Create ackers to enqueue/retry failed acks
 	ack, err := fleet.NewAcker(m.log, m.agentInfo, m.client)
 	if err != nil {
 		return fmt.Errorf("failed to create acker: %w", err)
 	}
-	retrier := retrier.New(ack, m.log)
-	batchedAcker := lazy.NewAcker(ack, m.log, lazy.WithRetrier(retrier))
+	retrier := retrier.New(ack, m.log, retrier.WithAckRecorder(m.actionJournal))
+	batchedAcker := lazy.NewAcker(ack, m.log, lazy.WithRetrier(retrier), lazy.WithAckRecorder(m.actionJournal))
 	actionAcker := store.NewStateStoreActionAcker(batchedAcker, m.stateStore)
+	m.setActionAcker(actionAcker)
+
+	m.actionQueue.SetObserver(m.reportQueuedActions)
+	m.reportQueuedActions(m.actionQueue.Actions())
 
 	if err := m.coord.AckUpgrade(ctx, actionAcker); err != nil {
 		m.log.Warnf("Failed to ack upgrade: %v", err)
@@ -190,19 +233,28 @@ func (m *managedConfigManager) Run(ctx context.Context) error {
 		m.client,
 		actionAcker,
 		m.coord.State,
+		m.coord.StateSubscribe,
 		m.stateStore,
 	)
 	if err != nil {
 		return err
 	}
+	apiKeyRotator.SetVerifier(gateway.VerifyClient)
 
 	// Not running a Fleet Server so the gateway and acker can be changed based on the configuration change.
 	if m.cfg.Fleet.Server == nil {
-		policyChanger.AddSetter(gateway)
-		policyChanger.AddSetter(ack)
+		clientSetters := m.clientSetters(gateway, ack, diagUploader, fleetclient.HostsMetricsReporter{}, apiKeyRotator)
+		if m.identity != nil {
+			m.identity.SetClient(m.client)
+			clientSetters = append(clientSetters, m.identity)
+			policyChanger.SetClientConfig(m.identity.ClientConfig)
+			apiKeyRotator.SetClientConfig(m.identity.ClientConfig)
+			go m.runIdentityRotation(ctx, clientSetters)
+		}
 
-		for _, cs := range m.initialClientSetters {
+		for _, cs := range clientSetters {
 			policyChanger.AddSetter(cs)
+			apiKeyRotator.AddSetter(cs)
 		}
 	} else {
 		// locally managed fleet server
@@ -210,6 +262,12 @@ func (m *managedConfigManager) Run(ctx context.Context) error {
 		for _, cs := range m.initialClientSetters {
 			cs.SetClient(m.client)
 		}
+
+		// the policy changes keep the local address, but every Fleet client must use the
+		// rotated API key
+		for _, cs := range m.clientSetters(gateway, ack, diagUploader, apiKeyRotator) {
+			apiKeyRotator.AddSetter(cs)
+		}
 	}
 
 	// Proxy errors from the gateway to our own channel.
@@ -236,6 +294,29 @@ func (m *managedConfigManager) Run(ctx context.Context) error {
 	return gatewayRunner.Err()
 }
 
+// clientSetters returns the setters of the Fleet client, followed by the initial ones.
+func (m *managedConfigManager) clientSetters(setters ...actions.ClientSetter) []actions.ClientSetter {
+	return append(setters, m.initialClientSetters...)
+}
+
+// runIdentityRotation renews the client certificate of the agent and recreates the Fleet client
+// of the setters with it.
+func (m *managedConfigManager) runIdentityRotation(ctx context.Context, setters []actions.ClientSetter) {
+	err := m.identity.Run(ctx, func() {
+		client, err := fleetclient.NewAuthWithConfig(m.log, m.cfg.Fleet.AccessAPIKey, m.identity.ClientConfig(m.cfg.Fleet.Client))
+		if err != nil {
+			m.log.Errorw("Failed to create the Fleet client with the renewed client certificate", "error.message", err)
+			return
+		}
+		for _, cs := range setters {
+			cs.SetClient(client)
+		}
+	})
+	if err != nil && !errors.Is(err, context.Canceled) {
+		m.log.Errorw("Agent client certificate rotation stopped", "error.message", err)
+	}
+}
+
 // runDispatcher passes actions collected from gateway to dispatcher or calls Dispatch with no actions every flushInterval.
 func runDispatcher(ctx context.Context, actionDispatcher dispatcher.Dispatcher, fleetGateway coordinator.FleetGateway, detailsSetter details.Observer, actionAcker acker.Acker, flushInterval time.Duration) {
 	t := time.NewTimer(flushInterval)
@@ -267,6 +348,94 @@ func (m *managedConfigManager) Watch() <-chan coordinator.ConfigChange {
 	return m.ch
 }
 
+// CancelQueuedAction removes the action with actionID from the action queue and acks
+// it to Fleet as failed.
+func (m *managedConfigManager) CancelQueuedAction(ctx context.Context, actionID string) error {
+	var action fleetapi.Action
+	for _, a := range m.actionQueue.Actions() {
+		if a.ID() == actionID {
+			action = a
+			break
+		}
+	}
+	// the action could have been dequeued since listed
+	if action == nil || m.actionQueue.Cancel(actionID) == 0 {
+		return fmt.Errorf("action %q is not queued", actionID)
+	}
+	if err := m.actionQueue.Save(); err != nil {
+		return fmt.Errorf("failed to persist action_queue: %w", err)
+	}
+	m.log.Infow("Queued action cancelled locally", "action_id", actionID, "action_type", action.Type())
+	m.actionJournal.Record(journal.StatusCancelled, nil, action)
+
+	// the upgrade is no longer scheduled
+	if upgradeDetails := m.coord.State().UpgradeDetails; upgradeDetails != nil &&
+		upgradeDetails.ActionID == actionID && upgradeDetails.State == details.StateScheduled {
+		m.coord.SetUpgradeDetails(nil)
+	}
+
+	fAction, ok := action.(fleetapi.FailableAction)
+	actionAcker := m.getActionAcker()
+	if !ok || actionAcker == nil {
+		return nil
+	}
+	if rAction, ok := action.(fleetapi.RetryableAction); ok {
+		rAction.SetRetryAttempt(-1)
+	}
+	fAction.SetError(fmt.Errorf("action %q cancelled locally", actionID))
+	if err := actionAcker.Ack(ctx, fAction); err != nil {
+		return fmt.Errorf("failed to ack cancelled action: %w", err)
+	}
+	if err := actionAcker.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit cancelled action ack: %w", err)
+	}
+	return nil
+}
+
+// ActionHistory returns the entries of the action journal selected by filter.
+func (m *managedConfigManager) ActionHistory(filter journal.Filter) ([]journal.Entry, error) {
+	return m.actionJournal.Query(filter)
+}
+
+func (m *managedConfigManager) setActionAcker(actionAcker acker.Acker) {
+	m.actionAckerMx.Lock()
+	defer m.actionAckerMx.Unlock()
+	m.actionAcker = actionAcker
+}
+
+func (m *managedConfigManager) getActionAcker() acker.Acker {
+	m.actionAckerMx.Lock()
+	defer m.actionAckerMx.Unlock()
+	return m.actionAcker
+}
+
+// reportQueuedActions reports the actions in the action queue in the coordinator state.
+func (m *managedConfigManager) reportQueuedActions(actions []fleetapi.Action) {
+	queued := make([]coordinator.QueuedAction, 0, len(actions))
+	for _, a := range actions {
+		sAction, ok := a.(fleetapi.ScheduledAction)
+		if !ok {
+			continue
+		}
+		start, _ := sAction.StartTime()
+		expiration, _ := sAction.Expiration()
+		q := coordinator.QueuedAction{
+			ID:         a.ID(),
+			Type:       a.Type(),
+			StartTime:  start,
+			Expiration: expiration,
+		}
+		if rAction, ok := a.(fleetapi.RetryableAction); ok {
+			q.RetryAttempt = rAction.RetryAttempt()
+		}
+		queued = append(queued, q)
+	}
+	sort.Slice(queued, func(i, j int) bool {
+		return queued[i].StartTime.Before(queued[j].StartTime)
+	})
+	m.coord.SetQueuedActions(queued)
+}
+
 func (m *managedConfigManager) wasUnenrolled() bool {
 	actions := m.stateStore.Actions()
 	for _, a := range actions {
@@ -333,7 +502,7 @@ func fleetServerRunning(state runtime.ComponentState) bool {
 	return false
 }
 
-func (m *managedConfigManager) initDispatcher(canceller context.CancelFunc) *handlers.PolicyChangeHandler {
+func (m *managedConfigManager) initDispatcher(canceller context.CancelFunc) (*handlers.PolicyChangeHandler, *handlers.RotateAPIKey, *uploader.Client) {
 	policyChanger := handlers.NewPolicyChangeHandler(
 		m.log,
 		m.agentInfo,
@@ -365,7 +534,7 @@ func (m *managedConfigManager) initDispatcher(canceller context.CancelFunc) *han
 
 	m.dispatcher.MustRegister(
 		&fleetapi.ActionUpgrade{},
-		handlers.NewUpgrade(m.log, m.coord),
+		handlers.NewUpgrade(m.log, m.coord, m.actionQueue),
 	)
 
 	m.dispatcher.MustRegister(
@@ -385,25 +554,47 @@ func (m *managedConfigManager) initDispatcher(canceller context.CancelFunc) *han
 		),
 	)
 
+	diagUploader := uploader.New(m.agentInfo.AgentID(), m.client, m.cfg.Settings.MonitoringConfig.Diagnostics.Uploader, uploader.WithResumeDir(paths.DiagnosticsUploadsDir()))
+	if m.autoCapture != nil {
+		m.autoCapture.SetUploader(diagUploader)
+	}
 	m.dispatcher.MustRegister(
 		&fleetapi.ActionDiagnostics{},
 		handlers.NewDiagnostics(
 			m.log,
 			m.coord,
 			m.cfg.Settings.MonitoringConfig.Diagnostics.Limit,
-			uploader.New(m.agentInfo.AgentID(), m.client, m.cfg.Settings.MonitoringConfig.Diagnostics.Uploader),
+			m.coord.DiagnosticsRedaction,
+			diagUploader,
 		),
 	)
 
+	apiKeyRotator := handlers.NewRotateAPIKey(
+		m.log,
+		m.agentInfo,
+		m.cfg,
+		m.store,
+		m.client,
+	)
+	m.dispatcher.MustRegister(
+		&fleetapi.ActionRotateAPIKey{},
+		apiKeyRotator,
+	)
+
 	m.dispatcher.MustRegister(
 		&fleetapi.ActionApp{},
 		handlers.NewAppAction(m.log, m.coord, m.agentInfo.AgentID()),
 	)
 
+	m.dispatcher.MustRegister(
+		&fleetapi.ActionCustom{},
+		handlers.NewCustomAction(m.log, m.coord),
+	)
+
 	m.dispatcher.MustRegister(
 		&fleetapi.ActionUnknown{},
 		handlers.NewUnknown(m.log),
 	)
 
-	return policyChanger
+	return policyChanger, apiKeyRotator, diagUploader
 }
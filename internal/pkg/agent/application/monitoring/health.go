@@ -0,0 +1,105 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package monitoring
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/elastic/elastic-agent-client/v7/pkg/client"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/coordinator"
+	monitoringCfg "github.com/elastic/elastic-agent/internal/pkg/core/monitoring/config"
+	agentclient "github.com/elastic/elastic-agent/pkg/control/v2/client"
+)
+
+// healthResponse is the response body of the /liveness and /readiness endpoints.
+type healthResponse struct {
+	Healthy  bool            `json:"healthy"`
+	State    string          `json:"state"`
+	Message  string          `json:"message"`
+	Failures []healthFailure `json:"failures,omitempty"`
+}
+
+// healthFailure explains why a check failed.
+type healthFailure struct {
+	Check  string `json:"check"`
+	Reason string `json:"reason"`
+}
+
+// healthHandler responds with 200 when the agent state meets the criteria of cfg and 503 otherwise,
+// the body lists the failing checks.
+func healthHandler(coord *coordinator.Coordinator, cfg monitoringCfg.HealthCheckConfig) func(http.ResponseWriter, *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		state := coord.State()
+		failures := checkHealth(state, cfg, time.Now().UTC())
+		resp := healthResponse{
+			Healthy:  len(failures) == 0,
+			State:    state.State.String(),
+			Message:  state.Message,
+			Failures: failures,
+		}
+
+		status := http.StatusOK
+		if !resp.Healthy {
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(status)
+		return json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// checkHealth returns the checks of cfg the state fails.
+func checkHealth(state coordinator.State, cfg monitoringCfg.HealthCheckConfig, now time.Time) []healthFailure {
+	var failures []healthFailure
+
+	if cfg.CheckState && state.State != agentclient.Healthy && !(cfg.DegradedHealthy && state.State == agentclient.Degraded) {
+		failures = append(failures, healthFailure{
+			Check:  "state",
+			Reason: fmt.Sprintf("agent is %s: %s", state.State, state.Message),
+		})
+	}
+
+	if cfg.MaxCheckinAge > 0 {
+		if state.FleetLastCheckin.IsZero() {
+			failures = append(failures, healthFailure{
+				Check:  "fleet_checkin",
+				Reason: "no successful checkin with Fleet Server",
+			})
+		} else if age := now.Sub(state.FleetLastCheckin); age > cfg.MaxCheckinAge {
+			failures = append(failures, healthFailure{
+				Check:  "fleet_checkin",
+				Reason: fmt.Sprintf("last successful checkin with Fleet Server %s ago, exceeds %s", age.Round(time.Second), cfg.MaxCheckinAge),
+			})
+		}
+	}
+
+	for _, id := range cfg.Components {
+		if reason := componentUnhealthy(state, id); reason != "" {
+			failures = append(failures, healthFailure{
+				Check:  "component",
+				Reason: reason,
+			})
+		}
+	}
+
+	return failures
+}
+
+// componentUnhealthy returns why the component is not HEALTHY, empty when it is.
+func componentUnhealthy(state coordinator.State, id string) string {
+	for _, c := range state.Components {
+		if c.Component.ID != id {
+			continue
+		}
+		if c.State.State != client.UnitStateHealthy {
+			return fmt.Sprintf("component %s is %s: %s", id, c.State.State, c.State.Message)
+		}
+		return ""
+	}
+	return fmt.Sprintf("component %s is not running", id)
+}
@@ -0,0 +1,113 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package monitoring
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/elastic/elastic-agent-client/v7/pkg/client"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/coordinator"
+	monitoringCfg "github.com/elastic/elastic-agent/internal/pkg/core/monitoring/config"
+	"github.com/elastic/elastic-agent/pkg/component"
+	"github.com/elastic/elastic-agent/pkg/component/runtime"
+	agentclient "github.com/elastic/elastic-agent/pkg/control/v2/client"
+)
+
+func TestCheckHealth(t *testing.T) {
+	now := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	components := []runtime.ComponentComponentState{
+		{
+			Component: component.Component{ID: "filestream-default"},
+			State:     runtime.ComponentState{State: client.UnitStateHealthy},
+		},
+		{
+			Component: component.Component{ID: "system/metrics-default"},
+			State:     runtime.ComponentState{State: client.UnitStateDegraded, Message: "missing permissions"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		state    coordinator.State
+		cfg      monitoringCfg.HealthCheckConfig
+		failures []healthFailure
+	}{
+		{
+			name:  "healthy",
+			state: coordinator.State{State: agentclient.Healthy},
+		},
+		{
+			name:  "failed without state check",
+			state: coordinator.State{State: agentclient.Failed, Message: "invalid policy"},
+		},
+		{
+			name:  "degraded",
+			state: coordinator.State{State: agentclient.Degraded, Message: "1 component degraded"},
+			cfg:   monitoringCfg.HealthCheckConfig{CheckState: true},
+			failures: []healthFailure{
+				{Check: "state", Reason: "agent is DEGRADED: 1 component degraded"},
+			},
+		},
+		{
+			name:  "degraded considered healthy",
+			state: coordinator.State{State: agentclient.Degraded},
+			cfg:   monitoringCfg.HealthCheckConfig{CheckState: true, DegradedHealthy: true},
+		},
+		{
+			name:  "failed with degraded considered healthy",
+			state: coordinator.State{State: agentclient.Failed, Message: "invalid policy"},
+			cfg:   monitoringCfg.HealthCheckConfig{CheckState: true, DegradedHealthy: true},
+			failures: []healthFailure{
+				{Check: "state", Reason: "agent is FAILED: invalid policy"},
+			},
+		},
+		{
+			name:  "recent checkin",
+			state: coordinator.State{State: agentclient.Healthy, FleetLastCheckin: now.Add(-time.Minute)},
+			cfg:   monitoringCfg.HealthCheckConfig{MaxCheckinAge: 5 * time.Minute},
+		},
+		{
+			name:  "old checkin",
+			state: coordinator.State{State: agentclient.Healthy, FleetLastCheckin: now.Add(-10 * time.Minute)},
+			cfg:   monitoringCfg.HealthCheckConfig{MaxCheckinAge: 5 * time.Minute},
+			failures: []healthFailure{
+				{Check: "fleet_checkin", Reason: "last successful checkin with Fleet Server 10m0s ago, exceeds 5m0s"},
+			},
+		},
+		{
+			name:  "no checkin",
+			state: coordinator.State{State: agentclient.Healthy},
+			cfg:   monitoringCfg.HealthCheckConfig{MaxCheckinAge: 5 * time.Minute},
+			failures: []healthFailure{
+				{Check: "fleet_checkin", Reason: "no successful checkin with Fleet Server"},
+			},
+		},
+		{
+			name:  "required components",
+			state: coordinator.State{State: agentclient.Healthy, Components: components},
+			cfg: monitoringCfg.HealthCheckConfig{
+				DegradedHealthy: true,
+				Components:      []string{"filestream-default", "system/metrics-default", "endpoint-default"},
+			},
+			failures: []healthFailure{
+				{Check: "component", Reason: "component system/metrics-default is DEGRADED: missing permissions"},
+				{Check: "component", Reason: "component endpoint-default is not running"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			failures := checkHealth(tc.state, tc.cfg, now)
+			diff := cmp.Diff(tc.failures, failures)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
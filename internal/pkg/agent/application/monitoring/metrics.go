@@ -0,0 +1,359 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package monitoring
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elastic/elastic-agent-client/v7/pkg/client"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/coordinator"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/details"
+	"github.com/elastic/elastic-agent/pkg/control/v2/cproto"
+	"github.com/elastic/elastic-agent/pkg/utils"
+)
+
+const (
+	openMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+	metricsPrefix          = "elastic_agent"
+
+	metricTypeCounter  = "counter"
+	metricTypeGauge    = "gauge"
+	metricTypeStateSet = "stateset"
+	metricTypeUnknown  = "unknown"
+)
+
+// metricsNamespaces are the namespaces of the agent registries exposed on /metrics.
+var metricsNamespaces = []string{"stats", "state"}
+
+var unitStates = []client.UnitState{
+	client.UnitStateStarting,
+	client.UnitStateConfiguring,
+	client.UnitStateHealthy,
+	client.UnitStateDegraded,
+	client.UnitStateFailed,
+	client.UnitStateStopping,
+	client.UnitStateStopped,
+}
+
+var upgradeStates = []details.State{
+	details.StateRequested,
+	details.StateScheduled,
+	details.StateDownloading,
+	details.StateExtracting,
+	details.StatePrepared,
+	details.StateReplacing,
+	details.StateRestarting,
+	details.StateWatching,
+	details.StateRollback,
+	details.StateCompleted,
+	details.StateFailed,
+}
+
+// metricsHandler serves the agent metrics in the OpenMetrics text format: the metrics of the agent
+// registries, the state of the agent, its components and units and, when scrapeComponents is set,
+// the metrics of the components /stats endpoints labeled with their component.
+func metricsHandler(ns func(string) *monitoring.Namespace, coord *coordinator.Coordinator, scrapeComponents bool) func(http.ResponseWriter, *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		m := newMetricSet()
+		for _, name := range metricsNamespaces {
+			snapshot := monitoring.CollectFlatSnapshot(ns(name).GetRegistry(), monitoring.Full, false)
+			m.addFlatSnapshot(metricsPrefix, snapshot, nil)
+		}
+
+		state := coord.State()
+		m.addState(state, time.Now().UTC())
+		if scrapeComponents {
+			m.addComponentStats(scrapeComponentStats(r.Context(), state))
+		}
+
+		w.Header().Set("Content-Type", openMetricsContentType)
+		return m.write(w)
+	}
+}
+
+// label is a label of a metric sample.
+type label struct {
+	name  string
+	value string
+}
+
+type sample struct {
+	suffix string
+	labels []label
+	value  float64
+}
+
+type metricFamily struct {
+	typ     string
+	help    string
+	samples []sample
+}
+
+// metricSet holds the metric families to expose, by name.
+type metricSet struct {
+	families map[string]*metricFamily
+}
+
+func newMetricSet() *metricSet {
+	return &metricSet{families: make(map[string]*metricFamily)}
+}
+
+func (m *metricSet) family(name, typ, help string) *metricFamily {
+	f, ok := m.families[name]
+	if !ok {
+		f = &metricFamily{typ: typ, help: help}
+		m.families[name] = f
+	}
+	return f
+}
+
+func (m *metricSet) add(name, typ, help string, value float64, labels ...label) {
+	f := m.family(name, typ, help)
+	suffix := ""
+	if typ == metricTypeCounter {
+		suffix = "_total"
+	}
+	f.samples = append(f.samples, sample{suffix: suffix, labels: labels, value: value})
+}
+
+// addStateSet adds a stateset sample for each of the states, set to 1 for current.
+func (m *metricSet) addStateSet(name, help string, states []string, current string, labels ...label) {
+	f := m.family(name, metricTypeStateSet, help)
+	for _, s := range states {
+		value := 0.0
+		if s == current {
+			value = 1
+		}
+		sampleLabels := append(append([]label{}, labels...), label{name: name, value: s})
+		f.samples = append(f.samples, sample{labels: sampleLabels, value: value})
+	}
+}
+
+// addFlatSnapshot adds the numeric and boolean metrics of the snapshot. As the registries don't tell
+// counters from gauges the metrics are of unknown type.
+func (m *metricSet) addFlatSnapshot(prefix string, snapshot monitoring.FlatSnapshot, labels []label) {
+	for k, v := range snapshot.Ints {
+		m.add(metricName(prefix, k), metricTypeUnknown, "", float64(v), labels...)
+	}
+	for k, v := range snapshot.Floats {
+		m.add(metricName(prefix, k), metricTypeUnknown, "", v, labels...)
+	}
+	for k, v := range snapshot.Bools {
+		m.add(metricName(prefix, k), metricTypeUnknown, "", boolToFloat(v), labels...)
+	}
+}
+
+func (m *metricSet) addState(state coordinator.State, now time.Time) {
+	agentStates := make([]string, 0, len(cproto.State_name))
+	for _, name := range cproto.State_name {
+		agentStates = append(agentStates, name)
+	}
+	sort.Strings(agentStates)
+
+	m.addStateSet(metricsPrefix+"_state", "State of the Elastic Agent.", agentStates, state.State.String())
+	m.addStateSet(metricsPrefix+"_fleet_state", "State of the connection to Fleet Server.", agentStates, state.FleetState.String())
+	if !state.FleetLastCheckin.IsZero() {
+		m.add(metricsPrefix+"_fleet_last_checkin_age_seconds", metricTypeGauge,
+			"Time since the last successful checkin with Fleet Server.", now.Sub(state.FleetLastCheckin).Seconds())
+	}
+
+	if state.UpgradeDetails != nil {
+		names := make([]string, 0, len(upgradeStates))
+		for _, s := range upgradeStates {
+			names = append(names, string(s))
+		}
+		m.addStateSet(metricsPrefix+"_upgrade_state", "State of the ongoing upgrade.", names, string(state.UpgradeDetails.State),
+			label{name: "target_version", value: state.UpgradeDetails.TargetVersion})
+	}
+
+	unitStateNames := make([]string, 0, len(unitStates))
+	for _, s := range unitStates {
+		unitStateNames = append(unitStateNames, s.String())
+	}
+	for _, c := range state.Components {
+		compLabels := []label{
+			{name: "component_id", value: c.Component.ID},
+			{name: "component_type", value: c.Component.Type()},
+		}
+		m.addStateSet(metricsPrefix+"_component_state", "State of the component.", unitStateNames, c.State.State.String(), compLabels...)
+		m.add(metricsPrefix+"_component_restarts", metricTypeCounter, "Number of restarts of the component process.", float64(c.State.Restarts), compLabels...)
+
+		for key, unit := range c.State.Units {
+			unitLabels := []label{
+				{name: "component_id", value: c.Component.ID},
+				{name: "unit_id", value: key.UnitID},
+				{name: "unit_type", value: key.UnitType.String()},
+			}
+			m.addStateSet(metricsPrefix+"_unit_state", "State of the unit.", unitStateNames, unit.State.String(), unitLabels...)
+		}
+	}
+}
+
+// componentStats are the metrics read from the /stats endpoint of a component.
+type componentStats struct {
+	id    string
+	typ   string
+	stats map[string]interface{}
+	err   error
+}
+
+func (m *metricSet) addComponentStats(stats []componentStats) {
+	for _, s := range stats {
+		labels := []label{
+			{name: "component_id", value: s.id},
+			{name: "component_type", value: s.typ},
+		}
+		m.add(metricsPrefix+"_component_scrape_up", metricTypeGauge, "Whether the component metrics were scraped.", boolToFloat(s.err == nil), labels...)
+		if s.err != nil {
+			continue
+		}
+		var snapshot monitoring.FlatSnapshot
+		snapshot.Floats = make(map[string]float64)
+		snapshot.Bools = make(map[string]bool)
+		flattenStats("", s.stats, &snapshot)
+		m.addFlatSnapshot(metricsPrefix+"_component", snapshot, labels)
+	}
+}
+
+// scrapeComponentStats reads the /stats endpoint of the running components, concurrently.
+func scrapeComponentStats(ctx context.Context, state coordinator.State) []componentStats {
+	var wg sync.WaitGroup
+	stats := make([]componentStats, 0, len(state.Components))
+	var mx sync.Mutex
+	for _, c := range state.Components {
+		if c.Component.InputSpec == nil || c.State.State != client.UnitStateHealthy && c.State.State != client.UnitStateDegraded {
+			continue
+		}
+		id, typ := c.Component.ID, c.Component.Type()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s := componentStats{id: id, typ: typ}
+			endpoint := prefixedEndpoint(utils.SocketURLWithFallback(id, paths.TempDir()))
+			b, statusCode, err := processMetrics(ctx, endpoint, "stats")
+			if err == nil && statusCode != http.StatusOK {
+				err = fmt.Errorf("unexpected status code %d", statusCode)
+			}
+			if err == nil {
+				err = json.Unmarshal(b, &s.stats)
+			}
+			s.err = err
+
+			mx.Lock()
+			defer mx.Unlock()
+			stats = append(stats, s)
+		}()
+	}
+	wg.Wait()
+	return stats
+}
+
+// flattenStats flattens the decoded JSON stats into snapshot, the keys of nested objects joined with dots.
+func flattenStats(prefix string, stats map[string]interface{}, snapshot *monitoring.FlatSnapshot) {
+	for k, v := range stats {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch value := v.(type) {
+		case map[string]interface{}:
+			flattenStats(key, value, snapshot)
+		case float64:
+			snapshot.Floats[key] = value
+		case bool:
+			snapshot.Bools[key] = value
+		}
+	}
+}
+
+// write writes the metric families sorted by name, in the OpenMetrics text format.
+func (m *metricSet) write(w io.Writer) error {
+	names := make([]string, 0, len(m.families))
+	for name := range m.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		f := m.families[name]
+		fmt.Fprintf(&b, "# TYPE %s %s\n", name, f.typ)
+		if f.help != "" {
+			fmt.Fprintf(&b, "# HELP %s %s\n", name, escapeMetricText(f.help))
+		}
+		for _, s := range f.samples {
+			b.WriteString(name)
+			b.WriteString(s.suffix)
+			if len(s.labels) > 0 {
+				b.WriteByte('{')
+				for i, l := range s.labels {
+					if i > 0 {
+						b.WriteByte(',')
+					}
+					fmt.Fprintf(&b, "%s=\"%s\"", l.name, escapeMetricText(l.value))
+				}
+				b.WriteByte('}')
+			}
+			b.WriteByte(' ')
+			b.WriteString(formatMetricValue(s.value))
+			b.WriteByte('\n')
+		}
+	}
+	b.WriteString("# EOF\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// metricName returns the metric name of the registry key, with the characters not allowed
+// in metric names replaced by underscores.
+func metricName(prefix, key string) string {
+	var b strings.Builder
+	b.WriteString(prefix)
+	b.WriteByte('_')
+	for _, r := range key {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == ':' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+func escapeMetricText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+func formatMetricValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,122 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package monitoring
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-client/v7/pkg/client"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/coordinator"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/details"
+	"github.com/elastic/elastic-agent/pkg/component"
+	"github.com/elastic/elastic-agent/pkg/component/runtime"
+	agentclient "github.com/elastic/elastic-agent/pkg/control/v2/client"
+)
+
+func writeMetrics(t *testing.T, m *metricSet) string {
+	var b strings.Builder
+	require.NoError(t, m.write(&b))
+	return b.String()
+}
+
+func TestMetricsRegistry(t *testing.T) {
+	reg := monitoring.NewRegistry()
+	monitoring.NewInt(reg, "beat.memstats.rss").Set(42)
+	monitoring.NewFloat(reg, "system.load.1").Set(0.5)
+	monitoring.NewBool(reg, "output.connected").Set(true)
+	monitoring.NewString(reg, "beat.name").Set("elastic-agent")
+
+	m := newMetricSet()
+	m.addFlatSnapshot(metricsPrefix, monitoring.CollectFlatSnapshot(reg, monitoring.Full, false), nil)
+
+	assert.Equal(t, `# TYPE elastic_agent_beat_memstats_rss unknown
+elastic_agent_beat_memstats_rss 42
+# TYPE elastic_agent_output_connected unknown
+elastic_agent_output_connected 1
+# TYPE elastic_agent_system_load_1 unknown
+elastic_agent_system_load_1 0.5
+# EOF
+`, writeMetrics(t, m))
+}
+
+func TestMetricsState(t *testing.T) {
+	now := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	state := coordinator.State{
+		State:            agentclient.Healthy,
+		FleetState:       agentclient.Healthy,
+		FleetLastCheckin: now.Add(-30 * time.Second),
+		UpgradeDetails:   &details.Details{TargetVersion: "8.14.0", State: details.StateDownloading},
+		Components: []runtime.ComponentComponentState{{
+			Component: component.Component{ID: "filestream-default", InputSpec: &component.InputRuntimeSpec{InputType: "filestream"}},
+			State: runtime.ComponentState{
+				State:    client.UnitStateDegraded,
+				Restarts: 2,
+				Units: map[runtime.ComponentUnitKey]runtime.ComponentUnitState{
+					{UnitType: client.UnitTypeInput, UnitID: "filestream-default-logs"}: {State: client.UnitStateFailed},
+				},
+			},
+		}},
+	}
+
+	m := newMetricSet()
+	m.addState(state, now)
+	out := writeMetrics(t, m)
+
+	for _, line := range []string{
+		`# TYPE elastic_agent_state stateset`,
+		`elastic_agent_state{elastic_agent_state="HEALTHY"} 1`,
+		`elastic_agent_state{elastic_agent_state="FAILED"} 0`,
+		`elastic_agent_fleet_state{elastic_agent_fleet_state="HEALTHY"} 1`,
+		`elastic_agent_fleet_last_checkin_age_seconds 30`,
+		`elastic_agent_upgrade_state{target_version="8.14.0",elastic_agent_upgrade_state="UPG_DOWNLOADING"} 1`,
+		`elastic_agent_component_state{component_id="filestream-default",component_type="filestream",elastic_agent_component_state="DEGRADED"} 1`,
+		`elastic_agent_component_state{component_id="filestream-default",component_type="filestream",elastic_agent_component_state="HEALTHY"} 0`,
+		`# TYPE elastic_agent_component_restarts counter`,
+		`elastic_agent_component_restarts_total{component_id="filestream-default",component_type="filestream"} 2`,
+		`elastic_agent_unit_state{component_id="filestream-default",unit_id="filestream-default-logs",unit_type="input",elastic_agent_unit_state="FAILED"} 1`,
+	} {
+		assert.Contains(t, out, line+"\n")
+	}
+	assert.True(t, strings.HasSuffix(out, "# EOF\n"))
+}
+
+func TestMetricsComponentStats(t *testing.T) {
+	m := newMetricSet()
+	m.addComponentStats([]componentStats{
+		{
+			id:  "filestream-default",
+			typ: "filestream",
+			stats: map[string]interface{}{
+				"libbeat": map[string]interface{}{
+					"output": map[string]interface{}{"events": map[string]interface{}{"acked": float64(12)}},
+				},
+				"beat": map[string]interface{}{"info": map[string]interface{}{"name": "filebeat"}},
+			},
+		},
+		{id: "system-metrics", typ: "system/metrics", err: errors.New("connection refused")},
+	})
+
+	assert.Equal(t, `# TYPE elastic_agent_component_libbeat_output_events_acked unknown
+elastic_agent_component_libbeat_output_events_acked{component_id="filestream-default",component_type="filestream"} 12
+# TYPE elastic_agent_component_scrape_up gauge
+# HELP elastic_agent_component_scrape_up Whether the component metrics were scraped.
+elastic_agent_component_scrape_up{component_id="filestream-default",component_type="filestream"} 1
+elastic_agent_component_scrape_up{component_id="system-metrics",component_type="system/metrics"} 0
+# EOF
+`, writeMetrics(t, m))
+}
+
+func TestMetricsEscaping(t *testing.T) {
+	m := newMetricSet()
+	m.add("elastic_agent_test", metricTypeGauge, "", 1, label{name: "message", value: "a \"quoted\"\nback\\slash"})
+	assert.Contains(t, writeMetrics(t, m), `elastic_agent_test{message="a \"quoted\"\nback\\slash"} 1`)
+}
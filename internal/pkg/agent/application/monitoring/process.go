@@ -135,7 +135,12 @@ func redirectToPath(w http.ResponseWriter, r *http.Request, id, path, operatingS
 }
 
 func processMetrics(ctx context.Context, endpoint, path string) ([]byte, int, error) {
-	hostData, err := parseURL(endpoint, "http", "", "", path, "")
+	return processRequest(ctx, endpoint, path, "", timeout)
+}
+
+// processRequest sends a GET request to the path of the monitoring endpoint of a process.
+func processRequest(ctx context.Context, endpoint, path, query string, timeout time.Duration) ([]byte, int, error) {
+	hostData, err := parseURL(endpoint, "http", "", "", path, query)
 	if err != nil {
 		return nil, 0, errorWithStatus(http.StatusInternalServerError, err)
 	}
@@ -0,0 +1,75 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/elastic/elastic-agent-client/v7/pkg/client"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/coordinator"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
+	"github.com/elastic/elastic-agent/pkg/utils"
+)
+
+const (
+	pprofCPUPath  = "debug/pprof/profile"
+	pprofHeapPath = "debug/pprof/heap"
+)
+
+// ComponentProfile holds the profiles read from the pprof endpoint of a component, or the error
+// reading them.
+type ComponentProfile struct {
+	ComponentID string
+	// CPU is the CPU profile, sampled for the requested duration.
+	CPU []byte
+	// Heap is the gzipped heap profile.
+	Heap []byte
+	Err  error
+}
+
+// ComponentProfiles reads the CPU and heap profiles of the running components from the pprof endpoint
+// of their monitoring server, concurrently. Only the profiles are requested, not the diagnostics of
+// the components. The components not serving the pprof endpoint report an error.
+func ComponentProfiles(ctx context.Context, state coordinator.State, cpuDuration time.Duration) []ComponentProfile {
+	seconds := max(int(cpuDuration.Seconds()), 1)
+
+	var wg sync.WaitGroup
+	profiles := make([]ComponentProfile, 0, len(state.Components))
+	var mx sync.Mutex
+	for _, c := range state.Components {
+		if c.Component.InputSpec == nil || c.State.State != client.UnitStateHealthy && c.State.State != client.UnitStateDegraded {
+			continue
+		}
+		id := c.Component.ID
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p := ComponentProfile{ComponentID: id}
+			endpoint := prefixedEndpoint(utils.SocketURLWithFallback(id, paths.TempDir()))
+			p.CPU, p.Err = readProfile(ctx, endpoint, pprofCPUPath, fmt.Sprintf("seconds=%d", seconds), time.Duration(seconds)*time.Second+timeout)
+			if p.Err == nil {
+				p.Heap, p.Err = readProfile(ctx, endpoint, pprofHeapPath, "", timeout)
+			}
+
+			mx.Lock()
+			defer mx.Unlock()
+			profiles = append(profiles, p)
+		}()
+	}
+	wg.Wait()
+	return profiles
+}
+
+func readProfile(ctx context.Context, endpoint, path, query string, timeout time.Duration) ([]byte, error) {
+	b, statusCode, err := processRequest(ctx, endpoint, path, query, timeout)
+	if err == nil && statusCode != http.StatusOK {
+		err = fmt.Errorf("unexpected status code %d reading %s", statusCode, path)
+	}
+	return b, err
+}
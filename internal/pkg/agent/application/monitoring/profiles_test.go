@@ -0,0 +1,84 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+//go:build !windows
+
+package monitoring
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-client/v7/pkg/client"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/coordinator"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
+	"github.com/elastic/elastic-agent/pkg/component"
+	"github.com/elastic/elastic-agent/pkg/component/runtime"
+	"github.com/elastic/elastic-agent/pkg/utils"
+)
+
+func TestComponentProfiles(t *testing.T) {
+	// keep the socket paths short
+	top, err := os.MkdirTemp("", "profiles")
+	require.NoError(t, err)
+	defer os.RemoveAll(top)
+	defer paths.SetTop(paths.Top())
+	paths.SetTop(top)
+	require.NoError(t, os.MkdirAll(paths.TempDir(), 0o755))
+
+	var requests []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/profile", func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RequestURI())
+		_, _ = w.Write([]byte("cpu"))
+	})
+	mux.HandleFunc("/debug/pprof/heap", func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RequestURI())
+		_, _ = w.Write([]byte("heap"))
+	})
+	socket := strings.TrimPrefix(utils.SocketURLWithFallback("filestream-default", paths.TempDir()), "unix://")
+	l, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: time.Second}
+	go func() { _ = srv.Serve(l) }()
+	defer srv.Close()
+
+	inputComponent := func(id string, state client.UnitState) runtime.ComponentComponentState {
+		return runtime.ComponentComponentState{
+			Component: component.Component{ID: id, InputSpec: &component.InputRuntimeSpec{}},
+			State:     runtime.ComponentState{State: state},
+		}
+	}
+	state := coordinator.State{Components: []runtime.ComponentComponentState{
+		inputComponent("filestream-default", client.UnitStateHealthy),
+		// no pprof endpoint
+		inputComponent("system/metrics-default", client.UnitStateDegraded),
+		// not running
+		inputComponent("log-default", client.UnitStateStarting),
+	}}
+
+	profiles := ComponentProfiles(context.Background(), state, 500*time.Millisecond)
+	require.Len(t, profiles, 2)
+	for _, p := range profiles {
+		switch p.ComponentID {
+		case "filestream-default":
+			require.NoError(t, p.Err)
+			assert.Equal(t, "cpu", string(p.CPU))
+			assert.Equal(t, "heap", string(p.Heap))
+		case "system/metrics-default":
+			assert.Error(t, p.Err)
+		default:
+			t.Fatalf("unexpected profiles of %s", p.ComponentID)
+		}
+	}
+	assert.Equal(t, []string{"/debug/pprof/profile?seconds=1", "/debug/pprof/heap"}, requests, "only the profiles are requested")
+}
@@ -70,6 +70,23 @@ func exposeMetricsEndpoint(
 	}
 	statsHandler := statsHandler(ns("stats"))
 	r.Handle("/stats", createHandler(statsHandler))
+	scrapeComponents := enableProcessStats && mcfg != nil && mcfg.HTTP != nil && mcfg.HTTP.Metrics.ScrapeComponents
+	r.Handle("/metrics", createHandler(metricsHandler(ns, coord, scrapeComponents)))
+
+	healthCfg := monitoringCfg.DefaultConfig().HTTP.Health
+	if mcfg != nil && mcfg.HTTP != nil {
+		healthCfg = mcfg.HTTP.Health
+	}
+	r.Handle("/liveness", createHandler(healthHandler(coord, healthCfg.Liveness)))
+	r.Handle("/readiness", createHandler(healthHandler(coord, healthCfg.Readiness)))
+
+	if mcfg != nil && mcfg.HTTP != nil && mcfg.HTTP.StateStream.Enabled {
+		if mcfg.HTTP.StateStream.Token == "" {
+			log.Warn("The state stream endpoint is enabled without a token, it is not exposed")
+		} else {
+			r.Handle("/state/stream", createHandler(stateStreamHandler(coord, mcfg.HTTP.StateStream.Token)))
+		}
+	}
 
 	if enableProcessStats {
 		r.Handle("/processes", createHandler(processesHandler(coord)))
@@ -0,0 +1,140 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package monitoring
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/coordinator"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/details"
+)
+
+// stateStreamKeepAlive is the interval of the comments keeping idle streams open through proxies.
+const stateStreamKeepAlive = 30 * time.Second
+
+type stateSubscriber interface {
+	StateSubscribe(ctx context.Context, bufferLen int) chan coordinator.State
+}
+
+// stateEvent is the data of the state events.
+type stateEvent struct {
+	State            string           `json:"state"`
+	Message          string           `json:"message"`
+	FleetState       string           `json:"fleet_state"`
+	FleetMessage     string           `json:"fleet_message"`
+	FleetLastCheckin *time.Time       `json:"fleet_last_checkin,omitempty"`
+	Components       []componentEvent `json:"components"`
+	UpgradeDetails   *details.Details `json:"upgrade_details,omitempty"`
+}
+
+type componentEvent struct {
+	ID       string      `json:"id"`
+	Type     string      `json:"type"`
+	State    string      `json:"state"`
+	Message  string      `json:"message"`
+	Restarts int         `json:"restarts,omitempty"`
+	Units    []unitEvent `json:"units"`
+}
+
+// unitEvent is the state of a unit. The payload reported by the unit is left out, it may hold
+// secrets and the stream isn't redacted as the diagnostics are.
+type unitEvent struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	State   string `json:"state"`
+	Message string `json:"message"`
+}
+
+// stateStreamHandler streams the changes of the coordinator state as server-sent events,
+// starting with the current state. Requests must be authorized with the bearer token.
+func stateStreamHandler(coord stateSubscriber, token string) func(http.ResponseWriter, *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		auth, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(auth), []byte(token)) != 1 {
+			return errorWithStatus(http.StatusUnauthorized, errors.New("invalid bearer token"))
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			return errorWithStatus(http.StatusInternalServerError, errors.New("streaming is not supported"))
+		}
+
+		ctx := r.Context()
+		states := coord.StateSubscribe(ctx, 32)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		keepAlive := time.NewTicker(stateStreamKeepAlive)
+		defer keepAlive.Stop()
+		for id := 1; ; {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-keepAlive.C:
+				if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+					return nil
+				}
+			case state := <-states:
+				data, err := json.Marshal(newStateEvent(state))
+				if err != nil {
+					return fmt.Errorf("failed to marshal state: %w", err)
+				}
+				if _, err := fmt.Fprintf(w, "id: %d\nevent: state\ndata: %s\n\n", id, data); err != nil {
+					// the client went away
+					return nil
+				}
+				id++
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func newStateEvent(state coordinator.State) stateEvent {
+	e := stateEvent{
+		State:          state.State.String(),
+		Message:        state.Message,
+		FleetState:     state.FleetState.String(),
+		FleetMessage:   state.FleetMessage,
+		Components:     make([]componentEvent, 0, len(state.Components)),
+		UpgradeDetails: state.UpgradeDetails,
+	}
+	if !state.FleetLastCheckin.IsZero() {
+		e.FleetLastCheckin = &state.FleetLastCheckin
+	}
+
+	for _, c := range state.Components {
+		ce := componentEvent{
+			ID:       c.Component.ID,
+			Type:     c.Component.Type(),
+			State:    c.State.State.String(),
+			Message:  c.State.Message,
+			Restarts: c.State.Restarts,
+			Units:    make([]unitEvent, 0, len(c.State.Units)),
+		}
+		for key, u := range c.State.Units {
+			ce.Units = append(ce.Units, unitEvent{
+				ID:      key.UnitID,
+				Type:    key.UnitType.String(),
+				State:   u.State.String(),
+				Message: u.Message,
+			})
+		}
+		sort.Slice(ce.Units, func(i, j int) bool { return ce.Units[i].ID < ce.Units[j].ID })
+		e.Components = append(e.Components, ce)
+	}
+	return e
+}
@@ -0,0 +1,109 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package monitoring
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-client/v7/pkg/client"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/coordinator"
+	"github.com/elastic/elastic-agent/pkg/component"
+	"github.com/elastic/elastic-agent/pkg/component/runtime"
+	agentclient "github.com/elastic/elastic-agent/pkg/control/v2/client"
+)
+
+type testStateSubscriber struct {
+	states chan coordinator.State
+}
+
+func (s *testStateSubscriber) StateSubscribe(_ context.Context, _ int) chan coordinator.State {
+	return s.states
+}
+
+func TestStateStream(t *testing.T) {
+	const token = "secret"
+	sub := &testStateSubscriber{states: make(chan coordinator.State, 2)}
+	srv := httptest.NewServer(createHandler(stateStreamHandler(sub, token)))
+	defer srv.Close()
+
+	for name, auth := range map[string]string{
+		"wrong token":      "Bearer wrong",
+		"no bearer scheme": token,
+		"other scheme":     "Basic " + token,
+		"missing":          "",
+	} {
+		t.Run("unauthorized with "+name, func(t *testing.T) {
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, srv.URL, nil)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", auth)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		})
+	}
+
+	t.Run("state events", func(t *testing.T) {
+		sub.states <- coordinator.State{State: agentclient.Healthy, FleetState: agentclient.Healthy}
+		sub.states <- coordinator.State{
+			State:   agentclient.Degraded,
+			Message: "1 component degraded",
+			Components: []runtime.ComponentComponentState{{
+				Component: component.Component{ID: "filestream-default"},
+				State: runtime.ComponentState{
+					State: client.UnitStateDegraded,
+					Units: map[runtime.ComponentUnitKey]runtime.ComponentUnitState{
+						{UnitType: client.UnitTypeInput, UnitID: "filestream-default-logs"}: {
+							State:   client.UnitStateDegraded,
+							Message: "file not found",
+							Payload: map[string]interface{}{"api_key": "secret"},
+						},
+					},
+				},
+			}},
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		var events []stateEvent
+		scanner := bufio.NewScanner(resp.Body)
+		for len(events) < 2 && scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+			if !ok {
+				continue
+			}
+			assert.NotContains(t, data, "api_key", "unit payloads are not streamed")
+			var e stateEvent
+			require.NoError(t, json.Unmarshal([]byte(data), &e))
+			events = append(events, e)
+		}
+		require.Len(t, events, 2)
+
+		assert.Equal(t, "HEALTHY", events[0].State)
+		assert.Equal(t, "DEGRADED", events[1].State)
+		require.Len(t, events[1].Components, 1)
+		assert.Equal(t, "filestream-default", events[1].Components[0].ID)
+		assert.Equal(t, []unitEvent{{ID: "filestream-default-logs", Type: "input", State: "DEGRADED", Message: "file not found"}},
+			events[1].Components[0].Units)
+	})
+}
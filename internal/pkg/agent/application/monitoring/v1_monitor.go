@@ -186,7 +186,8 @@ func (b *BeatsMonitor) EnrichArgs(unit, binary string, args []string) []string {
 			"-E", "http.enabled=true",
 			"-E", "http.host="+endpoint,
 		)
-		if b.config.C.Pprof != nil && b.config.C.Pprof.Enabled {
+		profiling := b.config.C.Diagnostics.Profiling
+		if b.config.C.Pprof != nil && b.config.C.Pprof.Enabled || profiling.Enabled && profiling.Components {
 			appendix = append(appendix,
 				"-E", "http.pprof.enabled=true",
 			)
@@ -218,3 +218,31 @@ type AddFields struct {
 	Fields Fields `json:"fields"`
 	Target string `json:"target"`
 }
+
+func TestEnrichArgsPprof(t *testing.T) {
+	for name, tc := range map[string]struct {
+		pprof     bool
+		profiling monitoringcfg.Profiling
+		expected  bool
+	}{
+		"disabled":                         {},
+		"pprof enabled":                    {pprof: true, expected: true},
+		"components profiling":             {profiling: monitoringcfg.Profiling{Enabled: true, Components: true}, expected: true},
+		"profiling of the agent only":      {profiling: monitoringcfg.Profiling{Enabled: true}},
+		"components profiling not enabled": {profiling: monitoringcfg.Profiling{Components: true}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := monitoringcfg.DefaultConfig()
+			cfg.Pprof = &monitoringcfg.PprofConfig{Enabled: tc.pprof}
+			cfg.Diagnostics.Profiling = tc.profiling
+			b := &BeatsMonitor{
+				enabled:         true,
+				config:          &monitoringConfig{C: cfg},
+				operatingSystem: runtime.GOOS,
+			}
+
+			args := b.EnrichArgs("filestream-default", "filebeat", nil)
+			assert.Equal(t, tc.expected, strings.Contains(strings.Join(args, " "), "http.pprof.enabled=true"))
+		})
+	}
+}
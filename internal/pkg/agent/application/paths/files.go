@@ -26,6 +26,18 @@ const defaultAgentEnrollFile = "enroll.yml"
 // defaultAgentActionStoreFile is the file that will contain the action that can be replayed after restart.
 const defaultAgentActionStoreFile = "action_store.yml"
 
+// defaultAgentActionJournalFile is the file that records the lifecycle of the Fleet actions.
+const defaultAgentActionJournalFile = "action_journal.ndjson"
+
+// defaultDiagnosticsUploadsDir is the directory keeping the diagnostics bundles until uploaded.
+const defaultDiagnosticsUploadsDir = "diagnostics-uploads"
+
+// defaultDiagnosticsCapturesDir is the directory keeping the diagnostics bundles captured automatically.
+const defaultDiagnosticsCapturesDir = "diagnostics-captures"
+
+// defaultDiagnosticsProfilesDir is the directory keeping the profiles sampled continuously.
+const defaultDiagnosticsProfilesDir = "diagnostics-profiles"
+
 // defaultAgentStateStoreYmlFile is the file that will contain the action that can be replayed after restart.
 const defaultAgentStateStoreYmlFile = "state.yml"
 
@@ -76,6 +88,30 @@ func AgentActionStoreFile() string {
 	return filepath.Join(Home(), defaultAgentActionStoreFile)
 }
 
+// AgentActionJournalFile is the file that records the lifecycle of the Fleet actions, it's kept
+// in the data directory to survive upgrades.
+func AgentActionJournalFile() string {
+	return filepath.Join(Data(), defaultAgentActionJournalFile)
+}
+
+// DiagnosticsUploadsDir is the directory keeping the diagnostics bundles requested by Fleet until
+// uploaded, an interrupted upload is resumed after a restart of the agent.
+func DiagnosticsUploadsDir() string {
+	return filepath.Join(Data(), defaultDiagnosticsUploadsDir)
+}
+
+// DiagnosticsCapturesDir is the default directory keeping the diagnostics bundles captured
+// automatically when a problem is detected.
+func DiagnosticsCapturesDir() string {
+	return filepath.Join(Data(), defaultDiagnosticsCapturesDir)
+}
+
+// DiagnosticsProfilesDir is the default directory keeping the profiles of the agent and of its
+// components sampled continuously.
+func DiagnosticsProfilesDir() string {
+	return filepath.Join(Data(), defaultDiagnosticsProfilesDir)
+}
+
 // AgentStateStoreYmlFile is the file that contains the persisted state of the agent including the action that can be replayed after restart.
 func AgentStateStoreYmlFile() string {
 	return filepath.Join(Home(), defaultAgentStateStoreYmlFile)
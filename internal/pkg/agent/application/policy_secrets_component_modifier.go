@@ -0,0 +1,86 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package application
+
+import (
+	"context"
+	"crypto/ecdh"
+	"fmt"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/coordinator"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/secret"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/protection"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/vault"
+	"github.com/elastic/elastic-agent/pkg/component"
+	"github.com/elastic/elastic-agent/pkg/utils"
+)
+
+// PolicySecretsComponentModifier decrypts the secrets of the policy Fleet encrypted to the public key
+// of the agent secrets key, right before the configuration of the units is sent to the components.
+// Example:
+//
+//	"outputs": {
+//		"default": {
+//			"type": "elasticsearch",
+//			"api_key": {
+//				"encrypted_secret": "BHVTZWQgYW4gZXBoZW1lcmFsIGtleQ=="
+//			}
+//		}
+//	}
+//
+// The agent key is read from the vault only when a unit configuration holds an encrypted secret.
+func PolicySecretsComponentModifier(agentKey func() (*ecdh.PrivateKey, error)) coordinator.ComponentsModifier {
+	return func(comps []component.Component, _ map[string]interface{}) ([]component.Component, error) {
+		var key *ecdh.PrivateKey
+		for i, comp := range comps {
+			for j, unit := range comp.Units {
+				if unit.Config == nil || unit.Config.Source == nil {
+					continue
+				}
+				unitCfgMap := unit.Config.Source.AsMap()
+				if !protection.HasEncryptedSecrets(unitCfgMap) {
+					continue
+				}
+
+				if key == nil {
+					k, err := agentKey()
+					if err != nil {
+						return nil, fmt.Errorf("could not get the agent key to decrypt the policy secrets: %w", err)
+					}
+					key = k
+				}
+
+				if err := protection.DecryptSecrets(unitCfgMap, key); err != nil {
+					return nil, fmt.Errorf("failed to decrypt the secrets of unit %s: %w", unit.ID, err)
+				}
+				unitCfg, err := component.ExpectedConfig(unitCfgMap)
+				if err != nil {
+					return nil, err
+				}
+
+				unit.Config = unitCfg
+				comp.Units[j] = unit
+			}
+			comps[i] = comp
+		}
+		return comps, nil
+	}
+}
+
+// agentSecretsKey derives the policy secrets key from the agent secret held in the vault. Unlike the
+// key of the agent identity, it doesn't change when the client certificate is renewed.
+func agentSecretsKey(ctx context.Context) func() (*ecdh.PrivateKey, error) {
+	return func() (*ecdh.PrivateKey, error) {
+		hasRoot, err := utils.HasRoot()
+		if err != nil {
+			return nil, fmt.Errorf("error checking for root/Administrator privileges: %w", err)
+		}
+		agentSecret, err := secret.GetAgentSecret(ctx, vault.WithUnprivileged(!hasRoot))
+		if err != nil {
+			return nil, fmt.Errorf("could not read the agent secret: %w", err)
+		}
+		return protection.SecretsKey(agentSecret.Value)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package application
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-client/v7/pkg/client"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/protection"
+	"github.com/elastic/elastic-agent/pkg/component"
+)
+
+func TestPolicySecretsComponentModifier(t *testing.T) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	apiKey, err := protection.EncryptSecret(key.PublicKey(), "id:secret")
+	require.NoError(t, err)
+
+	newComps := func() []component.Component {
+		outputCfg, err := component.ExpectedConfig(map[string]interface{}{"type": "elasticsearch", "api_key": apiKey})
+		require.NoError(t, err)
+		inputCfg, err := component.ExpectedConfig(map[string]interface{}{"type": "logfile", "id": "logfile-1"})
+		require.NoError(t, err)
+		return []component.Component{
+			{
+				ID: "logfile-default",
+				Units: []component.Unit{
+					{ID: "logfile-default", Type: client.UnitTypeOutput, Config: outputCfg},
+					{ID: "logfile-default-logfile-1", Type: client.UnitTypeInput, Config: inputCfg},
+				},
+			},
+		}
+	}
+
+	t.Run("secrets are decrypted", func(t *testing.T) {
+		keyReads := 0
+		modifier := PolicySecretsComponentModifier(func() (*ecdh.PrivateKey, error) {
+			keyReads++
+			return key, nil
+		})
+
+		for i := 0; i < 2; i++ {
+			comps, err := modifier(newComps(), nil)
+			require.NoError(t, err)
+			require.Len(t, comps, 1)
+			assert.Equal(t, "id:secret", comps[0].Units[0].Config.Source.AsMap()["api_key"])
+			assert.Equal(t, "logfile-1", comps[0].Units[1].Config.Source.AsMap()["id"])
+		}
+		assert.Equal(t, 2, keyReads, "the agent key is read once per modification")
+	})
+
+	t.Run("key is not read without secrets", func(t *testing.T) {
+		modifier := PolicySecretsComponentModifier(func() (*ecdh.PrivateKey, error) {
+			t.Fatal("unexpected read of the agent key")
+			return nil, nil
+		})
+
+		comps := newComps()[:1]
+		comps[0].Units = comps[0].Units[1:]
+		_, err := modifier(comps, nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("key error", func(t *testing.T) {
+		modifier := PolicySecretsComponentModifier(func() (*ecdh.PrivateKey, error) {
+			return nil, errors.New("vault is locked")
+		})
+
+		_, err := modifier(newComps(), nil)
+		assert.ErrorContains(t, err, "vault is locked")
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		otherKey, err := ecdh.P256().GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		modifier := PolicySecretsComponentModifier(func() (*ecdh.PrivateKey, error) {
+			return otherKey, nil
+		})
+
+		_, err = modifier(newComps(), nil)
+		assert.ErrorIs(t, err, protection.ErrInvalidEncryptedSecret)
+	})
+}
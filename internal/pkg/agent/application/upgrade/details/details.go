@@ -150,6 +150,22 @@ func (d *Details) Fail(err error) {
 	d.notifyObservers()
 }
 
+// Rollback is a convenience method to set the state of the upgrade
+// to StateRollback, record the reason of the rollback, and notify all
+// observers.
+func (d *Details) Rollback(reason error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.State = StateRollback
+	d.Metadata.FailedState = ""
+	d.Metadata.ErrorMsg = ""
+	if reason != nil {
+		d.Metadata.ErrorMsg = reason.Error()
+	}
+	d.notifyObservers()
+}
+
 // RegisterObserver allows an interested consumer of Details to register
 // themselves as an Observer. The registered observer is immediately notified
 // of the current upgrade details.
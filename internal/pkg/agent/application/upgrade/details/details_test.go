@@ -47,6 +47,16 @@ func TestDetailsFail(t *testing.T) {
 	require.Equal(t, "", det.Metadata.ErrorMsg)
 }
 
+func TestDetailsRollback(t *testing.T) {
+	det := NewDetails("99.999.9999", StateWatching, "test_action_id")
+
+	err := errors.New("health gates failed")
+	det.Rollback(err)
+	require.Equal(t, StateRollback, det.State)
+	require.Equal(t, State(""), det.Metadata.FailedState)
+	require.Equal(t, err.Error(), det.Metadata.ErrorMsg)
+}
+
 func TestDetailsObserver(t *testing.T) {
 	det := NewDetails("99.999.9999", StateRequested, "test_action_id")
 	require.Equal(t, StateRequested, det.State)
@@ -13,6 +13,7 @@ const (
 	StateScheduled   State = "UPG_SCHEDULED"
 	StateDownloading State = "UPG_DOWNLOADING"
 	StateExtracting  State = "UPG_EXTRACTING"
+	StatePrepared    State = "UPG_PREPARED"
 	StateReplacing   State = "UPG_REPLACING"
 	StateRestarting  State = "UPG_RESTARTING"
 	StateWatching    State = "UPG_WATCHING"
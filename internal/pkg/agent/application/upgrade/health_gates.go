@@ -0,0 +1,196 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package upgrade
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/configuration"
+	"github.com/elastic/elastic-agent/pkg/control/v2/client"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+)
+
+// ErrHealthGatesFailed is returned when the upgraded agent did not pass all the configured health gates
+// within the grace period.
+var ErrHealthGatesFailed = errors.New("agent did not pass upgrade health gates")
+
+// HealthGates tracks the user defined health gates an upgraded Elastic Agent must pass during the
+// watch period. A gate, once passed, stays passed for the rest of the watch period.
+type HealthGates struct {
+	log *logger.Logger
+	cfg configuration.UpgradeWatcherHealthGatesConfig
+
+	mx                sync.Mutex
+	healthyComponents map[string]bool
+	fleetCheckin      bool
+	probeErr          error
+	commandErr        error
+
+	// allows to inject the probe and command runners for tests
+	probeFn   func(ctx context.Context, url string) error
+	commandFn func(ctx context.Context, command []string) error
+}
+
+// NewHealthGates creates the health gates from the watcher configuration.
+func NewHealthGates(log *logger.Logger, cfg configuration.UpgradeWatcherHealthGatesConfig) *HealthGates {
+	g := &HealthGates{
+		log:               log,
+		cfg:               cfg,
+		healthyComponents: make(map[string]bool, len(cfg.Components)),
+		probeFn:           httpProbe,
+		commandFn:         runCommand,
+	}
+	for _, comp := range cfg.Components {
+		g.healthyComponents[comp] = false
+	}
+	if cfg.HTTPProbe != "" {
+		g.probeErr = errors.New("http probe did not run")
+	}
+	if len(cfg.Command) > 0 {
+		g.commandErr = errors.New("command did not run")
+	}
+	return g
+}
+
+// Observe records the gates passed by the given agent state.
+func (g *HealthGates) Observe(state *client.AgentState) {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+
+	if state.FleetState == client.Healthy && !g.fleetCheckin && g.cfg.FleetCheckin {
+		g.log.Info("Upgrade health gate passed: fleet checkin succeeded")
+		g.fleetCheckin = true
+	}
+
+	for _, comp := range state.Components {
+		if comp.State != client.Healthy {
+			continue
+		}
+		for _, key := range []string{comp.ID, comp.Name} {
+			if passed, ok := g.healthyComponents[key]; ok && !passed {
+				g.log.Infof("Upgrade health gate passed: component %s is healthy", key)
+				g.healthyComponents[key] = true
+			}
+		}
+	}
+}
+
+// Run periodically runs the HTTP probe and the command gates until they pass or the context is cancelled.
+func (g *HealthGates) Run(ctx context.Context, interval time.Duration) {
+	if g.cfg.HTTPProbe == "" && len(g.cfg.Command) == 0 {
+		return
+	}
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		if g.runProbes(ctx) {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// runProbes runs the probe and command gates that did not pass yet, it returns true once all of them passed.
+func (g *HealthGates) runProbes(ctx context.Context) bool {
+	g.mx.Lock()
+	probeErr, commandErr := g.probeErr, g.commandErr
+	g.mx.Unlock()
+
+	if probeErr != nil {
+		probeCtx, cancel := context.WithTimeout(ctx, g.cfg.Timeout)
+		probeErr = g.probeFn(probeCtx, g.cfg.HTTPProbe)
+		cancel()
+		if probeErr == nil {
+			g.log.Infof("Upgrade health gate passed: http probe %s succeeded", g.cfg.HTTPProbe)
+		} else {
+			g.log.Warnf("Upgrade health gate http probe %s failed: %s", g.cfg.HTTPProbe, probeErr)
+		}
+	}
+
+	if commandErr != nil {
+		commandCtx, cancel := context.WithTimeout(ctx, g.cfg.Timeout)
+		commandErr = g.commandFn(commandCtx, g.cfg.Command)
+		cancel()
+		if commandErr == nil {
+			g.log.Infof("Upgrade health gate passed: command %v succeeded", g.cfg.Command)
+		} else {
+			g.log.Warnf("Upgrade health gate command %v failed: %s", g.cfg.Command, commandErr)
+		}
+	}
+
+	g.mx.Lock()
+	defer g.mx.Unlock()
+	g.probeErr, g.commandErr = probeErr, commandErr
+	return probeErr == nil && commandErr == nil
+}
+
+// Check returns an error wrapping ErrHealthGatesFailed and describing every gate that did not pass.
+func (g *HealthGates) Check() error {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+
+	var err error
+	if g.cfg.FleetCheckin && !g.fleetCheckin {
+		err = multierror.Append(err, errors.New("fleet checkin did not succeed"))
+	}
+
+	var unhealthy []string
+	for comp, passed := range g.healthyComponents {
+		if !passed {
+			unhealthy = append(unhealthy, comp)
+		}
+	}
+	sort.Strings(unhealthy)
+	for _, comp := range unhealthy {
+		err = multierror.Append(err, fmt.Errorf("component %s did not reach HEALTHY", comp))
+	}
+
+	if g.probeErr != nil {
+		err = multierror.Append(err, fmt.Errorf("http probe %s: %w", g.cfg.HTTPProbe, g.probeErr))
+	}
+	if g.commandErr != nil {
+		err = multierror.Append(err, fmt.Errorf("command %v: %w", g.cfg.Command, g.commandErr))
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrHealthGatesFailed, err)
+	}
+	return nil
+}
+
+func httpProbe(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func runCommand(ctx context.Context, command []string) error {
+	//nolint:gosec // command is defined by the user in the agent configuration
+	return exec.CommandContext(ctx, command[0], command[1:]...).Run()
+}
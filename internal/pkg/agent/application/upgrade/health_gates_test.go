@@ -0,0 +1,102 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package upgrade
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/configuration"
+	"github.com/elastic/elastic-agent/pkg/control/v2/client"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+)
+
+func TestHealthGates_NoGates(t *testing.T) {
+	log, _ := logger.NewTesting(t.Name())
+	g := NewHealthGates(log, configuration.DefaultUpgradeConfig().Watcher.HealthGates)
+
+	assert.NoError(t, g.Check())
+}
+
+func TestHealthGates_Observe(t *testing.T) {
+	log, _ := logger.NewTesting(t.Name())
+	g := NewHealthGates(log, configuration.UpgradeWatcherHealthGatesConfig{
+		Components:   []string{"filestream-default", "metricbeat"},
+		FleetCheckin: true,
+	})
+
+	err := g.Check()
+	require.ErrorIs(t, err, ErrHealthGatesFailed)
+	assert.Contains(t, err.Error(), "fleet checkin did not succeed")
+	assert.Contains(t, err.Error(), "component filestream-default did not reach HEALTHY")
+	assert.Contains(t, err.Error(), "component metricbeat did not reach HEALTHY")
+
+	g.Observe(&client.AgentState{
+		FleetState: client.Healthy,
+		Components: []client.ComponentState{
+			{ID: "filestream-default", Name: "filestream", State: client.Degraded},
+			{ID: "system/metrics-default", Name: "metricbeat", State: client.Healthy},
+		},
+	})
+	err = g.Check()
+	require.ErrorIs(t, err, ErrHealthGatesFailed)
+	assert.NotContains(t, err.Error(), "fleet checkin")
+	assert.NotContains(t, err.Error(), "metricbeat")
+	assert.Contains(t, err.Error(), "component filestream-default did not reach HEALTHY")
+
+	// a gate stays passed even if the state changes afterwards
+	g.Observe(&client.AgentState{
+		FleetState: client.Failed,
+		Components: []client.ComponentState{
+			{ID: "filestream-default", Name: "filestream", State: client.Healthy},
+			{ID: "system/metrics-default", Name: "metricbeat", State: client.Failed},
+		},
+	})
+	assert.NoError(t, g.Check())
+}
+
+func TestHealthGates_Probes(t *testing.T) {
+	log, _ := logger.NewTesting(t.Name())
+
+	var healthy atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	g := NewHealthGates(log, configuration.UpgradeWatcherHealthGatesConfig{
+		HTTPProbe: srv.URL,
+		Command:   []string{"check"},
+		Timeout:   time.Second,
+	})
+	commandErr := errors.New("exit status 1")
+	g.commandFn = func(_ context.Context, command []string) error {
+		assert.Equal(t, []string{"check"}, command)
+		return commandErr
+	}
+
+	assert.False(t, g.runProbes(context.Background()))
+	err := g.Check()
+	require.ErrorIs(t, err, ErrHealthGatesFailed)
+	assert.Contains(t, err.Error(), "unexpected status code 503")
+	assert.ErrorIs(t, err, commandErr)
+
+	healthy.Store(true)
+	commandErr = nil
+	assert.True(t, g.runProbes(context.Background()))
+	assert.NoError(t, g.Check())
+}
@@ -0,0 +1,181 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package upgrade
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
+	v1 "github.com/elastic/elastic-agent/pkg/api/v1"
+	agtversion "github.com/elastic/elastic-agent/pkg/version"
+)
+
+// ErrNoRollbackTarget is returned when no installed version can be rolled back to.
+var ErrNoRollbackTarget = errors.New("no installed version to roll back to")
+
+// InstalledVersion describes an Elastic Agent version whose versioned home is still present on disk.
+type InstalledVersion struct {
+	// Version of the installed agent, empty for legacy versioned homes without version information
+	Version string `json:"version" yaml:"version"`
+	// Hash of the installed agent
+	Hash string `json:"hash" yaml:"hash"`
+	// VersionedHome represents the path where the installed agent is located relative to top path
+	VersionedHome string `json:"versioned_home" yaml:"versioned_home"`
+	// Current is true for the version currently running
+	Current bool `json:"current" yaml:"current"`
+}
+
+// ListInstalledVersions returns the Elastic Agent versions installed in the data directory of topDirPath,
+// sorted from the newest to the oldest version.
+func ListInstalledVersions(topDirPath string) ([]InstalledVersion, error) {
+	dataDirPath := paths.DataFrom(topDirPath)
+	entries, err := os.ReadDir(dataDirPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading data directory %q: %w", dataDirPath, err)
+	}
+
+	currentHome := filepath.Base(paths.VersionedHome(topDirPath))
+	dirPrefix := agentName + "-"
+
+	var versions []InstalledVersion
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), dirPrefix) {
+			continue
+		}
+
+		installed := InstalledVersion{
+			VersionedHome: filepath.Join("data", entry.Name()),
+			Current:       entry.Name() == currentHome,
+		}
+
+		manifest, err := readHomeManifest(filepath.Join(dataDirPath, entry.Name()))
+		if err == nil {
+			installed.Version = manifest.Package.Version
+			if manifest.Package.Snapshot {
+				installed.Version += snapshotSuffix
+			}
+			installed.Hash = manifest.Package.Hash
+			if len(installed.Hash) > hashLen {
+				installed.Hash = installed.Hash[:hashLen]
+			}
+		} else {
+			// no manifest, fall back to elastic-agent-<version>-<hash> or legacy elastic-agent-<hash> naming
+			suffix := strings.TrimPrefix(entry.Name(), dirPrefix)
+			if idx := strings.LastIndex(suffix, "-"); idx >= 0 {
+				installed.Version = suffix[:idx]
+				installed.Hash = suffix[idx+1:]
+			} else {
+				installed.Hash = suffix
+			}
+		}
+
+		versions = append(versions, installed)
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versionLess(versions[j].Version, versions[i].Version)
+	})
+	return versions, nil
+}
+
+// selectRollbackTarget picks the installed version to roll back to. When version is empty the most
+// recent installed version older than the current one is selected. Only versions older than the current
+// one can be rolled back to, and the versioned home staged by a prepared upgrade, relative to the top
+// path, is never a rollback target.
+func selectRollbackTarget(versions []InstalledVersion, version, currentVersion, preparedHome string) (InstalledVersion, error) {
+	var available []string
+	for _, v := range versions {
+		if v.Current || v.Version == "" || !versionLess(v.Version, currentVersion) {
+			continue
+		}
+		if preparedHome != "" && filepath.Base(v.VersionedHome) == filepath.Base(preparedHome) {
+			continue
+		}
+		// versions are sorted newest first
+		if version == "" || v.Version == version || v.Hash == version {
+			return v, nil
+		}
+		available = append(available, v.Version)
+	}
+
+	if version == "" {
+		return InstalledVersion{}, fmt.Errorf("%w: no installed version older than %s", ErrNoRollbackTarget, currentVersion)
+	}
+	return InstalledVersion{}, fmt.Errorf("%w: version %s is not an installed version older than %s, available versions: %v", ErrNoRollbackTarget, version, currentVersion, available)
+}
+
+// checkVersionedHome verifies that the versioned home of an installed version is complete enough to be
+// switched to.
+func checkVersionedHome(topDirPath string, installed InstalledVersion) error {
+	if installed.Version == "" {
+		return fmt.Errorf("unknown version for versioned home %s", installed.VersionedHome)
+	}
+
+	home := filepath.Join(topDirPath, installed.VersionedHome)
+	if manifest, err := readHomeManifest(home); err == nil {
+		if manifest.Package.Hash != "" && !strings.HasPrefix(manifest.Package.Hash, installed.Hash) {
+			return fmt.Errorf("package manifest hash %s does not match versioned home %s", manifest.Package.Hash, installed.VersionedHome)
+		}
+	}
+
+	binary := paths.BinaryPath(home, agentName)
+	if runtime.GOOS == windows {
+		binary += exe
+	}
+	info, err := os.Stat(binary)
+	if err != nil {
+		return fmt.Errorf("agent binary: %w", err)
+	}
+	if !info.Mode().IsRegular() || info.Size() == 0 {
+		return fmt.Errorf("agent binary %s is not a valid executable", binary)
+	}
+	if runtime.GOOS != windows && info.Mode().Perm()&0o111 == 0 {
+		return fmt.Errorf("agent binary %s is not executable", binary)
+	}
+
+	components := filepath.Join(home, "components")
+	if _, err := os.Stat(components); err != nil {
+		return fmt.Errorf("components directory: %w", err)
+	}
+
+	return nil
+}
+
+func readHomeManifest(home string) (*v1.PackageManifest, error) {
+	f, err := os.Open(filepath.Join(home, v1.ManifestFileName))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return v1.ParseManifest(f)
+}
+
+// hasValidVersion reports whether version can be parsed and ordered against other versions.
+func hasValidVersion(version string) bool {
+	_, err := agtversion.ParseVersion(version)
+	return err == nil
+}
+
+// versionLess reports whether version a is lower than b. Versions that cannot be parsed are considered
+// lower than any valid version.
+func versionLess(a, b string) bool {
+	parsedA, errA := agtversion.ParseVersion(a)
+	parsedB, errB := agtversion.ParseVersion(b)
+	switch {
+	case errA != nil && errB != nil:
+		return a < b
+	case errA != nil:
+		return true
+	case errB != nil:
+		return false
+	}
+	return parsedA.Less(*parsedB)
+}
@@ -0,0 +1,120 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package upgrade
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
+	v1 "github.com/elastic/elastic-agent/pkg/api/v1"
+)
+
+func TestListInstalledVersions(t *testing.T) {
+	top := t.TempDir()
+	setupInstalledHome(t, top, "elastic-agent-8.13.0-abcdef", "8.13.0", "abcdef123456", false)
+	setupInstalledHome(t, top, "elastic-agent-8.14.0-SNAPSHOT-123456", "8.14.0", "123456abcdef", true)
+	require.NoError(t, os.MkdirAll(filepath.Join(top, "data", "elastic-agent-8.12.1-fedcba"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(top, "data", "downloads"), 0o755))
+
+	versions, err := ListInstalledVersions(top)
+	require.NoError(t, err)
+	assert.Equal(t, []InstalledVersion{
+		{Version: "8.14.0-SNAPSHOT", Hash: "123456", VersionedHome: filepath.Join("data", "elastic-agent-8.14.0-SNAPSHOT-123456")},
+		{Version: "8.13.0", Hash: "abcdef", VersionedHome: filepath.Join("data", "elastic-agent-8.13.0-abcdef")},
+		{Version: "8.12.1", Hash: "fedcba", VersionedHome: filepath.Join("data", "elastic-agent-8.12.1-fedcba")},
+	}, versions)
+}
+
+func TestSelectRollbackTarget(t *testing.T) {
+	versions := []InstalledVersion{
+		{Version: "8.16.0", Hash: "dddddd", VersionedHome: filepath.Join("data", "elastic-agent-8.16.0-dddddd")},
+		{Version: "8.15.0", Hash: "aaaaaa", VersionedHome: filepath.Join("data", "elastic-agent-8.15.0-aaaaaa"), Current: true},
+		{Version: "8.14.0", Hash: "bbbbbb", VersionedHome: filepath.Join("data", "elastic-agent-8.14.0-bbbbbb")},
+		{Version: "8.13.0", Hash: "cccccc", VersionedHome: filepath.Join("data", "elastic-agent-8.13.0-cccccc")},
+	}
+
+	target, err := selectRollbackTarget(versions, "", "8.15.0", "")
+	require.NoError(t, err)
+	assert.Equal(t, "8.14.0", target.Version)
+
+	target, err = selectRollbackTarget(versions, "8.13.0", "8.15.0", "")
+	require.NoError(t, err)
+	assert.Equal(t, "cccccc", target.Hash)
+
+	target, err = selectRollbackTarget(versions, "cccccc", "8.15.0", "")
+	require.NoError(t, err)
+	assert.Equal(t, "8.13.0", target.Version)
+
+	_, err = selectRollbackTarget(versions, "8.15.0", "8.15.0", "")
+	assert.ErrorIs(t, err, ErrNoRollbackTarget, "current version is not a rollback target")
+
+	_, err = selectRollbackTarget(versions, "", "8.13.0", "")
+	assert.ErrorIs(t, err, ErrNoRollbackTarget)
+
+	_, err = selectRollbackTarget(versions, "8.16.0", "8.15.0", "")
+	assert.ErrorIs(t, err, ErrNoRollbackTarget, "newer version is not a rollback target")
+
+	_, err = selectRollbackTarget(versions, "dddddd", "8.15.0", "")
+	assert.ErrorIs(t, err, ErrNoRollbackTarget, "newer version is not a rollback target")
+
+	_, err = selectRollbackTarget(versions, "8.14.0", "8.15.0", filepath.Join("data", "elastic-agent-8.14.0-bbbbbb"))
+	assert.ErrorIs(t, err, ErrNoRollbackTarget, "prepared version is not a rollback target")
+
+	target, err = selectRollbackTarget(versions, "", "8.15.0", filepath.Join("data", "elastic-agent-8.14.0-bbbbbb"))
+	require.NoError(t, err)
+	assert.Equal(t, "8.13.0", target.Version, "prepared version is skipped")
+}
+
+func TestCheckVersionedHome(t *testing.T) {
+	top := t.TempDir()
+	installed := setupInstalledHome(t, top, "elastic-agent-8.13.0-abcdef", "8.13.0", "abcdef123456", false)
+	home := filepath.Join(top, installed.VersionedHome)
+
+	assert.Error(t, checkVersionedHome(top, installed), "missing binary")
+
+	binary := paths.BinaryPath(home, agentName)
+	if runtime.GOOS == windows {
+		binary += exe
+	}
+	require.NoError(t, os.MkdirAll(filepath.Dir(binary), 0o755))
+	require.NoError(t, os.WriteFile(binary, nil, 0o755))
+	assert.Error(t, checkVersionedHome(top, installed), "empty binary")
+
+	require.NoError(t, os.WriteFile(binary, []byte("binary"), 0o755))
+	assert.Error(t, checkVersionedHome(top, installed), "missing components")
+
+	require.NoError(t, os.MkdirAll(filepath.Join(home, "components"), 0o755))
+	assert.NoError(t, checkVersionedHome(top, installed))
+
+	mismatched := installed
+	mismatched.Hash = "fedcba"
+	assert.Error(t, checkVersionedHome(top, mismatched), "manifest hash does not match")
+}
+
+func setupInstalledHome(t *testing.T, top, dir, version, hash string, snapshot bool) InstalledVersion {
+	t.Helper()
+	home := filepath.Join(top, "data", dir)
+	require.NoError(t, os.MkdirAll(home, 0o755))
+
+	manifest := v1.NewManifest()
+	manifest.Package.Version = version
+	manifest.Package.Hash = hash
+	manifest.Package.Snapshot = snapshot
+	manifestBytes, err := yaml.Marshal(manifest)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(home, v1.ManifestFileName), manifestBytes, 0o644))
+
+	if snapshot {
+		version += snapshotSuffix
+	}
+	return InstalledVersion{Version: version, Hash: hash[:hashLen], VersionedHome: filepath.Join("data", dir)}
+}
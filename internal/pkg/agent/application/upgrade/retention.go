@@ -0,0 +1,329 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package upgrade
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/configuration"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/install"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+)
+
+// VersionDiskUsage is the disk space used by an installed version.
+type VersionDiskUsage struct {
+	InstalledVersion `yaml:",inline"`
+	// Size in bytes of the versioned home, without the downloaded artifacts
+	Size uint64 `json:"size" yaml:"size"`
+}
+
+// DiskUsage is the disk space used by the installed versions and the downloaded artifacts.
+type DiskUsage struct {
+	// Versions is the disk usage of each installed version, newest first
+	Versions []VersionDiskUsage `json:"versions" yaml:"versions"`
+	// Downloads is the size in bytes of the downloaded artifacts
+	Downloads uint64 `json:"downloads" yaml:"downloads"`
+	// Total is the size in bytes of the installed versions and the downloaded artifacts
+	Total uint64 `json:"total" yaml:"total"`
+}
+
+type downloadedFile struct {
+	path    string
+	size    uint64
+	modTime time.Time
+}
+
+// GetDiskUsage computes the disk space used by the installed versions in topDirPath and by the
+// downloaded artifacts in downloadsPath.
+func GetDiskUsage(topDirPath, downloadsPath string) (*DiskUsage, error) {
+	versions, err := ListInstalledVersions(topDirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &DiskUsage{Versions: make([]VersionDiskUsage, 0, len(versions))}
+	for _, v := range versions {
+		size, err := dirSize(filepath.Join(topDirPath, v.VersionedHome), downloadsPath)
+		if err != nil {
+			return nil, fmt.Errorf("computing size of %s: %w", v.VersionedHome, err)
+		}
+		usage.Versions = append(usage.Versions, VersionDiskUsage{InstalledVersion: v, Size: size})
+		usage.Total += size
+	}
+
+	downloads, err := listDownloads(downloadsPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range downloads {
+		usage.Downloads += d.size
+	}
+	usage.Total += usage.Downloads
+
+	return usage, nil
+}
+
+// EnforceRetention applies the retention policy to the installed versions and downloaded artifacts.
+// The version installed in currentVersionedHome, relative to topDirPath, and the versions referenced by
+// an ongoing or prepared upgrade are never removed.
+func EnforceRetention(log *logger.Logger, topDirPath, currentVersionedHome, downloadsPath string, cfg *configuration.UpgradeRetentionConfig) error {
+	return enforceRetention(log, topDirPath, currentVersionedHome, downloadsPath, cfg, time.Now())
+}
+
+func enforceRetention(log *logger.Logger, topDirPath, currentVersionedHome, downloadsPath string, cfg *configuration.UpgradeRetentionConfig, now time.Time) error {
+	if cfg == nil {
+		return nil
+	}
+	if currentVersionedHome == "" {
+		return errors.New("unknown current versioned home, skipping retention policy")
+	}
+
+	versions, err := ListInstalledVersions(topDirPath)
+	if err != nil {
+		return err
+	}
+
+	currentDir := filepath.Base(currentVersionedHome)
+	protected, err := protectedVersionedHomes(paths.DataFrom(topDirPath))
+	if err != nil {
+		// without knowing which versions an upgrade needs, removing any of them is unsafe
+		return err
+	}
+	protected[currentDir] = true
+	kept := previousVersionsToKeep(versions, currentDir, cfg.KeepVersions)
+
+	var rErr error
+	// retained versions can still be removed to honor the disk usage limit, oldest first
+	var retained []InstalledVersion
+	for _, v := range versions {
+		dir := filepath.Base(v.VersionedHome)
+		switch {
+		case protected[dir]:
+		case kept[dir] && !hasValidVersion(v.Version):
+			// unordered homes are not removed to honor the disk usage limit either
+			log.Debugw("Keeping versioned home without a valid version", "file.path", filepath.Join(topDirPath, v.VersionedHome))
+		case kept[dir]:
+			retained = append(retained, v)
+		default:
+			if err := removeVersionedHome(log, topDirPath, v); err != nil {
+				rErr = multierror.Append(rErr, err)
+			}
+		}
+	}
+
+	downloads, err := listDownloads(downloadsPath)
+	if err != nil {
+		return multierror.Append(rErr, err)
+	}
+
+	if cfg.DownloadsMaxAge > 0 {
+		var fresh []downloadedFile
+		for _, d := range downloads {
+			if now.Sub(d.modTime) <= cfg.DownloadsMaxAge {
+				fresh = append(fresh, d)
+				continue
+			}
+			if err := removeDownload(log, d); err != nil {
+				rErr = multierror.Append(rErr, err)
+			}
+		}
+		downloads = fresh
+	}
+
+	if cfg.MaxDiskUsage == 0 {
+		return rErr
+	}
+
+	var usage uint64
+	for _, d := range downloads {
+		usage += d.size
+	}
+	sizes := make(map[string]uint64, len(retained))
+	for _, v := range retained {
+		size, err := dirSize(filepath.Join(topDirPath, v.VersionedHome), downloadsPath)
+		if err != nil {
+			rErr = multierror.Append(rErr, fmt.Errorf("computing size of %s: %w", v.VersionedHome, err))
+		}
+		sizes[v.VersionedHome] = size
+		usage += size
+	}
+
+	// downloads are cheaper to recover than versions kept for rollback, remove them first
+	for len(downloads) > 0 && usage > cfg.MaxDiskUsage {
+		if err := removeDownload(log, downloads[0]); err != nil {
+			rErr = multierror.Append(rErr, err)
+		}
+		usage -= downloads[0].size
+		downloads = downloads[1:]
+	}
+	for len(retained) > 0 && usage > cfg.MaxDiskUsage {
+		oldest := retained[len(retained)-1]
+		if err := removeVersionedHome(log, topDirPath, oldest); err != nil {
+			rErr = multierror.Append(rErr, err)
+		}
+		usage -= sizes[oldest.VersionedHome]
+		retained = retained[:len(retained)-1]
+	}
+	if usage > cfg.MaxDiskUsage {
+		log.Warnw("Disk usage of previous versions and downloads still exceeds the retention limit", "usage", usage, "max_disk_usage", cfg.MaxDiskUsage)
+	}
+
+	return rErr
+}
+
+// previousVersionsToKeep returns the data directory names of the keep most recent installed versions
+// older than the version installed in currentDir. Versioned homes without a valid version, like legacy
+// elastic-agent-<hash> homes, cannot be ordered and are always kept unless keep is 0, as they can be the
+// only rollback target. All homes are kept when the current version is unknown.
+func previousVersionsToKeep(versions []InstalledVersion, currentDir string, keep int) map[string]bool {
+	kept := make(map[string]bool)
+	if keep <= 0 {
+		return kept
+	}
+
+	var currentVersion string
+	for _, v := range versions {
+		if filepath.Base(v.VersionedHome) == currentDir {
+			currentVersion = v.Version
+			break
+		}
+	}
+	if !hasValidVersion(currentVersion) {
+		for _, v := range versions {
+			kept[filepath.Base(v.VersionedHome)] = true
+		}
+		return kept
+	}
+
+	var keptVersions int
+	// versions are sorted newest first
+	for _, v := range versions {
+		switch {
+		case !hasValidVersion(v.Version):
+			kept[filepath.Base(v.VersionedHome)] = true
+		case keptVersions < keep && versionLess(v.Version, currentVersion):
+			kept[filepath.Base(v.VersionedHome)] = true
+			keptVersions++
+		}
+	}
+	return kept
+}
+
+// protectedVersionedHomes returns the data directory names of the versions referenced by the upgrade
+// marker and by a prepared upgrade.
+func protectedVersionedHomes(dataDirPath string) (map[string]bool, error) {
+	protected := make(map[string]bool)
+
+	marker, err := LoadMarker(dataDirPath)
+	if err != nil {
+		return nil, fmt.Errorf("loading upgrade marker: %w", err)
+	}
+	if marker != nil {
+		for _, home := range []string{marker.VersionedHome, marker.PrevVersionedHome} {
+			if home != "" {
+				protected[filepath.Base(home)] = true
+			}
+		}
+		for _, hash := range []string{marker.Hash, marker.PrevHash} {
+			if hash != "" {
+				protected[fmt.Sprintf("%s-%s", agentName, hash)] = true
+			}
+		}
+	}
+
+	prepared, err := LoadPrepared(dataDirPath)
+	if err != nil {
+		return nil, fmt.Errorf("loading prepared upgrade: %w", err)
+	}
+	if prepared != nil && prepared.VersionedHome != "" {
+		protected[filepath.Base(prepared.VersionedHome)] = true
+	}
+
+	return protected, nil
+}
+
+func removeVersionedHome(log *logger.Logger, topDirPath string, v InstalledVersion) error {
+	home := filepath.Join(topDirPath, v.VersionedHome)
+	log.Infow("Removing versioned home per retention policy", "file.path", home, "version", v.Version)
+	if err := install.RemoveBut(home, true); err != nil {
+		return fmt.Errorf("unable to remove versioned home %q: %w", home, err)
+	}
+	return nil
+}
+
+func removeDownload(log *logger.Logger, d downloadedFile) error {
+	log.Infow("Removing downloaded artifact per retention policy", "file.path", d.path)
+	if err := os.Remove(d.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("unable to remove file %q: %w", d.path, err)
+	}
+	return nil
+}
+
+// listDownloads returns the files in the downloads directory, oldest first.
+func listDownloads(downloadsPath string) ([]downloadedFile, error) {
+	entries, err := os.ReadDir(downloadsPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read directory %q: %w", downloadsPath, err)
+	}
+
+	files := make([]downloadedFile, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, downloadedFile{
+			path:    filepath.Join(downloadsPath, entry.Name()),
+			size:    uint64(info.Size()),
+			modTime: info.ModTime(),
+		})
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	return files, nil
+}
+
+// dirSize returns the size of the regular files in root, skipping the skip directory.
+func dirSize(root, skip string) (uint64, error) {
+	var size uint64
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			if skip != "" && filepath.Clean(path) == filepath.Clean(skip) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		size += uint64(info.Size())
+		return nil
+	})
+	return size, err
+}
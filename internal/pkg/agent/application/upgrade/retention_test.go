@@ -0,0 +1,135 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package upgrade
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/configuration"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+)
+
+func TestEnforceRetention(t *testing.T) {
+	now := time.Now()
+	log, _ := logger.NewTesting(t.Name())
+
+	setup := func(t *testing.T) (string, string) {
+		top := t.TempDir()
+		setupInstalledHome(t, top, "elastic-agent-8.11.0-111111", "8.11.0", "111111abcdef", false)
+		setupInstalledHome(t, top, "elastic-agent-8.12.0-222222", "8.12.0", "222222abcdef", false)
+		setupInstalledHome(t, top, "elastic-agent-8.13.0-333333", "8.13.0", "333333abcdef", false)
+		setupInstalledHome(t, top, "elastic-agent-8.14.0-444444", "8.14.0", "444444abcdef", false)
+		require.NoError(t, os.WriteFile(filepath.Join(top, "data", "elastic-agent-8.12.0-222222", "payload"), make([]byte, 1024), 0o644))
+
+		downloads := filepath.Join(top, "data", "elastic-agent-8.13.0-333333", "downloads")
+		require.NoError(t, os.MkdirAll(downloads, 0o755))
+		for name, age := range map[string]time.Duration{"old.tar.gz": 48 * time.Hour, "new.tar.gz": time.Hour} {
+			path := filepath.Join(downloads, name)
+			require.NoError(t, os.WriteFile(path, make([]byte, 512), 0o644))
+			require.NoError(t, os.Chtimes(path, now.Add(-age), now.Add(-age)))
+		}
+		return top, downloads
+	}
+
+	installedHomes := func(t *testing.T, top string) []string {
+		versions, err := ListInstalledVersions(top)
+		require.NoError(t, err)
+		var homes []string
+		for _, v := range versions {
+			homes = append(homes, filepath.Base(v.VersionedHome))
+		}
+		return homes
+	}
+
+	t.Run("default configuration keeps the previous version", func(t *testing.T) {
+		top, downloads := setup(t)
+		cfg := configuration.DefaultUpgradeConfig().Retention
+
+		require.NoError(t, enforceRetention(log, top, filepath.Join("data", "elastic-agent-8.13.0-333333"), downloads, cfg, now))
+		assert.Contains(t, installedHomes(t, top), "elastic-agent-8.12.0-222222", "the previous version must be kept for rollback")
+		assert.FileExists(t, filepath.Join(downloads, "old.tar.gz"), "downloads are kept by default")
+	})
+
+	t.Run("keep previous versions and purge stale downloads", func(t *testing.T) {
+		top, downloads := setup(t)
+		cfg := &configuration.UpgradeRetentionConfig{KeepVersions: 1, DownloadsMaxAge: 24 * time.Hour}
+
+		require.NoError(t, enforceRetention(log, top, filepath.Join("data", "elastic-agent-8.13.0-333333"), downloads, cfg, now))
+		assert.Equal(t, []string{"elastic-agent-8.13.0-333333", "elastic-agent-8.12.0-222222"}, installedHomes(t, top))
+		assert.NoFileExists(t, filepath.Join(downloads, "old.tar.gz"))
+		assert.FileExists(t, filepath.Join(downloads, "new.tar.gz"))
+	})
+
+	t.Run("prepared upgrade is protected", func(t *testing.T) {
+		top, downloads := setup(t)
+		require.NoError(t, markPrepared(log, filepath.Join(top, "data"), &PreparedUpgrade{
+			Version:       "8.14.0",
+			VersionedHome: filepath.Join("data", "elastic-agent-8.14.0-444444"),
+		}))
+
+		require.NoError(t, enforceRetention(log, top, filepath.Join("data", "elastic-agent-8.13.0-333333"), downloads, &configuration.UpgradeRetentionConfig{}, now))
+		assert.Equal(t, []string{"elastic-agent-8.14.0-444444", "elastic-agent-8.13.0-333333"}, installedHomes(t, top))
+		assert.FileExists(t, filepath.Join(downloads, "old.tar.gz"))
+	})
+
+	t.Run("disk usage limit removes downloads then oldest versions", func(t *testing.T) {
+		top, downloads := setup(t)
+		cfg := &configuration.UpgradeRetentionConfig{KeepVersions: 2, MaxDiskUsage: 1024}
+
+		require.NoError(t, enforceRetention(log, top, filepath.Join("data", "elastic-agent-8.13.0-333333"), downloads, cfg, now))
+		// 8.12.0 is bigger than the limit on its own, so both downloads and 8.11.0 go first, then 8.12.0
+		assert.Equal(t, []string{"elastic-agent-8.13.0-333333"}, installedHomes(t, top))
+		assert.NoFileExists(t, filepath.Join(downloads, "old.tar.gz"))
+		assert.NoFileExists(t, filepath.Join(downloads, "new.tar.gz"))
+	})
+
+	t.Run("legacy home without version is kept", func(t *testing.T) {
+		top, downloads := setup(t)
+		require.NoError(t, os.MkdirAll(filepath.Join(top, "data", "elastic-agent-abcdef"), 0o755))
+		cfg := &configuration.UpgradeRetentionConfig{KeepVersions: 1, MaxDiskUsage: 1}
+
+		require.NoError(t, enforceRetention(log, top, filepath.Join("data", "elastic-agent-8.14.0-444444"), downloads, cfg, now))
+		assert.Equal(t, []string{"elastic-agent-8.14.0-444444", "elastic-agent-abcdef"}, installedHomes(t, top), "the legacy home can be the only rollback target")
+	})
+
+	t.Run("legacy home without version is removed when no version is kept", func(t *testing.T) {
+		top, downloads := setup(t)
+		require.NoError(t, os.MkdirAll(filepath.Join(top, "data", "elastic-agent-abcdef"), 0o755))
+
+		require.NoError(t, enforceRetention(log, top, filepath.Join("data", "elastic-agent-8.14.0-444444"), downloads, &configuration.UpgradeRetentionConfig{}, now))
+		assert.Equal(t, []string{"elastic-agent-8.14.0-444444"}, installedHomes(t, top))
+	})
+
+	t.Run("unknown current version", func(t *testing.T) {
+		top, downloads := setup(t)
+		assert.Error(t, enforceRetention(log, top, "", downloads, &configuration.UpgradeRetentionConfig{}, now))
+		assert.Len(t, installedHomes(t, top), 4)
+	})
+}
+
+func TestGetDiskUsage(t *testing.T) {
+	top := t.TempDir()
+	setupInstalledHome(t, top, "elastic-agent-8.13.0-333333", "8.13.0", "333333abcdef", false)
+	home := filepath.Join(top, "data", "elastic-agent-8.13.0-333333")
+	require.NoError(t, os.WriteFile(filepath.Join(home, "payload"), make([]byte, 1024), 0o644))
+	downloads := filepath.Join(home, "downloads")
+	require.NoError(t, os.MkdirAll(downloads, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(downloads, "artifact.tar.gz"), make([]byte, 512), 0o644))
+
+	usage, err := GetDiskUsage(top, downloads)
+	require.NoError(t, err)
+	require.Len(t, usage.Versions, 1)
+	manifest, err := os.Stat(filepath.Join(home, "manifest.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1024+manifest.Size()), usage.Versions[0].Size, "downloads are not counted in the version size")
+	assert.Equal(t, uint64(512), usage.Downloads)
+	assert.Equal(t, usage.Versions[0].Size+512, usage.Total)
+}
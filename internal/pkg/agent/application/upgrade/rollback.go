@@ -34,7 +34,8 @@ const (
 )
 
 // Rollback rollbacks to previous version which was functioning before upgrade.
-func Rollback(ctx context.Context, log *logger.Logger, c client.Client, topDirPath, prevVersionedHome, prevHash string) error {
+// The keepVersions most recent versions older than the previous version are kept for later rollbacks.
+func Rollback(ctx context.Context, log *logger.Logger, c client.Client, topDirPath, prevVersionedHome, prevHash string, keepVersions int) error {
 	symlinkPath := filepath.Join(topDirPath, agentName)
 
 	var symlinkTarget string
@@ -63,12 +64,13 @@ func Rollback(ctx context.Context, log *logger.Logger, c client.Client, topDirPa
 	}
 
 	// cleanup everything except version we're rolling back into
-	return Cleanup(log, topDirPath, prevVersionedHome, prevHash, true, true)
+	return Cleanup(log, topDirPath, prevVersionedHome, prevHash, true, true, keepVersions)
 }
 
 // Cleanup removes all artifacts and files related to a specified version.
-func Cleanup(log *logger.Logger, topDirPath, currentVersionedHome, currentHash string, removeMarker, keepLogs bool) error {
-	log.Infow("Cleaning up upgrade", "hash", currentHash, "remove_marker", removeMarker)
+// The keepVersions most recent versions older than the current version are kept for rollback.
+func Cleanup(log *logger.Logger, topDirPath, currentVersionedHome, currentHash string, removeMarker, keepLogs bool, keepVersions int) error {
+	log.Infow("Cleaning up upgrade", "hash", currentHash, "remove_marker", removeMarker, "keep_versions", keepVersions)
 	<-time.After(afterRestartDelay)
 
 	// data directory path
@@ -114,8 +116,17 @@ func Cleanup(log *logger.Logger, topDirPath, currentVersionedHome, currentHash s
 		currentDir = fmt.Sprintf("%s-%s", agentName, currentHash)
 	}
 
+	kept := make(map[string]bool)
+	if keepVersions > 0 {
+		versions, listErr := ListInstalledVersions(topDirPath)
+		if listErr != nil {
+			return listErr
+		}
+		kept = previousVersionsToKeep(versions, currentDir, keepVersions)
+	}
+
 	for _, dir := range subdirs {
-		if dir == currentDir {
+		if dir == currentDir || kept[dir] {
 			continue
 		}
 
@@ -44,6 +44,9 @@ type UpdateMarker struct {
 	Action *fleetapi.ActionUpgrade `json:"action" yaml:"action"`
 
 	Details *details.Details `json:"details,omitempty" yaml:"details,omitempty"`
+
+	// RollbackReason is the reason why the upgrade watcher rolled back the upgrade
+	RollbackReason string `json:"rollback_reason,omitempty" yaml:"rollback_reason,omitempty"`
 }
 
 // GetActionID returns the Fleet Action ID associated with the
@@ -98,6 +101,7 @@ type updateMarkerSerializer struct {
 	Acked             bool                 `yaml:"acked"`
 	Action            *MarkerActionUpgrade `yaml:"action"`
 	Details           *details.Details     `yaml:"details"`
+	RollbackReason    string               `yaml:"rollback_reason,omitempty"`
 }
 
 func newMarkerSerializer(m *UpdateMarker) *updateMarkerSerializer {
@@ -112,6 +116,7 @@ func newMarkerSerializer(m *UpdateMarker) *updateMarkerSerializer {
 		Acked:             m.Acked,
 		Action:            convertToMarkerAction(m.Action),
 		Details:           m.Details,
+		RollbackReason:    m.RollbackReason,
 	}
 }
 
@@ -212,6 +217,7 @@ func loadMarker(markerFile string) (*UpdateMarker, error) {
 		Acked:             marker.Acked,
 		Action:            convertToActionUpgrade(marker.Action),
 		Details:           marker.Details,
+		RollbackReason:    marker.RollbackReason,
 	}, nil
 }
 
@@ -230,6 +236,7 @@ func SaveMarker(marker *UpdateMarker, shouldFsync bool) error {
 		Acked:             marker.Acked,
 		Action:            convertToMarkerAction(marker.Action),
 		Details:           marker.Details,
+		RollbackReason:    marker.RollbackReason,
 	}
 	markerBytes, err := yaml.Marshal(makerSerializer)
 	if err != nil {
@@ -0,0 +1,140 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package upgrade
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/errors"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+)
+
+const preparedFilename = ".upgrade-prepared"
+
+// PreparedUpgrade holds the information about an upgrade that has been downloaded,
+// verified and unpacked, but not yet switched to.
+type PreparedUpgrade struct {
+	// Version represents the version the agent has been prepared to upgrade to
+	Version string `json:"version" yaml:"version"`
+	// Hash of the prepared agent
+	Hash string `json:"hash" yaml:"hash"`
+	// VersionedHome represents the path where the prepared agent is located relative to top path
+	VersionedHome string `json:"versioned_home" yaml:"versioned_home"`
+	// BinarySHA256 is the SHA-256 of the prepared agent binary, checked before switching to it
+	BinarySHA256 string `json:"binary_sha256" yaml:"binary_sha256"`
+	// SourceURI the artifact has been downloaded from
+	SourceURI string `json:"source_uri,omitempty" yaml:"source_uri,omitempty"`
+	// PreparedOn marks a date when the upgrade was prepared
+	PreparedOn time.Time `json:"prepared_on" yaml:"prepared_on"`
+	// ActionID of the action that requested the preparation, if any
+	ActionID string `json:"action_id,omitempty" yaml:"action_id,omitempty"`
+}
+
+// markPrepared persists the prepared upgrade information so a later upgrade to the
+// same version can skip the download and unpack steps.
+func markPrepared(log *logger.Logger, dataDirPath string, prepared *PreparedUpgrade) error {
+	preparedBytes, err := yaml.Marshal(prepared)
+	if err != nil {
+		return errors.New(err, errors.TypeConfig, "failed to serialize prepared upgrade")
+	}
+
+	preparedPath := preparedFilePath(dataDirPath)
+	log.Infow("Writing prepared upgrade file", "file.path", preparedPath, "version", prepared.Version, "hash", prepared.Hash)
+	if err := os.WriteFile(preparedPath, preparedBytes, 0600); err != nil {
+		return errors.New(err, errors.TypeFilesystem, "failed to create prepared upgrade file", errors.M(errors.MetaKeyPath, preparedPath))
+	}
+
+	return nil
+}
+
+// LoadPrepared loads the prepared upgrade information. If the file does not exist it
+// returns nil and no error.
+func LoadPrepared(dataDirPath string) (*PreparedUpgrade, error) {
+	preparedBytes, err := os.ReadFile(preparedFilePath(dataDirPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	prepared := &PreparedUpgrade{}
+	if err := yaml.Unmarshal(preparedBytes, prepared); err != nil {
+		return nil, err
+	}
+
+	return prepared, nil
+}
+
+// CleanPrepared removes the prepared upgrade information from disk.
+func CleanPrepared(log *logger.Logger, dataDirPath string) error {
+	preparedFile := preparedFilePath(dataDirPath)
+	log.Infow("Removing prepared upgrade file", "file.path", preparedFile)
+	if err := os.Remove(preparedFile); !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// usablePrepared returns the prepared upgrade if it matches the requested version and the agent
+// binary of its versioned home is still the one prepared, nil otherwise so the artifact is
+// downloaded and unpacked again.
+func usablePrepared(log *logger.Logger, topDirPath, dataDirPath, version string) *PreparedUpgrade {
+	prepared, err := LoadPrepared(dataDirPath)
+	if err != nil {
+		log.Warnw("Unable to load prepared upgrade, performing a full upgrade", "error.message", err)
+		return nil
+	}
+	if prepared == nil || prepared.Version != version || prepared.VersionedHome == "" {
+		return nil
+	}
+
+	home := filepath.Join(topDirPath, prepared.VersionedHome)
+	binaryHash, err := preparedBinarySHA256(topDirPath, prepared.VersionedHome)
+	if err != nil {
+		log.Warnw("Prepared upgrade home is not usable, performing a full upgrade", "error.message", err, "file.path", home)
+		return nil
+	}
+	if prepared.BinarySHA256 == "" || binaryHash != prepared.BinarySHA256 {
+		log.Warnw("Prepared upgrade binary does not match the prepared one, performing a full upgrade", "file.path", home, "expected_sha256", prepared.BinarySHA256, "sha256", binaryHash)
+		return nil
+	}
+
+	return prepared
+}
+
+// preparedBinarySHA256 returns the hex encoded SHA-256 of the agent binary of the versioned home.
+func preparedBinarySHA256(topDirPath, versionedHome string) (string, error) {
+	binaryPath := paths.BinaryPath(filepath.Join(topDirPath, versionedHome), agentName)
+	if runtime.GOOS == windows {
+		binaryPath += exe
+	}
+	f, err := os.Open(binaryPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", binaryPath, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func preparedFilePath(dataDirPath string) string {
+	return filepath.Join(dataDirPath, preparedFilename)
+}
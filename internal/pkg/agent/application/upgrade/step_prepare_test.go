@@ -0,0 +1,72 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package upgrade
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+)
+
+func TestPreparedUpgrade(t *testing.T) {
+	log, _ := logger.NewTesting(t.Name())
+
+	topDir := t.TempDir()
+	dataDir := filepath.Join(topDir, "data")
+	require.NoError(t, os.MkdirAll(dataDir, 0o755))
+
+	loaded, err := LoadPrepared(dataDir)
+	require.NoError(t, err)
+	assert.Nil(t, loaded, "no prepared upgrade expected before marking")
+
+	prepared := &PreparedUpgrade{
+		Version:       "8.15.0",
+		Hash:          "abcdef",
+		VersionedHome: filepath.Join("data", "elastic-agent-8.15.0-abcdef"),
+		SourceURI:     "https://artifacts.elastic.co/downloads/",
+		PreparedOn:    time.Now().UTC().Truncate(time.Second),
+		ActionID:      "action-1",
+	}
+	require.NoError(t, markPrepared(log, dataDir, prepared))
+
+	loaded, err = LoadPrepared(dataDir)
+	require.NoError(t, err)
+	assert.Equal(t, prepared, loaded)
+
+	assert.Nil(t, usablePrepared(log, topDir, dataDir, "8.15.0"), "prepared home is missing, it should not be usable")
+
+	binaryPath := paths.BinaryPath(filepath.Join(topDir, prepared.VersionedHome), agentName)
+	if runtime.GOOS == windows {
+		binaryPath += exe
+	}
+	require.NoError(t, os.MkdirAll(filepath.Dir(binaryPath), 0o755))
+	require.NoError(t, os.WriteFile(binaryPath, []byte("prepared agent"), 0o755))
+	assert.Nil(t, usablePrepared(log, topDir, dataDir, "8.15.0"), "prepared upgrade without the binary hash should not be usable")
+
+	prepared.BinarySHA256, err = preparedBinarySHA256(topDir, prepared.VersionedHome)
+	require.NoError(t, err)
+	require.NoError(t, markPrepared(log, dataDir, prepared))
+	assert.Equal(t, prepared, usablePrepared(log, topDir, dataDir, "8.15.0"))
+	assert.Nil(t, usablePrepared(log, topDir, dataDir, "8.16.0"), "prepared upgrade for a different version should not be usable")
+
+	require.NoError(t, os.WriteFile(binaryPath, []byte("tampered agent"), 0o755))
+	assert.Nil(t, usablePrepared(log, topDir, dataDir, "8.15.0"), "prepared upgrade with a modified binary should not be usable")
+
+	require.NoError(t, CleanPrepared(log, dataDir))
+	loaded, err = LoadPrepared(dataDir)
+	require.NoError(t, err)
+	assert.Nil(t, loaded)
+
+	// cleaning an already cleaned prepared upgrade is not an error
+	require.NoError(t, CleanPrepared(log, dataDir))
+}
@@ -10,7 +10,6 @@ import (
 	"fmt"
 	"io/fs"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -173,64 +172,50 @@ func (u *Upgrader) Upgrade(ctx context.Context, version string, sourceURI string
 	span, ctx := apm.StartSpan(ctx, "upgrade", "app.internal")
 	defer span.End()
 
-	err = cleanNonMatchingVersionsFromDownloads(u.log, u.agentInfo.Version())
-	if err != nil {
-		u.log.Errorw("Unable to clean downloads before update", "error.message", err, "downloads.path", paths.Downloads())
-	}
-
-	det.SetState(details.StateDownloading)
-
-	sourceURI = u.sourceURI(sourceURI)
-
 	parsedVersion, err := agtversion.ParseVersion(version)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing version %q: %w", version, err)
 	}
 
-	archivePath, err := u.downloadArtifact(ctx, parsedVersion, sourceURI, det, skipVerifyOverride, skipDefaultPgp, pgpBytes...)
-	if err != nil {
-		// Run the same pre-upgrade cleanup task to get rid of any newly downloaded files
-		// This may have an issue if users are upgrading to the same version number.
-		if dErr := cleanNonMatchingVersionsFromDownloads(u.log, u.agentInfo.Version()); dErr != nil {
-			u.log.Errorw("Unable to remove file after verification failure", "error.message", dErr)
-		}
-
-		return nil, err
-	}
-
-	det.SetState(details.StateExtracting)
-
-	metadata, err := u.getPackageMetadata(archivePath)
-	if err != nil {
-		return nil, fmt.Errorf("reading metadata for elastic agent version %s package %q: %w", version, archivePath, err)
-	}
-
-	currentVersion := agentVersion{
-		version:  release.Version(),
-		snapshot: release.Snapshot(),
-		hash:     release.Commit(),
-	}
-
-	same, newVersion := isSameVersion(u.log, currentVersion, metadata, version)
-	if same {
-		return nil, fmt.Errorf("agent version is already %s", currentVersion)
-	}
-
-	u.log.Infow("Unpacking agent package", "version", newVersion)
+	sourceURI = u.sourceURI(sourceURI)
 
-	// Nice to have: add check that no archive files end up in the current versioned home
-	unpackRes, err := u.unpack(version, archivePath, paths.Data())
-	if err != nil {
-		return nil, err
+	var unpackRes UnpackResult
+	if prepared := usablePrepared(u.log, paths.Top(), paths.Data(), version); prepared != nil {
+		// the artifact has already been downloaded, verified and unpacked by a previous
+		// prepare-only upgrade, only the switch to the new version remains to be done.
+		u.log.Infow("Using previously prepared upgrade", "version", version, "versioned_home", prepared.VersionedHome, "prepared_on", prepared.PreparedOn)
+		unpackRes = UnpackResult{
+			Hash:          prepared.Hash,
+			VersionedHome: prepared.VersionedHome,
+		}
+	} else {
+		unpackRes, err = u.prepare(ctx, version, parsedVersion, sourceURI, det, skipVerifyOverride, skipDefaultPgp, pgpBytes...)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	newHash := unpackRes.Hash
-	if newHash == "" {
-		return nil, errors.New("unknown hash")
-	}
+	if action != nil && action.PrepareOnly {
+		binaryHash, err := preparedBinarySHA256(paths.Top(), unpackRes.VersionedHome)
+		if err != nil {
+			return nil, errors.New(err, "failed to hash the prepared agent binary")
+		}
+		prepared := &PreparedUpgrade{
+			Version:       version,
+			Hash:          unpackRes.Hash,
+			VersionedHome: unpackRes.VersionedHome,
+			BinarySHA256:  binaryHash,
+			SourceURI:     sourceURI,
+			PreparedOn:    time.Now(),
+			ActionID:      action.ActionID,
+		}
+		if err := markPrepared(u.log, paths.Data(), prepared); err != nil {
+			return nil, err
+		}
 
-	if unpackRes.VersionedHome == "" {
-		return nil, fmt.Errorf("versionedhome is empty: %v", unpackRes)
+		u.log.Infow("Upgrade prepared, not switching to the new version", "version", version, "versioned_home", unpackRes.VersionedHome)
+		det.SetState(details.StatePrepared)
+		return nil, nil
 	}
 
 	newHome := filepath.Join(paths.Top(), unpackRes.VersionedHome)
@@ -293,29 +278,16 @@ func (u *Upgrader) Upgrade(ctx context.Context, version string, sourceURI string
 		return nil, goerrors.Join(err, rollbackErr)
 	}
 
-	minParsedVersionForNewUpdateMarker := agtversion.NewParsedSemVer(8, 13, 0, "", "")
-	var watcherExecutable string
-	if parsedVersion.Less(*minParsedVersionForNewUpdateMarker) {
-		// use the current agent executable for watch, if downgrading the old agent doesn't understand the current agent's path structure.
-		watcherExecutable = paths.BinaryPath(paths.VersionedHome(paths.Top()), agentName)
-	} else {
-		// use the new agent executable as it should be able to parse the new update marker
-		watcherExecutable = paths.BinaryPath(filepath.Join(paths.Top(), unpackRes.VersionedHome), agentName)
+	if err := CleanPrepared(u.log, paths.Data()); err != nil {
+		u.log.Errorw("Unable to remove prepared upgrade file", "error.message", err)
 	}
-	var watcherCmd *exec.Cmd
-	if watcherCmd, err = InvokeWatcher(u.log, watcherExecutable); err != nil {
+
+	if err := startWatcher(ctx, u.log, watcherExecutable(parsedVersion, unpackRes.VersionedHome)); err != nil {
 		u.log.Errorw("Rolling back: starting watcher failed", "error.message", err)
 		rollbackErr := rollbackInstall(ctx, u.log, paths.Top(), hashedDir, currentVersionedHome)
 		return nil, goerrors.Join(err, rollbackErr)
 	}
 
-	watcherWaitErr := waitForWatcher(ctx, u.log, markerFilePath(paths.Data()), watcherMaxWaitTime)
-	if watcherWaitErr != nil {
-		killWatcherErr := watcherCmd.Process.Kill()
-		rollbackErr := rollbackInstall(ctx, u.log, paths.Top(), hashedDir, currentVersionedHome)
-		return nil, goerrors.Join(watcherWaitErr, killWatcherErr, rollbackErr)
-	}
-
 	cb := shutdownCallback(u.log, paths.Home(), release.Version(), version, filepath.Join(paths.Top(), unpackRes.VersionedHome))
 
 	// Clean everything from the downloads dir
@@ -328,6 +300,177 @@ func (u *Upgrader) Upgrade(ctx context.Context, version string, sourceURI string
 	return cb, nil
 }
 
+// Rollback switches the running agent back to a previously installed version still present on disk.
+// When version is empty the most recent installed version older than the running one is used.
+// It returns the version rolled back to and the shutdown callback that must be called by reexec.
+func (u *Upgrader) Rollback(ctx context.Context, version string, det *details.Details) (string, reexec.ShutdownCallbackFn, error) {
+	span, ctx := apm.StartSpan(ctx, "rollback", "app.internal")
+	defer span.End()
+
+	versions, err := ListInstalledVersions(paths.Top())
+	if err != nil {
+		return "", nil, err
+	}
+
+	// the home staged by a prepared upgrade has not been verified by an upgrade yet
+	var preparedHome string
+	prepared, err := LoadPrepared(paths.Data())
+	if err != nil {
+		return "", nil, fmt.Errorf("loading prepared upgrade: %w", err)
+	}
+	if prepared != nil {
+		preparedHome = prepared.VersionedHome
+	}
+
+	target, err := selectRollbackTarget(versions, version, release.VersionWithSnapshot(), preparedHome)
+	if err != nil {
+		return "", nil, err
+	}
+
+	u.log.Infow("Rolling back agent", "version", target.Version, "versioned_home", target.VersionedHome)
+	if err := checkVersionedHome(paths.Top(), target); err != nil {
+		return "", nil, fmt.Errorf("refusing to roll back to version %s, integrity check of %s failed: %w", target.Version, target.VersionedHome, err)
+	}
+
+	newHome := filepath.Join(paths.Top(), target.VersionedHome)
+	if err := copyActionStore(u.log, newHome); err != nil {
+		return "", nil, errors.New(err, "failed to copy action store")
+	}
+
+	if err := copyRunDirectory(u.log, filepath.Join(paths.Home(), "run"), filepath.Join(newHome, "run")); err != nil {
+		return "", nil, errors.New(err, "failed to copy run directory")
+	}
+
+	currentVersionedHome, err := filepath.Rel(paths.Top(), paths.Home())
+	if err != nil {
+		return "", nil, fmt.Errorf("calculating home path relative to top, home: %q top: %q : %w", paths.Home(), paths.Top(), err)
+	}
+
+	symlinkPath := filepath.Join(paths.Top(), agentName)
+	if err := changeSymlink(u.log, paths.Top(), symlinkPath, paths.BinaryPath(newHome, agentName)); err != nil {
+		return "", nil, fmt.Errorf("changing symlink to %s: %w", target.VersionedHome, err)
+	}
+
+	// The rolled back agent is marked like an upgraded one: the watcher started with it
+	// switches back to the current version if the rolled back agent does not work.
+	current := agentInstall{
+		version:       target.Version,
+		hash:          target.Hash,
+		versionedHome: target.VersionedHome,
+	}
+
+	previous := agentInstall{
+		version:       release.VersionWithSnapshot(),
+		hash:          release.Commit(),
+		versionedHome: currentVersionedHome,
+	}
+
+	if err := markUpgrade(u.log, paths.Data(), current, previous, nil, det); err != nil {
+		u.log.Errorw("Restoring symlink: marking rollback failed", "error.message", err)
+		restoreErr := changeSymlink(u.log, paths.Top(), symlinkPath, paths.BinaryPath(paths.Home(), agentName))
+		return "", nil, goerrors.Join(err, restoreErr)
+	}
+
+	// Versioned homes without version information are legacy ones, older than 8.13.
+	targetVersion, err := agtversion.ParseVersion(target.Version)
+	if err != nil {
+		targetVersion = nil
+	}
+	if err := startWatcher(ctx, u.log, watcherExecutable(targetVersion, target.VersionedHome)); err != nil {
+		u.log.Errorw("Restoring symlink: starting watcher failed", "error.message", err)
+		restoreErr := changeSymlink(u.log, paths.Top(), symlinkPath, paths.BinaryPath(paths.Home(), agentName))
+		markerErr := os.Remove(markerFilePath(paths.Data()))
+		return "", nil, goerrors.Join(err, restoreErr, markerErr)
+	}
+
+	return target.Version, shutdownCallback(u.log, paths.Home(), release.Version(), target.Version, newHome), nil
+}
+
+// prepare downloads, verifies and unpacks the agent artifact into its versioned home.
+func (u *Upgrader) prepare(ctx context.Context, version string, parsedVersion *agtversion.ParsedSemVer, sourceURI string, det *details.Details, skipVerifyOverride bool, skipDefaultPgp bool, pgpBytes ...string) (UnpackResult, error) {
+	err := cleanNonMatchingVersionsFromDownloads(u.log, u.agentInfo.Version())
+	if err != nil {
+		u.log.Errorw("Unable to clean downloads before update", "error.message", err, "downloads.path", paths.Downloads())
+	}
+
+	det.SetState(details.StateDownloading)
+
+	archivePath, err := u.downloadArtifact(ctx, parsedVersion, sourceURI, det, skipVerifyOverride, skipDefaultPgp, pgpBytes...)
+	if err != nil {
+		// Run the same pre-upgrade cleanup task to get rid of any newly downloaded files
+		// This may have an issue if users are upgrading to the same version number.
+		if dErr := cleanNonMatchingVersionsFromDownloads(u.log, u.agentInfo.Version()); dErr != nil {
+			u.log.Errorw("Unable to remove file after verification failure", "error.message", dErr)
+		}
+
+		return UnpackResult{}, err
+	}
+
+	det.SetState(details.StateExtracting)
+
+	metadata, err := u.getPackageMetadata(archivePath)
+	if err != nil {
+		return UnpackResult{}, fmt.Errorf("reading metadata for elastic agent version %s package %q: %w", version, archivePath, err)
+	}
+
+	currentVersion := agentVersion{
+		version:  release.Version(),
+		snapshot: release.Snapshot(),
+		hash:     release.Commit(),
+	}
+
+	same, newVersion := isSameVersion(u.log, currentVersion, metadata, version)
+	if same {
+		return UnpackResult{}, fmt.Errorf("agent version is already %s", currentVersion)
+	}
+
+	u.log.Infow("Unpacking agent package", "version", newVersion)
+
+	// Nice to have: add check that no archive files end up in the current versioned home
+	unpackRes, err := u.unpack(version, archivePath, paths.Data())
+	if err != nil {
+		return UnpackResult{}, err
+	}
+
+	if unpackRes.Hash == "" {
+		return UnpackResult{}, errors.New("unknown hash")
+	}
+
+	if unpackRes.VersionedHome == "" {
+		return UnpackResult{}, fmt.Errorf("versionedhome is empty: %v", unpackRes)
+	}
+
+	return unpackRes, nil
+}
+
+// watcherExecutable returns the agent executable watching the switch to the agent of targetVersionedHome.
+// Agents older than 8.13, or of unknown version, do not understand the path structure of the current agent
+// nor its update marker, the current agent executable watches them.
+func watcherExecutable(targetVersion *agtversion.ParsedSemVer, targetVersionedHome string) string {
+	minParsedVersionForNewUpdateMarker := agtversion.NewParsedSemVer(8, 13, 0, "", "")
+	if targetVersion == nil || targetVersion.Less(*minParsedVersionForNewUpdateMarker) {
+		// use the current agent executable for watch, if downgrading the old agent doesn't understand the current agent's path structure.
+		return paths.BinaryPath(paths.VersionedHome(paths.Top()), agentName)
+	}
+	// use the new agent executable as it should be able to parse the new update marker
+	return paths.BinaryPath(filepath.Join(paths.Top(), targetVersionedHome), agentName)
+}
+
+// startWatcher invokes the watcher with watcherExecutable and waits for it to watch the update marker,
+// the watcher is killed if it does not in time.
+var startWatcher = func(ctx context.Context, log *logger.Logger, watcherExecutable string) error {
+	watcherCmd, err := InvokeWatcher(log, watcherExecutable)
+	if err != nil {
+		return err
+	}
+
+	if err := waitForWatcher(ctx, log, markerFilePath(paths.Data()), watcherMaxWaitTime); err != nil {
+		killWatcherErr := watcherCmd.Process.Kill()
+		return goerrors.Join(err, killWatcherErr)
+	}
+	return nil
+}
+
 func waitForWatcher(ctx context.Context, log *logger.Logger, markerFilePath string, waitTime time.Duration) error {
 	return waitForWatcherWithTimeoutCreationFunc(ctx, log, markerFilePath, waitTime, context.WithTimeout)
 }
@@ -22,6 +22,7 @@ import (
 
 	"github.com/elastic/elastic-agent-libs/transport/httpcommon"
 	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/details"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/errors"
@@ -903,3 +904,75 @@ func writeState(t *testing.T, path string, state details.State) {
 		assert.NoError(t, err, "error writing out the test upgrade marker")
 	}
 }
+
+func TestUpgraderRollback(t *testing.T) {
+	setupTop := func(t *testing.T, version, hash string) (string, InstalledVersion) {
+		t.Helper()
+		top := t.TempDir()
+		oldTop := paths.Top()
+		paths.SetTop(top)
+		t.Cleanup(func() { paths.SetTop(oldTop) })
+
+		currentHome := filepath.Join(top, "data", fmt.Sprintf("elastic-agent-%s-%s", release.VersionWithSnapshot(), release.ShortCommit()))
+		require.NoError(t, os.MkdirAll(filepath.Join(currentHome, "run"), 0o755))
+
+		target := setupInstalledHome(t, top, fmt.Sprintf("elastic-agent-%s-%s", version, hash[:hashLen]), version, hash, false)
+		binary := paths.BinaryPath(filepath.Join(top, target.VersionedHome), agentName)
+		if runtime.GOOS == windows {
+			binary += exe
+		}
+		require.NoError(t, os.MkdirAll(filepath.Dir(binary), 0o755))
+		require.NoError(t, os.WriteFile(binary, []byte("agent"), 0o755))
+		require.NoError(t, os.MkdirAll(filepath.Join(top, target.VersionedHome, "components"), 0o755))
+		return top, target
+	}
+	stubWatcher := func(t *testing.T, err error) *string {
+		t.Helper()
+		var invoked string
+		defaultStartWatcher := startWatcher
+		startWatcher = func(_ context.Context, _ *logger.Logger, watcherExecutable string) error {
+			invoked = watcherExecutable
+			return err
+		}
+		t.Cleanup(func() { startWatcher = defaultStartWatcher })
+		return &invoked
+	}
+	log, _ := logger.NewTesting(t.Name())
+
+	t.Run("rollback is watched by the rolled back agent", func(t *testing.T) {
+		top, target := setupTop(t, "8.13.0", "abcdef123456")
+		invoked := stubWatcher(t, nil)
+
+		u := &Upgrader{log: log}
+		version, cb, err := u.Rollback(context.Background(), "8.13.0", details.NewDetails("8.13.0", details.StateRequested, ""))
+		require.NoError(t, err)
+		assert.Equal(t, "8.13.0", version)
+		assert.NotNil(t, cb)
+		assert.Equal(t, paths.BinaryPath(filepath.Join(top, target.VersionedHome), agentName), *invoked)
+		assert.FileExists(t, markerFilePath(paths.Data()), "the watcher reverts the rollback using the update marker")
+	})
+
+	t.Run("rollback to an agent older than 8.13 is watched by the current agent", func(t *testing.T) {
+		top, _ := setupTop(t, "8.12.0", "abcdef123456")
+		invoked := stubWatcher(t, nil)
+
+		u := &Upgrader{log: log}
+		_, _, err := u.Rollback(context.Background(), "8.12.0", details.NewDetails("8.12.0", details.StateRequested, ""))
+		require.NoError(t, err)
+		assert.Equal(t, paths.BinaryPath(paths.VersionedHome(top), agentName), *invoked)
+	})
+
+	t.Run("rollback is reverted when the watcher does not start", func(t *testing.T) {
+		top, _ := setupTop(t, "8.13.0", "abcdef123456")
+		stubWatcher(t, ErrWatcherNotStarted)
+
+		u := &Upgrader{log: log}
+		_, cb, err := u.Rollback(context.Background(), "8.13.0", details.NewDetails("8.13.0", details.StateRequested, ""))
+		require.ErrorIs(t, err, ErrWatcherNotStarted)
+		assert.Nil(t, cb)
+		assert.NoFileExists(t, markerFilePath(paths.Data()))
+		link, err := os.Readlink(filepath.Join(top, agentName))
+		require.NoError(t, err)
+		assert.Equal(t, paths.BinaryPath(paths.Home(), agentName), link, "the symlink must point to the current agent again")
+	})
+}
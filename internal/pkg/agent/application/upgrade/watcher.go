@@ -47,6 +47,7 @@ type AgentWatcher struct {
 	log           *logger.Logger
 	agentClient   client.Client
 	checkInterval time.Duration
+	healthGates   *HealthGates
 }
 
 // NewAgentWatcher creates a new agent watcher.
@@ -61,6 +62,11 @@ func NewAgentWatcher(ch chan error, log *logger.Logger, checkInterval time.Durat
 	return ec
 }
 
+// SetHealthGates sets the health gates that are fed with every state received from the agent.
+func (ch *AgentWatcher) SetHealthGates(gates *HealthGates) {
+	ch.healthGates = gates
+}
+
 // Run runs the checking loop.
 func (ch *AgentWatcher) Run(ctx context.Context) {
 	ch.log.Info("Agent watcher started")
@@ -208,6 +214,10 @@ LOOP:
 					}
 				}
 
+				if ch.healthGates != nil {
+					ch.healthGates.Observe(state)
+				}
+
 				if state.State == client.Failed {
 					// top-level failure (something is really wrong)
 					failedCh <- fmt.Errorf("%w: %s", ErrAgentStatusFailed, state.Message)
@@ -0,0 +1,207 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/errors"
+	"github.com/elastic/elastic-agent/internal/pkg/cli"
+	"github.com/elastic/elastic-agent/pkg/control"
+	"github.com/elastic/elastic-agent/pkg/control/v2/client"
+)
+
+const (
+	flagActionsType   = "type"
+	flagActionsStatus = "status"
+	flagActionsSince  = "since"
+	flagActionsUntil  = "until"
+	flagActionsLimit  = "limit"
+	flagActionsOutput = "output"
+)
+
+var actionsOutputs = map[string]outputter{
+	"human": actionsHumanOutput,
+	"json":  jsonOutput,
+	"yaml":  yamlOutput,
+}
+
+func newActionsCommandWithArgs(_ []string, streams *cli.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "actions",
+		Short: "Manage the Fleet actions of the running Elastic Agent",
+	}
+
+	cmd.AddCommand(newActionsListCommand(streams))
+	cmd.AddCommand(newActionsShowCommand(streams))
+	cmd.AddCommand(newActionsCancelCommand(streams))
+
+	return cmd
+}
+
+func newActionsListCommand(streams *cli.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the Fleet actions recorded in the action journal",
+		Long: `This command lists the lifecycle of the Fleet actions recorded in the action journal of the running Elastic Agent:
+when they were received, queued, dispatched, failed, expired, cancelled and acked.`,
+		Args: cobra.NoArgs,
+		Run: func(c *cobra.Command, args []string) {
+			if err := actionsListCmd(streams, c); err != nil {
+				fmt.Fprintf(streams.Err, "Error: %v\n%s\n", err, troubleshootMessage())
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().String(flagActionsType, "", "Only list the actions of this type")
+	cmd.Flags().String(flagActionsStatus, "", "Only list the journal entries with this status: received, queued, dispatched, failed, expired, cancelled, acked or ack_failed")
+	cmd.Flags().String(flagActionsSince, "", "Only list the journal entries recorded since this time, either a RFC3339 timestamp or a duration before now like 1h")
+	cmd.Flags().String(flagActionsUntil, "", "Only list the journal entries recorded until this time, either a RFC3339 timestamp or a duration before now like 1h")
+	cmd.Flags().Int(flagActionsLimit, 0, "Maximum number of the most recent journal entries listed, 0 lists all of them")
+	cmd.Flags().String(flagActionsOutput, "human", "Output the journal entries in either 'human', 'json', or 'yaml'")
+
+	return cmd
+}
+
+func newActionsShowCommand(streams *cli.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "show <action-id>",
+		Short: "Show the lifecycle of a Fleet action recorded in the action journal",
+		Args:  cobra.ExactArgs(1),
+		Run: func(c *cobra.Command, args []string) {
+			if err := actionsShowCmd(streams, c, args[0]); err != nil {
+				fmt.Fprintf(streams.Err, "Error: %v\n%s\n", err, troubleshootMessage())
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().String(flagActionsOutput, "human", "Output the journal entries in either 'human', 'json', or 'yaml'")
+
+	return cmd
+}
+
+func actionsListCmd(streams *cli.IOStreams, cmd *cobra.Command) error {
+	now := time.Now()
+	filter := client.ActionHistoryFilter{}
+	filter.ActionType, _ = cmd.Flags().GetString(flagActionsType)
+	filter.Status, _ = cmd.Flags().GetString(flagActionsStatus)
+	filter.Limit, _ = cmd.Flags().GetInt(flagActionsLimit)
+
+	var err error
+	since, _ := cmd.Flags().GetString(flagActionsSince)
+	if filter.Since, err = parseActionsTime(since, now); err != nil {
+		return fmt.Errorf("invalid --%s: %w", flagActionsSince, err)
+	}
+	until, _ := cmd.Flags().GetString(flagActionsUntil)
+	if filter.Until, err = parseActionsTime(until, now); err != nil {
+		return fmt.Errorf("invalid --%s: %w", flagActionsUntil, err)
+	}
+
+	return actionHistoryCmd(streams, cmd, filter)
+}
+
+func actionsShowCmd(streams *cli.IOStreams, cmd *cobra.Command, id string) error {
+	return actionHistoryCmd(streams, cmd, client.ActionHistoryFilter{ActionID: id})
+}
+
+func actionHistoryCmd(streams *cli.IOStreams, cmd *cobra.Command, filter client.ActionHistoryFilter) error {
+	output, _ := cmd.Flags().GetString(flagActionsOutput)
+	outputFunc, ok := actionsOutputs[output]
+	if !ok {
+		return fmt.Errorf("unsupported output: %s", output)
+	}
+
+	c := client.New()
+	err := c.Connect(context.Background())
+	if err != nil {
+		return errors.New(err, "Failed communicating to running daemon", errors.TypeNetwork, errors.M("socket", control.Address()))
+	}
+	defer c.Disconnect()
+
+	entries, err := c.ActionHistory(context.Background(), filter)
+	if err != nil {
+		return errors.New(err, "Failed to read the action journal")
+	}
+	if filter.ActionID != "" && len(entries) == 0 {
+		return fmt.Errorf("no action %q in the action journal", filter.ActionID)
+	}
+
+	return outputFunc(streams.Out, entries)
+}
+
+// parseActionsTime parses value as a RFC3339 timestamp or as a duration before now.
+func parseActionsTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func actionsHumanOutput(w io.Writer, output interface{}) error {
+	entries, ok := output.([]client.ActionHistoryEntry)
+	if !ok {
+		return fmt.Errorf("unexpected type %T", output)
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "No action in the action journal.")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 4, 1, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIMESTAMP\tACTION ID\tTYPE\tSTATUS\tRETRY\tERROR")
+	for _, e := range entries {
+		retry := ""
+		if e.RetryAttempt > 0 {
+			retry = fmt.Sprintf("%d", e.RetryAttempt)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.Timestamp.Local().Format(time.RFC3339), e.ActionID, e.ActionType, e.Status, retry, e.Error)
+	}
+	return tw.Flush()
+}
+
+func newActionsCancelCommand(streams *cli.IOStreams) *cobra.Command {
+	return &cobra.Command{
+		Use:   "cancel <action-id>",
+		Short: "Cancel a queued Fleet action",
+		Long: `This command cancels a scheduled Fleet action still waiting in the action queue of the running Elastic Agent.
+The cancelled action is acknowledged to Fleet with an error. The queued actions are listed by the status command.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(c *cobra.Command, args []string) {
+			if err := actionsCancelCmd(streams, args[0]); err != nil {
+				fmt.Fprintf(streams.Err, "Error: %v\n%s\n", err, troubleshootMessage())
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+func actionsCancelCmd(streams *cli.IOStreams, id string) error {
+	c := client.New()
+	err := c.Connect(context.Background())
+	if err != nil {
+		return errors.New(err, "Failed communicating to running daemon", errors.TypeNetwork, errors.M("socket", control.Address()))
+	}
+	defer c.Disconnect()
+
+	if err := c.CancelAction(context.Background(), id); err != nil {
+		return errors.New(err, fmt.Sprintf("Failed to cancel action %q", id))
+	}
+
+	fmt.Fprintf(streams.Out, "Action %s cancelled.\n", id)
+	return nil
+}
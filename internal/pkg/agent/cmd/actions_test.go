@@ -0,0 +1,57 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/pkg/control/v2/client"
+)
+
+func TestParseActionsTime(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+
+	ts, err := parseActionsTime("", now)
+	require.NoError(t, err)
+	assert.True(t, ts.IsZero())
+
+	ts, err = parseActionsTime("90m", now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-90*time.Minute), ts)
+
+	ts, err = parseActionsTime("2024-01-01T00:00:00Z", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), ts)
+
+	_, err = parseActionsTime("yesterday", now)
+	assert.Error(t, err)
+}
+
+func TestActionsHumanOutput(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 0, 0, 0, time.Local)
+
+	var b bytes.Buffer
+	require.NoError(t, actionsHumanOutput(&b, []client.ActionHistoryEntry{
+		{Timestamp: ts, ActionID: "upgrade-1", ActionType: "UPGRADE", Status: "received"},
+		{Timestamp: ts, ActionID: "upgrade-1", ActionType: "UPGRADE", Status: "failed", Error: "download failed", RetryAttempt: 1},
+	}))
+
+	formatted := ts.Format(time.RFC3339)
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, []string{"TIMESTAMP", "ACTION", "ID", "TYPE", "STATUS", "RETRY", "ERROR"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{formatted, "upgrade-1", "UPGRADE", "received"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{formatted, "upgrade-1", "UPGRADE", "failed", "1", "download", "failed"}, strings.Fields(lines[2]))
+
+	b.Reset()
+	require.NoError(t, actionsHumanOutput(&b, []client.ActionHistoryEntry{}))
+	assert.Equal(t, "No action in the action journal.\n", b.String())
+}
@@ -16,7 +16,9 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
 	"github.com/elastic/elastic-agent/internal/pkg/cli"
+	monitoringCfg "github.com/elastic/elastic-agent/internal/pkg/core/monitoring/config"
 	"github.com/elastic/elastic-agent/internal/pkg/diagnostics"
 )
 
@@ -35,6 +37,10 @@ func newDiagnosticsCommand(_ []string, streams *cli.IOStreams) *cobra.Command {
 
 	cmd.Flags().StringP("file", "f", "", "name of the output diagnostics zip archive")
 	cmd.Flags().BoolP("cpu-profile", "p", false, "wait to collect a CPU profile")
+	cmd.Flags().String("redaction-mode", "", "redaction mode overriding the one of the agent.monitoring.diagnostics.redaction policy, either 'mask' or 'hash'")
+	cmd.Flags().Duration("profiles-since", 0, "add the profiles sampled by the continuous profiler within this duration, like 1h")
+
+	cmd.AddCommand(newDiagnosticsAnalyzeCommand(streams))
 
 	return cmd
 }
@@ -62,7 +68,24 @@ func diagnosticCmd(streams *cli.IOStreams, cmd *cobra.Command) error {
 		return fmt.Errorf("failed collecting diagnostics: %w", err)
 	}
 
-	if err := diagnostics.ZipArchive(streams.Err, f, agentDiag, unitDiags, compDiags); err != nil {
+	// the redaction policy and profiling of the running Elastic Agent, from its configuration file
+	diagCfg := getConfig(streams).Settings.MonitoringConfig.Diagnostics
+	redaction := diagCfg.Redaction
+	if mode, _ := cmd.Flags().GetString("redaction-mode"); mode != "" {
+		redaction.Mode = mode
+	}
+
+	if since, _ := cmd.Flags().GetDuration("profiles-since"); since > 0 {
+		profiles, err := readProfiles(diagCfg.Profiling, since)
+		if err != nil {
+			fmt.Fprintf(streams.Err, "[WARNING]: failed to read the profiles: %s\n", err)
+		} else if len(profiles) == 0 {
+			fmt.Fprintf(streams.Err, "[WARNING]: no profile sampled within %s, is agent.monitoring.diagnostics.profiling enabled?\n", since)
+		}
+		agentDiag = append(agentDiag, profiles...)
+	}
+
+	if err := diagnostics.ZipArchive(streams.Err, f, redaction, agentDiag, unitDiags, compDiags); err != nil {
 		return fmt.Errorf("unable to create archive %q: %w", filepath, err)
 	}
 	fmt.Fprintf(streams.Out, "Created diagnostics archive %q\n", filepath)
@@ -107,6 +130,15 @@ func collectDiagnostics(ctx context.Context, streams *cli.IOStreams, cpuProfile
 	return agentDiag, unitDiags, compDiags, nil
 }
 
+// readProfiles reads the profiles sampled by the continuous profiler within the duration.
+func readProfiles(cfg monitoringCfg.Profiling, since time.Duration) ([]client.DiagnosticFileResult, error) {
+	dir := cfg.Dir
+	if dir == "" {
+		dir = paths.DiagnosticsProfilesDir()
+	}
+	return diagnostics.ReadProfiles(dir, time.Now().Add(-since))
+}
+
 func createFile(filepath string) (*os.File, error) {
 	// Ensure all the folders on filepath exist as os.Create does not do so.
 	// 0777 is the same permission, before unmask, os.Create uses.
@@ -0,0 +1,219 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/jedib0t/go-pretty/v6/list"
+	"github.com/spf13/cobra"
+
+	"github.com/elastic/elastic-agent/internal/pkg/cli"
+	"github.com/elastic/elastic-agent/internal/pkg/diagnostics/analyze"
+)
+
+const (
+	flagAnalyzeOutput           = "output"
+	flagAnalyzeRestartThreshold = "restart-threshold"
+	flagAnalyzeHeapThreshold    = "heap-threshold"
+)
+
+var analyzeOutputs = map[string]outputter{
+	"human": analyzeHumanOutput,
+	"json":  jsonOutput,
+	"yaml":  yamlOutput,
+}
+
+func newDiagnosticsAnalyzeCommand(streams *cli.IOStreams) *cobra.Command {
+	defaults := analyze.DefaultOptions()
+	cmd := &cobra.Command{
+		Use:   "analyze <archive>",
+		Short: "Analyze a diagnostics archive and report the problems found in it",
+		Long: `This command reads a diagnostics zip archive created by the diagnostics command and reports the
+failed and degraded components and units with their last logged errors, the crash-looping components,
+the Fleet connectivity errors, the failed upgrades, the processes using a lot of memory and the
+components of the policy that are not running as configured.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(c *cobra.Command, args []string) {
+			if err := diagnosticsAnalyzeCmd(streams, c, args[0]); err != nil {
+				fmt.Fprintf(streams.Err, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().String(flagAnalyzeOutput, "human", "Output the report in either 'human', 'json', or 'yaml'")
+	cmd.Flags().Int(flagAnalyzeRestartThreshold, defaults.RestartThreshold, "Number of restarts from which a component is reported as crash-looping")
+	cmd.Flags().String(flagAnalyzeHeapThreshold, units.BytesSize(float64(defaults.HeapThreshold)), "Heap in use from which a process is reported as using high memory, like 512MiB")
+
+	return cmd
+}
+
+func diagnosticsAnalyzeCmd(streams *cli.IOStreams, cmd *cobra.Command, archive string) error {
+	output, _ := cmd.Flags().GetString(flagAnalyzeOutput)
+	outputFunc, ok := analyzeOutputs[output]
+	if !ok {
+		return fmt.Errorf("unsupported output: %s", output)
+	}
+
+	opts := analyze.DefaultOptions()
+	opts.RestartThreshold, _ = cmd.Flags().GetInt(flagAnalyzeRestartThreshold)
+	heapThreshold, _ := cmd.Flags().GetString(flagAnalyzeHeapThreshold)
+	var err error
+	if opts.HeapThreshold, err = units.RAMInBytes(heapThreshold); err != nil {
+		return fmt.Errorf("invalid --%s: %w", flagAnalyzeHeapThreshold, err)
+	}
+
+	report, err := analyze.AnalyzeFile(archive, opts)
+	if err != nil {
+		return err
+	}
+	return outputFunc(streams.Out, report)
+}
+
+func analyzeHumanOutput(w io.Writer, output interface{}) error {
+	report, ok := output.(*analyze.Report)
+	if !ok {
+		return fmt.Errorf("unexpected type %T", output)
+	}
+
+	l := list.NewWriter()
+	l.SetStyle(list.StyleConnectedLight)
+	l.SetOutputMirror(w)
+
+	agent := report.Agent
+	l.AppendItem("elastic-agent")
+	l.Indent()
+	if agent.Version != "" {
+		l.AppendItem(fmt.Sprintf("version: %s (commit %s)", agent.Version, agent.Commit))
+	}
+	if agent.State != "" {
+		l.AppendItem(fmt.Sprintf("status: (%s) %s", agent.State, agent.Message))
+		l.AppendItem(fmt.Sprintf("fleet: (%s) %s", agent.FleetState, agent.FleetMessage))
+	}
+	l.UnIndent()
+
+	if len(report.Unhealthy) > 0 {
+		l.AppendItem("unhealthy")
+		l.Indent()
+		for _, u := range report.Unhealthy {
+			if u.UnitID == "" {
+				l.AppendItem(u.ComponentID)
+			} else {
+				l.AppendItem(fmt.Sprintf("%s / %s (%s)", u.ComponentID, u.UnitID, u.UnitType))
+			}
+			l.Indent()
+			l.AppendItem(fmt.Sprintf("status: (%s) %s", u.State, u.Message))
+			if u.LastError != nil {
+				l.AppendItem("last error: " + formatAnalyzeLogEntry(u.LastError))
+			}
+			l.UnIndent()
+		}
+		l.UnIndent()
+	}
+
+	if len(report.CrashLoops) > 0 {
+		l.AppendItem("crash_loops")
+		l.Indent()
+		for _, c := range report.CrashLoops {
+			l.AppendItem(c.ComponentID)
+			l.Indent()
+			l.AppendItem(fmt.Sprintf("restarts: %d", c.Restarts))
+			l.AppendItem(fmt.Sprintf("exits logged: %d", c.Exits))
+			if c.LastExit != nil {
+				l.AppendItem("last exit: " + formatAnalyzeLogEntry(c.LastExit))
+			}
+			l.UnIndent()
+		}
+		l.UnIndent()
+	}
+
+	if f := report.FleetConnectivity; f != nil {
+		l.AppendItem("fleet_connectivity")
+		l.Indent()
+		if f.State != "" {
+			l.AppendItem(fmt.Sprintf("status: (%s) %s", f.State, f.Message))
+		}
+		if f.Errors > 0 {
+			l.AppendItem(fmt.Sprintf("failed check-ins: %d between %s and %s", f.Errors,
+				f.FirstError.Local().Format(time.RFC3339), f.LastError.Local().Format(time.RFC3339)))
+			if f.LastErrorMsg != "" {
+				l.AppendItem("last error: " + f.LastErrorMsg)
+			}
+		}
+		l.UnIndent()
+	}
+
+	if len(report.UpgradeFailures) > 0 {
+		l.AppendItem("upgrade_failures")
+		l.Indent()
+		for _, u := range report.UpgradeFailures {
+			item := u.State
+			if u.ActionID != "" {
+				item = u.ActionID + ": " + item
+			}
+			l.AppendItem(item)
+			l.Indent()
+			if u.TargetVersion != "" {
+				l.AppendItem("target_version: " + u.TargetVersion)
+			}
+			if u.FailedState != "" {
+				l.AppendItem("failed_state: " + u.FailedState)
+			}
+			if !u.Timestamp.IsZero() {
+				l.AppendItem("timestamp: " + u.Timestamp.Local().Format(time.RFC3339))
+			}
+			if u.Error != "" {
+				l.AppendItem("error: " + u.Error)
+			}
+			l.UnIndent()
+		}
+		l.UnIndent()
+	}
+
+	if len(report.HighMemory) > 0 {
+		l.AppendItem("high_memory")
+		l.Indent()
+		for _, m := range report.HighMemory {
+			l.AppendItem(fmt.Sprintf("%s: %s heap in use (%s)", m.Process, units.BytesSize(float64(m.InUseBytes)), m.Profile))
+		}
+		l.UnIndent()
+	}
+
+	if len(report.ConfigMismatches) > 0 {
+		l.AppendItem("config_mismatches")
+		l.Indent()
+		for _, m := range report.ConfigMismatches {
+			l.AppendItem(fmt.Sprintf("%s: %s", m.ComponentID, m.Reason))
+		}
+		l.UnIndent()
+	}
+
+	if len(report.Warnings) > 0 {
+		l.AppendItem("warnings")
+		l.Indent()
+		for _, warning := range report.Warnings {
+			l.AppendItem(warning)
+		}
+		l.UnIndent()
+	}
+
+	_ = l.Render()
+	if report.Problems() == 0 {
+		fmt.Fprintln(w, "No problem found in the diagnostics archive.")
+	}
+	return nil
+}
+
+func formatAnalyzeLogEntry(e *analyze.LogEntry) string {
+	if e.Timestamp.IsZero() {
+		return e.Message
+	}
+	return e.Timestamp.Local().Format(time.RFC3339) + " " + e.Message
+}
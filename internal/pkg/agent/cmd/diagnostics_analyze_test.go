@@ -0,0 +1,44 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/internal/pkg/diagnostics/analyze"
+)
+
+func TestAnalyzeHumanOutput(t *testing.T) {
+	t.Run("problems", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, analyzeHumanOutput(&out, &analyze.Report{
+			Agent: analyze.AgentSummary{Version: "8.13.0", Commit: "abc123", State: "DEGRADED", Message: "1 unit degraded", FleetState: "HEALTHY"},
+			Unhealthy: []analyze.UnhealthyEntry{
+				{ComponentID: "filestream-default", UnitID: "filestream-default-logs", UnitType: "input", State: "DEGRADED", Message: "file not found",
+					LastError: &analyze.LogEntry{Message: "Harvester could not be started"}},
+			},
+			HighMemory: []analyze.MemoryUsage{
+				{Process: "filestream-default", Profile: "components/filestream-default/heap.pprof.gz", InUseBytes: 512 * 1024 * 1024},
+			},
+		}))
+
+		s := out.String()
+		assert.Contains(t, s, "version: 8.13.0 (commit abc123)")
+		assert.Contains(t, s, "filestream-default / filestream-default-logs (input)")
+		assert.Contains(t, s, "last error: Harvester could not be started")
+		assert.Contains(t, s, "filestream-default: 512MiB heap in use (components/filestream-default/heap.pprof.gz)")
+		assert.NotContains(t, s, "No problem found")
+	})
+
+	t.Run("no problems", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, analyzeHumanOutput(&out, &analyze.Report{Agent: analyze.AgentSummary{Version: "8.13.0"}}))
+		assert.Contains(t, out.String(), "No problem found in the diagnostics archive.")
+	})
+}
@@ -7,6 +7,8 @@ package cmd
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"encoding/base64"
 	"fmt"
 	"io"
 	"math/rand"
@@ -23,12 +25,14 @@ import (
 
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/filelock"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/identity"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/info"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/secret"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/configuration"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/errors"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/install"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/protection"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/storage"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/vault"
 	"github.com/elastic/elastic-agent/internal/pkg/cli"
@@ -582,6 +586,30 @@ func (c *enrollCmd) enroll(ctx context.Context, persistentConfig map[string]inte
 		},
 	}
 
+	// The agent key pair is kept in the vault, the agent authenticates to a remote Fleet Server with
+	// the client certificate signed from this request.
+	localFleetServer := c.options.FleetServer.ConnStr != ""
+	var agentKey *ecdsa.PrivateKey
+	if c.requestsIdentity() {
+		agentKey, err = identity.NewKey()
+		if err != nil {
+			return fmt.Errorf("failed to generate the agent key: %w", err)
+		}
+		r.CSR, err = identity.CertificateRequest(agentKey, metadata.Host.Hostname)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Fleet encrypts the policy secrets to the key derived from the agent secret, it outlives the
+	// renewals of the client certificate.
+	if !c.options.SkipCreateSecret {
+		r.SecretsPublicKey, err = secretsPublicKey(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
 	resp, err := cmd.Execute(ctx, r)
 	if err != nil {
 		return errors.New(err,
@@ -589,6 +617,17 @@ func (c *enrollCmd) enroll(ctx context.Context, persistentConfig map[string]inte
 			errors.TypeNetwork)
 	}
 
+	if agentKey != nil {
+		if err := c.storeIdentity(ctx, agentKey, resp.Item.Certificate); err != nil {
+			return err
+		}
+	} else if c.hasOperatorCertificate() {
+		c.log.Info("The agent authenticates with the client certificate provided by --elastic-agent-cert.")
+		if err := removeIdentity(ctx); err != nil {
+			return err
+		}
+	}
+
 	fleetConfig, err := createFleetConfigFromEnroll(resp.Item.AccessAPIKey, c.remoteConfig)
 	if err != nil {
 		return err
@@ -596,7 +635,6 @@ func (c *enrollCmd) enroll(ctx context.Context, persistentConfig map[string]inte
 
 	agentConfig := c.createAgentConfig(resp.Item.ID, persistentConfig, c.options.FleetServer.Headers)
 
-	localFleetServer := c.options.FleetServer.ConnStr != ""
 	if localFleetServer {
 		//nolint:dupl // not duplicates, just similar params are passed
 		serverConfig, err := createFleetServerBootstrapConfig(
@@ -656,6 +694,71 @@ func (c *enrollCmd) enroll(ctx context.Context, persistentConfig map[string]inte
 	return nil
 }
 
+// requestsIdentity returns true when the agent requests Fleet to sign a client certificate for its key.
+// The client certificate provided by the operator with --elastic-agent-cert is never replaced.
+func (c *enrollCmd) requestsIdentity() bool {
+	return !c.options.SkipCreateSecret && c.options.FleetServer.ConnStr == "" && !c.hasOperatorCertificate()
+}
+
+// hasOperatorCertificate returns true when the operator provided the client certificate of the agent.
+func (c *enrollCmd) hasOperatorCertificate() bool {
+	return c.options.Certificate != "" || c.options.Key != ""
+}
+
+// storeIdentity stores the agent key and the client certificate signed by Fleet in the vault.
+// Fleet Server not supporting client certificates returns none, any previous identity is then removed
+// as it belongs to a former enrollment.
+func (c *enrollCmd) storeIdentity(ctx context.Context, key *ecdsa.PrivateKey, certificate string) error {
+	if certificate == "" {
+		c.log.Info("Fleet Server did not sign a client certificate, the agent authenticates with its API key only.")
+		return removeIdentity(ctx)
+	}
+
+	hasRoot, err := utils.HasRoot()
+	if err != nil {
+		return fmt.Errorf("checking if running with root/Administrator privileges: %w", err)
+	}
+	opts := []vault.OptionFunc{vault.WithUnprivileged(!hasRoot)}
+
+	id, err := identity.New(key, certificate)
+	if err != nil {
+		return err
+	}
+	if err := identity.Save(ctx, id, opts...); err != nil {
+		return fmt.Errorf("failed to store the agent identity: %w", err)
+	}
+	return nil
+}
+
+// secretsPublicKey returns the base64 encoded public key of the policy secrets key of the agent.
+func secretsPublicKey(ctx context.Context) (string, error) {
+	hasRoot, err := utils.HasRoot()
+	if err != nil {
+		return "", fmt.Errorf("checking if running with root/Administrator privileges: %w", err)
+	}
+	agentSecret, err := secret.GetAgentSecret(ctx, vault.WithUnprivileged(!hasRoot))
+	if err != nil {
+		return "", fmt.Errorf("failed to read the agent secret: %w", err)
+	}
+	key, err := protection.SecretsKey(agentSecret.Value)
+	if err != nil {
+		return "", fmt.Errorf("failed to derive the policy secrets key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(key.PublicKey().Bytes()), nil
+}
+
+// removeIdentity removes the identity of a former enrollment from the vault.
+func removeIdentity(ctx context.Context) error {
+	hasRoot, err := utils.HasRoot()
+	if err != nil {
+		return fmt.Errorf("checking if running with root/Administrator privileges: %w", err)
+	}
+	if err := identity.Remove(ctx, vault.WithUnprivileged(!hasRoot)); err != nil {
+		return fmt.Errorf("failed to remove the previous agent identity: %w", err)
+	}
+	return nil
+}
+
 func (c *enrollCmd) startAgent(ctx context.Context) (<-chan *os.ProcessState, error) {
 	cmd, err := os.Executable()
 	if err != nil {
@@ -338,6 +338,35 @@ func TestEnroll(t *testing.T) {
 	))
 }
 
+func TestEnrollRequestsIdentity(t *testing.T) {
+	for name, tc := range map[string]struct {
+		options enrollCmdOption
+		want    bool
+	}{
+		"remote fleet-server": {
+			options: enrollCmdOption{URL: "https://fleet.example.com"},
+			want:    true,
+		},
+		"operator certificate": {
+			options: enrollCmdOption{URL: "https://fleet.example.com", Certificate: "/etc/agent/client.crt", Key: "/etc/agent/client.key"},
+			want:    false,
+		},
+		"local fleet-server": {
+			options: enrollCmdOption{FleetServer: enrollCmdFleetServerOption{ConnStr: "https://es.example.com:9200"}},
+			want:    false,
+		},
+		"no secret": {
+			options: enrollCmdOption{URL: "https://fleet.example.com", SkipCreateSecret: true},
+			want:    false,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := &enrollCmd{options: &tc.options}
+			assert.Equal(t, tc.want, c.requestsIdentity())
+		})
+	}
+}
+
 func TestValidateArgs(t *testing.T) {
 	url := "http://localhost:8220"
 	enrolmentToken := "my-enrollment-token"
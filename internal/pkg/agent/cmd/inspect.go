@@ -7,7 +7,10 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"reflect"
+	"sort"
 	"strings"
 	"time"
 
@@ -28,7 +31,10 @@ import (
 	"github.com/elastic/elastic-agent/internal/pkg/cli"
 	"github.com/elastic/elastic-agent/internal/pkg/config"
 	"github.com/elastic/elastic-agent/internal/pkg/config/operations"
+	"github.com/elastic/elastic-agent/internal/pkg/diagnostics"
 	"github.com/elastic/elastic-agent/pkg/component"
+	"github.com/elastic/elastic-agent/pkg/control"
+	"github.com/elastic/elastic-agent/pkg/control/v2/client"
 	"github.com/elastic/elastic-agent/pkg/core/logger"
 	"github.com/elastic/elastic-agent/pkg/utils"
 )
@@ -90,6 +96,13 @@ returned. In this mode the configuration is provided by default, using the --sho
 
 The selected input or output runtime specification for a component is never provided unless enabled with --show-spec.
 
+Use --diff to compare the components model computed from the configuration on disk with the one of the running Elastic
+Agent. The added, removed and changed components, their units and configuration, and the components blocked by the
+capabilities are displayed. The running values are the ones of the running Elastic Agent, the on_disk values the ones
+computed from the configuration on disk. The units configuration is redacted with the diagnostics redaction policy, with
+the mask mode the changes of the redacted values are not displayed. A component ID cannot be selected in this mode.
+--diff is only supported by a standalone Elastic Agent, Fleet-managed ones modify the components they run.
+
 Variable substitution is always performed when computing the components, and it cannot be disabled. By default only the
 first set of computed variables are used. This can prevent some of the dynamic providers (kubernetes, docker, etc.) from
 providing all the possible variables it could have discovered if given more time. The --variables-wait allows an
@@ -104,6 +117,7 @@ variables for the configuration.
 			}
 			opts.showConfig, _ = c.Flags().GetBool("show-config")
 			opts.showSpec, _ = c.Flags().GetBool("show-spec")
+			opts.diff, _ = c.Flags().GetBool("diff")
 			opts.variablesWait, _ = c.Flags().GetDuration("variables-wait")
 
 			ctx, cancel := context.WithCancel(context.Background())
@@ -118,6 +132,7 @@ variables for the configuration.
 
 	cmd.Flags().Bool("show-config", false, "show the configuration for all units")
 	cmd.Flags().Bool("show-spec", false, "show the runtime specification for a component")
+	cmd.Flags().Bool("diff", false, "show the differences with the components model of the running Elastic Agent")
 	cmd.Flags().Duration("variables-wait", time.Duration(0), "wait this amount of time for variables before performing substitution")
 
 	return cmd
@@ -238,6 +253,7 @@ type inspectComponentsOpts struct {
 	id            string
 	showConfig    bool
 	showSpec      bool
+	diff          bool
 	variablesWait time.Duration
 }
 
@@ -246,7 +262,25 @@ func blockedByCaps(c component.Component, caps capabilities.Capabilities) bool {
 	return !caps.AllowInput(c.InputType) || !caps.AllowOutput(c.OutputType)
 }
 
+// splitBlockedByCaps separates the components allowed by the given Capabilities config from the blocked ones.
+func splitBlockedByCaps(comps []component.Component, caps capabilities.Capabilities) (allowed []component.Component, blocked []component.Component) {
+	allowed = []component.Component{}
+	blocked = []component.Component{}
+	for _, c := range comps {
+		if blockedByCaps(c, caps) {
+			blocked = append(blocked, c)
+		} else {
+			allowed = append(allowed, c)
+		}
+	}
+	return allowed, blocked
+}
+
 func inspectComponents(ctx context.Context, cfgPath string, opts inspectComponentsOpts, streams *cli.IOStreams) error {
+	if opts.diff && opts.id != "" {
+		return fmt.Errorf("a component ID cannot be selected with --diff")
+	}
+
 	l, err := newErrorLogger()
 	if err != nil {
 		return err
@@ -289,6 +323,47 @@ func inspectComponents(ctx context.Context, cfgPath string, opts inspectComponen
 		return fmt.Errorf("failed to render components: %w", err)
 	}
 
+	if opts.diff {
+		agentCfg, err := agentConfiguration(m)
+		if err != nil {
+			return fmt.Errorf("failed to load the configuration: %w", err)
+		}
+		// the components of a Fleet-managed agent are modified with the Fleet settings, the signature
+		// and the decrypted secrets of the policy, they cannot be computed here.
+		if !configuration.IsStandalone(agentCfg.Fleet) {
+			return fmt.Errorf("--diff is only supported by a standalone Elastic Agent")
+		}
+		redactor, err := diagnostics.NewRedactor(agentCfg.Settings.MonitoringConfig.Diagnostics.Redaction)
+		if err != nil {
+			return fmt.Errorf("invalid diagnostics redaction policy: %w", err)
+		}
+
+		caps, err := capabilities.LoadFile(paths.AgentCapabilitiesPath(), l)
+		if err != nil {
+			return err
+		}
+		allowed, blocked := splitBlockedByCaps(comps, caps)
+
+		c := client.New()
+		err = c.Connect(ctx)
+		if err != nil {
+			return errors.New(err, "Failed communicating to running daemon", errors.TypeNetwork, errors.M("socket", control.Address()))
+		}
+		defer c.Disconnect()
+
+		running, err := c.ComponentModel(ctx)
+		if err != nil {
+			return errors.New(err, "Failed to fetch the components model of the running daemon")
+		}
+		onDisk := client.ComponentModel{
+			Components:            modelComponents(allowed),
+			BlockedByCapabilities: modelComponents(blocked),
+		}
+		redactComponentModel(redactor, streams.Err, running)
+		redactComponentModel(redactor, streams.Err, onDisk)
+		return printComponentsDiff(diffComponentModels(running, onDisk), streams)
+	}
+
 	// Hide configuration unless toggled on.
 	if !opts.showConfig {
 		for i, comp := range comps {
@@ -336,19 +411,36 @@ func inspectComponents(ctx context.Context, cfgPath string, opts inspectComponen
 	if err != nil {
 		return err
 	}
-	allowed := []component.Component{}
-	blocked := []component.Component{}
-	for _, c := range comps {
-		if blockedByCaps(c, caps) {
-			blocked = append(blocked, c)
-		} else {
-			allowed = append(allowed, c)
-		}
-	}
+	allowed, blocked := splitBlockedByCaps(comps, caps)
 
 	return printComponents(allowed, blocked, streams)
 }
 
+// agentConfiguration returns the agent configuration of the computed configuration.
+func agentConfiguration(cfg map[string]interface{}) (*configuration.Configuration, error) {
+	config, err := config.NewConfigFrom(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	agentCfg := configuration.DefaultConfiguration()
+	if err := config.Unpack(agentCfg); err != nil {
+		return nil, err
+	}
+	return agentCfg, nil
+}
+
+// redactComponentModel redacts the configuration of the units of the components model.
+func redactComponentModel(r *diagnostics.Redactor, errOut io.Writer, model client.ComponentModel) {
+	for _, comps := range [][]client.ModelComponent{model.Components, model.BlockedByCapabilities} {
+		for _, comp := range comps {
+			for i, unit := range comp.Units {
+				comp.Units[i].Config = r.RedactConfig(errOut, unit.Config)
+			}
+		}
+	}
+}
+
 func getMonitoringFn(ctx context.Context, cfg map[string]interface{}) (component.GenerateMonitoringCfgFn, error) {
 	config, err := config.NewConfigFrom(cfg)
 	if err != nil {
@@ -483,6 +575,213 @@ func findComponent(components []component.Component, id string) (component.Compo
 	return component.Component{}, false
 }
 
+// componentsDiff is the difference between the components model of the running Elastic Agent and the one computed
+// from the configuration on disk.
+type componentsDiff struct {
+	Components            componentsChanges `yaml:"components"`
+	BlockedByCapabilities blockedChanges    `yaml:"blocked_by_capabilities"`
+}
+
+type componentsChanges struct {
+	// Added are the IDs of the components only on disk.
+	Added []string `yaml:"added"`
+	// Removed are the IDs of the components only running.
+	Removed []string          `yaml:"removed"`
+	Changed []componentChange `yaml:"changed"`
+}
+
+type componentChange struct {
+	ID           string        `yaml:"id"`
+	UnitsAdded   []string      `yaml:"units_added,omitempty"`
+	UnitsRemoved []string      `yaml:"units_removed,omitempty"`
+	Changes      []valueChange `yaml:"changes,omitempty"`
+}
+
+// valueChange is a value that differs, a nil value is missing on its side.
+type valueChange struct {
+	Path    string      `yaml:"path"`
+	Running interface{} `yaml:"running"`
+	OnDisk  interface{} `yaml:"on_disk"`
+}
+
+type blockedChanges struct {
+	Added   []string `yaml:"added"`
+	Removed []string `yaml:"removed"`
+}
+
+// modelComponents converts the components to the components model returned by the running Elastic Agent, so
+// both can be compared.
+func modelComponents(comps []component.Component) []client.ModelComponent {
+	model := make([]client.ModelComponent, 0, len(comps))
+	for _, c := range comps {
+		mc := client.ModelComponent{
+			ID:         c.ID,
+			InputType:  c.InputType,
+			OutputType: c.OutputType,
+			Error:      c.ErrMsg,
+			Units:      make([]client.ModelUnit, 0, len(c.Units)),
+		}
+		if c.Err != nil {
+			mc.Error = c.Err.Error()
+		}
+		for _, u := range c.Units {
+			mu := client.ModelUnit{
+				ID:       u.ID,
+				Type:     client.UnitType(u.Type),
+				LogLevel: u.LogLevel.String(),
+				// same conversion as the Control protocol, the values are compared with the running ones
+				Config: u.Config.GetSource().AsMap(),
+			}
+			if u.Err != nil {
+				mu.Error = u.Err.Error()
+			}
+			mc.Units = append(mc.Units, mu)
+		}
+		model = append(model, mc)
+	}
+	return model
+}
+
+// diffComponentModels returns the difference between the running components model and the one on disk.
+func diffComponentModels(running, onDisk client.ComponentModel) componentsDiff {
+	diff := componentsDiff{Components: componentsChanges{Changed: []componentChange{}}}
+	diff.Components.Added, diff.Components.Removed = diffIDs(componentIDs(running.Components), componentIDs(onDisk.Components))
+	diff.BlockedByCapabilities.Added, diff.BlockedByCapabilities.Removed = diffIDs(componentIDs(running.BlockedByCapabilities), componentIDs(onDisk.BlockedByCapabilities))
+
+	for _, d := range onDisk.Components {
+		for _, r := range running.Components {
+			if r.ID != d.ID {
+				continue
+			}
+			if change, changed := diffComponent(r, d); changed {
+				diff.Components.Changed = append(diff.Components.Changed, change)
+			}
+			break
+		}
+	}
+	return diff
+}
+
+// diffComponent returns the changes between the running component and the one on disk with the same ID.
+func diffComponent(running, onDisk client.ModelComponent) (componentChange, bool) {
+	change := componentChange{ID: onDisk.ID}
+	diffValue("input_type", running.InputType, onDisk.InputType, &change.Changes)
+	diffValue("output_type", running.OutputType, onDisk.OutputType, &change.Changes)
+	diffValue("error", running.Error, onDisk.Error, &change.Changes)
+
+	runningIDs := make([]string, 0, len(running.Units))
+	for _, u := range running.Units {
+		runningIDs = append(runningIDs, u.ID)
+	}
+	onDiskIDs := make([]string, 0, len(onDisk.Units))
+	for _, u := range onDisk.Units {
+		onDiskIDs = append(onDiskIDs, u.ID)
+	}
+	change.UnitsAdded, change.UnitsRemoved = diffIDs(runningIDs, onDiskIDs)
+
+	for _, d := range onDisk.Units {
+		for _, r := range running.Units {
+			if r.ID != d.ID {
+				continue
+			}
+			path := fmt.Sprintf("units[%s]", d.ID)
+			diffValue(path+".type", r.Type.String(), d.Type.String(), &change.Changes)
+			diffValue(path+".log_level", r.LogLevel, d.LogLevel, &change.Changes)
+			diffValue(path+".error", r.Error, d.Error, &change.Changes)
+			diffValue(path+".config", r.Config, d.Config, &change.Changes)
+			break
+		}
+	}
+	changed := len(change.UnitsAdded) > 0 || len(change.UnitsRemoved) > 0 || len(change.Changes) > 0
+	return change, changed
+}
+
+// diffValue appends the changes between the running value and the one on disk at the given path, walking the
+// maps and the lists to report the changes of their values.
+func diffValue(path string, running, onDisk interface{}, changes *[]valueChange) {
+	runningMap, runningIsMap := running.(map[string]interface{})
+	onDiskMap, onDiskIsMap := onDisk.(map[string]interface{})
+	if runningIsMap && onDiskIsMap {
+		keys := make([]string, 0, len(runningMap)+len(onDiskMap))
+		for k := range runningMap {
+			keys = append(keys, k)
+		}
+		for k := range onDiskMap {
+			if _, ok := runningMap[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			diffValue(path+"."+k, runningMap[k], onDiskMap[k], changes)
+		}
+		return
+	}
+
+	runningList, runningIsList := running.([]interface{})
+	onDiskList, onDiskIsList := onDisk.([]interface{})
+	if runningIsList && onDiskIsList {
+		for i := 0; i < len(runningList) || i < len(onDiskList); i++ {
+			var r, d interface{}
+			if i < len(runningList) {
+				r = runningList[i]
+			}
+			if i < len(onDiskList) {
+				d = onDiskList[i]
+			}
+			diffValue(fmt.Sprintf("%s[%d]", path, i), r, d, changes)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(running, onDisk) {
+		*changes = append(*changes, valueChange{Path: path, Running: running, OnDisk: onDisk})
+	}
+}
+
+// diffIDs returns the IDs only on disk and the IDs only running, in their order.
+func diffIDs(running, onDisk []string) (added []string, removed []string) {
+	added = []string{}
+	removed = []string{}
+	for _, id := range onDisk {
+		if !containsID(running, id) {
+			added = append(added, id)
+		}
+	}
+	for _, id := range running {
+		if !containsID(onDisk, id) {
+			removed = append(removed, id)
+		}
+	}
+	return added, removed
+}
+
+func containsID(ids []string, id string) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+func componentIDs(comps []client.ModelComponent) []string {
+	ids := make([]string, 0, len(comps))
+	for _, c := range comps {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+func printComponentsDiff(diff componentsDiff, streams *cli.IOStreams) error {
+	data, err := yaml.Marshal(diff)
+	if err != nil {
+		return errors.New(err, "could not marshal to YAML")
+	}
+	_, err = streams.Out.Write(data)
+	return err
+}
+
 func newErrorLogger() (*logger.Logger, error) {
 	return logger.NewWithLogpLevel("", logp.ErrorLevel, false)
 }
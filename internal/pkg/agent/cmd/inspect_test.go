@@ -0,0 +1,130 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cmd
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+
+	"github.com/elastic/elastic-agent/internal/pkg/cli"
+	"github.com/elastic/elastic-agent/internal/pkg/core/monitoring/config"
+	"github.com/elastic/elastic-agent/internal/pkg/diagnostics"
+	"github.com/elastic/elastic-agent/pkg/control/v2/client"
+)
+
+func TestDiffComponentModels(t *testing.T) {
+	unit := func(id string, cfg map[string]interface{}) client.ModelUnit {
+		return client.ModelUnit{ID: id, Type: client.UnitTypeInput, LogLevel: "info", Config: cfg}
+	}
+	running := client.ModelComponent{
+		ID:         "filestream-default",
+		InputType:  "filestream",
+		OutputType: "elasticsearch",
+		Units: []client.ModelUnit{
+			unit("filestream-default", map[string]interface{}{"hosts": []interface{}{"a", "b"}, "ssl": map[string]interface{}{"enabled": true}}),
+			unit("filestream-default-logs", nil),
+		},
+	}
+	onDisk := client.ModelComponent{
+		ID:         "filestream-default",
+		InputType:  "filestream",
+		OutputType: "elasticsearch",
+		Units: []client.ModelUnit{
+			unit("filestream-default", map[string]interface{}{"hosts": []interface{}{"a"}, "ssl": map[string]interface{}{"enabled": false}, "timeout": "10s"}),
+			unit("filestream-default-system", nil),
+		},
+	}
+	onDisk.Units[0].LogLevel = "debug"
+
+	diff := diffComponentModels(
+		client.ComponentModel{
+			Components:            []client.ModelComponent{running, {ID: "log-default"}},
+			BlockedByCapabilities: []client.ModelComponent{{ID: "system/metrics-default"}},
+		},
+		client.ComponentModel{
+			Components: []client.ModelComponent{onDisk, {ID: "http/metrics-default"}},
+		},
+	)
+
+	assert.Equal(t, []string{"http/metrics-default"}, diff.Components.Added)
+	assert.Equal(t, []string{"log-default"}, diff.Components.Removed)
+	assert.Equal(t, []string{}, diff.BlockedByCapabilities.Added)
+	assert.Equal(t, []string{"system/metrics-default"}, diff.BlockedByCapabilities.Removed)
+	require.Len(t, diff.Components.Changed, 1)
+	assert.Equal(t, componentChange{
+		ID:           "filestream-default",
+		UnitsAdded:   []string{"filestream-default-system"},
+		UnitsRemoved: []string{"filestream-default-logs"},
+		Changes: []valueChange{
+			{Path: "units[filestream-default].log_level", Running: "info", OnDisk: "debug"},
+			{Path: "units[filestream-default].config.hosts[1]", Running: "b", OnDisk: nil},
+			{Path: "units[filestream-default].config.ssl.enabled", Running: true, OnDisk: false},
+			{Path: "units[filestream-default].config.timeout", Running: nil, OnDisk: "10s"},
+		},
+	}, diff.Components.Changed[0])
+}
+
+func TestDiffComponentModelsUnchanged(t *testing.T) {
+	model := client.ComponentModel{
+		Components: []client.ModelComponent{{
+			ID:    "filestream-default",
+			Units: []client.ModelUnit{{ID: "filestream-default", Config: map[string]interface{}{"a": 1.0}}},
+		}},
+	}
+	diff := diffComponentModels(model, model)
+
+	streams, _, out, _ := cli.NewTestingIOStreams()
+	require.NoError(t, printComponentsDiff(diff, streams))
+	var printed map[string]map[string][]interface{}
+	require.NoError(t, yaml.Unmarshal(out.Bytes(), &printed))
+	assert.Equal(t, map[string]map[string][]interface{}{
+		"components":              {"added": {}, "removed": {}, "changed": {}},
+		"blocked_by_capabilities": {"added": {}, "removed": {}},
+	}, printed)
+}
+
+func TestDiffComponentModelsRedacted(t *testing.T) {
+	model := func(password string) client.ComponentModel {
+		return client.ComponentModel{
+			Components: []client.ModelComponent{{
+				ID: "filestream-default",
+				Units: []client.ModelUnit{{
+					ID:     "filestream-default",
+					Config: map[string]interface{}{"hosts": []interface{}{"a"}, "ssl": map[string]interface{}{"password": password}},
+				}},
+			}},
+		}
+	}
+
+	t.Run("mask", func(t *testing.T) {
+		redactor, err := diagnostics.NewRedactor(config.Redaction{Mode: diagnostics.RedactionModeMask})
+		require.NoError(t, err)
+		running, onDisk := model("running-secret"), model("on-disk-secret")
+		redactComponentModel(redactor, io.Discard, running)
+		redactComponentModel(redactor, io.Discard, onDisk)
+
+		diff := diffComponentModels(running, onDisk)
+		assert.Empty(t, diff.Components.Changed, "the changes of the masked values are not displayed")
+	})
+
+	t.Run("hash", func(t *testing.T) {
+		redactor, err := diagnostics.NewRedactor(config.Redaction{Mode: diagnostics.RedactionModeHash, Salt: "salt"})
+		require.NoError(t, err)
+		running, onDisk := model("running-secret"), model("on-disk-secret")
+		redactComponentModel(redactor, io.Discard, running)
+		redactComponentModel(redactor, io.Discard, onDisk)
+
+		diff := diffComponentModels(running, onDisk)
+		streams, _, out, _ := cli.NewTestingIOStreams()
+		require.NoError(t, printComponentsDiff(diff, streams))
+		assert.Contains(t, out.String(), "units[filestream-default].config.ssl.password")
+		assert.NotContains(t, out.String(), "running-secret")
+		assert.NotContains(t, out.String(), "on-disk-secret")
+	})
+}
@@ -5,7 +5,10 @@
 package cmd
 
 import (
+	"archive/zip"
 	"bufio"
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"errors"
@@ -18,10 +21,8 @@ import (
 	"strings"
 	"time"
 
-	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 
-	"github.com/elastic/elastic-agent-libs/logp"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
 	"github.com/elastic/elastic-agent/internal/pkg/cli"
 	"github.com/elastic/elastic-agent/pkg/core/logger"
@@ -36,7 +37,7 @@ const (
 )
 
 var (
-	logFilePattern  = regexp.MustCompile(`elastic-agent-(\d+)(-\d+)?\.ndjson$`)
+	logFilePattern  = regexp.MustCompile(`elastic-agent-(\d+)(-\d+)?\.ndjson(\.gz)?$`)
 	errLineFiltered = errors.New("this line was filtered out")
 )
 
@@ -75,19 +76,11 @@ func addColorModifier(entry []byte) []byte {
 	if err != nil {
 		return entry
 	}
-	switch strings.ToLower(e.LogLevel) {
-
-	case logp.InfoLevel.String():
-		return []byte(color.CyanString(string(entry)))
-	case logp.WarnLevel.String():
-		return []byte(color.YellowString(string(entry)))
-	case logp.ErrorLevel.String():
-		return []byte(color.RedString(string(entry)))
-	case logp.CriticalLevel.String():
-		return []byte(color.HiRedString(string(entry)))
-	default:
+	colorFn := levelColor(e.LogLevel)
+	if colorFn == nil {
 		return entry
 	}
+	return []byte(colorFn("%s", entry))
 }
 
 // stackWriter collects written byte slices and then pops them in
@@ -178,6 +171,14 @@ func newLogsCommandWithArgs(_ []string, streams *cli.IOStreams) *cobra.Command {
 	cmd.Flags().IntP("number", "n", 10, "Maximum number of lines at the end of logs to output.")
 
 	cmd.Flags().StringP("component", "C", "", "Filter logs and output only logs for the given component ID.")
+	cmd.Flags().String("unit", "", "Filter logs and output only logs for the given unit ID.")
+	cmd.Flags().String("min-level", "", "Output only logs with at least the given level: debug, info, warn, error or critical.")
+	cmd.Flags().String("max-level", "", "Output only logs with at most the given level: debug, info, warn, error or critical.")
+	cmd.Flags().String("since", "", "Output only logs written since the given RFC3339 time, date, or duration ago like 2h.")
+	cmd.Flags().String("until", "", "Output only logs written until the given RFC3339 time, date, or duration ago like 2h.")
+	cmd.Flags().StringArray("field", nil, "Output only logs with the given field equal to a value, name=value, or matching a regular expression, name~=regex. Can be repeated.")
+	cmd.Flags().StringP("output", "o", logOutputRaw, "Output format of the logs: raw, pretty or json.")
+	cmd.Flags().String("diagnostics", "", "Read the logs from the given diagnostics archive instead of the logs directory.")
 
 	return cmd
 }
@@ -187,6 +188,21 @@ func logsCmd(streams *cli.IOStreams, cmd *cobra.Command) error {
 	lines, _ := cmd.Flags().GetInt("number")
 	follow, _ := cmd.Flags().GetBool("follow")
 	noColor, _ := cmd.Flags().GetBool("no-color")
+	output, _ := cmd.Flags().GetString("output")
+	archive, _ := cmd.Flags().GetString("diagnostics")
+
+	var opts logQueryOptions
+	opts.unit, _ = cmd.Flags().GetString("unit")
+	opts.minLevel, _ = cmd.Flags().GetString("min-level")
+	opts.maxLevel, _ = cmd.Flags().GetString("max-level")
+	opts.since, _ = cmd.Flags().GetString("since")
+	opts.until, _ = cmd.Flags().GetString("until")
+	opts.fields, _ = cmd.Flags().GetStringArray("field")
+
+	query, err := newLogQuery(opts, time.Now())
+	if err != nil {
+		return err
+	}
 
 	var (
 		filter   filterFunc
@@ -196,16 +212,38 @@ func logsCmd(streams *cli.IOStreams, cmd *cobra.Command) error {
 	if component != "" {
 		filter = createComponentFilter(component)
 	}
+	if !query.empty() {
+		filter = allFilters(filter, query.filter)
+	}
 
-	if !noColor {
-		modifier = addColorModifier
+	switch output {
+	case logOutputRaw:
+		if !noColor {
+			modifier = addColorModifier
+		}
+	case logOutputPretty:
+		modifier = newPrettyModifier(!noColor)
+	case logOutputJSON:
+		modifier = jsonModifier
+	default:
+		return fmt.Errorf("unsupported output %q, expected raw, pretty or json", output)
+	}
+
+	if archive != "" {
+		if follow {
+			return errors.New("logs of a diagnostics archive cannot be followed")
+		}
+		if err := printArchiveLogs(streams.Out, archive, lines, filter, modifier); err != nil {
+			return fmt.Errorf("failed to get logs from diagnostics archive %q: %w", archive, err)
+		}
+		return nil
 	}
 
 	logsDir := filepath.Join(paths.Home(), logger.DefaultLogDirectory)
 	// uncomment for debugging
 	// fmt.Fprintf(streams.Err, "logs dir: %q", logsDir)
 
-	err := printLogs(cmd.Context(), streams.Out, logsDir, lines, follow, filter, modifier)
+	err = printLogs(cmd.Context(), streams.Out, logsDir, lines, follow, filter, modifier)
 	if err != nil {
 		return fmt.Errorf("failed to get logs: %w", err)
 	}
@@ -230,40 +268,18 @@ func printLogs(ctx context.Context, w io.Writer, dir string, lines int, follow b
 		modifier: modifier,
 	}
 
-	var (
-		fileIndex = len(files) - 1
-		printed   = 0
-	)
-
-	buf := make([]byte, logBufferSize)
-
 	// we need to store the file size ASAP before it changes by new lines
 	// but right before we start looking for the last N lines in this file
 	// to minimize likelihood of corrupted output
-	fileToFollow := files[fileIndex]
+	fileToFollow := files[len(files)-1]
 	followOffset, err := getFileSize(fileToFollow)
 	if err != nil {
 		return fmt.Errorf("failed to prepare for watching file %q: %w", fileToFollow, err)
 	}
 
-	// start looking for the N lines among all log files started with the most recent one
-	for {
-		filename := files[fileIndex]
-		// try to read the requested amount of lines from the end of the file
-		justPrinted, err := printLogFile(filename, lines-printed, stackWriter, buf)
-		if err != nil {
-			return fmt.Errorf("failed to print log file %q: %w", filename, err)
-		}
-		// account for what we've read in total, to stop once we reached the given number
-		printed += justPrinted
-		if printed >= lines {
-			break
-		}
-		// if we have not read/printed enough lines, we switch to the previous file and repeat
-		fileIndex--
-		if fileIndex < 0 {
-			break
-		}
+	err = printLastLines(files, openLogFile, lines, stackWriter)
+	if err != nil {
+		return err
 	}
 
 	// all log lines written above were written in LIFO order, we need to invert that
@@ -288,21 +304,152 @@ func printLogs(ctx context.Context, w io.Writer, dir string, lines int, follow b
 	return nil
 }
 
+// printArchiveLogs prints the last `lines` number of log lines from the log files of the diagnostics
+// archive applying the `filter` and printing all the log lines to `w`.
+func printArchiveLogs(w io.Writer, archive string, lines int, filter filterFunc, modifier modifierFunc) error {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return fmt.Errorf("failed to open diagnostics archive: %w", err)
+	}
+	defer zr.Close()
+
+	files := make(map[string]*zip.File)
+	names := make([]string, 0)
+	for _, f := range zr.File {
+		if !strings.HasPrefix(f.Name, "logs/") || f.FileInfo().IsDir() || !logFilePattern.MatchString(f.Name) {
+			continue
+		}
+		files[f.Name] = f
+		names = append(names, f.Name)
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sortLogFilenames(names)
+
+	stackWriter := &stackWriter{
+		filter:   filter,
+		modifier: modifier,
+	}
+	err = printLastLines(names, func(name string) (logReader, error) {
+		f, ok := files[name]
+		if !ok {
+			return nil, fmt.Errorf("log file %q not found in the archive", name)
+		}
+		r, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open log file %q for reading: %w", name, err)
+		}
+		defer r.Close()
+		return readLogFile(name, r)
+	}, lines, stackWriter)
+	if err != nil {
+		return err
+	}
+
+	return stackWriter.PopAll(w)
+}
+
+// printLastLines looks for the last `lines` number of log lines among the log `files`, sorted in the log
+// rotation order, starting with the most recent one. The found lines are written to `w` in LIFO order.
+func printLastLines(files []string, open logOpener, lines int, w *stackWriter) error {
+	buf := make([]byte, logBufferSize)
+	printed := 0
+	for fileIndex := len(files) - 1; fileIndex >= 0; fileIndex-- {
+		filename := files[fileIndex]
+		// try to read the requested amount of lines from the end of the file
+		justPrinted, err := printLogReader(open, filename, lines-printed, w, buf)
+		if err != nil {
+			return fmt.Errorf("failed to print log file %q: %w", filename, err)
+		}
+		// account for what we've read in total, to stop once we reached the given number
+		printed += justPrinted
+		if printed >= lines {
+			break
+		}
+	}
+	return nil
+}
+
+// logReader is a log file read backwards.
+type logReader interface {
+	io.ReaderAt
+	io.Closer
+	Size() int64
+}
+
+// logOpener opens the log file with the given name.
+type logOpener func(name string) (logReader, error)
+
+type fileLogReader struct {
+	*os.File
+	size int64
+}
+
+func (f *fileLogReader) Size() int64 {
+	return f.size
+}
+
+type memLogReader struct {
+	*bytes.Reader
+}
+
+func (memLogReader) Close() error {
+	return nil
+}
+
+// openLogFile opens the log file, gzipped files are decompressed in memory.
+func openLogFile(filename string) (logReader, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file %q for reading: %w", filename, err)
+	}
+	if strings.HasSuffix(filename, ".gz") {
+		defer file.Close()
+		return readLogFile(filename, file)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to stat log file %q: %w", filename, err)
+	}
+	return &fileLogReader{File: file, size: info.Size()}, nil
+}
+
+// readLogFile reads the whole log file in memory, decompressing gzipped files.
+func readLogFile(name string, r io.Reader) (logReader, error) {
+	if strings.HasSuffix(name, ".gz") {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress log file %q: %w", name, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log file %q: %w", name, err)
+	}
+	return memLogReader{bytes.NewReader(content)}, nil
+}
+
 // printLogFile reads the target file defined by the absolute path `filename` backwards in chunks
 // defined by the size of the given `buf`  until it finds enough lines defined by `maxLines`
 // or the whole file is read. Prints all found lines to `w` in LIFO order.
 func printLogFile(filename string, maxLines int, w *stackWriter, buf []byte) (linesWritten int, err error) {
-	file, err := os.Open(filename)
+	return printLogReader(openLogFile, filename, maxLines, w, buf)
+}
+
+// printLogReader is printLogFile reading the file opened with `open`.
+func printLogReader(open logOpener, filename string, maxLines int, w *stackWriter, buf []byte) (linesWritten int, err error) {
+	file, err := open(filename)
 	if err != nil {
-		return 0, fmt.Errorf("failed to open log file %q for reading: %w", filename, err)
+		return 0, err
 	}
 	defer file.Close()
 
-	info, err := file.Stat()
-	if err != nil {
-		return 0, fmt.Errorf("failed to stat log file %q: %w", filename, err)
-	}
-	offset := info.Size()
+	offset := file.Size()
 	bufferSize := int64(len(buf))
 
 	var leftOverBuf []byte
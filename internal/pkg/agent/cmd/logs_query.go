@@ -0,0 +1,366 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+const (
+	logOutputRaw    = "raw"
+	logOutputPretty = "pretty"
+	logOutputJSON   = "json"
+)
+
+// logLevels orders the log levels, the levels logged by the components included.
+var logLevels = map[string]int{
+	"debug":    0,
+	"info":     1,
+	"warn":     2,
+	"warning":  2,
+	"error":    3,
+	"critical": 4,
+	"dpanic":   4,
+	"panic":    4,
+	"fatal":    4,
+}
+
+// fieldCondition matches a field of the log entry, by equality or with a regular expression.
+type fieldCondition struct {
+	field string
+	value string
+	regex *regexp.Regexp
+}
+
+func (c fieldCondition) match(entry map[string]interface{}) bool {
+	v, ok := lookupLogField(entry, c.field)
+	if !ok {
+		return false
+	}
+	s := formatLogField(v)
+	if c.regex != nil {
+		return c.regex.MatchString(s)
+	}
+	return s == c.value
+}
+
+// logQuery selects the log entries by level range, time range and field conditions.
+type logQuery struct {
+	levelRange bool
+	minLevel   int
+	maxLevel   int
+	since      time.Time
+	until      time.Time
+	conditions []fieldCondition
+}
+
+// logQueryOptions are the options of the logs command defining the query.
+type logQueryOptions struct {
+	minLevel string
+	maxLevel string
+	since    string
+	until    string
+	unit     string
+	// fields are conditions in the form name=value, or name~=regex
+	fields []string
+}
+
+// newLogQuery creates the query defined by the options, relative times are relative to now.
+func newLogQuery(opts logQueryOptions, now time.Time) (*logQuery, error) {
+	q := &logQuery{minLevel: 0, maxLevel: logLevels["fatal"]}
+
+	if opts.minLevel != "" {
+		level, ok := logLevels[strings.ToLower(opts.minLevel)]
+		if !ok {
+			return nil, fmt.Errorf("unknown log level %q", opts.minLevel)
+		}
+		q.levelRange = true
+		q.minLevel = level
+	}
+	if opts.maxLevel != "" {
+		level, ok := logLevels[strings.ToLower(opts.maxLevel)]
+		if !ok {
+			return nil, fmt.Errorf("unknown log level %q", opts.maxLevel)
+		}
+		q.levelRange = true
+		q.maxLevel = level
+	}
+	if q.minLevel > q.maxLevel {
+		return nil, fmt.Errorf("minimum log level %s is above the maximum log level %s", opts.minLevel, opts.maxLevel)
+	}
+
+	var err error
+	if opts.since != "" {
+		if q.since, err = parseLogTime(opts.since, now); err != nil {
+			return nil, fmt.Errorf("invalid since: %w", err)
+		}
+	}
+	if opts.until != "" {
+		if q.until, err = parseLogTime(opts.until, now); err != nil {
+			return nil, fmt.Errorf("invalid until: %w", err)
+		}
+	}
+
+	if opts.unit != "" {
+		q.conditions = append(q.conditions, fieldCondition{field: "unit.id", value: opts.unit})
+	}
+	for _, f := range opts.fields {
+		if name, expr, ok := strings.Cut(f, "~="); ok {
+			regex, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regular expression for field %s: %w", name, err)
+			}
+			q.conditions = append(q.conditions, fieldCondition{field: name, regex: regex})
+			continue
+		}
+		name, value, ok := strings.Cut(f, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid field condition %q, expected name=value or name~=regex", f)
+		}
+		q.conditions = append(q.conditions, fieldCondition{field: name, value: value})
+	}
+
+	return q, nil
+}
+
+// empty returns true when the query selects every log entry.
+func (q *logQuery) empty() bool {
+	return !q.levelRange && q.since.IsZero() && q.until.IsZero() && len(q.conditions) == 0
+}
+
+// filter is the filterFunc of the query.
+func (q *logQuery) filter(line []byte) bool {
+	var entry map[string]interface{}
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return false
+	}
+
+	if q.levelRange {
+		raw, _ := lookupLogField(entry, "log.level")
+		level, ok := logLevels[strings.ToLower(formatLogField(raw))]
+		if !ok || level < q.minLevel || level > q.maxLevel {
+			return false
+		}
+	}
+
+	if !q.since.IsZero() || !q.until.IsZero() {
+		raw, _ := lookupLogField(entry, "@timestamp")
+		ts, err := time.Parse(time.RFC3339Nano, formatLogField(raw))
+		if err != nil {
+			return false
+		}
+		if !q.since.IsZero() && ts.Before(q.since) || !q.until.IsZero() && ts.After(q.until) {
+			return false
+		}
+	}
+
+	for _, c := range q.conditions {
+		if !c.match(entry) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseLogTime parses an RFC3339 time, a date, or a duration relative to now like 1h.
+func parseLogTime(s string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, s, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%q is neither an RFC3339 time, a date nor a duration", s)
+}
+
+// allFilters combines the filters, a line is printed when every filter lets it through.
+func allFilters(filters ...filterFunc) filterFunc {
+	var fns []filterFunc
+	for _, f := range filters {
+		if f != nil {
+			fns = append(fns, f)
+		}
+	}
+	switch len(fns) {
+	case 0:
+		return nil
+	case 1:
+		return fns[0]
+	}
+	return func(line []byte) bool {
+		for _, f := range fns {
+			if !f(line) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// lookupLogField returns the value of the field, the field name is matched against both
+// dotted keys ("log.level") and nested objects ("component": {"id": ...}).
+func lookupLogField(entry map[string]interface{}, field string) (interface{}, bool) {
+	if v, ok := entry[field]; ok {
+		return v, true
+	}
+	for i := strings.IndexByte(field, '.'); i >= 0; {
+		if sub, ok := entry[field[:i]].(map[string]interface{}); ok {
+			if v, ok := lookupLogField(sub, field[i+1:]); ok {
+				return v, true
+			}
+		}
+		next := strings.IndexByte(field[i+1:], '.')
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return nil, false
+}
+
+func formatLogField(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(value)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// newPrettyModifier creates a modifier formatting the log entries as
+// "<timestamp> <level> [<component>/<unit>] <message>".
+func newPrettyModifier(colored bool) modifierFunc {
+	return func(line []byte) []byte {
+		var entry map[string]interface{}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return line
+		}
+
+		field := func(name string) string {
+			v, _ := lookupLogField(entry, name)
+			return formatLogField(v)
+		}
+		level := strings.ToUpper(field("log.level"))
+		source := field("component.id")
+		if unit := field("unit.id"); unit != "" {
+			source += "/" + unit
+		}
+
+		var b strings.Builder
+		b.WriteString(field("@timestamp"))
+		b.WriteByte(' ')
+		if colorFn := levelColor(level); colored && colorFn != nil {
+			b.WriteString(colorFn("%-5s", level))
+		} else {
+			fmt.Fprintf(&b, "%-5s", level)
+		}
+		if source != "" {
+			fmt.Fprintf(&b, " [%s]", source)
+		}
+		b.WriteByte(' ')
+		b.WriteString(field("message"))
+		return []byte(b.String())
+	}
+}
+
+// jsonModifier re-encodes the log entries with the dotted keys expanded into objects.
+func jsonModifier(line []byte) []byte {
+	var entry map[string]interface{}
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return line
+	}
+	b, err := json.Marshal(expandDottedKeys(entry))
+	if err != nil {
+		return line
+	}
+	return b
+}
+
+// expandDottedKeys turns the dotted keys into nested objects, the keys conflicting with
+// a value that is not an object are kept dotted.
+func expandDottedKeys(m map[string]interface{}) map[string]interface{} {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	// the shorter keys first, so nested objects are merged into existing ones
+	sort.Slice(keys, func(i, j int) bool { return len(keys[i]) < len(keys[j]) })
+
+	out := make(map[string]interface{}, len(m))
+	for _, k := range keys {
+		v := m[k]
+		if sub, ok := v.(map[string]interface{}); ok {
+			v = expandDottedKeys(sub)
+		}
+
+		parts := strings.Split(k, ".")
+		target := out
+		for _, p := range parts[:len(parts)-1] {
+			next, ok := target[p]
+			if !ok {
+				obj := make(map[string]interface{})
+				target[p] = obj
+				target = obj
+				continue
+			}
+			obj, ok := next.(map[string]interface{})
+			if !ok {
+				target = nil
+				break
+			}
+			target = obj
+		}
+		last := parts[len(parts)-1]
+		if target == nil {
+			out[k] = v
+			continue
+		}
+		if existing, ok := target[last].(map[string]interface{}); ok {
+			if sub, ok := v.(map[string]interface{}); ok {
+				for sk, sv := range sub {
+					existing[sk] = sv
+				}
+				continue
+			}
+		}
+		target[last] = v
+	}
+	return out
+}
+
+// levelColor returns the function coloring the log level, nil for uncolored levels.
+func levelColor(level string) func(format string, a ...interface{}) string {
+	switch strings.ToLower(level) {
+	case "info":
+		return color.CyanString
+	case "warn", "warning":
+		return color.YellowString
+	case "error":
+		return color.RedString
+	case "critical", "dpanic", "panic", "fatal":
+		return color.HiRedString
+	}
+	return nil
+}
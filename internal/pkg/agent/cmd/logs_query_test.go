@@ -0,0 +1,126 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogQuery(t *testing.T) {
+	now := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	const (
+		agentInfo  = `{"log.level":"info","@timestamp":"2024-01-02T10:00:00.000Z","log.logger":"coordinator","message":"Unit state changed"}`
+		unitWarn   = `{"log.level":"warn","@timestamp":"2024-01-02T11:30:00.000Z","message":"File not found","component":{"id":"filestream-default","type":"filestream"},"unit.id":"filestream-default-logs"}`
+		unitError  = `{"log.level":"error","@timestamp":"2024-01-02T11:50:00.000Z","message":"Connection refused","component":{"id":"filestream-default"},"unit":{"id":"filestream-default-logs"},"log.origin":{"file.line":42}}`
+		agentDebug = `{"log.level":"debug","@timestamp":"2024-01-02T11:55:00.000Z","message":"Checkin done"}`
+	)
+	lines := []string{agentInfo, unitWarn, unitError, agentDebug, "not json"}
+
+	cases := []struct {
+		name     string
+		opts     logQueryOptions
+		expected []string
+	}{
+		{
+			name:     "minimum level",
+			opts:     logQueryOptions{minLevel: "warn"},
+			expected: []string{unitWarn, unitError},
+		},
+		{
+			name:     "level range",
+			opts:     logQueryOptions{minLevel: "info", maxLevel: "WARNING"},
+			expected: []string{agentInfo, unitWarn},
+		},
+		{
+			name:     "relative since",
+			opts:     logQueryOptions{since: "20m"},
+			expected: []string{unitError, agentDebug},
+		},
+		{
+			name:     "time range",
+			opts:     logQueryOptions{since: "2024-01-02T11:00:00Z", until: "2024-01-02T11:52:00Z"},
+			expected: []string{unitWarn, unitError},
+		},
+		{
+			name:     "unit ID dotted or nested",
+			opts:     logQueryOptions{unit: "filestream-default-logs"},
+			expected: []string{unitWarn, unitError},
+		},
+		{
+			name:     "field equality",
+			opts:     logQueryOptions{fields: []string{"log.origin.file.line=42"}},
+			expected: []string{unitError},
+		},
+		{
+			name:     "field regex",
+			opts:     logQueryOptions{fields: []string{"message~=(?i)^(file|checkin)"}},
+			expected: []string{unitWarn, agentDebug},
+		},
+		{
+			name:     "all conditions",
+			opts:     logQueryOptions{minLevel: "warn", fields: []string{"component.type=filestream"}},
+			expected: []string{unitWarn},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := newLogQuery(tc.opts, now)
+			require.NoError(t, err)
+			assert.False(t, q.empty())
+
+			var matched []string
+			for _, l := range lines {
+				if q.filter([]byte(l)) {
+					matched = append(matched, l)
+				}
+			}
+			assert.Equal(t, tc.expected, matched)
+		})
+	}
+
+	t.Run("invalid options", func(t *testing.T) {
+		for _, opts := range []logQueryOptions{
+			{minLevel: "verbose"},
+			{minLevel: "error", maxLevel: "info"},
+			{since: "yesterday"},
+			{fields: []string{"message"}},
+			{fields: []string{"message~=("}},
+		} {
+			_, err := newLogQuery(opts, now)
+			assert.Error(t, err, "options %+v", opts)
+		}
+	})
+
+	t.Run("empty query", func(t *testing.T) {
+		q, err := newLogQuery(logQueryOptions{}, now)
+		require.NoError(t, err)
+		assert.True(t, q.empty())
+	})
+}
+
+func TestLogOutputModifiers(t *testing.T) {
+	line := []byte(`{"log.level":"warn","@timestamp":"2024-01-02T11:30:00.000Z","message":"File not found","component":{"id":"filestream-default"},"unit.id":"filestream-default-logs","log.origin":{"file.line":42},"log.logger":"input"}`)
+
+	t.Run("pretty", func(t *testing.T) {
+		assert.Equal(t, "2024-01-02T11:30:00.000Z WARN  [filestream-default/filestream-default-logs] File not found",
+			string(newPrettyModifier(false)(line)))
+		assert.Equal(t, "not json", string(newPrettyModifier(false)([]byte("not json"))))
+	})
+
+	t.Run("json", func(t *testing.T) {
+		assert.JSONEq(t, `{
+			"@timestamp": "2024-01-02T11:30:00.000Z",
+			"message": "File not found",
+			"component": {"id": "filestream-default"},
+			"unit": {"id": "filestream-default-logs"},
+			"log": {"level": "warn", "logger": "input", "origin": {"file": {"line": 42}}}
+		}`, string(jsonModifier(line)))
+	})
+}
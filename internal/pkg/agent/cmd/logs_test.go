@@ -5,7 +5,9 @@
 package cmd
 
 import (
+	"archive/zip"
 	"bytes"
+	"compress/gzip"
 	"context"
 	"fmt"
 	"io"
@@ -539,6 +541,62 @@ func TestPrintLogFile(t *testing.T) {
 	})
 }
 
+func TestPrintRotatedLogs(t *testing.T) {
+	gzipLines := func(t *testing.T, content string) io.Reader {
+		var b bytes.Buffer
+		gz := gzip.NewWriter(&b)
+		_, err := io.WriteString(gz, content)
+		require.NoError(t, err)
+		require.NoError(t, gz.Close())
+		return &b
+	}
+
+	t.Run("gzipped rotated files", func(t *testing.T) {
+		dir := t.TempDir()
+		createFileContent(t, dir, file+".gz", gzipLines(t, generateLines(line1, 1, 10)))
+		createFileContent(t, dir, file1+".gz", gzipLines(t, generateLines(line2, 1, 10)))
+		createFileContent(t, dir, file2, bytes.NewBufferString(generateLines(line3, 1, 3)))
+
+		names, err := getLogFilenames(dir)
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			filepath.Join(dir, file+".gz"),
+			filepath.Join(dir, file1+".gz"),
+			filepath.Join(dir, file2),
+		}, names)
+
+		result := bytes.NewBuffer(nil)
+		err = printLogs(context.Background(), result, dir, 15, false, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, generateLines(line1, 9, 10)+generateLines(line2, 1, 10)+generateLines(line3, 1, 3), result.String())
+	})
+
+	t.Run("diagnostics archive", func(t *testing.T) {
+		archive := filepath.Join(t.TempDir(), "diagnostics.zip")
+		f, err := os.Create(archive)
+		require.NoError(t, err)
+		zw := zip.NewWriter(f)
+		for _, tf := range []testFile{
+			{name: "logs/elastic-agent-abcdef/" + file, content: generateLines(line1, 1, 5)},
+			{name: "logs/elastic-agent-abcdef/" + file1, content: generateLines(line2, 1, 5)},
+			{name: "logs/elastic-agent-abcdef/events/elastic-agent-event-log-20230530.ndjson", content: "excluded\n"},
+			{name: "state.yaml", content: "excluded\n"},
+		} {
+			w, err := zw.Create(tf.name)
+			require.NoError(t, err)
+			_, err = io.WriteString(w, tf.content)
+			require.NoError(t, err)
+		}
+		require.NoError(t, zw.Close())
+		require.NoError(t, f.Close())
+
+		result := bytes.NewBuffer(nil)
+		err = printArchiveLogs(result, archive, 7, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, generateLines(line1, 4, 5)+generateLines(line2, 1, 5), result.String())
+	})
+}
+
 func TestColorModifier(t *testing.T) {
 	t.Skip() // remove if you want to see examples of the messages on your terminal
 	cases := []struct {
@@ -0,0 +1,101 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/elastic/elastic-agent/pkg/control"
+	"github.com/elastic/elastic-agent/pkg/control/v2/client"
+	"github.com/elastic/elastic-agent/pkg/utils"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/errors"
+	"github.com/elastic/elastic-agent/internal/pkg/cli"
+)
+
+const (
+	flagRollbackTo   = "to"
+	flagRollbackList = "list"
+)
+
+func newRollbackCommandWithArgs(_ []string, streams *cli.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rollback",
+		Short: "Roll back the currently installed Elastic Agent to a previously installed version",
+		Long: `This command switches the currently installed Elastic Agent back to a version still present on disk.
+Without --to the most recent installed version older than the running one is used.`,
+		Args: cobra.NoArgs,
+		Run: func(c *cobra.Command, args []string) {
+			if err := rollbackCmd(streams, c); err != nil {
+				fmt.Fprintf(streams.Err, "Error: %v\n%s\n", err, troubleshootMessage())
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().String(flagRollbackTo, "", "Version or hash of an installed Elastic Agent older than the running one to roll back to")
+	cmd.Flags().Bool(flagRollbackList, false, "List the installed versions available for rollback")
+
+	return cmd
+}
+
+func rollbackCmd(streams *cli.IOStreams, cmd *cobra.Command) error {
+	if list, _ := cmd.Flags().GetBool(flagRollbackList); list {
+		versions, err := upgrade.ListInstalledVersions(paths.Top())
+		if err != nil {
+			return errors.New(err, "failed to list installed versions")
+		}
+		return printInstalledVersions(streams.Out, versions)
+	}
+
+	version, _ := cmd.Flags().GetString(flagRollbackTo)
+
+	c := client.New()
+	err := c.Connect(context.Background())
+	if err != nil {
+		return errors.New(err, "Failed communicating to running daemon", errors.TypeNetwork, errors.M("socket", control.Address()))
+	}
+	defer c.Disconnect()
+
+	isBeingUpgraded, err := upgrade.IsInProgress(c, utils.GetWatcherPIDs)
+	if err != nil {
+		return fmt.Errorf("failed to check if upgrade is already in progress: %w", err)
+	}
+	if isBeingUpgraded {
+		return errors.New("an upgrade is in progress; please try again later.")
+	}
+
+	version, err = c.Rollback(context.Background(), version)
+	if err != nil {
+		return errors.New(err, "Failed trigger rollback of daemon")
+	}
+	fmt.Fprintf(streams.Out, "Rollback triggered to version %s, Elastic Agent is currently restarting\n", version)
+	return nil
+}
+
+func printInstalledVersions(w io.Writer, versions []upgrade.InstalledVersion) error {
+	tw := tabwriter.NewWriter(w, 4, 1, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tHASH\tVERSIONED HOME\tCURRENT")
+	for _, v := range versions {
+		version := v.Version
+		if version == "" {
+			version = "unknown"
+		}
+		current := ""
+		if v.Current {
+			current = "*"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", version, v.Hash, v.VersionedHome, current)
+	}
+	return tw.Flush()
+}
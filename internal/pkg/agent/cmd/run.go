@@ -47,6 +47,7 @@ import (
 	"github.com/elastic/elastic-agent/internal/pkg/config"
 	monitoringCfg "github.com/elastic/elastic-agent/internal/pkg/core/monitoring/config"
 	"github.com/elastic/elastic-agent/internal/pkg/diagnostics"
+	"github.com/elastic/elastic-agent/internal/pkg/diagnostics/profiler"
 	"github.com/elastic/elastic-agent/internal/pkg/release"
 	"github.com/elastic/elastic-agent/pkg/component"
 	"github.com/elastic/elastic-agent/pkg/control/v2/server"
@@ -236,6 +237,14 @@ func runElasticAgent(ctx context.Context, cancel context.CancelFunc, override cf
 		l.Error(errors.New(err, "failed to invoke rollback watcher"))
 	}
 
+	// remove the versions and downloads left over by previous upgrades
+	if paths.RunningInstalled() {
+		if err := upgrade.EnforceRetention(l, paths.Top(), paths.VersionedHome(paths.Top()), cfg.Settings.DownloadConfig.TargetDirectory, cfg.Settings.Upgrade.Retention); err != nil {
+			// we should not fail because old versions cannot be cleaned up
+			l.Warnw("Failed to enforce upgrade retention policy", "error.message", err)
+		}
+	}
+
 	execPath, err := reexecPath()
 	if err != nil {
 		return err
@@ -327,6 +336,12 @@ func runElasticAgent(ctx context.Context, cancel context.CancelFunc, override cf
 		appErr <- err
 	}()
 
+	// Sample the profiles of the agent and of the components continuously, if enabled,
+	// not in testing mode or as an OTel collector
+	if !testingMode && !runAsOtel {
+		go profiler.New(l.Named("diagnostics"), coord, cfg.Settings.MonitoringConfig.Diagnostics.Profiling).Run(ctx)
+	}
+
 	// listen for signals
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGHUP)
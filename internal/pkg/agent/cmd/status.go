@@ -20,7 +20,9 @@ import (
 
 	"gopkg.in/yaml.v2"
 
+	"github.com/docker/go-units"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 
 	"github.com/jedib0t/go-pretty/v6/list"
 
@@ -51,6 +53,7 @@ func newStatusCommand(_ []string, streams *cli.IOStreams) *cobra.Command {
 	}
 
 	cmd.Flags().String("output", "human", "Output the status information in either 'human', 'full', 'json', or 'yaml'.  'human' only shows non-healthy details, others show full details. (default: human)")
+	cmd.Flags().Bool("watch", false, "Watch the status as it changes. On a terminal the full status is rendered with the changes highlighted, otherwise the state transitions are printed as JSON lines.")
 
 	return cmd
 }
@@ -63,6 +66,12 @@ func statusCmd(streams *cli.IOStreams, cmd *cobra.Command, args []string) error
 	}
 
 	ctx := handleSignal(context.Background())
+	if watch, _ := cmd.Flags().GetBool("watch"); watch {
+		out, ok := streams.Out.(*os.File)
+		tty := ok && term.IsTerminal(int(out.Fd()))
+		return watchStatus(ctx, streams.Out, func() client.Client { return client.New() }, tty, statusWatchRetryInterval)
+	}
+
 	innerCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
@@ -151,6 +160,35 @@ func listAgentState(l list.Writer, state *client.AgentState, all bool) {
 
 	// Upgrade details
 	listUpgradeDetails(l, state.UpgradeDetails)
+
+	listQueuedActions(l, state.QueuedActions)
+
+	if all {
+		listDiskUsage(l, state.DiskUsage)
+	}
+}
+
+func listDiskUsage(l list.Writer, diskUsage *cproto.DiskUsage) {
+	if diskUsage == nil {
+		return
+	}
+
+	l.AppendItem("disk_usage")
+	l.Indent()
+	for _, v := range diskUsage.Versions {
+		version := v.Version
+		if version == "" {
+			version = "unknown"
+		}
+		item := fmt.Sprintf("%s (%s): %s", version, v.Hash, units.BytesSize(float64(v.Size)))
+		if v.Current {
+			item += " [current]"
+		}
+		l.AppendItem(item)
+	}
+	l.AppendItem("downloads: " + units.BytesSize(float64(diskUsage.Downloads)))
+	l.AppendItem("total: " + units.BytesSize(float64(diskUsage.Total)))
+	l.UnIndent()
 }
 
 func listUpgradeDetails(l list.Writer, upgradeDetails *cproto.UpgradeDetails) {
@@ -193,6 +231,29 @@ func listUpgradeDetails(l list.Writer, upgradeDetails *cproto.UpgradeDetails) {
 	l.UnIndent()
 }
 
+func listQueuedActions(l list.Writer, actions []*cproto.QueuedAction) {
+	if len(actions) == 0 {
+		return
+	}
+
+	l.AppendItem("queued_actions")
+	l.Indent()
+	for _, a := range actions {
+		l.AppendItem(a.Id)
+		l.Indent()
+		l.AppendItem("type: " + a.Type)
+		l.AppendItem("start_time: " + a.StartTime)
+		if a.Expiration != "" {
+			l.AppendItem("expiration: " + a.Expiration)
+		}
+		if a.RetryAttempt > 0 {
+			l.AppendItem(fmt.Sprintf("retry_attempt: %d", a.RetryAttempt))
+		}
+		l.UnIndent()
+	}
+	l.UnIndent()
+}
+
 func listFleetState(l list.Writer, state *client.AgentState, all bool) {
 	l.AppendItem("fleet")
 	l.Indent()
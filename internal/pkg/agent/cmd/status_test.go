@@ -254,6 +254,44 @@ func TestListUpgradeDetails(t *testing.T) {
 	}
 }
 
+func TestListDiskUsage(t *testing.T) {
+	l := list.NewWriter()
+	l.SetStyle(list.StyleConnectedLight)
+
+	listDiskUsage(l, &cproto.DiskUsage{
+		Versions: []*cproto.VersionDiskUsage{
+			{Version: "8.14.0", Hash: "abcdef", Current: true, Size: 512 * 1024 * 1024},
+			{Version: "8.13.0", Hash: "123456", Size: 256 * 1024 * 1024},
+		},
+		Downloads: 1024,
+		Total:     768*1024*1024 + 1024,
+	})
+	require.Equal(t, `── disk_usage
+   ├─ 8.14.0 (abcdef): 512MiB [current]
+   ├─ 8.13.0 (123456): 256MiB
+   ├─ downloads: 1KiB
+   └─ total: 768MiB`, l.Render())
+}
+
+func TestListQueuedActions(t *testing.T) {
+	l := list.NewWriter()
+	l.SetStyle(list.StyleConnectedLight)
+
+	listQueuedActions(l, []*cproto.QueuedAction{
+		{Id: "action-1", Type: "UPGRADE", StartTime: "2024-01-01T00:00:00Z", Expiration: "2024-01-02T00:00:00Z"},
+		{Id: "action-2", Type: "POLICY_REASSIGN", StartTime: "2024-01-01T01:00:00Z", RetryAttempt: 2},
+	})
+	require.Equal(t, `── queued_actions
+   ├─ action-1
+   │  ├─ type: UPGRADE
+   │  ├─ start_time: 2024-01-01T00:00:00Z
+   │  └─ expiration: 2024-01-02T00:00:00Z
+   └─ action-2
+      ├─ type: POLICY_REASSIGN
+      ├─ start_time: 2024-01-01T01:00:00Z
+      └─ retry_attempt: 2`, l.Render())
+}
+
 func TestHumanDurationUntil(t *testing.T) {
 	now := time.Now()
 	cases := map[string]struct {
@@ -0,0 +1,311 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/list"
+
+	"github.com/elastic/elastic-agent/pkg/control/v2/client"
+)
+
+const (
+	// statusWatchRetryInterval is the interval between connection attempts while the Elastic Agent is not running.
+	statusWatchRetryInterval = time.Second
+	// statusWatchRefreshInterval is the interval the terminal view is rendered at to keep the durations current.
+	statusWatchRefreshInterval = time.Second
+
+	ansiClearScreen = "\x1b[H\x1b[2J"
+	ansiHighlight   = "\x1b[1;33m"
+	ansiReset       = "\x1b[0m"
+)
+
+// statusTransition is a change of state printed as a JSON line when the output is not a terminal.
+// To is empty when the agent stops reporting the entry, like a removed component or a finished upgrade.
+type statusTransition struct {
+	Timestamp   time.Time `json:"@timestamp"`
+	Kind        string    `json:"kind"`
+	ComponentID string    `json:"component_id,omitempty"`
+	UnitID      string    `json:"unit_id,omitempty"`
+	From        string    `json:"from,omitempty"`
+	To          string    `json:"to"`
+	Message     string    `json:"message,omitempty"`
+}
+
+// watchedEntry is the state of the agent, Fleet, the upgrade, a component or a unit.
+type watchedEntry struct {
+	kind        string
+	componentID string
+	unitID      string
+	state       string
+	message     string
+
+	// since is the time of the last state change
+	since time.Time
+	// changed is set when the state or message changed with the last received state
+	changed bool
+}
+
+func (e *watchedEntry) key() string {
+	return e.kind + "/" + e.componentID + "/" + e.unitID
+}
+
+// statusWatchView tracks the changes of the states received from the running Elastic Agent.
+type statusWatchView struct {
+	state     *client.AgentState
+	updated   time.Time
+	connected bool
+	entries   map[string]*watchedEntry
+}
+
+// watchStatus renders the state of the running Elastic Agent as it changes, until ctx is cancelled. On a terminal
+// the whole state is rendered, highlighting the changes, otherwise only the state transitions are written as
+// JSON lines. Connection failures are retried, the Elastic Agent can restart while watched.
+func watchStatus(ctx context.Context, w io.Writer, newClient func() client.Client, tty bool, retryInterval time.Duration) error {
+	type event struct {
+		state *client.AgentState
+		err   error
+	}
+	events := make(chan event)
+	go func() {
+		for {
+			err := recvStates(ctx, newClient, func(state *client.AgentState) {
+				select {
+				case events <- event{state: state}:
+				case <-ctx.Done():
+				}
+			})
+			if ctx.Err() != nil {
+				return
+			}
+			select {
+			case events <- event{err: err}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(retryInterval):
+			}
+		}
+	}()
+
+	var refresh <-chan time.Time
+	if tty {
+		ticker := time.NewTicker(statusWatchRefreshInterval)
+		defer ticker.Stop()
+		refresh = ticker.C
+	}
+
+	view := &statusWatchView{}
+	for {
+		now := time.Now()
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-refresh:
+		case e := <-events:
+			if e.err != nil {
+				view.connected = false
+				break
+			}
+			view.connected = true
+			transitions := view.update(e.state, now)
+			if !tty {
+				if err := writeTransitions(w, transitions); err != nil {
+					return err
+				}
+			}
+		}
+		if tty {
+			if err := view.render(w, now); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// recvStates passes every state received from the running Elastic Agent to handle, until the connection fails.
+func recvStates(ctx context.Context, newClient func() client.Client, handle func(*client.AgentState)) error {
+	c := newClient()
+	if err := c.Connect(ctx); err != nil {
+		return err
+	}
+	defer c.Disconnect()
+
+	watch, err := c.StateWatch(ctx)
+	if err != nil {
+		return err
+	}
+
+	for {
+		state, err := watch.Recv()
+		if err != nil {
+			return err
+		}
+		handle(state)
+	}
+}
+
+// update tracks the changes of state and returns the state transitions, the first state is reported as
+// transitions from an empty state.
+func (v *statusWatchView) update(state *client.AgentState, now time.Time) []statusTransition {
+	sort.SliceStable(state.Components, func(i, j int) bool { return state.Components[i].ID < state.Components[j].ID })
+	for _, c := range state.Components {
+		sort.SliceStable(c.Units, func(i, j int) bool { return c.Units[i].UnitID < c.Units[j].UnitID })
+	}
+
+	first := v.entries == nil
+	prev := v.entries
+	v.entries = make(map[string]*watchedEntry)
+	v.state = state
+	v.updated = now
+
+	var transitions []statusTransition
+	for _, e := range watchedEntries(state) {
+		p, ok := prev[e.key()]
+		switch {
+		case !ok || p.state != e.state:
+			from := ""
+			if ok {
+				from = p.state
+			}
+			e.since = now
+			e.changed = !first
+			transitions = append(transitions, statusTransition{
+				Timestamp:   now.UTC(),
+				Kind:        e.kind,
+				ComponentID: e.componentID,
+				UnitID:      e.unitID,
+				From:        from,
+				To:          e.state,
+				Message:     e.message,
+			})
+		default:
+			e.since = p.since
+			e.changed = p.message != e.message
+		}
+		v.entries[e.key()] = e
+	}
+
+	removed := make([]*watchedEntry, 0)
+	for key, p := range prev {
+		if _, ok := v.entries[key]; !ok {
+			removed = append(removed, p)
+		}
+	}
+	sort.Slice(removed, func(i, j int) bool { return removed[i].key() < removed[j].key() })
+	for _, p := range removed {
+		transitions = append(transitions, statusTransition{
+			Timestamp:   now.UTC(),
+			Kind:        p.kind,
+			ComponentID: p.componentID,
+			UnitID:      p.unitID,
+			From:        p.state,
+		})
+	}
+	return transitions
+}
+
+// watchedEntries returns the entries of the state, in rendering order.
+func watchedEntries(state *client.AgentState) []*watchedEntry {
+	entries := []*watchedEntry{
+		{kind: "fleet", state: state.FleetState.String(), message: state.FleetMessage},
+		{kind: "agent", state: state.State.String(), message: state.Message},
+	}
+	for _, c := range state.Components {
+		entries = append(entries, &watchedEntry{kind: "component", componentID: c.ID, state: c.State.String(), message: c.Message})
+		for _, u := range c.Units {
+			entries = append(entries, &watchedEntry{kind: "unit", componentID: c.ID, unitID: u.UnitID, state: u.State.String(), message: u.Message})
+		}
+	}
+	if state.UpgradeDetails != nil {
+		message := ""
+		if state.UpgradeDetails.Metadata != nil {
+			message = state.UpgradeDetails.Metadata.ErrorMsg
+		}
+		entries = append(entries, &watchedEntry{kind: "upgrade", state: state.UpgradeDetails.State, message: message})
+	}
+	return entries
+}
+
+func writeTransitions(w io.Writer, transitions []statusTransition) error {
+	enc := json.NewEncoder(w)
+	for _, t := range transitions {
+		if err := enc.Encode(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// render clears the terminal and renders the last received state, the entries changed by that state highlighted.
+func (v *statusWatchView) render(w io.Writer, now time.Time) error {
+	var b bytes.Buffer
+	b.WriteString(ansiClearScreen)
+	switch {
+	case !v.connected && v.state == nil:
+		b.WriteString("Waiting for the Elastic Agent daemon to be running... (press Ctrl+C to stop)\n")
+	case !v.connected:
+		fmt.Fprintf(&b, "Connection to the Elastic Agent daemon lost %s ago, reconnecting... (press Ctrl+C to stop)\n\n", now.Sub(v.updated).Round(time.Second))
+	default:
+		fmt.Fprintf(&b, "Last state change %s ago (press Ctrl+C to stop)\n\n", now.Sub(v.updated).Round(time.Second))
+	}
+
+	if v.state != nil {
+		l := list.NewWriter()
+		l.SetStyle(list.StyleConnectedLight)
+		l.SetOutputMirror(&b)
+
+		l.AppendItem("fleet")
+		l.Indent()
+		l.AppendItem(v.statusItem(&watchedEntry{kind: "fleet"}, now))
+		l.UnIndent()
+
+		l.AppendItem("elastic-agent")
+		l.Indent()
+		l.AppendItem(v.statusItem(&watchedEntry{kind: "agent"}, now))
+		for _, c := range v.state.Components {
+			l.AppendItem(c.ID)
+			l.Indent()
+			l.AppendItem(v.statusItem(&watchedEntry{kind: "component", componentID: c.ID}, now))
+			for _, u := range c.Units {
+				l.AppendItem(u.UnitID)
+				l.Indent()
+				l.AppendItem(v.statusItem(&watchedEntry{kind: "unit", componentID: c.ID, unitID: u.UnitID}, now))
+				l.UnIndent()
+			}
+			l.UnIndent()
+		}
+		l.UnIndent()
+
+		listUpgradeDetails(l, v.state.UpgradeDetails)
+		_ = l.Render()
+	}
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// statusItem formats the status of the entry identified by id, with the time since its last state change.
+func (v *statusWatchView) statusItem(id *watchedEntry, now time.Time) string {
+	e, ok := v.entries[id.key()]
+	if !ok {
+		return "status: unknown"
+	}
+	item := fmt.Sprintf("status: (%s) %s [%s]", e.state, e.message, now.Sub(e.since).Round(time.Second))
+	if e.changed {
+		return ansiHighlight + item + ansiReset
+	}
+	return item
+}
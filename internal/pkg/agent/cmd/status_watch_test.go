@@ -0,0 +1,147 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/pkg/control/v2/client"
+	"github.com/elastic/elastic-agent/pkg/control/v2/client/mocks"
+	"github.com/elastic/elastic-agent/pkg/control/v2/cproto"
+)
+
+type fakeStateWatch struct {
+	states []*client.AgentState
+	done   func()
+}
+
+func (f *fakeStateWatch) Recv() (*client.AgentState, error) {
+	if len(f.states) == 0 {
+		f.done()
+		return nil, errors.New("connection closed")
+	}
+	state := f.states[0]
+	f.states = f.states[1:]
+	return state, nil
+}
+
+func agentState(state client.State, components ...client.ComponentState) *client.AgentState {
+	return &client.AgentState{
+		State:      state,
+		FleetState: client.Healthy,
+		Components: components,
+	}
+}
+
+func componentState(id string, state client.State, message string) client.ComponentState {
+	return client.ComponentState{
+		ID:      id,
+		State:   state,
+		Message: message,
+		Units: []client.ComponentUnitState{
+			{UnitID: id + "-unit", UnitType: client.UnitTypeInput, State: state, Message: message},
+		},
+	}
+}
+
+func TestStatusWatchViewUpdate(t *testing.T) {
+	now := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	v := &statusWatchView{}
+
+	transitions := v.update(agentState(client.Healthy, componentState("filestream-default", client.Healthy, "Healthy")), now)
+	assert.Equal(t, []statusTransition{
+		{Timestamp: now, Kind: "fleet", To: "HEALTHY"},
+		{Timestamp: now, Kind: "agent", To: "HEALTHY"},
+		{Timestamp: now, Kind: "component", ComponentID: "filestream-default", To: "HEALTHY", Message: "Healthy"},
+		{Timestamp: now, Kind: "unit", ComponentID: "filestream-default", UnitID: "filestream-default-unit", To: "HEALTHY", Message: "Healthy"},
+	}, transitions)
+
+	later := now.Add(time.Minute)
+	state := agentState(client.Degraded, componentState("filestream-default", client.Degraded, "file not found"))
+	state.UpgradeDetails = &cproto.UpgradeDetails{TargetVersion: "8.14.0", State: "UPG_DOWNLOADING"}
+	transitions = v.update(state, later)
+	assert.Equal(t, []statusTransition{
+		{Timestamp: later, Kind: "agent", From: "HEALTHY", To: "DEGRADED"},
+		{Timestamp: later, Kind: "component", ComponentID: "filestream-default", From: "HEALTHY", To: "DEGRADED", Message: "file not found"},
+		{Timestamp: later, Kind: "unit", ComponentID: "filestream-default", UnitID: "filestream-default-unit", From: "HEALTHY", To: "DEGRADED", Message: "file not found"},
+		{Timestamp: later, Kind: "upgrade", To: "UPG_DOWNLOADING"},
+	}, transitions)
+	assert.Equal(t, now, v.entries["fleet//"].since, "unchanged entries keep the time of their last change")
+	assert.False(t, v.entries["fleet//"].changed)
+	assert.True(t, v.entries["agent//"].changed)
+
+	transitions = v.update(agentState(client.Degraded), later)
+	assert.Equal(t, []statusTransition{
+		{Timestamp: later, Kind: "component", ComponentID: "filestream-default", From: "DEGRADED"},
+		{Timestamp: later, Kind: "unit", ComponentID: "filestream-default", UnitID: "filestream-default-unit", From: "DEGRADED"},
+		{Timestamp: later, Kind: "upgrade", From: "UPG_DOWNLOADING"},
+	}, transitions)
+}
+
+func TestWatchStatus(t *testing.T) {
+	newClient := func(cancel context.CancelFunc, states ...*client.AgentState) func() client.Client {
+		return func() client.Client {
+			c := mocks.NewClient(t)
+			c.EXPECT().Connect(mock.Anything).Return(nil)
+			// the connection can still be closing when the watch returns
+			c.EXPECT().Disconnect().Return().Maybe()
+			c.EXPECT().StateWatch(mock.Anything).Return(&fakeStateWatch{states: states, done: cancel}, nil)
+			return c
+		}
+	}
+
+	t.Run("transitions as JSON lines", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var out bytes.Buffer
+		err := watchStatus(ctx, &out, newClient(cancel,
+			agentState(client.Healthy),
+			agentState(client.Healthy),
+			agentState(client.Failed),
+		), false, time.Millisecond)
+		require.NoError(t, err)
+
+		var transitions []statusTransition
+		scanner := bufio.NewScanner(&out)
+		for scanner.Scan() {
+			var transition statusTransition
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &transition))
+			transitions = append(transitions, transition)
+		}
+		require.Len(t, transitions, 3, "the repeated state must not be reported")
+		assert.Equal(t, "HEALTHY", transitions[2].From)
+		assert.Equal(t, "FAILED", transitions[2].To)
+	})
+
+	t.Run("terminal view", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var out bytes.Buffer
+		err := watchStatus(ctx, &out, newClient(cancel,
+			agentState(client.Healthy, componentState("filestream-default", client.Healthy, "Healthy")),
+			agentState(client.Healthy, componentState("filestream-default", client.Failed, "crashed")),
+		), true, time.Millisecond)
+		require.NoError(t, err)
+
+		screens := strings.Split(out.String(), ansiClearScreen)
+		last := screens[len(screens)-1]
+		assert.Contains(t, last, "filestream-default")
+		assert.Contains(t, last, ansiHighlight+"status: (FAILED) crashed [0s]"+ansiReset)
+		assert.Contains(t, last, "status: (HEALTHY)  [0s]", "unchanged entries are not highlighted")
+	})
+}
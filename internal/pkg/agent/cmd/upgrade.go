@@ -28,14 +28,17 @@ const (
 	flagPGPBytes       = "pgp"
 	flagPGPBytesPath   = "pgp-path"
 	flagPGPBytesURI    = "pgp-uri"
+	flagDetach         = "detach"
 )
 
 func newUpgradeCommandWithArgs(_ []string, streams *cli.IOStreams) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "upgrade <version>",
 		Short: "Upgrade the currently installed Elastic Agent to the specified version",
-		Long:  "This command upgrades the currently installed Elastic Agent to the specified version.",
-		Args:  cobra.ExactArgs(1),
+		Long: `This command upgrades the currently installed Elastic Agent to the specified version.
+It shows the upgrade progress and waits until the upgraded Elastic Agent has been confirmed by the upgrade watcher,
+use --detach to return as soon as the upgrade has been triggered.`,
+		Args: cobra.ExactArgs(1),
 		Run: func(c *cobra.Command, args []string) {
 			if err := upgradeCmd(streams, c, args); err != nil {
 				fmt.Fprintf(streams.Err, "Error: %v\n%s\n", err, troubleshootMessage())
@@ -50,6 +53,7 @@ func newUpgradeCommandWithArgs(_ []string, streams *cli.IOStreams) *cobra.Comman
 	cmd.Flags().String(flagPGPBytes, "", "PGP to use for package verification")
 	cmd.Flags().String(flagPGPBytesURI, "", "Path to a web location containing PGP to use for package verification")
 	cmd.Flags().String(flagPGPBytesPath, "", "Path to a file containing PGP to use for package verification")
+	cmd.Flags().Bool(flagDetach, false, "Return once the upgrade is triggered instead of waiting for it to complete")
 
 	return cmd
 }
@@ -104,10 +108,37 @@ func upgradeCmd(streams *cli.IOStreams, cmd *cobra.Command, args []string) error
 		}
 	}
 	skipDefaultPgp, _ := cmd.Flags().GetBool(flagSkipDefaultPgp)
-	version, err = c.Upgrade(context.Background(), version, sourceURI, skipVerification, skipDefaultPgp, pgpChecks...)
+	if detach, _ := cmd.Flags().GetBool(flagDetach); detach {
+		version, err = c.Upgrade(context.Background(), version, sourceURI, skipVerification, skipDefaultPgp, pgpChecks...)
+		if err != nil {
+			return errors.New(err, "Failed trigger upgrade of daemon")
+		}
+		fmt.Fprintf(streams.Out, "Upgrade triggered to version %s, Elastic Agent is currently restarting\n", version)
+		return nil
+	}
+
+	// the upgrade RPC returns once the new version is in place, watch the progress from the start
+	ctx, cancel := context.WithCancel(handleSignal(context.Background()))
+	defer cancel()
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- watchUpgrade(ctx, streams.Out, func() client.Client { return client.New() }, upgradeWatchRetryInterval)
+	}()
+
+	_, err = c.Upgrade(ctx, version, sourceURI, skipVerification, skipDefaultPgp, pgpChecks...)
 	if err != nil {
+		cancel()
+		<-watchErr
 		return errors.New(err, "Failed trigger upgrade of daemon")
 	}
-	fmt.Fprintf(streams.Out, "Upgrade triggered to version %s, Elastic Agent is currently restarting\n", version)
+
+	err = <-watchErr
+	if errors.Is(err, context.Canceled) {
+		return errUpgradeWatchCancelled
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(streams.Out, "Elastic Agent upgraded to version %s\n", version)
 	return nil
 }
@@ -0,0 +1,153 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/schollz/progressbar/v3"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/details"
+	"github.com/elastic/elastic-agent/pkg/control/v2/client"
+	"github.com/elastic/elastic-agent/pkg/control/v2/cproto"
+)
+
+// upgradeWatchRetryInterval is the interval between connection attempts while the Elastic Agent restarts.
+const upgradeWatchRetryInterval = time.Second
+
+var errUpgradeWatchCancelled = errors.New("stopped watching the upgrade, the upgrade continues in the background")
+
+var upgradeStateDescriptions = map[details.State]string{
+	details.StateRequested:   "Upgrade requested",
+	details.StateScheduled:   "Upgrade scheduled",
+	details.StateDownloading: "Downloading",
+	details.StateExtracting:  "Extracting artifact",
+	details.StatePrepared:    "Upgrade prepared",
+	details.StateReplacing:   "Replacing Elastic Agent",
+	details.StateRestarting:  "Restarting Elastic Agent",
+	details.StateWatching:    "Watching upgraded Elastic Agent",
+	details.StateRollback:    "Rolling back",
+	details.StateFailed:      "Upgrade failed",
+}
+
+// watchUpgrade renders the progress of the ongoing upgrade until the upgrade watcher confirms the upgrade, or
+// the upgrade fails or is rolled back. Connection failures, expected while the Elastic Agent restarts, are retried
+// until ctx is cancelled.
+func watchUpgrade(ctx context.Context, w io.Writer, newClient func() client.Client, retryInterval time.Duration) error {
+	bar := progressbar.NewOptions(100,
+		progressbar.OptionSetWriter(w),
+		progressbar.OptionSetDescription("Waiting for upgrade to start"),
+		progressbar.OptionSetPredictTime(false),
+		progressbar.OptionSetWidth(30),
+	)
+	defer func() {
+		_ = bar.Finish()
+		fmt.Fprintln(w)
+	}()
+
+	// the upgrade is complete once upgrade details are cleared after the upgraded agent has been watched,
+	// details are also empty before the upgrade starts and while the upgraded agent starts
+	watching := false
+	// details of a previous failed or rolled back upgrade are reported until this upgrade starts
+	started := false
+	for {
+		done, err := recvUpgradeDetails(ctx, newClient, func(det *cproto.UpgradeDetails) (bool, error) {
+			if det == nil {
+				if watching {
+					bar.Describe("Upgrade completed")
+					_ = bar.Set(100)
+					return true, nil
+				}
+				return false, nil
+			}
+
+			state := details.State(det.State)
+			if state != details.StateFailed && state != details.StateRollback {
+				started = true
+			} else if !started {
+				return false, nil
+			}
+
+			description, ok := upgradeStateDescriptions[state]
+			if !ok {
+				description = det.State
+			}
+
+			switch state {
+			case details.StateDownloading:
+				if det.Metadata != nil {
+					description = fmt.Sprintf("%s (%s/s)", description, units.BytesSize(det.Metadata.DownloadRate))
+					// the bar finishes once full, keep it below 100% until the upgrade completes
+					_ = bar.Set(min(int(det.Metadata.DownloadPercent*100), 99))
+					// the artifact is verified once downloaded, before the upgrade moves to the next state
+					if det.Metadata.DownloadPercent >= 1 {
+						description = "Verifying artifact"
+					}
+				}
+			case details.StateWatching:
+				watching = true
+			case details.StateFailed:
+				bar.Describe(description)
+				if det.Metadata != nil {
+					return true, fmt.Errorf("upgrade to version %s failed in state %s: %s", det.TargetVersion, det.Metadata.FailedState, det.Metadata.ErrorMsg)
+				}
+				return true, fmt.Errorf("upgrade to version %s failed", det.TargetVersion)
+			case details.StateRollback:
+				bar.Describe("Upgrade rolled back")
+				if det.Metadata != nil && det.Metadata.ErrorMsg != "" {
+					return true, fmt.Errorf("upgrade to version %s rolled back: %s", det.TargetVersion, det.Metadata.ErrorMsg)
+				}
+				return true, fmt.Errorf("upgrade to version %s rolled back", det.TargetVersion)
+			}
+			bar.Describe(description)
+			return false, nil
+		})
+		if done {
+			return err
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// connection lost, the Elastic Agent is most likely restarting
+		bar.Describe("Waiting for Elastic Agent to restart")
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+// recvUpgradeDetails passes every upgrade details received from the running Elastic Agent to handle until it
+// reports being done. It returns false when the connection to the Elastic Agent failed.
+func recvUpgradeDetails(ctx context.Context, newClient func() client.Client, handle func(*cproto.UpgradeDetails) (bool, error)) (bool, error) {
+	c := newClient()
+	if err := c.Connect(ctx); err != nil {
+		return false, err
+	}
+	defer c.Disconnect()
+
+	watch, err := c.UpgradeWatch(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	for {
+		det, err := watch.Recv()
+		if err != nil {
+			return false, err
+		}
+		if done, err := handle(det); done {
+			return true, err
+		}
+	}
+}
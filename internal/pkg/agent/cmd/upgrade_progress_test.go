@@ -0,0 +1,133 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/details"
+	"github.com/elastic/elastic-agent/pkg/control/v2/client"
+	"github.com/elastic/elastic-agent/pkg/control/v2/client/mocks"
+	"github.com/elastic/elastic-agent/pkg/control/v2/cproto"
+)
+
+type fakeUpgradeWatch struct {
+	details []*cproto.UpgradeDetails
+	err     error
+}
+
+func (f *fakeUpgradeWatch) Recv() (*cproto.UpgradeDetails, error) {
+	if len(f.details) == 0 {
+		return nil, f.err
+	}
+	det := f.details[0]
+	f.details = f.details[1:]
+	return det, nil
+}
+
+func upgradeDetails(state details.State, errorMsg string) *cproto.UpgradeDetails {
+	return &cproto.UpgradeDetails{
+		TargetVersion: "8.14.0",
+		State:         string(state),
+		Metadata:      &cproto.UpgradeDetailsMetadata{ErrorMsg: errorMsg, DownloadPercent: 0.5, DownloadRate: 1024},
+	}
+}
+
+// newWatchClients returns a client factory serving one connection per watch, in order.
+func newWatchClients(t *testing.T, watches ...*fakeUpgradeWatch) func() client.Client {
+	return func() client.Client {
+		require.NotEmpty(t, watches, "unexpected reconnection")
+		watch := watches[0]
+		watches = watches[1:]
+
+		c := mocks.NewClient(t)
+		c.EXPECT().Connect(mock.Anything).Return(nil)
+		c.EXPECT().Disconnect().Return()
+		c.EXPECT().UpgradeWatch(mock.Anything).Return(watch, nil)
+		return c
+	}
+}
+
+func TestWatchUpgrade(t *testing.T) {
+	disconnected := errors.New("connection closed")
+
+	t.Run("completed after restart", func(t *testing.T) {
+		newClient := newWatchClients(t,
+			&fakeUpgradeWatch{
+				details: []*cproto.UpgradeDetails{
+					// details of a previous rolled back upgrade
+					upgradeDetails(details.StateRollback, "previous failure"),
+					upgradeDetails(details.StateRequested, ""),
+					upgradeDetails(details.StateDownloading, ""),
+					{TargetVersion: "8.14.0", State: string(details.StateDownloading), Metadata: &cproto.UpgradeDetailsMetadata{DownloadPercent: 1}},
+					upgradeDetails(details.StateExtracting, ""),
+					upgradeDetails(details.StateReplacing, ""),
+					upgradeDetails(details.StateRestarting, ""),
+				},
+				err: disconnected,
+			},
+			&fakeUpgradeWatch{
+				// upgraded agent starting, then watched
+				details: []*cproto.UpgradeDetails{nil, upgradeDetails(details.StateWatching, ""), nil},
+			},
+		)
+
+		var out bytes.Buffer
+		require.NoError(t, watchUpgrade(context.Background(), &out, newClient, time.Millisecond))
+		assert.Contains(t, out.String(), "Verifying artifact")
+		assert.Contains(t, out.String(), "Upgrade completed")
+	})
+
+	t.Run("rolled back", func(t *testing.T) {
+		newClient := newWatchClients(t,
+			&fakeUpgradeWatch{
+				details: []*cproto.UpgradeDetails{
+					upgradeDetails(details.StateWatching, ""),
+					upgradeDetails(details.StateRollback, "health gates failed"),
+				},
+			},
+		)
+
+		var out bytes.Buffer
+		err := watchUpgrade(context.Background(), &out, newClient, time.Millisecond)
+		assert.EqualError(t, err, "upgrade to version 8.14.0 rolled back: health gates failed")
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		failed := upgradeDetails(details.StateFailed, "no space left on device")
+		failed.Metadata.FailedState = string(details.StateExtracting)
+		newClient := newWatchClients(t,
+			&fakeUpgradeWatch{
+				details: []*cproto.UpgradeDetails{upgradeDetails(details.StateExtracting, ""), failed},
+			},
+		)
+
+		var out bytes.Buffer
+		err := watchUpgrade(context.Background(), &out, newClient, time.Millisecond)
+		assert.EqualError(t, err, "upgrade to version 8.14.0 failed in state UPG_EXTRACTING: no space left on device")
+	})
+
+	t.Run("cancelled while restarting", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		newClient := func() client.Client {
+			cancel()
+			c := mocks.NewClient(t)
+			c.EXPECT().Connect(mock.Anything).Return(disconnected)
+			return c
+		}
+
+		var out bytes.Buffer
+		err := watchUpgrade(ctx, &out, newClient, time.Millisecond)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -93,6 +93,12 @@ func watchCmd(log *logp.Logger, cfg *configuration.Configuration) error {
 		_ = locker.Unlock()
 	}()
 
+	retention := cfg.Settings.Upgrade.Retention
+	var keepVersions int
+	if retention != nil {
+		keepVersions = retention.KeepVersions
+	}
+
 	isWithinGrace, tilGrace := gracePeriod(marker, cfg.Settings.Upgrade.Watcher.GracePeriod)
 	if !isWithinGrace {
 		log.Infof("not within grace [updatedOn %v] %v", marker.UpdatedOn.String(), time.Since(marker.UpdatedOn).String())
@@ -100,7 +106,7 @@ func watchCmd(log *logp.Logger, cfg *configuration.Configuration) error {
 		// if we're not within grace and marker is still there it might mean
 		// that cleanup was not performed ok, cleanup everything except current version
 		// hash is the same as hash of agent which initiated watcher.
-		if err := upgrade.Cleanup(log, paths.Top(), paths.VersionedHome(paths.Top()), release.ShortCommit(), true, false); err != nil {
+		if err := upgrade.Cleanup(log, paths.Top(), paths.VersionedHome(paths.Top()), release.ShortCommit(), true, false, keepVersions); err != nil {
 			log.Error("clean up of prior watcher run failed", err)
 		}
 		// exit nicely
@@ -112,12 +118,15 @@ func watchCmd(log *logp.Logger, cfg *configuration.Configuration) error {
 	upgradeDetails := initUpgradeDetails(marker, upgrade.SaveMarker, log)
 
 	errorCheckInterval := cfg.Settings.Upgrade.Watcher.ErrorCheck.Interval
+	healthGates := upgrade.NewHealthGates(log, cfg.Settings.Upgrade.Watcher.HealthGates)
 	ctx := context.Background()
-	if err := watch(ctx, tilGrace, errorCheckInterval, log); err != nil {
+	if err := watch(ctx, tilGrace, errorCheckInterval, healthGates, log); err != nil {
 		log.Error("Error detected, proceeding to rollback: %v", err)
 
-		upgradeDetails.SetState(details.StateRollback)
-		err = upgrade.Rollback(ctx, log, client.New(), paths.Top(), marker.PrevVersionedHome, marker.PrevHash)
+		// record the reason in the marker, it is saved along with the rollback state
+		marker.RollbackReason = err.Error()
+		upgradeDetails.Rollback(err)
+		err = upgrade.Rollback(ctx, log, client.New(), paths.Top(), marker.PrevVersionedHome, marker.PrevHash, keepVersions)
 		if err != nil {
 			log.Error("rollback failed", err)
 			upgradeDetails.Fail(err)
@@ -135,18 +144,24 @@ func watchCmd(log *logp.Logger, cfg *configuration.Configuration) error {
 	// Why is this being skipped on Windows? The comment above is not clear.
 	// issue: https://github.com/elastic/elastic-agent/issues/3027
 	removeMarker := !isWindows()
-	err = upgrade.Cleanup(log, paths.Top(), marker.VersionedHome, marker.Hash, removeMarker, false)
+	err = upgrade.Cleanup(log, paths.Top(), marker.VersionedHome, marker.Hash, removeMarker, false, keepVersions)
 	if err != nil {
 		log.Error("cleanup after successful watch failed", err)
+		return err
 	}
-	return err
+
+	if err := upgrade.EnforceRetention(log, paths.Top(), marker.VersionedHome, cfg.Settings.DownloadConfig.TargetDirectory, retention); err != nil {
+		// not fatal, the policy is enforced again at the next start
+		log.Error("enforcing retention policy after successful watch failed", err)
+	}
+	return nil
 }
 
 func isWindows() bool {
 	return runtime.GOOS == "windows"
 }
 
-func watch(ctx context.Context, tilGrace time.Duration, errorCheckInterval time.Duration, log *logger.Logger) error {
+func watch(ctx context.Context, tilGrace time.Duration, errorCheckInterval time.Duration, healthGates *upgrade.HealthGates, log *logger.Logger) error {
 	errChan := make(chan error)
 
 	ctx, cancel := context.WithCancel(ctx)
@@ -158,7 +173,9 @@ func watch(ctx context.Context, tilGrace time.Duration, errorCheckInterval time.
 	}()
 
 	agentWatcher := upgrade.NewAgentWatcher(errChan, log, errorCheckInterval)
+	agentWatcher.SetHealthGates(healthGates)
 	go agentWatcher.Run(ctx)
+	go healthGates.Run(ctx, errorCheckInterval)
 
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGHUP)
@@ -176,6 +193,10 @@ WATCHLOOP:
 			break WATCHLOOP
 		// grace period passed, agent is considered stable
 		case <-t.C:
+			if err := healthGates.Check(); err != nil {
+				log.Errorf("Grace period passed, health gates failed: %s", err.Error())
+				return err
+			}
 			log.Info("Grace period passed, not watching")
 			break WATCHLOOP
 		// Agent in degraded state.
@@ -225,13 +246,13 @@ func getConfig(streams *cli.IOStreams) *configuration.Configuration {
 	pathConfigFile := paths.ConfigFile()
 	rawConfig, err := config.LoadFile(pathConfigFile)
 	if err != nil {
-		fmt.Fprintf(streams.Err, "could not read configuration file %s", pathConfigFile)
+		fmt.Fprintf(streams.Err, "could not read configuration file %s\n", pathConfigFile)
 		return defaultCfg
 	}
 
 	cfg, err := configuration.NewFromConfig(rawConfig)
 	if err != nil {
-		fmt.Fprintf(streams.Err, "could not parse configuration file %s", pathConfigFile)
+		fmt.Fprintf(streams.Err, "could not parse configuration file %s\n", pathConfigFile)
 		return defaultCfg
 	}
 
@@ -0,0 +1,44 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package configuration
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// ErrMissingPolicySigningKey is returned when the full policy signature is required without the key validating it.
+var ErrMissingPolicySigningKey = errors.New("policy_signature.signing_key is required when policy_signature.require_full is set")
+
+// PolicySignatureConfig is the configuration of the validation of the policies received from Fleet.
+// It's only read from the local configuration, a policy can't disable it.
+type PolicySignatureConfig struct {
+	// RequireFull rejects any policy whose signature does not cover the full policy document.
+	RequireFull bool `yaml:"require_full" config:"require_full" json:"require_full"`
+	// SigningKey is the base64 encoded public key validating the policy signature, required with
+	// RequireFull. When a signed policy rotates the key, the new key is persisted with the Fleet
+	// configuration of the agent and takes precedence over the local one, until the agent re-enrolls.
+	SigningKey string `yaml:"signing_key,omitempty" config:"signing_key" json:"signing_key,omitempty"`
+}
+
+// Validate validates the policy signature configuration.
+func (c *PolicySignatureConfig) Validate() error {
+	if !c.RequireFull {
+		return nil
+	}
+	if c.SigningKey == "" {
+		return ErrMissingPolicySigningKey
+	}
+	if _, err := base64.StdEncoding.DecodeString(c.SigningKey); err != nil {
+		return fmt.Errorf("invalid policy_signature.signing_key: %w", err)
+	}
+	return nil
+}
+
+// DefaultPolicySignatureConfig creates a config with pre-set default values.
+func DefaultPolicySignatureConfig() *PolicySignatureConfig {
+	return &PolicySignatureConfig{}
+}
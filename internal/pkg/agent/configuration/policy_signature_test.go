@@ -0,0 +1,35 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package configuration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/internal/pkg/config"
+)
+
+func TestPolicySignatureConfig(t *testing.T) {
+	load := func(policySignature map[string]interface{}) (*Configuration, error) {
+		return NewFromConfig(config.MustNewConfigFrom(map[string]interface{}{
+			"agent": map[string]interface{}{"policy_signature": policySignature},
+		}))
+	}
+
+	cfg, err := load(map[string]interface{}{"require_full": true, "signing_key": "c2lnbmluZyBrZXk="})
+	require.NoError(t, err)
+	assert.True(t, cfg.Settings.PolicySignature.RequireFull)
+
+	_, err = load(map[string]interface{}{"require_full": true})
+	assert.ErrorContains(t, err, ErrMissingPolicySigningKey.Error())
+
+	_, err = load(map[string]interface{}{"require_full": true, "signing_key": "not base64!"})
+	assert.ErrorContains(t, err, "invalid policy_signature.signing_key")
+
+	_, err = load(map[string]interface{}{"require_full": false})
+	assert.NoError(t, err)
+}
@@ -12,21 +12,57 @@ const (
 
 	// interval between checks for new (upgraded) Agent returning an error status.
 	defaultStatusCheckInterval = 30 * time.Second
+
+	// timeout for a single health gate HTTP probe or command execution.
+	defaultHealthGateTimeout = 10 * time.Second
+
+	// number of versions older than the current one kept for rollback.
+	defaultKeepVersions = 1
 )
 
 // UpgradeConfig is the configuration related to Agent upgrades.
 type UpgradeConfig struct {
-	Watcher *UpgradeWatcherConfig `yaml:"watcher" config:"watcher" json:"watcher"`
+	Watcher   *UpgradeWatcherConfig   `yaml:"watcher" config:"watcher" json:"watcher"`
+	Retention *UpgradeRetentionConfig `yaml:"retention" config:"retention" json:"retention"`
 }
 
 type UpgradeWatcherConfig struct {
-	GracePeriod time.Duration             `yaml:"grace_period" config:"grace_period" json:"grace_period"`
-	ErrorCheck  UpgradeWatcherCheckConfig `yaml:"error_check" config:"error_check" json:"error_check"`
+	GracePeriod time.Duration                   `yaml:"grace_period" config:"grace_period" json:"grace_period"`
+	ErrorCheck  UpgradeWatcherCheckConfig       `yaml:"error_check" config:"error_check" json:"error_check"`
+	HealthGates UpgradeWatcherHealthGatesConfig `yaml:"health_gates" config:"health_gates" json:"health_gates"`
 }
 type UpgradeWatcherCheckConfig struct {
 	Interval time.Duration `yaml:"interval" config:"interval" json:"interval"`
 }
 
+// UpgradeWatcherHealthGatesConfig defines the health gates an upgraded Agent must pass
+// before the end of the grace period, otherwise the upgrade is rolled back.
+type UpgradeWatcherHealthGatesConfig struct {
+	// Components is a list of component IDs or names that must reach the HEALTHY state.
+	Components []string `yaml:"components" config:"components" json:"components"`
+	// FleetCheckin requires the Agent to successfully check in with Fleet.
+	FleetCheckin bool `yaml:"fleet_checkin" config:"fleet_checkin" json:"fleet_checkin"`
+	// HTTPProbe is a local URL that must respond with a 2xx status code.
+	HTTPProbe string `yaml:"http_probe" config:"http_probe" json:"http_probe"`
+	// Command is a command, with its arguments, that must exit with a zero exit code.
+	Command []string `yaml:"command" config:"command" json:"command"`
+	// Timeout is the timeout for a single HTTP probe or command execution.
+	Timeout time.Duration `yaml:"timeout" config:"timeout" json:"timeout"`
+}
+
+// UpgradeRetentionConfig defines how many previous versions and downloaded artifacts are kept on disk.
+// It is enforced at startup and after each upgrade.
+type UpgradeRetentionConfig struct {
+	// KeepVersions is the number of versions older than the current one kept for rollback. Legacy homes
+	// without a version are kept unless it is 0.
+	KeepVersions int `yaml:"keep_versions" config:"keep_versions" json:"keep_versions"`
+	// MaxDiskUsage caps the size in bytes of the previous versions and downloaded artifacts, 0 means no limit.
+	// The current version and the versions needed by an ongoing upgrade are never removed.
+	MaxDiskUsage uint64 `yaml:"max_disk_usage" config:"max_disk_usage" json:"max_disk_usage"`
+	// DownloadsMaxAge is the age after which downloaded artifacts are removed, 0 means they are kept.
+	DownloadsMaxAge time.Duration `yaml:"downloads_max_age" config:"downloads_max_age" json:"downloads_max_age"`
+}
+
 func DefaultUpgradeConfig() *UpgradeConfig {
 	return &UpgradeConfig{
 		Watcher: &UpgradeWatcherConfig{
@@ -34,6 +70,12 @@ func DefaultUpgradeConfig() *UpgradeConfig {
 			ErrorCheck: UpgradeWatcherCheckConfig{
 				Interval: defaultStatusCheckInterval,
 			},
+			HealthGates: UpgradeWatcherHealthGatesConfig{
+				Timeout: defaultHealthGateTimeout,
+			},
+		},
+		Retention: &UpgradeRetentionConfig{
+			KeepVersions: defaultKeepVersions,
 		},
 	}
 }
@@ -0,0 +1,122 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package protection
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+)
+
+var (
+	ErrPolicyNotSigned         = errors.New("policy is not signed")
+	ErrMissingSigningKey       = errors.New("no signature validation key")
+	ErrPolicyNotFullySigned    = errors.New("policy signature does not cover the full policy")
+	ErrInvalidSignedPolicyData = errors.New("invalid signed policy data")
+)
+
+// ValidateFullPolicySignature validates that the policy signature covers the entire policy document
+// and returns the signature validation key to use for the next policy.
+//
+// In this mode the signed data is the whole policy, except the "signed" property itself:
+//
+//	"signed": {
+//		"data": "<base64 of the JSON serialized policy>",
+//		"signature": "<base64 of the signature of the data>"
+//	}
+//
+// The policy is rejected when it is not signed, when the signature is invalid or when any of its
+// properties is missing from the signed data or differs from it, so nothing can be injected in the
+// policy between Fleet and the agent.
+//
+// The signatureValidationKey parameter is required, the key of a policy is never trusted without a
+// previously known key. The returned key is the key of the agent.protection.signing_key of the signed
+// data when present, allowing the key rotation, otherwise the passed key.
+func ValidateFullPolicySignature(log *logger.Logger, policy map[string]interface{}, signatureValidationKey []byte) ([]byte, error) {
+	log = log.With("context", "Validate full policy signature")
+
+	if len(signatureValidationKey) == 0 {
+		return nil, ErrMissingSigningKey
+	}
+
+	data, signature, err := getPolicySignedDataAndSignature(policy)
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrPolicyNotSigned
+	}
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("Policy data length: %v, signature length: %v", len(data), len(signature))
+
+	var signedPolicy map[string]interface{}
+	if err := json.Unmarshal(data, &signedPolicy); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSignedPolicyData, err)
+	}
+
+	signedKey, err := getPolicySignatureValidationKey(signedPolicy)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	err = ValidateSignature(data, signature, signatureValidationKey)
+	log.Debugf("Policy signature validation result: %v", err)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := isPolicyMatching(policy, signedPolicy); err != nil {
+		return nil, err
+	}
+
+	if keys, err := unsignedKeys(policy, signedPolicy); err != nil {
+		return nil, err
+	} else if len(keys) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrPolicyNotFullySigned, strings.Join(keys, ", "))
+	}
+
+	if len(signedKey) > 0 {
+		return signedKey, nil
+	}
+	return signatureValidationKey, nil
+}
+
+// unsignedKeys returns the sorted top level keys of the policy that are missing from, or differ
+// from, the signed policy.
+func unsignedKeys(policy, signedPolicy map[string]interface{}) ([]string, error) {
+	unsigned := make(map[string]interface{}, len(policy))
+	for k, v := range policy {
+		if k != "signed" {
+			unsigned[k] = v
+		}
+	}
+
+	// round trip the policy through JSON to compare it with the same types as the signed data
+	b, err := json.Marshal(unsigned)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize the policy: %w", err)
+	}
+	unsigned = nil
+	if err := json.Unmarshal(b, &unsigned); err != nil {
+		return nil, fmt.Errorf("failed to deserialize the policy: %w", err)
+	}
+
+	var keys []string
+	for k, v := range unsigned {
+		if sv, ok := signedPolicy[k]; !ok || !reflect.DeepEqual(v, sv) {
+			keys = append(keys, k)
+		}
+	}
+	for k := range signedPolicy {
+		if _, ok := unsigned[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
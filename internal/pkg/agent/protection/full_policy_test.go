@@ -0,0 +1,168 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package protection
+
+import (
+	"crypto/ecdsa"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func signFullPolicy(t *testing.T, policy map[string]interface{}, pk *ecdsa.PrivateKey) map[string]interface{} {
+	data, err := json.Marshal(policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := sign(data, pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed := make(map[string]interface{}, len(policy)+1)
+	for k, v := range policy {
+		signed[k] = v
+	}
+	signed["signed"] = map[string]interface{}{
+		"data":      base64.StdEncoding.EncodeToString(data),
+		"signature": base64.StdEncoding.EncodeToString(signature),
+	}
+	return signed
+}
+
+func TestValidateFullPolicySignature(t *testing.T) {
+	pk, pubK, err := genKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPk, otherPubK, err := genKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newPolicy := func(signingKey []byte) map[string]interface{} {
+		return map[string]interface{}{
+			"id":       "681b1230-b798-11ed-8be1-47153ce217a7",
+			"revision": 2,
+			"agent": map[string]interface{}{
+				"protection": map[string]interface{}{
+					"enabled":     true,
+					"signing_key": base64.StdEncoding.EncodeToString(signingKey),
+				},
+			},
+			"outputs": map[string]interface{}{
+				"default": map[string]interface{}{"type": "elasticsearch", "hosts": []interface{}{"https://127.0.0.1:9200"}},
+			},
+			"inputs": []interface{}{
+				map[string]interface{}{"id": "logfile-system", "type": "logfile", "use_output": "default"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		policy  func() map[string]interface{}
+		key     []byte
+		wantKey []byte
+		wantErr error
+	}{
+		{
+			name:    "unsigned policy",
+			policy:  func() map[string]interface{} { return newPolicy(pubK) },
+			key:     pubK,
+			wantErr: ErrPolicyNotSigned,
+		},
+		{
+			name:    "fully signed policy without known key",
+			policy:  func() map[string]interface{} { return signFullPolicy(t, newPolicy(pubK), pk) },
+			wantErr: ErrMissingSigningKey,
+		},
+		{
+			name:    "fully signed policy with the known key",
+			policy:  func() map[string]interface{} { return signFullPolicy(t, newPolicy(pubK), pk) },
+			key:     pubK,
+			wantKey: pubK,
+		},
+		{
+			name:    "fully signed policy rotating the key",
+			policy:  func() map[string]interface{} { return signFullPolicy(t, newPolicy(otherPubK), pk) },
+			key:     pubK,
+			wantKey: otherPubK,
+		},
+		{
+			name:    "policy signed with another key",
+			policy:  func() map[string]interface{} { return signFullPolicy(t, newPolicy(pubK), otherPk) },
+			key:     pubK,
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name: "injected input",
+			policy: func() map[string]interface{} {
+				policy := signFullPolicy(t, newPolicy(pubK), pk)
+				policy["inputs"] = append(policy["inputs"].([]interface{}), map[string]interface{}{"id": "injected", "type": "osquery"})
+				return policy
+			},
+			key:     pubK,
+			wantErr: ErrPolicyNotFullySigned,
+		},
+		{
+			name: "added property",
+			policy: func() map[string]interface{} {
+				policy := signFullPolicy(t, newPolicy(pubK), pk)
+				policy["output_permissions"] = map[string]interface{}{}
+				return policy
+			},
+			key:     pubK,
+			wantErr: ErrPolicyNotFullySigned,
+		},
+		{
+			name: "removed property",
+			policy: func() map[string]interface{} {
+				policy := signFullPolicy(t, newPolicy(pubK), pk)
+				delete(policy, "outputs")
+				return policy
+			},
+			key:     pubK,
+			wantErr: ErrPolicyNotFullySigned,
+		},
+		{
+			name: "policy signed without signing key",
+			policy: func() map[string]interface{} {
+				policy := newPolicy(pubK)
+				delete(policy, "agent")
+				return signFullPolicy(t, policy, pk)
+			},
+			key:     pubK,
+			wantKey: pubK,
+		},
+		{
+			name: "mismatched policy id",
+			policy: func() map[string]interface{} {
+				policy := signFullPolicy(t, newPolicy(pubK), pk)
+				policy["id"] = "another-policy"
+				return policy
+			},
+			key:     pubK,
+			wantErr: ErrMismatchedPolicyID,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			key, err := ValidateFullPolicySignature(getLogger(), tc.policy(), tc.key)
+			diff := cmp.Diff(tc.wantErr, err, cmpopts.EquateErrors())
+			if diff != "" {
+				t.Fatal(diff)
+			}
+			diff = cmp.Diff(tc.wantKey, key)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package protection
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/vault/aesgcm"
+)
+
+// EncryptedSecretKey is the key of the policy property holding an encrypted secret.
+const EncryptedSecretKey = "encrypted_secret"
+
+// secretsKeyLabel separates the policy secrets key from the other uses of the agent secret.
+const secretsKeyLabel = "elastic-agent policy secrets"
+
+var ErrInvalidEncryptedSecret = errors.New("invalid encrypted secret")
+
+// EncryptSecret encrypts the secret value to the public key of the agent, as Fleet does, and returns
+// the policy property holding it:
+//
+//	"api_key": {
+//		"encrypted_secret": "<base64 of the ephemeral public key, the AES-GCM nonce and encrypted value>"
+//	}
+//
+// The public key of the agent is the public key of SecretsKey, sent to Fleet on enroll. The value is
+// encrypted with AES-GCM keyed with the SHA-256 of the ECDH shared secret of a new ephemeral key and
+// the public key of the agent.
+func EncryptSecret(pub *ecdh.PublicKey, value string) (map[string]interface{}, error) {
+	ephemeral, err := pub.Curve().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate the ephemeral key: %w", err)
+	}
+	key, err := secretKey(ephemeral, pub)
+	if err != nil {
+		return nil, err
+	}
+	enc, err := aesgcm.Encrypt(key, []byte(value))
+	if err != nil {
+		return nil, err
+	}
+	enc = append(ephemeral.PublicKey().Bytes(), enc...)
+	return map[string]interface{}{EncryptedSecretKey: base64.StdEncoding.EncodeToString(enc)}, nil
+}
+
+// SecretsKey returns the X25519 key decrypting the policy secrets, derived from the agent secret held
+// in the vault. The key is stable for the lifetime of the agent secret, unlike the key of the agent
+// identity that changes on each renewal of the client certificate.
+func SecretsKey(agentSecret []byte) (*ecdh.PrivateKey, error) {
+	if len(agentSecret) == 0 {
+		return nil, errors.New("empty agent secret")
+	}
+	h := sha256.New()
+	h.Write([]byte(secretsKeyLabel))
+	h.Write(agentSecret)
+	return ecdh.X25519().NewPrivateKey(h.Sum(nil))
+}
+
+// HasEncryptedSecrets returns true if any property of the configuration holds an encrypted secret.
+func HasEncryptedSecrets(cfg map[string]interface{}) bool {
+	return hasEncryptedSecrets(cfg)
+}
+
+// DecryptSecrets replaces the properties of the configuration holding an encrypted secret with the
+// secret decrypted with the private key of the agent. The configuration is modified in place.
+func DecryptSecrets(cfg map[string]interface{}, key *ecdh.PrivateKey) error {
+	_, err := decryptSecrets(cfg, key, "")
+	return err
+}
+
+func hasEncryptedSecrets(v interface{}) bool {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if isEncryptedSecret(val) {
+			return true
+		}
+		for _, mv := range val {
+			if hasEncryptedSecrets(mv) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, sv := range val {
+			if hasEncryptedSecrets(sv) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func decryptSecrets(v interface{}, key *ecdh.PrivateKey, path string) (interface{}, error) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if isEncryptedSecret(val) {
+			return decryptSecret(val, key, path)
+		}
+		for k, mv := range val {
+			dv, err := decryptSecrets(mv, key, joinPath(path, k))
+			if err != nil {
+				return nil, err
+			}
+			val[k] = dv
+		}
+	case []interface{}:
+		for i, sv := range val {
+			dv, err := decryptSecrets(sv, key, fmt.Sprintf("%s.%d", path, i))
+			if err != nil {
+				return nil, err
+			}
+			val[i] = dv
+		}
+	}
+	return v, nil
+}
+
+func decryptSecret(m map[string]interface{}, key *ecdh.PrivateKey, path string) (string, error) {
+	s, ok := m[EncryptedSecretKey].(string)
+	if !ok {
+		return "", fmt.Errorf("%w at %s: value is not a string", ErrInvalidEncryptedSecret, path)
+	}
+	enc, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", fmt.Errorf("%w at %s: %w", ErrInvalidEncryptedSecret, path, err)
+	}
+	n := len(key.PublicKey().Bytes())
+	if len(enc) <= n {
+		return "", fmt.Errorf("%w at %s: value is too short", ErrInvalidEncryptedSecret, path)
+	}
+	ephemeral, err := key.Curve().NewPublicKey(enc[:n])
+	if err != nil {
+		return "", fmt.Errorf("%w at %s: %w", ErrInvalidEncryptedSecret, path, err)
+	}
+	secret, err := secretKey(key, ephemeral)
+	if err != nil {
+		return "", fmt.Errorf("%w at %s: %w", ErrInvalidEncryptedSecret, path, err)
+	}
+	dec, err := aesgcm.Decrypt(secret, enc[n:])
+	if err != nil {
+		return "", fmt.Errorf("%w at %s: %w", ErrInvalidEncryptedSecret, path, err)
+	}
+	return string(dec), nil
+}
+
+// secretKey returns the AES key of the secrets shared by the private and the public keys.
+func secretKey(priv *ecdh.PrivateKey, pub *ecdh.PublicKey) ([]byte, error) {
+	shared, err := priv.ECDH(pub)
+	if err != nil {
+		return nil, fmt.Errorf("could not compute the shared secret: %w", err)
+	}
+	key := sha256.Sum256(shared)
+	return key[:], nil
+}
+
+func isEncryptedSecret(m map[string]interface{}) bool {
+	if len(m) != 1 {
+		return false
+	}
+	_, ok := m[EncryptedSecretKey]
+	return ok
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,137 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package protection
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDecryptSecrets(t *testing.T) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	apiKey, err := EncryptSecret(key.PublicKey(), "id:secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	password, err := EncryptSecret(key.PublicKey(), "changeme")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := map[string]interface{}{
+		"outputs": map[string]interface{}{
+			"default": map[string]interface{}{"type": "elasticsearch", "api_key": apiKey},
+		},
+		"inputs": []interface{}{
+			map[string]interface{}{
+				"type":    "httpjson",
+				"streams": []interface{}{map[string]interface{}{"request.password": password}},
+			},
+		},
+	}
+
+	if !HasEncryptedSecrets(cfg) {
+		t.Fatal("expected the configuration to hold encrypted secrets")
+	}
+	if err := DecryptSecrets(cfg, key); err != nil {
+		t.Fatal(err)
+	}
+	if HasEncryptedSecrets(cfg) {
+		t.Fatal("expected all the secrets to be decrypted")
+	}
+
+	diff := cmp.Diff(map[string]interface{}{
+		"outputs": map[string]interface{}{
+			"default": map[string]interface{}{"type": "elasticsearch", "api_key": "id:secret"},
+		},
+		"inputs": []interface{}{
+			map[string]interface{}{
+				"type":    "httpjson",
+				"streams": []interface{}{map[string]interface{}{"request.password": "changeme"}},
+			},
+		},
+	}, cfg)
+	if diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestDecryptSecretsInvalid(t *testing.T) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiKey, err := EncryptSecret(otherKey.PublicKey(), "id:secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, secret := range map[string]map[string]interface{}{
+		"other key":  apiKey,
+		"not base64": {EncryptedSecretKey: "not base64!"},
+		"too short":  {EncryptedSecretKey: "c2hvcnQ="},
+		"not string": {EncryptedSecretKey: 42},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := map[string]interface{}{"outputs": map[string]interface{}{"default": map[string]interface{}{"api_key": secret}}}
+			err := DecryptSecrets(cfg, key)
+			if !errors.Is(err, ErrInvalidEncryptedSecret) {
+				t.Fatalf("expected %v, got %v", ErrInvalidEncryptedSecret, err)
+			}
+		})
+	}
+}
+
+func TestSecretsKey(t *testing.T) {
+	agentSecret := []byte("0123456789abcdef0123456789abcdef")
+	key, err := SecretsKey(agentSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the key is the same for the lifetime of the agent secret
+	sameKey, err := SecretsKey(agentSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !key.Equal(sameKey) {
+		t.Fatal("expected the same key for the same agent secret")
+	}
+	apiKey, err := EncryptSecret(key.PublicKey(), "id:secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := map[string]interface{}{"api_key": apiKey}
+	if err := DecryptSecrets(cfg, sameKey); err != nil {
+		t.Fatal(err)
+	}
+	if cfg["api_key"] != "id:secret" {
+		t.Fatalf("expected decrypted secret, got %v", cfg["api_key"])
+	}
+
+	otherKey, err := SecretsKey([]byte("fedcba9876543210fedcba9876543210"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Equal(otherKey) {
+		t.Fatal("expected a different key for another agent secret")
+	}
+
+	if _, err := SecretsKey(nil); err == nil {
+		t.Fatal("expected an error for an empty agent secret")
+	}
+}
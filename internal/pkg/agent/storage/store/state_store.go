@@ -6,7 +6,10 @@ package store
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -32,6 +35,10 @@ type storeLoad interface {
 
 type action = fleetapi.Action
 
+// maxStateHistory is the maximum number of state transitions kept in the
+// state history, the oldest ones are dropped first.
+const maxStateHistory = 1000
+
 // StateStore is a combined agent state storage initially derived from the former actionStore
 // and modified to allow persistence of additional agent specific state information.
 // The following is the original actionStore implementation description:
@@ -45,13 +52,18 @@ type StateStore struct {
 	dirty bool
 	state stateT
 
+	// stateHistoryStart is the sequence number of the oldest transition of the
+	// state history, it identifies transitions while they are sent to fleet-server.
+	stateHistoryStart uint64
+
 	mx sync.RWMutex
 }
 
 type stateT struct {
-	action   action
-	ackToken string
-	queue    []action
+	action       action
+	ackToken     string
+	queue        []action
+	stateHistory []fleetapi.CheckinStateTransition
 }
 
 // actionSerializer is a combined yml serializer for the ActionPolicyChange and ActionUnenroll
@@ -67,11 +79,13 @@ type actionSerializer struct {
 // stateSerializer is used to serialize the state to yaml.
 // action serialization is handled through the actionSerializer struct
 // queue serialization is handled through yaml struct tags or the actions unmarshaller defined in fleetapi
+// state history is serialized as gzip compressed JSON, base64 encoded, as it can hold many transitions
 // TODO clean up action serialization (have it be part of the fleetapi?)
 type stateSerializer struct {
-	Action   *actionSerializer `yaml:"action,omitempty"`
-	AckToken string            `yaml:"ack_token,omitempty"`
-	Queue    fleetapi.Actions  `yaml:"action_queue,omitempty"`
+	Action       *actionSerializer `yaml:"action,omitempty"`
+	AckToken     string            `yaml:"ack_token,omitempty"`
+	Queue        fleetapi.Actions  `yaml:"action_queue,omitempty"`
+	StateHistory string            `yaml:"state_history,omitempty"`
 }
 
 // NewStateStoreWithMigration creates a new state store and migrates the old one.
@@ -124,6 +138,14 @@ func NewStateStore(log *logger.Logger, store storeLoad) (*StateStore, error) {
 		queue:    sr.Queue,
 	}
 
+	if sr.StateHistory != "" {
+		state.stateHistory, err = decodeStateHistory(sr.StateHistory)
+		if err != nil {
+			// the state history is informative only, losing it must not prevent the agent from starting
+			log.Warnf("failed to load state history, discarding it: %v", err)
+		}
+	}
+
 	if sr.Action != nil {
 		if sr.Action.IsDetected != nil {
 			state.action = &fleetapi.ActionUnenroll{
@@ -258,6 +280,59 @@ func (s *StateStore) SetQueue(q []action) {
 
 }
 
+// AddStateHistory appends state transitions to the state history, dropping the
+// oldest transitions beyond maxStateHistory.
+func (s *StateStore) AddStateHistory(transitions ...fleetapi.CheckinStateTransition) {
+	if len(transitions) == 0 {
+		return
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.state.stateHistory = append(s.state.stateHistory, transitions...)
+	if dropped := len(s.state.stateHistory) - maxStateHistory; dropped > 0 {
+		s.state.stateHistory = append([]fleetapi.CheckinStateTransition(nil), s.state.stateHistory[dropped:]...)
+		s.stateHistoryStart += uint64(dropped)
+	}
+	s.dirty = true
+}
+
+// StateHistory returns a copy of the state history, oldest first, and the
+// sequence number following its last transition to pass to TrimStateHistory.
+func (s *StateStore) StateHistory() ([]fleetapi.CheckinStateTransition, uint64) {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	end := s.stateHistoryStart + uint64(len(s.state.stateHistory))
+	if len(s.state.stateHistory) == 0 {
+		return nil, end
+	}
+	h := make([]fleetapi.CheckinStateTransition, len(s.state.stateHistory))
+	copy(h, s.state.stateHistory)
+	return h, end
+}
+
+// TrimStateHistory removes the transitions returned by StateHistory along
+// with the sequence number end, once they were sent to fleet-server.
+// Transitions added since then are kept, even when older ones were dropped
+// in between.
+func (s *StateStore) TrimStateHistory(end uint64) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if end <= s.stateHistoryStart || len(s.state.stateHistory) == 0 {
+		return
+	}
+	n := end - s.stateHistoryStart
+	if n > uint64(len(s.state.stateHistory)) {
+		n = uint64(len(s.state.stateHistory))
+	}
+	s.state.stateHistory = append([]fleetapi.CheckinStateTransition(nil), s.state.stateHistory[n:]...)
+	s.stateHistoryStart += n
+	s.dirty = true
+}
+
 // Save saves the actions into a state store.
 func (s *StateStore) Save() error {
 	s.mx.Lock()
@@ -274,6 +349,14 @@ func (s *StateStore) Save() error {
 		Queue:    s.state.queue,
 	}
 
+	if len(s.state.stateHistory) > 0 {
+		history, err := encodeStateHistory(s.state.stateHistory)
+		if err != nil {
+			return err
+		}
+		serialize.StateHistory = history
+	}
+
 	if s.state.action != nil {
 		if apc, ok := s.state.action.(*fleetapi.ActionPolicyChange); ok {
 			serialize.Action = &actionSerializer{apc.ActionID, apc.ActionType, apc.Policy, nil}
@@ -355,3 +438,33 @@ func yamlToReader(in interface{}) (io.Reader, error) {
 	}
 	return bytes.NewReader(data), nil
 }
+
+func encodeStateHistory(history []fleetapi.CheckinStateTransition) (string, error) {
+	buf := &bytes.Buffer{}
+	zw := gzip.NewWriter(buf)
+	if err := json.NewEncoder(zw).Encode(history); err != nil {
+		return "", fmt.Errorf("could not encode state history: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return "", fmt.Errorf("could not compress state history: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+func decodeStateHistory(encoded string) ([]fleetapi.CheckinStateTransition, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode state history: %w", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("could not decompress state history: %w", err)
+	}
+	defer zr.Close()
+
+	var history []fleetapi.CheckinStateTransition
+	if err := json.NewDecoder(zr).Decode(&history); err != nil {
+		return nil, fmt.Errorf("could not decode state history: %w", err)
+	}
+	return history, nil
+}
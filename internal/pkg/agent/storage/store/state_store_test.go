@@ -6,6 +6,7 @@ package store
 
 import (
 	"context"
+	"fmt"
 	"path/filepath"
 	"sync"
 	"testing"
@@ -235,6 +236,70 @@ func runTestStateStore(t *testing.T, ackToken string) {
 			require.Equal(t, ackToken, store.AckToken())
 		}))
 
+	t.Run("can save a bounded state history",
+		withFile(func(t *testing.T, file string) {
+			s, err := storage.NewDiskStore(file)
+			require.NoError(t, err)
+			store, err := NewStateStore(log, s)
+			require.NoError(t, err)
+			history, _ := store.StateHistory()
+			require.Empty(t, history)
+
+			now := time.Now().UTC().Truncate(time.Second)
+			for i := 0; i < maxStateHistory+10; i++ {
+				store.AddStateHistory(fleetapi.CheckinStateTransition{
+					Timestamp:   now.Add(time.Duration(i) * time.Second),
+					ComponentID: "filestream-default",
+					Status:      "HEALTHY",
+					Message:     fmt.Sprintf("transition %d", i),
+				})
+			}
+			// the 10 oldest transitions were dropped, trimming the 15 oldest
+			// ones removes 5 more
+			store.TrimStateHistory(15)
+			require.NoError(t, store.Save())
+
+			s, err = storage.NewDiskStore(file)
+			require.NoError(t, err)
+			store1, err := NewStateStore(log, s)
+			require.NoError(t, err)
+
+			history, _ = store1.StateHistory()
+			require.Len(t, history, maxStateHistory-5)
+			require.Equal(t, "transition 15", history[0].Message)
+			require.Equal(t, now.Add(15*time.Second), history[0].Timestamp)
+			require.Equal(t, fmt.Sprintf("transition %d", maxStateHistory+9), history[len(history)-1].Message)
+		}))
+
+	t.Run("trimming the sent state history keeps transitions added meanwhile",
+		withFile(func(t *testing.T, file string) {
+			s, err := storage.NewDiskStore(file)
+			require.NoError(t, err)
+			store, err := NewStateStore(log, s)
+			require.NoError(t, err)
+
+			add := func(from, to int) {
+				for i := from; i < to; i++ {
+					store.AddStateHistory(fleetapi.CheckinStateTransition{
+						ComponentID: "filestream-default",
+						Status:      "HEALTHY",
+						Message:     fmt.Sprintf("transition %d", i),
+					})
+				}
+			}
+			add(0, maxStateHistory)
+			sent, end := store.StateHistory()
+			require.Len(t, sent, maxStateHistory)
+
+			// transitions recorded while the checkin is in flight drop the oldest ones
+			add(maxStateHistory, maxStateHistory+10)
+			store.TrimStateHistory(end)
+
+			history, _ := store.StateHistory()
+			require.Len(t, history, 10)
+			require.Equal(t, fmt.Sprintf("transition %d", maxStateHistory), history[0].Message)
+		}))
+
 	t.Run("when we ACK we save to disk",
 		withFile(func(t *testing.T, file string) {
 			ActionPolicyChange := &fleetapi.ActionPolicyChange{
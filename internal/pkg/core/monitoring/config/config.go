@@ -5,9 +5,12 @@
 package config
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
+	"github.com/docker/go-units"
+
 	c "github.com/elastic/elastic-agent-libs/config"
 )
 
@@ -41,6 +44,10 @@ type MonitoringHTTPConfig struct {
 	Host    string        `yaml:"host" config:"host"`
 	Port    int           `yaml:"port" config:"port" validate:"min=0,max=65535,nonzero"`
 	Buffer  *BufferConfig `yaml:"buffer" config:"buffer"`
+	Metrics MetricsConfig `yaml:"metrics" config:"metrics"`
+	Health  HealthConfig  `yaml:"health" config:"health"`
+	// StateStream configures the /state/stream endpoint.
+	StateStream StateStreamConfig `yaml:"state_stream" config:"state_stream"`
 }
 
 // Unpack reads a config object into the settings.
@@ -51,11 +58,19 @@ func (c *MonitoringHTTPConfig) Unpack(cfg *c.C) error {
 		Host    string        `yaml:"host" config:"host"`
 		Port    int           `yaml:"port" config:"port" validate:"min=0,max=65535,nonzero"`
 		Buffer  *BufferConfig `yaml:"buffer" config:"buffer"`
+		Metrics MetricsConfig `yaml:"metrics" config:"metrics"`
+		Health  HealthConfig  `yaml:"health" config:"health"`
+
+		StateStream StateStreamConfig `yaml:"state_stream" config:"state_stream"`
 	}{
 		Enabled: c.Enabled,
 		Host:    c.Host,
 		Port:    c.Port,
 		Buffer:  c.Buffer,
+		Metrics: c.Metrics,
+		Health:  c.Health,
+
+		StateStream: c.StateStream,
 	}
 
 	if err := cfg.Unpack(&tmp); err != nil {
@@ -71,6 +86,10 @@ func (c *MonitoringHTTPConfig) Unpack(cfg *c.C) error {
 		Host:    tmp.Host,
 		Port:    tmp.Port,
 		Buffer:  tmp.Buffer,
+		Metrics: tmp.Metrics,
+		Health:  tmp.Health,
+
+		StateStream: tmp.StateStream,
 	}
 
 	return nil
@@ -83,6 +102,49 @@ type PprofConfig struct {
 	Enabled bool `yaml:"enabled" config:"enabled"`
 }
 
+// MetricsConfig configures the /metrics endpoint exposing the agent metrics in the OpenMetrics format.
+type MetricsConfig struct {
+	// ScrapeComponents adds the metrics of the components /stats endpoints, labeled with their component.
+	// It requires the HTTP endpoint to be enabled.
+	ScrapeComponents bool `yaml:"scrape_components" config:"scrape_components"`
+}
+
+// HealthConfig configures the checks of the /liveness and /readiness endpoints.
+type HealthConfig struct {
+	Liveness  HealthCheckConfig `yaml:"liveness" config:"liveness"`
+	Readiness HealthCheckConfig `yaml:"readiness" config:"readiness"`
+}
+
+// HealthCheckConfig defines the criteria for the agent to be considered healthy.
+type HealthCheckConfig struct {
+	// CheckState requires the agent to be HEALTHY, without any check the endpoint only
+	// confirms the agent process responds.
+	CheckState bool `yaml:"check_state" config:"check_state"`
+	// DegradedHealthy considers a DEGRADED agent as healthy when the state is checked.
+	DegradedHealthy bool `yaml:"degraded_healthy" config:"degraded_healthy"`
+	// MaxCheckinAge is the maximum time since the last successful checkin with Fleet Server,
+	// zero disables the check.
+	MaxCheckinAge time.Duration `yaml:"max_checkin_age" config:"max_checkin_age"`
+	// Components are the IDs of the components required to be HEALTHY.
+	Components []string `yaml:"components" config:"components"`
+}
+
+func defaultHealthConfig() HealthConfig {
+	return HealthConfig{
+		Liveness:  HealthCheckConfig{CheckState: false, DegradedHealthy: true},
+		Readiness: HealthCheckConfig{CheckState: true, DegradedHealthy: false},
+	}
+}
+
+// StateStreamConfig configures the /state/stream endpoint streaming the agent state changes
+// as server-sent events.
+type StateStreamConfig struct {
+	Enabled bool `yaml:"enabled" config:"enabled"`
+	// Token is the bearer token the requests must be authorized with, the endpoint
+	// is not exposed without one.
+	Token string `yaml:"token" config:"token"`
+}
+
 // BufferConfig is a struct for for the metrics buffer endpoint
 type BufferConfig struct {
 	Enabled bool `yaml:"enabled" config:"enabled"`
@@ -100,6 +162,7 @@ func DefaultConfig() *MonitoringConfig {
 			Enabled: false,
 			Host:    DefaultHost,
 			Port:    defaultPort,
+			Health:  defaultHealthConfig(),
 		},
 		Namespace:   defaultNamespace,
 		APM:         defaultAPMConfig(),
@@ -134,13 +197,19 @@ type Uploader struct {
 	MaxRetries int           `config:"max_retries"`
 	InitDur    time.Duration `config:"init_duration"`
 	MaxDur     time.Duration `config:"max_duration"`
+	// Concurrency is the number of chunks uploaded in parallel.
+	Concurrency int `config:"concurrency"`
+	// Compression is the algorithm proposed to fleet-server to compress the chunks, either zstd or none.
+	Compression string `config:"compression"`
 }
 
 func defaultUploader() Uploader {
 	return Uploader{
-		MaxRetries: 10,
-		InitDur:    time.Second,
-		MaxDur:     time.Minute * 10,
+		MaxRetries:  10,
+		InitDur:     time.Second,
+		MaxDur:      time.Minute * 10,
+		Concurrency: 4,
+		Compression: "zstd",
 	}
 }
 
@@ -157,15 +226,165 @@ func defaultLimit() Limit {
 	}
 }
 
+// Redaction contains the redaction policy applied to the files of the diagnostics bundles.
+type Redaction struct {
+	// Mode is either mask, replacing the redacted values with <REDACTED>, or hash, replacing them with
+	// a keyed hash of the value so equal values can still be correlated.
+	Mode string `config:"mode"`
+	// Salt is the key of the hashes. When empty a random key is generated for each bundle, the hashes
+	// can then only be correlated within a bundle.
+	Salt string `config:"salt"`
+	// Keys are regular expressions matched against the keys of the YAML and JSON files, the string
+	// values of the matching keys are redacted. They are added to the built-in keys: certificate,
+	// passphrase, password, token and key.
+	Keys []string `config:"keys"`
+	// ExcludeKeys are regular expressions of the keys never redacted, added to the built-in routekey.
+	ExcludeKeys []string `config:"exclude_keys"`
+	// Values are the patterns matched against the string values and the text files, the matching
+	// parts are redacted.
+	Values []RedactionPattern `config:"values"`
+	// Logs enables the redaction of the log files copied into the bundles.
+	Logs bool `config:"logs"`
+}
+
+// RedactionPattern is a named regular expression. The pattern of the built-in patterns (email, ipv4,
+// ipv6 and authorization) can be omitted.
+type RedactionPattern struct {
+	Name    string `config:"name"`
+	Pattern string `config:"pattern"`
+}
+
+func defaultRedaction() Redaction {
+	return Redaction{
+		Mode: "mask",
+	}
+}
+
+// AutoCapture contains the policy capturing diagnostics bundles locally, without waiting for them to be
+// requested, when a problem is detected.
+type AutoCapture struct {
+	Enabled bool `config:"enabled"`
+	// OnFailed captures a bundle when a component or one of its units enters the FAILED state.
+	OnFailed bool `config:"on_failed"`
+	// CrashLoop captures a bundle when a component is crash-looping.
+	CrashLoop CrashLoopTrigger `config:"crash_loop"`
+	// Memory captures a bundle when a process exceeds a memory threshold.
+	Memory MemoryTrigger `config:"memory"`
+	// Interval captures a bundle periodically, it's disabled when zero.
+	Interval time.Duration `config:"interval"`
+	// MinInterval is the minimum duration between two captures, the problems detected sooner are not
+	// captured.
+	MinInterval time.Duration `config:"min_interval"`
+	// CPUProfile adds CPU profiles of the agent and of the components to the bundles.
+	CPUProfile bool `config:"cpu_profile"`
+	// Dir is the directory the bundles are kept in, diagnostics-captures in the data directory when empty.
+	Dir string `config:"dir"`
+	// MaxSize is the total size of the bundles kept, the oldest ones are removed beyond it.
+	MaxSize ByteSize `config:"max_size"`
+	// Upload uploads the bundles to Fleet, when the agent is managed. Fleet doesn't list them with the
+	// bundles requested by an action, they are kept under an action ID prefixed with auto-capture-.
+	Upload bool `config:"upload"`
+}
+
+// CrashLoopTrigger detects a component restarting Restarts times within Window, it's disabled when
+// Restarts is zero.
+type CrashLoopTrigger struct {
+	Restarts int           `config:"restarts"`
+	Window   time.Duration `config:"window"`
+}
+
+// MemoryTrigger detects the agent or a component process using more resident memory than Threshold,
+// checked every Period. It's disabled when Threshold is zero.
+type MemoryTrigger struct {
+	Threshold ByteSize      `config:"threshold"`
+	Period    time.Duration `config:"period"`
+}
+
+// ByteSize is a size in bytes, set either as a number of bytes or as a string like 512MiB.
+type ByteSize int64
+
+// Unpack reads a size from a number or a string.
+func (b *ByteSize) Unpack(v interface{}) error {
+	switch v := v.(type) {
+	case int64:
+		*b = ByteSize(v)
+	case uint64:
+		*b = ByteSize(v)
+	case float64:
+		*b = ByteSize(v)
+	case string:
+		size, err := units.RAMInBytes(v)
+		if err != nil {
+			return err
+		}
+		*b = ByteSize(size)
+	default:
+		return fmt.Errorf("unsupported size %v of type %T", v, v)
+	}
+	if *b < 0 {
+		return fmt.Errorf("negative size %v", v)
+	}
+	return nil
+}
+
+func defaultAutoCapture() AutoCapture {
+	return AutoCapture{
+		Enabled:  false,
+		OnFailed: true,
+		CrashLoop: CrashLoopTrigger{
+			Restarts: 3,
+			Window:   5 * time.Minute,
+		},
+		Memory: MemoryTrigger{
+			Period: 30 * time.Second,
+		},
+		MinInterval: 15 * time.Minute,
+		MaxSize:     500 * 1024 * 1024,
+	}
+}
+
+// Profiling contains the configuration of the continuous profiling of the agent and of its components,
+// the profiles are sampled periodically and kept locally.
+type Profiling struct {
+	Enabled bool `config:"enabled"`
+	// Interval is the duration between two samples.
+	Interval time.Duration `config:"interval"`
+	// CPUDuration is the duration of the CPU profiles of the agent and of the components.
+	CPUDuration time.Duration `config:"cpu_duration"`
+	// Components samples the profiles of the components as well, through the pprof endpoint of their
+	// monitoring server.
+	Components bool `config:"components"`
+	// Dir is the directory the profiles are kept in, diagnostics-profiles in the data directory when empty.
+	Dir string `config:"dir"`
+	// MaxSize is the total size of the profiles kept, the oldest samples are removed beyond it.
+	MaxSize ByteSize `config:"max_size"`
+}
+
+func defaultProfiling() Profiling {
+	return Profiling{
+		Enabled:     false,
+		Interval:    5 * time.Minute,
+		CPUDuration: 10 * time.Second,
+		Components:  true,
+		MaxSize:     200 * 1024 * 1024,
+	}
+}
+
 // Diagnostics contains the configuration needed to configure the diagnostics handler.
 type Diagnostics struct {
-	Uploader Uploader `config:"uploader"`
-	Limit    Limit    `config:"limit"`
+	Uploader    Uploader    `config:"uploader"`
+	Limit       Limit       `config:"limit"`
+	Redaction   Redaction   `config:"redaction"`
+	AutoCapture AutoCapture `config:"auto_capture"`
+	Profiling   Profiling   `config:"profiling"`
 }
 
 func defaultDiagnostics() Diagnostics {
 	return Diagnostics{
-		Uploader: defaultUploader(),
-		Limit:    defaultLimit(),
+		Uploader:    defaultUploader(),
+		Limit:       defaultLimit(),
+		Redaction:   defaultRedaction(),
+		AutoCapture: defaultAutoCapture(),
+		Profiling:   defaultProfiling(),
 	}
 }
@@ -113,3 +113,61 @@ func TestAPMConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestAutoCaptureConfig(t *testing.T) {
+	tcs := map[string]struct {
+		in        map[string]interface{}
+		threshold ByteSize
+		maxSize   ByteSize
+		err       bool
+	}{
+		"default": {
+			in:      map[string]interface{}{},
+			maxSize: 500 * 1024 * 1024,
+		},
+		"human sizes": {
+			in: map[string]interface{}{
+				"memory.threshold": "1.5GiB",
+				"max_size":         "100MB",
+			},
+			threshold: 1536 * 1024 * 1024,
+			maxSize:   100 * 1024 * 1024,
+		},
+		"bytes": {
+			in: map[string]interface{}{
+				"memory.threshold": 1024,
+				"max_size":         2048,
+			},
+			threshold: 1024,
+			maxSize:   2048,
+		},
+		"invalid size": {
+			in:  map[string]interface{}{"max_size": "lots"},
+			err: true,
+		},
+		"negative size": {
+			in:  map[string]interface{}{"max_size": -1},
+			err: true,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			in, err := config.NewConfigFrom(map[string]interface{}{
+				"diagnostics": map[string]interface{}{"auto_capture": tc.in},
+			})
+			require.NoError(t, err)
+
+			cfg := DefaultConfig()
+			err = in.Unpack(cfg)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.threshold, cfg.Diagnostics.AutoCapture.Memory.Threshold)
+			assert.Equal(t, tc.maxSize, cfg.Diagnostics.AutoCapture.MaxSize)
+		})
+	}
+}
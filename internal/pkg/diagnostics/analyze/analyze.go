@@ -0,0 +1,509 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// Package analyze inspects a diagnostics archive written by diagnostics.ZipArchive and reports
+// the known problems found in it.
+package analyze
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/pprof/profile"
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-agent-client/v7/pkg/client"
+	agentclient "github.com/elastic/elastic-agent/pkg/control/v2/client"
+)
+
+const (
+	upgradeStateFailed   = "UPG_FAILED"
+	upgradeStateRollback = "UPG_ROLLBACK"
+
+	upgradeActionType  = "UPGRADE"
+	actionStatusFailed = "failed"
+)
+
+// Options are the thresholds used by the analysis.
+type Options struct {
+	// RestartThreshold is the number of restarts, or process exits, from which a component is
+	// reported as crash-looping.
+	RestartThreshold int
+	// HeapThreshold is the heap in use, in bytes, from which a process is reported as using high memory.
+	HeapThreshold int64
+}
+
+// DefaultOptions returns the default thresholds.
+func DefaultOptions() Options {
+	return Options{
+		RestartThreshold: 3,
+		HeapThreshold:    256 * 1024 * 1024,
+	}
+}
+
+// Report is the result of the analysis of a diagnostics archive, the sections are empty when
+// no problem of their kind was found.
+type Report struct {
+	Agent             AgentSummary       `json:"agent" yaml:"agent"`
+	Unhealthy         []UnhealthyEntry   `json:"unhealthy,omitempty" yaml:"unhealthy,omitempty"`
+	CrashLoops        []CrashLoop        `json:"crash_loops,omitempty" yaml:"crash_loops,omitempty"`
+	FleetConnectivity *FleetConnectivity `json:"fleet_connectivity,omitempty" yaml:"fleet_connectivity,omitempty"`
+	UpgradeFailures   []UpgradeFailure   `json:"upgrade_failures,omitempty" yaml:"upgrade_failures,omitempty"`
+	HighMemory        []MemoryUsage      `json:"high_memory,omitempty" yaml:"high_memory,omitempty"`
+	ConfigMismatches  []ConfigMismatch   `json:"config_mismatches,omitempty" yaml:"config_mismatches,omitempty"`
+	// Warnings are the parts of the archive that are missing or could not be read.
+	Warnings []string `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+}
+
+// Problems returns the number of problems found, warnings excluded.
+func (r *Report) Problems() int {
+	n := len(r.Unhealthy) + len(r.CrashLoops) + len(r.UpgradeFailures) + len(r.HighMemory) + len(r.ConfigMismatches)
+	if r.FleetConnectivity != nil {
+		n++
+	}
+	return n
+}
+
+// AgentSummary is the version and state of the Elastic Agent when the archive was created.
+type AgentSummary struct {
+	Version      string `json:"version,omitempty" yaml:"version,omitempty"`
+	Commit       string `json:"commit,omitempty" yaml:"commit,omitempty"`
+	Snapshot     bool   `json:"snapshot,omitempty" yaml:"snapshot,omitempty"`
+	State        string `json:"state,omitempty" yaml:"state,omitempty"`
+	Message      string `json:"message,omitempty" yaml:"message,omitempty"`
+	FleetState   string `json:"fleet_state,omitempty" yaml:"fleet_state,omitempty"`
+	FleetMessage string `json:"fleet_message,omitempty" yaml:"fleet_message,omitempty"`
+	LogLevel     string `json:"log_level,omitempty" yaml:"log_level,omitempty"`
+}
+
+// LogEntry is a log entry found in the logs of the archive.
+type LogEntry struct {
+	Timestamp time.Time `json:"@timestamp" yaml:"timestamp"`
+	Message   string    `json:"message" yaml:"message"`
+}
+
+// UnhealthyEntry is a degraded or failed component, or unit when UnitID is set.
+type UnhealthyEntry struct {
+	ComponentID string `json:"component_id" yaml:"component_id"`
+	UnitID      string `json:"unit_id,omitempty" yaml:"unit_id,omitempty"`
+	UnitType    string `json:"unit_type,omitempty" yaml:"unit_type,omitempty"`
+	State       string `json:"state" yaml:"state"`
+	Message     string `json:"message" yaml:"message"`
+	// LastError is the last error logged by the unit, or by the component for a component entry.
+	LastError *LogEntry `json:"last_error,omitempty" yaml:"last_error,omitempty"`
+}
+
+// CrashLoop is a component restarted, or whose process exited, at least Options.RestartThreshold times.
+type CrashLoop struct {
+	ComponentID string    `json:"component_id" yaml:"component_id"`
+	Restarts    int       `json:"restarts" yaml:"restarts"`
+	Exits       int       `json:"exits" yaml:"exits"`
+	LastExit    *LogEntry `json:"last_exit,omitempty" yaml:"last_exit,omitempty"`
+}
+
+// FleetConnectivity summarizes the failed check-ins with Fleet Server.
+type FleetConnectivity struct {
+	State        string    `json:"state" yaml:"state"`
+	Message      string    `json:"message,omitempty" yaml:"message,omitempty"`
+	Errors       int       `json:"errors" yaml:"errors"`
+	FirstError   time.Time `json:"first_error,omitempty" yaml:"first_error,omitempty"`
+	LastError    time.Time `json:"last_error,omitempty" yaml:"last_error,omitempty"`
+	LastErrorMsg string    `json:"last_error_msg,omitempty" yaml:"last_error_msg,omitempty"`
+}
+
+// UpgradeFailure is a failed or rolled back upgrade, from the upgrade details or the action journal.
+type UpgradeFailure struct {
+	ActionID      string    `json:"action_id,omitempty" yaml:"action_id,omitempty"`
+	TargetVersion string    `json:"target_version,omitempty" yaml:"target_version,omitempty"`
+	State         string    `json:"state" yaml:"state"`
+	FailedState   string    `json:"failed_state,omitempty" yaml:"failed_state,omitempty"`
+	Error         string    `json:"error,omitempty" yaml:"error,omitempty"`
+	Timestamp     time.Time `json:"@timestamp,omitempty" yaml:"timestamp,omitempty"`
+}
+
+// MemoryUsage is the heap in use of a process, from its heap profile.
+type MemoryUsage struct {
+	// Process is elastic-agent or the component directory of the archive.
+	Process    string `json:"process" yaml:"process"`
+	Profile    string `json:"profile" yaml:"profile"`
+	InUseBytes int64  `json:"inuse_bytes" yaml:"inuse_bytes"`
+}
+
+// ConfigMismatch is a component of the policy that is not running as configured.
+type ConfigMismatch struct {
+	ComponentID string `json:"component_id" yaml:"component_id"`
+	Reason      string `json:"reason" yaml:"reason"`
+}
+
+// stateFile is the content of state.yaml.
+type stateFile struct {
+	State          agentclient.State `yaml:"state"`
+	Message        string            `yaml:"message"`
+	FleetState     agentclient.State `yaml:"fleet_state"`
+	FleetMessage   string            `yaml:"fleet_message"`
+	LogLevel       string            `yaml:"log_level"`
+	Components     []stateComponent  `yaml:"components"`
+	UpgradeDetails *struct {
+		TargetVersion string `yaml:"target_version"`
+		State         string `yaml:"state"`
+		ActionID      string `yaml:"action_id"`
+		Metadata      struct {
+			FailedState string `yaml:"failed_state"`
+			ErrorMsg    string `yaml:"error_msg"`
+		} `yaml:"metadata"`
+	} `yaml:"upgrade_details"`
+}
+
+type stateComponent struct {
+	ID    string `yaml:"id"`
+	State struct {
+		State    client.UnitState `yaml:"state"`
+		Message  string           `yaml:"message"`
+		Restarts int              `yaml:"restarts"`
+		// Units are keyed by <unit type>-<unit ID>.
+		Units map[string]struct {
+			State   client.UnitState `yaml:"state"`
+			Message string           `yaml:"message"`
+		} `yaml:"units"`
+	} `yaml:"state"`
+}
+
+// componentsFile is the content of components-expected.yaml and components-actual.yaml.
+type componentsFile struct {
+	Components []struct {
+		ID         string `yaml:"id"`
+		Error      string `yaml:"error"`
+		InputType  string `yaml:"input_type"`
+		OutputType string `yaml:"output_type"`
+	} `yaml:"components"`
+}
+
+// journalFile is the content of action-journal.yaml.
+type journalFile struct {
+	Entries []struct {
+		Timestamp  time.Time `yaml:"timestamp"`
+		ActionID   string    `yaml:"action_id"`
+		ActionType string    `yaml:"action_type"`
+		Status     string    `yaml:"status"`
+		Error      string    `yaml:"error"`
+	} `yaml:"entries"`
+}
+
+// AnalyzeFile analyzes the diagnostics archive at path.
+func AnalyzeFile(path string, opts Options) (*Report, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open diagnostics archive %q: %w", path, err)
+	}
+	defer zr.Close()
+	return Analyze(&zr.Reader, opts)
+}
+
+// Analyze analyzes the diagnostics archive. Missing or unreadable files are reported as warnings, an
+// error is only returned when the archive contains none of the files written by the Elastic Agent.
+func Analyze(zr *zip.Reader, opts Options) (*Report, error) {
+	a := &analyzer{zr: zr, opts: opts, report: &Report{}}
+
+	var state stateFile
+	hasState := a.readYAML("state.yaml", &state)
+	var version struct {
+		Version  string `yaml:"version"`
+		Commit   string `yaml:"commit"`
+		Snapshot bool   `yaml:"snapshot"`
+	}
+	hasVersion := a.readYAML("version.txt", &version)
+	logs := a.scanLogs()
+	if !hasState && !hasVersion && logs.files == 0 {
+		return nil, errors.New("not an Elastic Agent diagnostics archive: state.yaml, version.txt and logs are missing")
+	}
+
+	a.report.Agent = AgentSummary{
+		Version:  version.Version,
+		Commit:   version.Commit,
+		Snapshot: version.Snapshot,
+	}
+	if hasState {
+		a.report.Agent.State = state.State.String()
+		a.report.Agent.Message = state.Message
+		a.report.Agent.FleetState = state.FleetState.String()
+		a.report.Agent.FleetMessage = state.FleetMessage
+		a.report.Agent.LogLevel = state.LogLevel
+	}
+
+	a.unhealthy(&state, logs)
+	a.crashLoops(&state, logs)
+	a.fleetConnectivity(hasState, &state, logs)
+	a.upgradeFailures(&state)
+	a.highMemory()
+	a.configMismatches(logs)
+	return a.report, nil
+}
+
+type analyzer struct {
+	zr     *zip.Reader
+	opts   Options
+	report *Report
+}
+
+func (a *analyzer) warnf(format string, args ...interface{}) {
+	a.report.Warnings = append(a.report.Warnings, fmt.Sprintf(format, args...))
+}
+
+// readYAML decodes the file of the archive into out, returns false when it is missing or invalid.
+func (a *analyzer) readYAML(name string, out interface{}) bool {
+	f, err := a.zr.Open(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			a.warnf("%s is missing", name)
+		} else {
+			a.warnf("failed to open %s: %v", name, err)
+		}
+		return false
+	}
+	defer f.Close()
+
+	if err := yaml.NewDecoder(f).Decode(out); err != nil {
+		if errors.Is(err, io.EOF) {
+			a.warnf("%s is empty", name)
+		} else {
+			a.warnf("failed to parse %s: %v", name, err)
+		}
+		return false
+	}
+	return true
+}
+
+func (a *analyzer) unhealthy(state *stateFile, logs *logSummary) {
+	for _, c := range state.Components {
+		var entries []UnhealthyEntry
+		for key, u := range c.State.Units {
+			if !unhealthyState(u.State) {
+				continue
+			}
+			unitType, unitID, _ := strings.Cut(key, "-")
+			entries = append(entries, UnhealthyEntry{
+				ComponentID: c.ID,
+				UnitID:      unitID,
+				UnitType:    unitType,
+				State:       u.State.String(),
+				Message:     u.Message,
+				LastError:   logs.lastError(unitID, c.ID),
+			})
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].UnitID < entries[j].UnitID })
+
+		if unhealthyState(c.State.State) {
+			a.report.Unhealthy = append(a.report.Unhealthy, UnhealthyEntry{
+				ComponentID: c.ID,
+				State:       c.State.State.String(),
+				Message:     c.State.Message,
+				LastError:   logs.lastError("", c.ID),
+			})
+		}
+		a.report.Unhealthy = append(a.report.Unhealthy, entries...)
+	}
+}
+
+func unhealthyState(s client.UnitState) bool {
+	return s == client.UnitStateDegraded || s == client.UnitStateFailed
+}
+
+func (a *analyzer) crashLoops(state *stateFile, logs *logSummary) {
+	restarts := make(map[string]int)
+	ids := make(map[string]struct{})
+	for _, c := range state.Components {
+		restarts[c.ID] = c.State.Restarts
+		ids[c.ID] = struct{}{}
+	}
+	for id := range logs.exits {
+		ids[id] = struct{}{}
+	}
+
+	for id := range ids {
+		exits := logs.exits[id]
+		if restarts[id] < a.opts.RestartThreshold && len(exits) < a.opts.RestartThreshold {
+			continue
+		}
+		loop := CrashLoop{ComponentID: id, Restarts: restarts[id], Exits: len(exits)}
+		if len(exits) > 0 {
+			last := exits[len(exits)-1]
+			loop.LastExit = &last
+		}
+		a.report.CrashLoops = append(a.report.CrashLoops, loop)
+	}
+	sort.Slice(a.report.CrashLoops, func(i, j int) bool {
+		return a.report.CrashLoops[i].ComponentID < a.report.CrashLoops[j].ComponentID
+	})
+}
+
+func (a *analyzer) fleetConnectivity(hasState bool, state *stateFile, logs *logSummary) {
+	failing := hasState && (state.FleetState == agentclient.Degraded || state.FleetState == agentclient.Failed)
+	if !failing && logs.fleet.Errors == 0 {
+		return
+	}
+	fleet := logs.fleet
+	if hasState {
+		fleet.State = state.FleetState.String()
+		fleet.Message = state.FleetMessage
+	}
+	a.report.FleetConnectivity = &fleet
+}
+
+func (a *analyzer) upgradeFailures(state *stateFile) {
+	reported := make(map[string]struct{})
+	if d := state.UpgradeDetails; d != nil && (d.State == upgradeStateFailed || d.State == upgradeStateRollback) {
+		a.report.UpgradeFailures = append(a.report.UpgradeFailures, UpgradeFailure{
+			ActionID:      d.ActionID,
+			TargetVersion: d.TargetVersion,
+			State:         d.State,
+			FailedState:   d.Metadata.FailedState,
+			Error:         d.Metadata.ErrorMsg,
+		})
+		reported[d.ActionID] = struct{}{}
+	}
+
+	if !a.exists("action-journal.yaml") {
+		return
+	}
+	var journal journalFile
+	if !a.readYAML("action-journal.yaml", &journal) {
+		return
+	}
+	for _, e := range journal.Entries {
+		if !strings.EqualFold(e.ActionType, upgradeActionType) || e.Status != actionStatusFailed {
+			continue
+		}
+		if _, ok := reported[e.ActionID]; ok {
+			continue
+		}
+		reported[e.ActionID] = struct{}{}
+		a.report.UpgradeFailures = append(a.report.UpgradeFailures, UpgradeFailure{
+			ActionID:  e.ActionID,
+			State:     upgradeStateFailed,
+			Error:     e.Error,
+			Timestamp: e.Timestamp,
+		})
+	}
+}
+
+// highMemory reports the processes with a heap profile above the threshold, the Elastic Agent profile
+// is at the root of the archive and the component profiles under components/<component>/.
+func (a *analyzer) highMemory() {
+	usage := make(map[string]MemoryUsage)
+	for _, f := range a.zr.File {
+		if path.Base(f.Name) != "heap.pprof.gz" {
+			continue
+		}
+		process := "elastic-agent"
+		if rest, ok := strings.CutPrefix(f.Name, "components/"); ok {
+			process, _, _ = strings.Cut(rest, "/")
+		} else if f.Name != "heap.pprof.gz" {
+			continue
+		}
+
+		inUse, err := heapInUse(f)
+		if err != nil {
+			a.warnf("failed to parse heap profile %s: %v", f.Name, err)
+			continue
+		}
+		if inUse > usage[process].InUseBytes {
+			usage[process] = MemoryUsage{Process: process, Profile: f.Name, InUseBytes: inUse}
+		}
+	}
+
+	for _, u := range usage {
+		if u.InUseBytes >= a.opts.HeapThreshold {
+			a.report.HighMemory = append(a.report.HighMemory, u)
+		}
+	}
+	sort.Slice(a.report.HighMemory, func(i, j int) bool {
+		return a.report.HighMemory[i].InUseBytes > a.report.HighMemory[j].InUseBytes
+	})
+}
+
+// heapInUse returns the sum of the inuse_space samples of the heap profile.
+func heapInUse(f *zip.File) (int64, error) {
+	r, err := f.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	p, err := profile.Parse(r)
+	if err != nil {
+		return 0, err
+	}
+	idx := -1
+	for i, st := range p.SampleType {
+		if st.Type == "inuse_space" {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return 0, errors.New("no inuse_space samples")
+	}
+	var total int64
+	for _, s := range p.Sample {
+		total += s.Value[idx]
+	}
+	return total, nil
+}
+
+// configMismatches reports the components of the policy with an error, the differences between the
+// expected and running components, and the components filtered by the capabilities.
+func (a *analyzer) configMismatches(logs *logSummary) {
+	var expected, actual componentsFile
+	hasExpected := a.readYAML("components-expected.yaml", &expected)
+	hasActual := a.readYAML("components-actual.yaml", &actual)
+
+	reported := make(map[ConfigMismatch]struct{})
+	add := func(m ConfigMismatch) {
+		if _, ok := reported[m]; ok {
+			return
+		}
+		reported[m] = struct{}{}
+		a.report.ConfigMismatches = append(a.report.ConfigMismatches, m)
+	}
+
+	running := make(map[string]struct{})
+	for _, c := range actual.Components {
+		running[c.ID] = struct{}{}
+	}
+	expectedIDs := make(map[string]struct{})
+	for _, c := range expected.Components {
+		expectedIDs[c.ID] = struct{}{}
+		switch {
+		case c.Error != "":
+			add(ConfigMismatch{ComponentID: c.ID, Reason: fmt.Sprintf("invalid component (input type %q, output type %q): %s", c.InputType, c.OutputType, c.Error)})
+		case hasActual:
+			if _, ok := running[c.ID]; !ok {
+				add(ConfigMismatch{ComponentID: c.ID, Reason: "expected component is not running"})
+			}
+		}
+	}
+	if hasExpected {
+		for _, c := range actual.Components {
+			if _, ok := expectedIDs[c.ID]; !ok {
+				add(ConfigMismatch{ComponentID: c.ID, Reason: "running component is not expected by the policy"})
+			}
+		}
+	}
+
+	for _, m := range logs.capabilities {
+		add(m)
+	}
+}
+
+func (a *analyzer) exists(name string) bool {
+	_, err := fs.Stat(a.zr, name)
+	return err == nil
+}
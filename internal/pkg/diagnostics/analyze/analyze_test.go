@@ -0,0 +1,238 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package analyze
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/pprof/profile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testState = `state: 3
+message: 1 or more components/units in a failed state
+fleet_state: 4
+fleet_message: fail to checkin to fleet-server
+log_level: info
+components:
+  - id: filestream-default
+    state:
+      state: 4
+      message: "Failed: pid '42' exited with code '2'"
+      restarts: 5
+      units:
+        input-filestream-default-logs:
+          state: 4
+          message: "Failed: pid '42' exited with code '2'"
+        output-filestream-default:
+          state: 2
+          message: Healthy
+  - id: system/metrics-default
+    state:
+      state: 2
+      message: Healthy
+      units:
+        input-system/metrics-default-system:
+          state: 3
+          message: error fetching cpu metrics
+upgrade_details:
+  target_version: 8.14.0
+  state: UPG_FAILED
+  action_id: upgrade-1
+  metadata:
+    failed_state: UPG_DOWNLOADING
+    error_msg: "failed download of agent binary: 404 Not Found"
+`
+
+const testComponentsExpected = `components:
+  - id: filestream-default
+    input_type: filestream
+    output_type: elasticsearch
+  - id: system/metrics-default
+    input_type: system/metrics
+    output_type: elasticsearch
+  - id: unknown-default
+    error: input not supported
+    input_type: unknown
+    output_type: elasticsearch
+`
+
+const testComponentsActual = `components:
+  - id: filestream-default
+  - id: system/metrics-default
+`
+
+const testJournal = `entries:
+  - timestamp: 2024-01-02T10:00:00Z
+    action_id: upgrade-1
+    action_type: UPGRADE
+    status: failed
+    error: download failed
+  - timestamp: 2024-01-01T10:00:00Z
+    action_id: upgrade-0
+    action_type: UPGRADE
+    status: failed
+    error: upgrade to the same version
+  - timestamp: 2024-01-02T11:00:00Z
+    action_id: policy-1
+    action_type: POLICY_CHANGE
+    status: failed
+`
+
+var testLogs = []string{
+	`{"log.level":"info","@timestamp":"2024-01-02T11:00:00.000Z","message":"Component 'packet-default' with input type 'packet' filtered by capabilities.yml"}`,
+	`{"log.level":"info","@timestamp":"2024-01-02T11:01:00.000Z","message":"Component state changed filestream-default (HEALTHY->STOPPED): Suppressing FAILED state due to restart for '40' exited with code '2'","component":{"id":"filestream-default","state":"STOPPED"}}`,
+	`{"log.level":"info","@timestamp":"2024-01-02T11:01:00.000Z","message":"Unit state changed filestream-default-logs (HEALTHY->STOPPED): Suppressing FAILED state due to restart for '40' exited with code '2'","component":{"id":"filestream-default"},"unit":{"id":"filestream-default-logs","type":"input"}}`,
+	`{"log.level":"info","@timestamp":"2024-01-02T11:02:00.000Z","message":"Component state changed filestream-default (HEALTHY->STOPPED): Suppressing FAILED state due to restart for '41' exited with code '2'","component":{"id":"filestream-default","state":"STOPPED"}}`,
+	`{"log.level":"error","@timestamp":"2024-01-02T11:03:00.000Z","message":"Component state changed filestream-default (HEALTHY->FAILED): Failed: pid '42' exited with code '2'","component":{"id":"filestream-default","state":"FAILED"}}`,
+	`{"log.level":"error","@timestamp":"2024-01-02T11:02:30.000Z","message":"Harvester could not be started","component":{"id":"filestream-default"},"unit.id":"filestream-default-logs"}`,
+	`{"log.level":"warn","@timestamp":"2024-01-02T11:04:00.000Z","log.logger":"fleet_gateway","message":"Possible transient error during checkin with fleet-server, retrying","error.message":"dial tcp: connection refused"}`,
+	`{"log.level":"error","@timestamp":"2024-01-02T11:05:00.000Z","log.logger":"fleet_gateway","message":"Cannot checkin in with fleet-server, retrying","error":{"message":"fleet-server returned 503"}}`,
+	`not json`,
+}
+
+func heapProfile(t *testing.T, inUse ...int64) []byte {
+	t.Helper()
+	p := &profile.Profile{
+		SampleType: []*profile.ValueType{
+			{Type: "alloc_space", Unit: "bytes"},
+			{Type: "inuse_space", Unit: "bytes"},
+		},
+		PeriodType: &profile.ValueType{Type: "space", Unit: "bytes"},
+	}
+	for _, v := range inUse {
+		p.Sample = append(p.Sample, &profile.Sample{Value: []int64{v * 2, v}})
+	}
+	var b bytes.Buffer
+	require.NoError(t, p.Write(&b))
+	return b.Bytes()
+}
+
+func gzipped(t *testing.T, s string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	gz := gzip.NewWriter(&b)
+	_, err := gz.Write([]byte(s))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	return b.Bytes()
+}
+
+func testArchive(t *testing.T, files map[string][]byte) *zip.Reader {
+	t.Helper()
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	zr, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	require.NoError(t, err)
+	return zr
+}
+
+func TestAnalyze(t *testing.T) {
+	const mb = 1024 * 1024
+	zr := testArchive(t, map[string][]byte{
+		"version.txt":              []byte("version: 8.13.0\ncommit: abc123\nsnapshot: false\n"),
+		"state.yaml":               []byte(testState),
+		"components-expected.yaml": []byte(testComponentsExpected),
+		"components-actual.yaml":   []byte(testComponentsActual),
+		"action-journal.yaml":      []byte(testJournal),
+		"heap.pprof.gz":            heapProfile(t, 10*mb, 20*mb),
+		"components/filestream-default/heap.pprof.gz":                  heapProfile(t, 300*mb, 100*mb),
+		"components/system-metrics-default/heap.pprof.gz":              heapProfile(t, 50*mb),
+		"logs/elastic-agent-abc123/elastic-agent-20240102.ndjson":      []byte(strings.Join(testLogs[:5], "\n") + "\n"),
+		"logs/elastic-agent-abc123/elastic-agent-20240102-1.ndjson.gz": gzipped(t, strings.Join(testLogs[5:], "\n")),
+	})
+
+	report, err := Analyze(zr, DefaultOptions())
+	require.NoError(t, err)
+
+	assert.Equal(t, AgentSummary{
+		Version:      "8.13.0",
+		Commit:       "abc123",
+		State:        "DEGRADED",
+		Message:      "1 or more components/units in a failed state",
+		FleetState:   "FAILED",
+		FleetMessage: "fail to checkin to fleet-server",
+		LogLevel:     "info",
+	}, report.Agent)
+
+	harvesterError := &LogEntry{
+		Timestamp: time.Date(2024, 1, 2, 11, 2, 30, 0, time.UTC),
+		Message:   "Harvester could not be started",
+	}
+	componentError := &LogEntry{
+		Timestamp: time.Date(2024, 1, 2, 11, 3, 0, 0, time.UTC),
+		Message:   "Component state changed filestream-default (HEALTHY->FAILED): Failed: pid '42' exited with code '2'",
+	}
+	assert.Equal(t, []UnhealthyEntry{
+		{ComponentID: "filestream-default", State: "FAILED", Message: "Failed: pid '42' exited with code '2'", LastError: componentError},
+		{ComponentID: "filestream-default", UnitID: "filestream-default-logs", UnitType: "input", State: "FAILED", Message: "Failed: pid '42' exited with code '2'", LastError: harvesterError},
+		{ComponentID: "system/metrics-default", UnitID: "system/metrics-default-system", UnitType: "input", State: "DEGRADED", Message: "error fetching cpu metrics"},
+	}, report.Unhealthy)
+
+	assert.Equal(t, []CrashLoop{
+		{ComponentID: "filestream-default", Restarts: 5, Exits: 3, LastExit: componentError},
+	}, report.CrashLoops)
+
+	assert.Equal(t, &FleetConnectivity{
+		State:        "FAILED",
+		Message:      "fail to checkin to fleet-server",
+		Errors:       2,
+		FirstError:   time.Date(2024, 1, 2, 11, 4, 0, 0, time.UTC),
+		LastError:    time.Date(2024, 1, 2, 11, 5, 0, 0, time.UTC),
+		LastErrorMsg: "fleet-server returned 503",
+	}, report.FleetConnectivity)
+
+	assert.Equal(t, []UpgradeFailure{
+		{ActionID: "upgrade-1", TargetVersion: "8.14.0", State: "UPG_FAILED", FailedState: "UPG_DOWNLOADING", Error: "failed download of agent binary: 404 Not Found"},
+		{ActionID: "upgrade-0", State: "UPG_FAILED", Error: "upgrade to the same version", Timestamp: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)},
+	}, report.UpgradeFailures)
+
+	assert.Equal(t, []MemoryUsage{
+		{Process: "filestream-default", Profile: "components/filestream-default/heap.pprof.gz", InUseBytes: 400 * mb},
+	}, report.HighMemory)
+
+	assert.Equal(t, []ConfigMismatch{
+		{ComponentID: "unknown-default", Reason: `invalid component (input type "unknown", output type "elasticsearch"): input not supported`},
+		{ComponentID: "packet-default", Reason: "Component 'packet-default' with input type 'packet' filtered by capabilities.yml"},
+	}, report.ConfigMismatches)
+
+	assert.Empty(t, report.Warnings)
+	assert.Equal(t, 10, report.Problems())
+}
+
+func TestAnalyzeMissingFiles(t *testing.T) {
+	t.Run("not a diagnostics archive", func(t *testing.T) {
+		_, err := Analyze(testArchive(t, map[string][]byte{"readme.txt": []byte("hello")}), DefaultOptions())
+		assert.Error(t, err)
+	})
+
+	t.Run("partial archive", func(t *testing.T) {
+		report, err := Analyze(testArchive(t, map[string][]byte{
+			"version.txt":   []byte("version: 8.13.0\n"),
+			"heap.pprof.gz": []byte("not a profile"),
+		}), DefaultOptions())
+		require.NoError(t, err)
+		assert.Zero(t, report.Problems())
+		assert.Equal(t, []string{
+			"state.yaml is missing",
+			"failed to parse heap profile heap.pprof.gz: parsing profile: unrecognized profile format",
+			"components-expected.yaml is missing",
+			"components-actual.yaml is missing",
+		}, report.Warnings)
+	})
+}
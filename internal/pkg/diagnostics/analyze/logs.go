@@ -0,0 +1,198 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package analyze
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	logFileRegex = regexp.MustCompile(`^elastic-agent-.*\.ndjson(\.gz)?$`)
+	// capabilityRegex matches the message logged by the coordinator when a component is filtered by the capabilities
+	capabilityRegex = regexp.MustCompile(`^Component '([^']*)' with (?:input|output) type '[^']*' filtered by capabilities\.yml`)
+)
+
+// logSummary is what the analysis needs from the logs of the archive.
+type logSummary struct {
+	files int
+	// the last errors logged, by unit ID and by component ID
+	lastUnitErrors      map[string]LogEntry
+	lastComponentErrors map[string]LogEntry
+	// exits are the process exits of the components, in time order
+	exits        map[string][]LogEntry
+	fleet        FleetConnectivity
+	capabilities []ConfigMismatch
+}
+
+// lastError returns the last error logged by the unit, falling back to the errors of the component.
+func (s *logSummary) lastError(unitID, componentID string) *LogEntry {
+	if e, ok := s.lastUnitErrors[unitID]; ok && unitID != "" {
+		return &e
+	}
+	if e, ok := s.lastComponentErrors[componentID]; ok {
+		return &e
+	}
+	return nil
+}
+
+// scanLogs reads the elastic-agent-*.ndjson files under logs/, rotated files compressed with gzip included.
+func (a *analyzer) scanLogs() *logSummary {
+	s := &logSummary{
+		lastUnitErrors:      make(map[string]LogEntry),
+		lastComponentErrors: make(map[string]LogEntry),
+		exits:               make(map[string][]LogEntry),
+	}
+	for _, f := range a.zr.File {
+		if !strings.HasPrefix(f.Name, "logs/") || !logFileRegex.MatchString(path.Base(f.Name)) {
+			continue
+		}
+		s.files++
+		if err := a.scanLogFile(f.Name, s); err != nil {
+			a.warnf("failed to read log file %s: %v", f.Name, err)
+		}
+	}
+
+	for id := range s.exits {
+		exits := s.exits[id]
+		sort.SliceStable(exits, func(i, j int) bool { return exits[i].Timestamp.Before(exits[j].Timestamp) })
+	}
+	sort.Slice(s.capabilities, func(i, j int) bool { return s.capabilities[i].ComponentID < s.capabilities[j].ComponentID })
+	return s
+}
+
+func (a *analyzer) scanLogFile(name string, s *logSummary) error {
+	f, err := a.zr.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(name, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			s.add(line)
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// add records the log entry, lines that are not JSON are ignored.
+func (s *logSummary) add(line []byte) {
+	var entry map[string]interface{}
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return
+	}
+	field := func(name string) string {
+		v, _ := lookupField(entry, name).(string)
+		return v
+	}
+
+	level := strings.ToLower(field("log.level"))
+	message := field("message")
+	componentID := field("component.id")
+	unitID := field("unit.id")
+	ts, _ := time.Parse(time.RFC3339Nano, field("@timestamp"))
+	logged := LogEntry{Timestamp: ts, Message: message}
+	if errMsg := field("error.message"); errMsg != "" {
+		logged.Message += ": " + errMsg
+	}
+
+	s.addExit(componentID, unitID, logged)
+	if m := capabilityRegex.FindStringSubmatch(message); m != nil {
+		s.capabilities = append(s.capabilities, ConfigMismatch{ComponentID: m[1], Reason: message})
+	}
+
+	isError := false
+	switch level {
+	case "error", "critical", "dpanic", "panic", "fatal":
+		isError = true
+	case "warn", "warning":
+	default:
+		return
+	}
+
+	if strings.Contains(message, "checkin") && strings.Contains(message, "fleet-server") {
+		s.fleet.Errors++
+		if s.fleet.FirstError.IsZero() || ts.Before(s.fleet.FirstError) {
+			s.fleet.FirstError = ts
+		}
+		if !ts.Before(s.fleet.LastError) {
+			s.fleet.LastError = ts
+			s.fleet.LastErrorMsg = field("error.message")
+		}
+	}
+	if !isError {
+		return
+	}
+	if unitID != "" {
+		if last, ok := s.lastUnitErrors[unitID]; !ok || !ts.Before(last.Timestamp) {
+			s.lastUnitErrors[unitID] = logged
+		}
+	}
+	if componentID != "" {
+		if last, ok := s.lastComponentErrors[componentID]; !ok || !ts.Before(last.Timestamp) {
+			s.lastComponentErrors[componentID] = logged
+		}
+	}
+}
+
+// addExit records the component state changes reported by the runtime when the process of a component
+// exits while it should be running. The units report the same message, only the component state changes
+// are counted, and the exits of stopped components are not included.
+func (s *logSummary) addExit(componentID, unitID string, entry LogEntry) {
+	if componentID == "" || unitID != "" {
+		return
+	}
+	if !strings.Contains(entry.Message, "exited with code") || strings.Contains(entry.Message, "Stopped: pid") {
+		return
+	}
+	s.exits[componentID] = append(s.exits[componentID], entry)
+}
+
+// lookupField returns the value of the field, matching both dotted keys ("log.level") and
+// nested objects ("component": {"id": ...}).
+func lookupField(entry map[string]interface{}, field string) interface{} {
+	if v, ok := entry[field]; ok {
+		return v
+	}
+	for i := strings.IndexByte(field, '.'); i >= 0; {
+		if sub, ok := entry[field[:i]].(map[string]interface{}); ok {
+			if v := lookupField(sub, field[i+1:]); v != nil {
+				return v
+			}
+		}
+		next := strings.IndexByte(field[i+1:], '.')
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return nil
+}
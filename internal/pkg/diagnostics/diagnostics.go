@@ -14,7 +14,6 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
-	"reflect"
 	"runtime/pprof"
 	"strings"
 	"time"
@@ -24,6 +23,7 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
+	"github.com/elastic/elastic-agent/internal/pkg/core/monitoring/config"
 	"github.com/elastic/elastic-agent/internal/pkg/release"
 	"github.com/elastic/elastic-agent/pkg/component"
 	"github.com/elastic/elastic-agent/version"
@@ -149,8 +149,37 @@ func pprofDiag(name string, debug int) func(context.Context) []byte {
 	}
 }
 
-// CreateCPUProfile will gather a CPU profile over a given time duration.
+// ErrCPUProfileInProgress is returned by TryCreateCPUProfile when another CPU profile is being gathered.
+var ErrCPUProfileInProgress = errors.New("a CPU profile is already being gathered")
+
+// cpuProfileSem serializes the CPU profiles, the Go runtime gathers one at a time.
+var cpuProfileSem = make(chan struct{}, 1)
+
+// CreateCPUProfile will gather a CPU profile over a given time duration, once the CPU profile
+// being gathered, if any, is done.
 func CreateCPUProfile(ctx context.Context, period time.Duration) ([]byte, error) {
+	select {
+	case cpuProfileSem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-cpuProfileSem }()
+	return cpuProfile(ctx, period)
+}
+
+// TryCreateCPUProfile will gather a CPU profile over a given time duration, unless another
+// CPU profile is being gathered, then ErrCPUProfileInProgress is returned.
+func TryCreateCPUProfile(ctx context.Context, period time.Duration) ([]byte, error) {
+	select {
+	case cpuProfileSem <- struct{}{}:
+	default:
+		return nil, ErrCPUProfileInProgress
+	}
+	defer func() { <-cpuProfileSem }()
+	return cpuProfile(ctx, period)
+}
+
+func cpuProfile(ctx context.Context, period time.Duration) ([]byte, error) {
 	var writeBuf bytes.Buffer
 	err := pprof.StartCPUProfile(&writeBuf)
 	if err != nil {
@@ -171,7 +200,13 @@ func CreateCPUProfile(ctx context.Context, period time.Duration) ([]byte, error)
 
 // ZipArchive creates a zipped diagnostics bundle using the passed writer with the passed diagnostics and local logs.
 // If any error is encountered when writing the contents of the archive it is returned.
-func ZipArchive(errOut, w io.Writer, agentDiag []client.DiagnosticFileResult, unitDiags []client.DiagnosticUnitResult, compDiags []client.DiagnosticComponentResult) error {
+func ZipArchive(errOut, w io.Writer, redaction config.Redaction, agentDiag []client.DiagnosticFileResult, unitDiags []client.DiagnosticUnitResult, compDiags []client.DiagnosticComponentResult) error {
+	// the archive is not written when the redaction policy is invalid, rather than written unredacted
+	redactor, err := NewRedactor(redaction)
+	if err != nil {
+		return fmt.Errorf("invalid redaction policy: %w", err)
+	}
+
 	ts := time.Now().UTC()
 	zw := zip.NewWriter(w)
 	defer zw.Close()
@@ -185,7 +220,7 @@ func ZipArchive(errOut, w io.Writer, agentDiag []client.DiagnosticFileResult, un
 		if err != nil {
 			return fmt.Errorf("error creating header for agent diagnostics: %w", err)
 		}
-		err = writeRedacted(errOut, zf, ad.Filename, ad)
+		err = redactor.writeRedacted(errOut, zf, ad.Filename, ad)
 		if err != nil {
 			return fmt.Errorf("error writing file for agent diagnostics: %w", err)
 		}
@@ -207,7 +242,7 @@ func ZipArchive(errOut, w io.Writer, agentDiag []client.DiagnosticFileResult, un
 	}
 	// write each units diagnostics into its own directory
 	// layout becomes components/<component-id>/<unit-id>/<filename>
-	_, err := zw.CreateHeader(&zip.FileHeader{
+	_, err = zw.CreateHeader(&zip.FileHeader{
 		Name:     "components/",
 		Method:   zip.Deflate,
 		Modified: ts,
@@ -245,7 +280,7 @@ func ZipArchive(errOut, w io.Writer, agentDiag []client.DiagnosticFileResult, un
 					if err != nil {
 						return fmt.Errorf("error creating .zip header for %s: %w", res.Filename, err)
 					}
-					err = writeRedacted(errOut, resFileWriter, filePath, res)
+					err = redactor.writeRedacted(errOut, resFileWriter, filePath, res)
 					if err != nil {
 						return fmt.Errorf("error writing %s in zip file: %w", res.Filename, err)
 					}
@@ -282,7 +317,7 @@ func ZipArchive(errOut, w io.Writer, agentDiag []client.DiagnosticFileResult, un
 				if err != nil {
 					return err
 				}
-				err = writeRedacted(errOut, w, filePath, fr)
+				err = redactor.writeRedacted(errOut, w, filePath, fr)
 				if err != nil {
 					return err
 				}
@@ -291,7 +326,19 @@ func ZipArchive(errOut, w io.Writer, agentDiag []client.DiagnosticFileResult, un
 	}
 
 	// Gather Logs:
-	return zipLogs(zw, ts)
+	if err := zipLogs(errOut, zw, ts, redactor); err != nil {
+		return err
+	}
+
+	zf, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     RedactionManifestFilename,
+		Method:   zip.Deflate,
+		Modified: ts,
+	})
+	if err != nil {
+		return fmt.Errorf("error creating .zip header for %s: %w", RedactionManifestFilename, err)
+	}
+	return redactor.writeManifest(zf)
 }
 
 func writeErrorResult(zw *zip.Writer, path string, errBody string) error {
@@ -312,89 +359,12 @@ func writeErrorResult(zw *zip.Writer, path string, errBody string) error {
 	return nil
 }
 
-func writeRedacted(errOut, resultWriter io.Writer, fullFilePath string, fileResult client.DiagnosticFileResult) error {
-	out := &fileResult.Content
-
-	// Should we support json too?
-	if fileResult.ContentType == "application/yaml" {
-		unmarshalled := map[interface{}]interface{}{}
-		err := yaml.Unmarshal(fileResult.Content, &unmarshalled)
-		if err != nil {
-			// Best effort, output a warning but still include the file
-			fmt.Fprintf(errOut, "[WARNING] Could not redact %s due to unmarshalling error: %s\n", fullFilePath, err)
-		} else {
-			redacted, err := yaml.Marshal(redactMap(errOut, unmarshalled))
-			if err != nil {
-				// Best effort, output a warning but still include the file
-				fmt.Fprintf(errOut, "[WARNING] Could not redact %s due to marshalling error: %s\n", fullFilePath, err)
-			} else {
-				out = &redacted
-			}
-		}
-	}
-
-	_, err := resultWriter.Write(*out)
-	return err
-}
-
-// redactMap sensitive values from the underlying map
-// the whole generic function here is out of paranoia. Although extremely unlikely,
-// we have no way of guaranteeing we'll get a "normal" map[string]interface{},
-// since the diagnostic interface is a bit of a free-for-all
-func redactMap[K comparable](errOut io.Writer, inputMap map[K]interface{}) map[K]interface{} {
-	if inputMap == nil {
-		return nil
-	}
-	for rootKey, rootValue := range inputMap {
-		if rootValue != nil {
-			switch cast := rootValue.(type) {
-			case map[string]interface{}:
-				rootValue = redactMap(errOut, cast)
-			case map[interface{}]interface{}:
-				rootValue = redactMap(errOut, cast)
-			case map[int]interface{}:
-				rootValue = redactMap(errOut, cast)
-			case string:
-				if keyString, ok := any(rootKey).(string); ok {
-					if redactKey(keyString) {
-						rootValue = REDACTED
-					}
-				}
-			default:
-				// in cases where we got some weird kind of map we couldn't parse, print a warning
-				if reflect.TypeOf(rootValue).Kind() == reflect.Map {
-					fmt.Fprintf(errOut, "[WARNING]: file may be partly redacted, could not cast value %v of type %T", rootKey, rootValue)
-				}
-
-			}
-		}
-
-		inputMap[rootKey] = rootValue
-
-	}
-	return inputMap
-}
-
-func redactKey(k string) bool {
-	// "routekey" shouldn't be redacted.
-	// Add any other exceptions here.
-	if k == "routekey" {
-		return false
-	}
-
-	return strings.Contains(k, "certificate") ||
-		strings.Contains(k, "passphrase") ||
-		strings.Contains(k, "password") ||
-		strings.Contains(k, "token") ||
-		strings.Contains(k, "key")
-}
-
-func zipLogs(zw *zip.Writer, ts time.Time) error {
+func zipLogs(errOut io.Writer, zw *zip.Writer, ts time.Time, redactor *Redactor) error {
 	currentDir := filepath.Base(paths.Home())
 	if !paths.IsVersionHome() {
 		// running in a container with custom top path set
 		// logs are directly under top path
-		return zipLogsWithPath(paths.Home(), currentDir, true, zw, ts)
+		return zipLogsWithPath(errOut, paths.Home(), currentDir, true, zw, ts, redactor)
 	}
 
 	dataDir, err := os.Open(paths.Data())
@@ -415,7 +385,7 @@ func zipLogs(zw *zip.Writer, ts time.Time) error {
 		}
 		collectServices := dir == currentDir
 		path := filepath.Join(paths.Data(), dir)
-		if err := zipLogsWithPath(path, dir, collectServices, zw, ts); err != nil {
+		if err := zipLogsWithPath(errOut, path, dir, collectServices, zw, ts, redactor); err != nil {
 			return err
 		}
 	}
@@ -424,7 +394,7 @@ func zipLogs(zw *zip.Writer, ts time.Time) error {
 }
 
 // zipLogs walks paths.Logs() and copies the file structure into zw in "logs/"
-func zipLogsWithPath(pathsHome, commitName string, collectServices bool, zw *zip.Writer, ts time.Time) error {
+func zipLogsWithPath(errOut io.Writer, pathsHome, commitName string, collectServices bool, zw *zip.Writer, ts time.Time, redactor *Redactor) error {
 	_, err := zw.CreateHeader(&zip.FileHeader{
 		Name:     "logs/",
 		Method:   zip.Deflate,
@@ -435,7 +405,7 @@ func zipLogsWithPath(pathsHome, commitName string, collectServices bool, zw *zip
 	}
 
 	if collectServices {
-		if err := collectServiceComponentsLogs(zw); err != nil {
+		if err := collectServiceComponentsLogs(errOut, zw, redactor); err != nil {
 			return fmt.Errorf("failed to collect endpoint-security logs: %w", err)
 		}
 	}
@@ -480,11 +450,11 @@ func zipLogsWithPath(pathsHome, commitName string, collectServices bool, zw *zip
 			return nil
 		}
 
-		return saveLogs(name, path, zw)
+		return saveLogs(errOut, name, path, zw, redactor)
 	})
 }
 
-func collectServiceComponentsLogs(zw *zip.Writer) error {
+func collectServiceComponentsLogs(errOut io.Writer, zw *zip.Writer, redactor *Redactor) error {
 	platform, err := component.LoadPlatformDetail()
 	if err != nil {
 		return fmt.Errorf("failed to gather system information: %w", err)
@@ -518,7 +488,7 @@ func collectServiceComponentsLogs(zw *zip.Writer) error {
 				return nil
 			}
 
-			return saveLogs("services/"+name, path, zw)
+			return saveLogs(errOut, "services/"+name, path, zw, redactor)
 		})
 		if err != nil {
 			return err
@@ -527,7 +497,7 @@ func collectServiceComponentsLogs(zw *zip.Writer) error {
 	return nil
 }
 
-func saveLogs(name string, logPath string, zw *zip.Writer) error {
+func saveLogs(errOut io.Writer, name string, logPath string, zw *zip.Writer, redactor *Redactor) error {
 	ts := time.Now().UTC()
 	lf, err := os.Open(logPath)
 	if err != nil {
@@ -545,10 +515,5 @@ func saveLogs(name string, logPath string, zw *zip.Writer) error {
 	if err != nil {
 		return err
 	}
-	_, err = io.Copy(zf, lf)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return redactor.redactLogs(errOut, zf, "logs/"+filepath.ToSlash(name), lf)
 }
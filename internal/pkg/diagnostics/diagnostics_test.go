@@ -109,7 +109,7 @@ i4EFZLWrFRsAAAARYWxleGtAZ3JlbWluLm5lc3QBAg==
 	outWriter := strings.Builder{}
 	res := client.DiagnosticFileResult{Content: formatted, ContentType: "application/yaml"}
 
-	err = writeRedacted(&errOut, &outWriter, "test/path", res)
+	err = defaultRedactor(t).writeRedacted(&errOut, &outWriter, "test/path", res)
 	require.NoError(t, err)
 
 	require.Empty(t, errOut.String())
@@ -134,7 +134,7 @@ mapping:
 	outWriter := strings.Builder{}
 
 	res := client.DiagnosticFileResult{Content: []byte(testComplexKey), ContentType: "application/yaml"}
-	err := writeRedacted(&errOut, &outWriter, "test/path", res)
+	err := defaultRedactor(t).writeRedacted(&errOut, &outWriter, "test/path", res)
 	require.NoError(t, err)
 
 	require.Empty(t, errOut.String())
@@ -159,12 +159,30 @@ func TestUnitAndStateMapping(t *testing.T) {
 	outWriter := strings.Builder{}
 	res := client.DiagnosticFileResult{Content: formatted, ContentType: "application/yaml"}
 
-	err = writeRedacted(&errOut, &outWriter, "test/path", res)
+	err = defaultRedactor(t).writeRedacted(&errOut, &outWriter, "test/path", res)
 	require.NoError(t, err)
 
 	require.Empty(t, errOut.String())
 }
 
+func TestCreateCPUProfileSerialized(t *testing.T) {
+	done := make(chan error)
+	go func() {
+		_, err := CreateCPUProfile(context.Background(), 200*time.Millisecond)
+		done <- err
+	}()
+	require.Eventually(t, func() bool { return len(cpuProfileSem) == 1 }, 5*time.Second, time.Millisecond)
+
+	_, err := TryCreateCPUProfile(context.Background(), 10*time.Millisecond)
+	assert.ErrorIs(t, err, ErrCPUProfileInProgress)
+
+	// waits for the CPU profile being gathered
+	p, err := CreateCPUProfile(context.Background(), 10*time.Millisecond)
+	require.NoError(t, err)
+	assert.NotEmpty(t, p)
+	require.NoError(t, <-done)
+}
+
 func TestZipLogs(t *testing.T) {
 	// Setup a directory structure of: logs/httpjson/log.ndjson
 	{
@@ -177,7 +195,7 @@ func TestZipLogs(t *testing.T) {
 	// Zip the logs directory.
 	buf := new(bytes.Buffer)
 	w := zip.NewWriter(buf)
-	require.NoError(t, zipLogs(w, time.Now()))
+	require.NoError(t, zipLogs(io.Discard, w, time.Now(), defaultRedactor(t)))
 	require.NoError(t, w.Close())
 
 	type zippedItem struct {
@@ -0,0 +1,144 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// Package profiler samples the profiles of the agent and of its components continuously, and keeps
+// them locally to investigate a problem after it happened.
+package profiler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"runtime/pprof"
+	"time"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/coordinator"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/monitoring"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
+	"github.com/elastic/elastic-agent/internal/pkg/core/monitoring/config"
+	"github.com/elastic/elastic-agent/internal/pkg/diagnostics"
+	"github.com/elastic/elastic-agent/pkg/control/v2/client"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+)
+
+const (
+	// agentProcessName is the name the profiles of the agent are kept under.
+	agentProcessName = "elastic-agent"
+	// heapFilename is the name of the gzipped heap profiles.
+	heapFilename = "heap.pprof.gz"
+)
+
+// tryGetCPUDiag is a wrapper around diagnostics.TryCreateCPUProfile so it can be replaced in unit-tests.
+var tryGetCPUDiag = func(ctx context.Context, d time.Duration) ([]byte, error) {
+	return diagnostics.TryCreateCPUProfile(ctx, d)
+}
+
+// getComponentProfiles is a wrapper around monitoring.ComponentProfiles so it can be replaced in unit-tests.
+var getComponentProfiles = monitoring.ComponentProfiles
+
+type stateProvider interface {
+	State() coordinator.State
+}
+
+// Profiler samples the CPU and heap profiles of the agent, and the profiles of the components,
+// periodically. The profiles are kept locally, bounded in size.
+type Profiler struct {
+	log   *logger.Logger
+	cfg   config.Profiling
+	coord stateProvider
+	dir   string
+	now   func() time.Time
+}
+
+// New returns a new Profiler.
+func New(log *logger.Logger, coord stateProvider, cfg config.Profiling) *Profiler {
+	dir := cfg.Dir
+	if dir == "" {
+		dir = paths.DiagnosticsProfilesDir()
+	}
+	return &Profiler{
+		log:   log,
+		cfg:   cfg,
+		coord: coord,
+		dir:   dir,
+		now:   time.Now,
+	}
+}
+
+// Run samples the profiles every interval until the context is cancelled.
+func (p *Profiler) Run(ctx context.Context) {
+	if !p.cfg.Enabled || p.cfg.Interval <= 0 {
+		return
+	}
+	p.log.Infof("Continuous profiling enabled, profiles are kept in %s", p.dir)
+
+	t := time.NewTicker(p.cfg.Interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			p.sample(ctx)
+		}
+	}
+}
+
+// sample samples the profiles of the agent and of the components concurrently and writes them.
+func (p *Profiler) sample(ctx context.Context) {
+	ts := p.now().UTC()
+	p.log.Debugf("Sampling the profiles at %s", ts.Format(time.RFC3339))
+
+	var components chan []monitoring.ComponentProfile
+	if p.cfg.Components {
+		components = make(chan []monitoring.ComponentProfile, 1)
+		go func() {
+			components <- getComponentProfiles(ctx, p.coord.State(), p.cfg.CPUDuration)
+		}()
+	}
+
+	profiles := make(map[string][]client.DiagnosticFileResult)
+	cpu, err := tryGetCPUDiag(ctx, p.cfg.CPUDuration)
+	switch {
+	case errors.Is(err, diagnostics.ErrCPUProfileInProgress):
+		// the CPU profile gathered for a diagnostics bundle comes first
+		p.log.Debug("Continuous profiling skipped the CPU profile of the agent, another one is being gathered")
+	case err != nil:
+		p.log.Warnw("Continuous profiling failed to sample the CPU profile of the agent", "error.message", err)
+	default:
+		profiles[agentProcessName] = append(profiles[agentProcessName], client.DiagnosticFileResult{
+			Filename: diagnostics.DiagCPUFilename,
+			Content:  cpu,
+		})
+	}
+	var heap bytes.Buffer
+	if err := pprof.Lookup("heap").WriteTo(&heap, 0); err != nil {
+		p.log.Warnw("Continuous profiling failed to sample the heap profile of the agent", "error.message", err)
+	} else {
+		profiles[agentProcessName] = append(profiles[agentProcessName], client.DiagnosticFileResult{
+			Filename: heapFilename,
+			Content:  heap.Bytes(),
+		})
+	}
+
+	if components != nil {
+		for _, c := range <-components {
+			if c.Err != nil {
+				p.log.Debugf("Continuous profiling failed to fetch the profiles of %s: %v", c.ComponentID, c.Err)
+				continue
+			}
+			profiles[c.ComponentID] = []client.DiagnosticFileResult{
+				{Filename: diagnostics.DiagCPUFilename, Content: c.CPU},
+				{Filename: heapFilename, Content: c.Heap},
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	if err := diagnostics.WriteProfileSample(p.dir, ts, profiles, int64(p.cfg.MaxSize)); err != nil {
+		p.log.Errorw("Continuous profiling failed to write the profiles", "error.message", err)
+	}
+}
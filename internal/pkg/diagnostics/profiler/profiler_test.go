@@ -0,0 +1,72 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package profiler
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/coordinator"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/monitoring"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
+	"github.com/elastic/elastic-agent/internal/pkg/core/monitoring/config"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+)
+
+type fakeStateProvider struct {
+	state coordinator.State
+}
+
+func (f *fakeStateProvider) State() coordinator.State {
+	return f.state
+}
+
+func TestProfilerSample(t *testing.T) {
+	paths.SetTop(t.TempDir())
+	cfg := config.DefaultConfig().Diagnostics.Profiling
+	cfg.Enabled = true
+
+	testLogger, _ := logger.NewTesting("profiler-test")
+	p := New(testLogger, &fakeStateProvider{}, cfg)
+	p.now = func() time.Time { return time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC) }
+
+	defaultTryGetCPUDiag := tryGetCPUDiag
+	defer func() { tryGetCPUDiag = defaultTryGetCPUDiag }()
+	tryGetCPUDiag = func(_ context.Context, d time.Duration) ([]byte, error) {
+		assert.Equal(t, cfg.CPUDuration, d)
+		return []byte("agent cpu"), nil
+	}
+	defaultGetComponentProfiles := getComponentProfiles
+	defer func() { getComponentProfiles = defaultGetComponentProfiles }()
+	getComponentProfiles = func(_ context.Context, _ coordinator.State, d time.Duration) []monitoring.ComponentProfile {
+		assert.Equal(t, cfg.CPUDuration, d)
+		return []monitoring.ComponentProfile{
+			{ComponentID: "system/metrics-default", CPU: []byte("component cpu"), Heap: []byte("component heap")},
+			{ComponentID: "endpoint-default", Err: errors.New("not supported")},
+		}
+	}
+
+	p.sample(context.Background())
+
+	sample := filepath.Join(paths.DiagnosticsProfilesDir(), "20240102T100000Z")
+	content, err := os.ReadFile(filepath.Join(sample, "elastic-agent", "cpu.pprof"))
+	require.NoError(t, err)
+	assert.Equal(t, "agent cpu", string(content))
+	assert.FileExists(t, filepath.Join(sample, "elastic-agent", "heap.pprof.gz"))
+	content, err = os.ReadFile(filepath.Join(sample, "system-metrics-default", "cpu.pprof"))
+	require.NoError(t, err)
+	assert.Equal(t, "component cpu", string(content))
+	content, err = os.ReadFile(filepath.Join(sample, "system-metrics-default", "heap.pprof.gz"))
+	require.NoError(t, err)
+	assert.Equal(t, "component heap", string(content))
+	assert.NoDirExists(t, filepath.Join(sample, "endpoint-default"))
+}
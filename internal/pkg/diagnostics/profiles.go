@@ -0,0 +1,154 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package diagnostics
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/elastic/elastic-agent/pkg/control/v2/client"
+)
+
+const (
+	// ProfilesDir is the directory of the diagnostics archives the sampled profiles are written into.
+	ProfilesDir = "profiles"
+	// profileSampleLayout is the layout of the names of the sample directories, in UTC.
+	profileSampleLayout = "20060102T150405Z"
+)
+
+// profileSample is a sample directory of the profiles.
+type profileSample struct {
+	path string
+	ts   time.Time
+	size int64
+}
+
+// WriteProfileSample writes the profiles of the processes sampled at ts into their own directory of dir,
+// laid out as <sample>/<process>/<filename>, then removes the oldest samples until the profiles kept
+// fit in maxSize. The sample just written is always kept.
+func WriteProfileSample(dir string, ts time.Time, profiles map[string][]client.DiagnosticFileResult, maxSize int64) error {
+	sampleDir := filepath.Join(dir, ts.UTC().Format(profileSampleLayout))
+	for process, results := range profiles {
+		processDir := filepath.Join(sampleDir, strings.ReplaceAll(process, "/", "-"))
+		if err := os.MkdirAll(processDir, 0o700); err != nil {
+			return fmt.Errorf("failed to create the profiles directory: %w", err)
+		}
+		for _, r := range results {
+			if err := os.WriteFile(filepath.Join(processDir, filepath.Base(r.Filename)), r.Content, 0o600); err != nil {
+				return fmt.Errorf("failed to write the profile %s of %s: %w", r.Filename, process, err)
+			}
+		}
+	}
+
+	samples, err := profileSamples(dir)
+	if err != nil {
+		return err
+	}
+	var total int64
+	for _, s := range samples {
+		total += s.size
+	}
+	for _, s := range samples {
+		if total <= maxSize {
+			break
+		}
+		if s.path == sampleDir {
+			continue
+		}
+		if err := os.RemoveAll(s.path); err != nil {
+			return fmt.Errorf("failed to remove the profiles sampled at %s: %w", s.ts.Format(time.RFC3339), err)
+		}
+		total -= s.size
+	}
+	return nil
+}
+
+// ReadProfiles returns the profiles of dir sampled since the given time, oldest first, as the files of
+// the profiles directory of a diagnostics archive. A missing dir has no profiles.
+func ReadProfiles(dir string, since time.Time) ([]client.DiagnosticFileResult, error) {
+	samples, err := profileSamples(dir)
+	if err != nil {
+		return nil, err
+	}
+	var results []client.DiagnosticFileResult
+	for _, s := range samples {
+		if s.ts.Before(since) {
+			continue
+		}
+		err := filepath.WalkDir(s.path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(dir, p)
+			if err != nil {
+				return err
+			}
+			content, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			results = append(results, client.DiagnosticFileResult{
+				Name:        "profile",
+				Filename:    path.Join(ProfilesDir, filepath.ToSlash(rel)),
+				Description: "Profile sampled by the continuous profiler",
+				ContentType: "application/octet-stream",
+				Content:     content,
+				Generated:   s.ts,
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the profiles sampled at %s: %w", s.ts.Format(time.RFC3339), err)
+		}
+	}
+	return results, nil
+}
+
+// profileSamples returns the sample directories of dir with their size, oldest first.
+func profileSamples(dir string) ([]profileSample, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the profiles: %w", err)
+	}
+	var samples []profileSample
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		ts, err := time.Parse(profileSampleLayout, e.Name())
+		if err != nil {
+			// not a sample
+			continue
+		}
+		s := profileSample{path: filepath.Join(dir, e.Name()), ts: ts}
+		err = filepath.WalkDir(s.path, func(_ string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			fi, err := d.Info()
+			if err != nil {
+				return err
+			}
+			s.size += fi.Size()
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the profiles sampled at %s: %w", ts.Format(time.RFC3339), err)
+		}
+		samples = append(samples, s)
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].ts.Before(samples[j].ts) })
+	return samples, nil
+}
@@ -0,0 +1,61 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package diagnostics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/pkg/control/v2/client"
+)
+
+func TestProfileSamples(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	profiles := func(size int) map[string][]client.DiagnosticFileResult {
+		return map[string][]client.DiagnosticFileResult{
+			"elastic-agent":          {{Filename: "cpu.pprof", Content: make([]byte, size)}},
+			"system/metrics-default": {{Filename: "heap.pprof.gz", Content: make([]byte, size)}},
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, WriteProfileSample(dir, start.Add(time.Duration(i)*time.Hour), profiles(100), 500))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a sample"), 0o600))
+
+	results, err := ReadProfiles(dir, start)
+	require.NoError(t, err)
+	var names []string
+	for _, r := range results {
+		names = append(names, r.Filename)
+	}
+	assert.Equal(t, []string{
+		"profiles/20240102T110000Z/elastic-agent/cpu.pprof",
+		"profiles/20240102T110000Z/system-metrics-default/heap.pprof.gz",
+		"profiles/20240102T120000Z/elastic-agent/cpu.pprof",
+		"profiles/20240102T120000Z/system-metrics-default/heap.pprof.gz",
+	}, names, "the oldest sample must be removed to stay under the maximum size")
+	assert.Equal(t, start.Add(2*time.Hour), results[3].Generated)
+
+	results, err = ReadProfiles(dir, start.Add(90*time.Minute))
+	require.NoError(t, err)
+	assert.Len(t, results, 2, "only the samples since the given time are read")
+
+	require.NoError(t, WriteProfileSample(dir, start.Add(3*time.Hour), profiles(1000), 500))
+	results, err = ReadProfiles(dir, start)
+	require.NoError(t, err)
+	require.Len(t, results, 2, "the sample just written is kept even over the maximum size")
+	assert.Equal(t, "profiles/20240102T130000Z/elastic-agent/cpu.pprof", results[0].Filename)
+
+	results, err = ReadProfiles(filepath.Join(dir, "missing"), start)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
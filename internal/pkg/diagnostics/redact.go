@@ -0,0 +1,458 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package diagnostics
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-agent/internal/pkg/core/monitoring/config"
+	"github.com/elastic/elastic-agent/pkg/control/v2/client"
+)
+
+const (
+	// RedactionModeMask replaces the redacted values with REDACTED.
+	RedactionModeMask = "mask"
+	// RedactionModeHash replaces the redacted values with a keyed hash of the value.
+	RedactionModeHash = "hash"
+
+	// RedactionManifestFilename is the file of the archive listing what was redacted.
+	RedactionManifestFilename = "redaction-manifest.yaml"
+)
+
+var (
+	// builtinRedactionKeys are the keys always redacted, the keys of the policy are added to them.
+	builtinRedactionKeys = []string{"certificate", "passphrase", "password", "token", "key"}
+	// builtinExcludedKeys are the keys never redacted, "routekey" shouldn't be redacted.
+	builtinExcludedKeys = []string{"^routekey$"}
+)
+
+// builtinRedactionPatterns are the value patterns that can be referred to by name only.
+var builtinRedactionPatterns = map[string]string{
+	"email":         `[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`,
+	"ipv4":          `\b(?:(?:25[0-5]|2[0-4][0-9]|1?[0-9]?[0-9])\.){3}(?:25[0-5]|2[0-4][0-9]|1?[0-9]?[0-9])\b`,
+	"ipv6":          `\b(?:[0-9a-fA-F]{1,4}:){7}[0-9a-fA-F]{1,4}\b|\b(?:[0-9a-fA-F]{1,4}:){1,7}:(?:[0-9a-fA-F]{1,4}:){0,6}[0-9a-fA-F]{1,4}\b`,
+	"authorization": `(?i)\b(?:bearer|apikey|basic)\s+[a-zA-Z0-9._~+/=-]{8,}`,
+}
+
+// RedactionManifest lists what was redacted in a diagnostics archive, without the redacted values.
+type RedactionManifest struct {
+	Mode  string              `yaml:"mode"`
+	Files []RedactedFileEntry `yaml:"files,omitempty"`
+}
+
+// RedactedFileEntry is what was redacted in a file of the archive.
+type RedactedFileEntry struct {
+	Path string `yaml:"path"`
+	// Keys is the number of values redacted by key, by dotted path of the key.
+	Keys map[string]int `yaml:"keys,omitempty"`
+	// Values is the number of values redacted by pattern, by pattern name.
+	Values map[string]int `yaml:"values,omitempty"`
+	// Error is set when the file could not be redacted and was written as is.
+	Error string `yaml:"error,omitempty"`
+
+	// redacted is the number of redactions
+	redacted int
+}
+
+type redactionPattern struct {
+	name  string
+	regex *regexp.Regexp
+}
+
+// Redactor redacts the files written to a diagnostics archive following the redaction policy, and
+// records what it redacted in the manifest. It is not safe for concurrent use.
+type Redactor struct {
+	mode        string
+	salt        []byte
+	keys        []*regexp.Regexp
+	excludeKeys []*regexp.Regexp
+	values      []redactionPattern
+	logs        bool
+
+	manifest RedactionManifest
+}
+
+// NewRedactor creates the Redactor applying the redaction policy.
+func NewRedactor(cfg config.Redaction) (*Redactor, error) {
+	r := &Redactor{mode: cfg.Mode, logs: cfg.Logs}
+	switch r.mode {
+	case "":
+		r.mode = RedactionModeMask
+	case RedactionModeMask:
+	case RedactionModeHash:
+		r.salt = []byte(cfg.Salt)
+		if len(r.salt) == 0 {
+			r.salt = make([]byte, 32)
+			if _, err := rand.Read(r.salt); err != nil {
+				return nil, fmt.Errorf("failed to generate the redaction hash key: %w", err)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown redaction mode %q, expected %s or %s", cfg.Mode, RedactionModeMask, RedactionModeHash)
+	}
+	r.manifest.Mode = r.mode
+
+	var err error
+	if r.keys, err = compileRedactionRegexes("key", append(builtinRedactionKeys, cfg.Keys...)); err != nil {
+		return nil, err
+	}
+	if r.excludeKeys, err = compileRedactionRegexes("excluded key", append(builtinExcludedKeys, cfg.ExcludeKeys...)); err != nil {
+		return nil, err
+	}
+	for _, p := range cfg.Values {
+		expr := p.Pattern
+		if expr == "" {
+			builtin, ok := builtinRedactionPatterns[p.Name]
+			if !ok {
+				return nil, fmt.Errorf("redaction pattern %q has no pattern and is not a built-in pattern", p.Name)
+			}
+			expr = builtin
+		}
+		regex, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", p.Name, err)
+		}
+		r.values = append(r.values, redactionPattern{name: p.Name, regex: regex})
+	}
+	return r, nil
+}
+
+func compileRedactionRegexes(kind string, exprs []string) ([]*regexp.Regexp, error) {
+	regexes := make([]*regexp.Regexp, 0, len(exprs))
+	for _, expr := range exprs {
+		regex, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction %s %q: %w", kind, expr, err)
+		}
+		regexes = append(regexes, regex)
+	}
+	return regexes, nil
+}
+
+// Manifest returns what was redacted so far.
+func (r *Redactor) Manifest() RedactionManifest {
+	return r.manifest
+}
+
+// writeManifest writes the manifest into the archive.
+func (r *Redactor) writeManifest(w io.Writer) error {
+	o, err := yaml.Marshal(r.manifest)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(o)
+	return err
+}
+
+// record adds the entry to the manifest when something was redacted, or the file could not be.
+func (r *Redactor) record(entry *RedactedFileEntry) {
+	if entry.redacted == 0 && entry.Error == "" {
+		return
+	}
+	r.manifest.Files = append(r.manifest.Files, *entry)
+}
+
+func (r *Redactor) redactKey(k string) bool {
+	for _, regex := range r.excludeKeys {
+		if regex.MatchString(k) {
+			return false
+		}
+	}
+	for _, regex := range r.keys {
+		if regex.MatchString(k) {
+			return true
+		}
+	}
+	return false
+}
+
+// replacement returns the string replacing the redacted value.
+func (r *Redactor) replacement(value string) string {
+	if r.mode != RedactionModeHash {
+		return REDACTED
+	}
+	mac := hmac.New(sha256.New, r.salt)
+	mac.Write([]byte(value))
+	return "<REDACTED:" + hex.EncodeToString(mac.Sum(nil))[:16] + ">"
+}
+
+// redactString replaces the parts of s matching the value patterns.
+func (r *Redactor) redactString(s string, entry *RedactedFileEntry) string {
+	for _, p := range r.values {
+		s = p.regex.ReplaceAllStringFunc(s, func(match string) string {
+			if entry.Values == nil {
+				entry.Values = make(map[string]int)
+			}
+			entry.Values[p.name]++
+			entry.redacted++
+			return r.replacement(match)
+		})
+	}
+	return s
+}
+
+// redactValue redacts the string values of the matching keys and the parts of the string values
+// matching the value patterns, path is the dotted path of the value.
+func (r *Redactor) redactValue(errOut io.Writer, path string, value interface{}, entry *RedactedFileEntry) interface{} {
+	switch cast := value.(type) {
+	case map[string]interface{}:
+		return redactMap(r, errOut, path, cast, entry)
+	case map[interface{}]interface{}:
+		return redactMap(r, errOut, path, cast, entry)
+	case map[int]interface{}:
+		return redactMap(r, errOut, path, cast, entry)
+	case []interface{}:
+		for i, v := range cast {
+			cast[i] = r.redactValue(errOut, path, v, entry)
+		}
+		return cast
+	case string:
+		return r.redactString(cast, entry)
+	case nil:
+		return nil
+	default:
+		// in cases where we got some weird kind of map we couldn't parse, print a warning
+		if reflect.TypeOf(value).Kind() == reflect.Map {
+			fmt.Fprintf(errOut, "[WARNING]: file may be partly redacted, could not cast value %v of type %T", path, value)
+		}
+		return value
+	}
+}
+
+// redactMap sensitive values from the underlying map
+// the whole generic function here is out of paranoia. Although extremely unlikely,
+// we have no way of guaranteeing we'll get a "normal" map[string]interface{},
+// since the diagnostic interface is a bit of a free-for-all
+func redactMap[K comparable](r *Redactor, errOut io.Writer, path string, inputMap map[K]interface{}, entry *RedactedFileEntry) map[K]interface{} {
+	if inputMap == nil {
+		return nil
+	}
+	for rootKey, rootValue := range inputMap {
+		keyPath := fmt.Sprint(rootKey)
+		if path != "" {
+			keyPath = path + "." + keyPath
+		}
+		if keyString, ok := any(rootKey).(string); ok && r.redactKey(keyString) {
+			switch cast := rootValue.(type) {
+			case string:
+				inputMap[rootKey] = r.redactKeyValue(keyPath, cast, entry)
+				continue
+			case []interface{}:
+				// lists of strings, like certificate authorities
+				for i, v := range cast {
+					if s, ok := v.(string); ok {
+						cast[i] = r.redactKeyValue(keyPath, s, entry)
+					}
+				}
+			}
+		}
+		inputMap[rootKey] = r.redactValue(errOut, keyPath, rootValue, entry)
+	}
+	return inputMap
+}
+
+// RedactConfig redacts the configuration in place, by key and value as the YAML files of the
+// diagnostics bundles, and returns it.
+func (r *Redactor) RedactConfig(errOut io.Writer, cfg map[string]interface{}) map[string]interface{} {
+	return redactMap(r, errOut, "", cfg, &RedactedFileEntry{})
+}
+
+// redactKeyValue redacts the string value of the key at path.
+func (r *Redactor) redactKeyValue(path, value string, entry *RedactedFileEntry) string {
+	if entry.Keys == nil {
+		entry.Keys = make(map[string]int)
+	}
+	entry.Keys[path]++
+	entry.redacted++
+	return r.replacement(value)
+}
+
+// writeRedacted writes the result redacted, the YAML and JSON results are redacted by key and value
+// and the text results by value. The other results, like the profiles, are written as is.
+func (r *Redactor) writeRedacted(errOut, resultWriter io.Writer, fullFilePath string, fileResult client.DiagnosticFileResult) error {
+	out := fileResult.Content
+	entry := &RedactedFileEntry{Path: fullFilePath}
+
+	switch {
+	case fileResult.ContentType == "application/yaml":
+		unmarshalled := map[interface{}]interface{}{}
+		err := yaml.Unmarshal(fileResult.Content, &unmarshalled)
+		if err != nil {
+			// Best effort, output a warning but still include the file
+			fmt.Fprintf(errOut, "[WARNING] Could not redact %s due to unmarshalling error: %s\n", fullFilePath, err)
+			entry.Error = err.Error()
+			break
+		}
+		redacted, err := yaml.Marshal(redactMap(r, errOut, "", unmarshalled, entry))
+		if err != nil {
+			// Best effort, output a warning but still include the file
+			fmt.Fprintf(errOut, "[WARNING] Could not redact %s due to marshalling error: %s\n", fullFilePath, err)
+			entry.Error = err.Error()
+			break
+		}
+		out = redacted
+	case fileResult.ContentType == "application/json":
+		var unmarshalled interface{}
+		dec := json.NewDecoder(bytes.NewReader(fileResult.Content))
+		dec.UseNumber()
+		if err := dec.Decode(&unmarshalled); err != nil {
+			fmt.Fprintf(errOut, "[WARNING] Could not redact %s due to unmarshalling error: %s\n", fullFilePath, err)
+			entry.Error = err.Error()
+			break
+		}
+		redacted := r.redactValue(errOut, "", unmarshalled, entry)
+		if entry.redacted == 0 {
+			break
+		}
+		o, err := marshalJSON(redacted, "  ")
+		if err != nil {
+			fmt.Fprintf(errOut, "[WARNING] Could not redact %s due to marshalling error: %s\n", fullFilePath, err)
+			entry.Error = err.Error()
+			break
+		}
+		out = o
+	case strings.HasPrefix(fileResult.ContentType, "text/"):
+		out = []byte(r.redactString(string(fileResult.Content), entry))
+	}
+
+	r.record(entry)
+	_, err := resultWriter.Write(out)
+	return err
+}
+
+// redactLogs copies the log file, redacted when the redaction of the logs is enabled. The lines of the
+// ndjson files are redacted by key and value, and re-encoded only when something was redacted. The lines
+// of the other text files are redacted by value, the compressed log files are copied as is.
+func (r *Redactor) redactLogs(errOut io.Writer, w io.Writer, name string, lf io.Reader) error {
+	if !r.logs {
+		_, err := io.Copy(w, lf)
+		return err
+	}
+
+	entry := &RedactedFileEntry{Path: name}
+	defer r.record(entry)
+	if !strings.HasSuffix(name, ".ndjson") && !strings.HasSuffix(name, ".log") && !strings.HasSuffix(name, ".json") {
+		entry.Error = "not a text log file"
+		_, err := io.Copy(w, lf)
+		return err
+	}
+
+	br := bufio.NewReader(lf)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			if _, werr := w.Write(r.redactLogLine(errOut, line, entry)); werr != nil {
+				return werr
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (r *Redactor) redactLogLine(errOut io.Writer, line []byte, entry *RedactedFileEntry) []byte {
+	trimmed := bytes.TrimRight(line, "\r\n")
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return []byte(r.redactString(string(line), entry))
+	}
+
+	var fields map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(trimmed))
+	dec.UseNumber()
+	if err := dec.Decode(&fields); err != nil {
+		return []byte(r.redactString(string(line), entry))
+	}
+
+	before := entry.redacted
+	redacted := redactMap(r, errOut, "", fields, entry)
+	if entry.redacted == before {
+		return line
+	}
+	o, err := marshalSorted(redacted)
+	if err != nil {
+		return []byte(r.redactString(string(line), entry))
+	}
+	return append(o, line[len(trimmed):]...)
+}
+
+// marshalJSON encodes v without escaping the HTML characters of the REDACTED replacement.
+func marshalJSON(v interface{}, indent string) ([]byte, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", indent)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
+
+// marshalSorted encodes the log entry with the @timestamp, log.level and message fields first, as
+// written by the loggers, and the other fields sorted.
+func marshalSorted(fields map[string]interface{}) ([]byte, error) {
+	first := []string{"log.level", "@timestamp", "message"}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b bytes.Buffer
+	b.WriteByte('{')
+	write := func(k string) error {
+		if b.Len() > 1 {
+			b.WriteByte(',')
+		}
+		kb, err := marshalJSON(k, "")
+		if err != nil {
+			return err
+		}
+		vb, err := marshalJSON(fields[k], "")
+		if err != nil {
+			return err
+		}
+		b.Write(kb)
+		b.WriteByte(':')
+		b.Write(vb)
+		return nil
+	}
+	for _, k := range first {
+		if _, ok := fields[k]; ok {
+			if err := write(k); err != nil {
+				return nil, err
+			}
+			delete(fields, k)
+		}
+	}
+	for _, k := range keys {
+		if _, ok := fields[k]; !ok {
+			continue
+		}
+		if err := write(k); err != nil {
+			return nil, err
+		}
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
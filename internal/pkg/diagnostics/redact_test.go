@@ -0,0 +1,195 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package diagnostics
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
+	"github.com/elastic/elastic-agent/internal/pkg/core/monitoring/config"
+	"github.com/elastic/elastic-agent/pkg/control/v2/client"
+)
+
+func defaultRedactor(t *testing.T) *Redactor {
+	t.Helper()
+	r, err := NewRedactor(config.DefaultConfig().Diagnostics.Redaction)
+	require.NoError(t, err)
+	return r
+}
+
+func TestRedactorPolicy(t *testing.T) {
+	cfg := config.DefaultConfig().Diagnostics.Redaction
+	cfg.Mode = RedactionModeHash
+	cfg.Salt = "salt"
+	cfg.Keys = []string{"^tenant$"}
+	cfg.Values = []config.RedactionPattern{
+		{Name: "email"},
+		{Name: "ipv4"},
+		{Name: "account", Pattern: `acct-[0-9]+`},
+	}
+	r, err := NewRedactor(cfg)
+	require.NoError(t, err)
+
+	content := `outputs:
+  default:
+    api_key: secret-key
+    hosts: ["https://10.0.0.1:9200"]
+    ssl:
+      certificate_authorities: ["ca-one", "ca-two"]
+routekey: kept
+owner: jane@example.com
+other_owner: jane@example.com
+account: acct-1234
+tenant: acme
+`
+	var out bytes.Buffer
+	require.NoError(t, r.writeRedacted(io.Discard, &out, "computed-config.yaml",
+		client.DiagnosticFileResult{Content: []byte(content), ContentType: "application/yaml"}))
+
+	var redacted map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(out.Bytes(), &redacted))
+	assert.Equal(t, "kept", redacted["routekey"], "excluded keys must not be redacted")
+	assert.Equal(t, redacted["owner"], redacted["other_owner"], "equal values must have equal hashes")
+	assert.Regexp(t, regexp.MustCompile(`^<REDACTED:[0-9a-f]{16}>$`), redacted["owner"])
+	assert.NotContains(t, out.String(), "secret-key")
+	assert.NotContains(t, out.String(), "ca-one")
+	assert.NotContains(t, out.String(), "10.0.0.1")
+	assert.NotContains(t, out.String(), "acct-1234")
+	assert.NotContains(t, out.String(), "acme")
+	assert.Contains(t, out.String(), "https://<REDACTED:")
+
+	assert.Equal(t, RedactionManifest{
+		Mode: RedactionModeHash,
+		Files: []RedactedFileEntry{{
+			Path: "computed-config.yaml",
+			Keys: map[string]int{
+				"outputs.default.api_key":                     1,
+				"outputs.default.ssl.certificate_authorities": 2,
+				"tenant": 1,
+			},
+			Values:   map[string]int{"email": 2, "ipv4": 1, "account": 1},
+			redacted: 8,
+		}},
+	}, r.Manifest())
+
+	t.Run("hashes are keyed", func(t *testing.T) {
+		other, err := NewRedactor(config.Redaction{Mode: RedactionModeHash, Salt: "other"})
+		require.NoError(t, err)
+		assert.NotEqual(t, r.replacement("jane@example.com"), other.replacement("jane@example.com"))
+	})
+}
+
+func TestRedactorResultTypes(t *testing.T) {
+	cfg := config.DefaultConfig().Diagnostics.Redaction
+	cfg.Values = []config.RedactionPattern{{Name: "email"}}
+	r, err := NewRedactor(cfg)
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, r.writeRedacted(io.Discard, &out, "components/filestream/state.json",
+		client.DiagnosticFileResult{Content: []byte(`{"token":"abc","count":12345678901234567890,"owner":"jane@example.com"}`), ContentType: "application/json"}))
+	assert.JSONEq(t, `{"token":"<REDACTED>","count":12345678901234567890,"owner":"<REDACTED>"}`, out.String())
+
+	out.Reset()
+	require.NoError(t, r.writeRedacted(io.Discard, &out, "notes.txt",
+		client.DiagnosticFileResult{Content: []byte("contact jane@example.com"), ContentType: "text/plain"}))
+	assert.Equal(t, "contact <REDACTED>", out.String())
+
+	out.Reset()
+	profile := []byte("jane@example.com")
+	require.NoError(t, r.writeRedacted(io.Discard, &out, "heap.pprof.gz",
+		client.DiagnosticFileResult{Content: profile, ContentType: "application/octet-stream"}))
+	assert.Equal(t, profile, out.Bytes(), "binary results are written as is")
+
+	out.Reset()
+	require.NoError(t, r.writeRedacted(io.Discard, &out, "broken.yaml",
+		client.DiagnosticFileResult{Content: []byte("key: [unterminated"), ContentType: "application/yaml"}))
+	manifest := r.Manifest()
+	require.Len(t, manifest.Files, 3)
+	assert.Equal(t, "broken.yaml", manifest.Files[2].Path)
+	assert.NotEmpty(t, manifest.Files[2].Error, "the files that could not be redacted are listed")
+}
+
+func TestRedactorInvalidPolicy(t *testing.T) {
+	for name, cfg := range map[string]config.Redaction{
+		"unknown mode":             {Mode: "shred"},
+		"invalid key":              {Keys: []string{"("}},
+		"invalid excluded key":     {ExcludeKeys: []string{"("}},
+		"unknown built-in pattern": {Values: []config.RedactionPattern{{Name: "phone"}}},
+		"invalid value pattern":    {Values: []config.RedactionPattern{{Name: "custom", Pattern: "("}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewRedactor(cfg)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestZipArchiveRedactsLogs(t *testing.T) {
+	paths.SetTop(t.TempDir())
+	dir := filepath.Join(paths.Home(), "logs")
+	require.NoError(t, os.MkdirAll(dir, 0o700))
+	unchanged := `{"log.level":"info","@timestamp":"2024-01-02T10:00:00.000Z","message":"Unit state changed","unit":{"id":"filestream-default"}}`
+	logs := strings.Join([]string{
+		unchanged,
+		`{"log.level":"info","@timestamp":"2024-01-02T10:01:00.000Z","message":"Connecting to 10.0.0.1","component":{"id":"filestream-default"},"api_key":"secret-key"}`,
+		`plain text from 10.0.0.2`,
+	}, "\n") + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "elastic-agent-20240102.ndjson"), []byte(logs), 0o600))
+
+	cfg := config.DefaultConfig().Diagnostics.Redaction
+	cfg.Logs = true
+	cfg.Values = []config.RedactionPattern{{Name: "ipv4"}}
+
+	var b bytes.Buffer
+	require.NoError(t, ZipArchive(io.Discard, &b, cfg, nil, nil, nil))
+	zr, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	require.NoError(t, err)
+
+	readFile := func(name string) string {
+		f, err := zr.Open(name)
+		require.NoError(t, err)
+		defer f.Close()
+		content, err := io.ReadAll(f)
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	logName := "logs/elastic-agent-unknow/elastic-agent-20240102.ndjson"
+	assert.Equal(t, strings.Join([]string{
+		unchanged,
+		`{"log.level":"info","@timestamp":"2024-01-02T10:01:00.000Z","message":"Connecting to <REDACTED>","api_key":"<REDACTED>","component":{"id":"filestream-default"}}`,
+		`plain text from <REDACTED>`,
+	}, "\n")+"\n", readFile(logName), "only the redacted lines are re-encoded")
+
+	var manifest RedactionManifest
+	require.NoError(t, yaml.Unmarshal([]byte(readFile(RedactionManifestFilename)), &manifest))
+	assert.Equal(t, RedactionManifest{
+		Mode: RedactionModeMask,
+		Files: []RedactedFileEntry{{
+			Path:   logName,
+			Keys:   map[string]int{"api_key": 1},
+			Values: map[string]int{"ipv4": 2},
+		}},
+	}, manifest)
+
+	t.Run("invalid policy", func(t *testing.T) {
+		var b bytes.Buffer
+		err := ZipArchive(io.Discard, &b, config.Redaction{Mode: "shred"}, nil, nil, nil)
+		assert.ErrorContains(t, err, "invalid redaction policy")
+		assert.Zero(t, b.Len(), "nothing must be written with an invalid policy")
+	})
+}
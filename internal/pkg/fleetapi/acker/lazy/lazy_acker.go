@@ -22,12 +22,21 @@ type retrier interface {
 	Enqueue([]fleetapi.Action)
 }
 
+// AckRecorder records the outcome of the acks of the actions, such as the action journal.
+type AckRecorder interface {
+	// RecordAckResponse records the actions acked in a batch with the Fleet Server response.
+	RecordAckResponse(actions []fleetapi.Action, resp *fleetapi.AckResponse)
+	// RecordAckFailure records the actions which ack failed with err.
+	RecordAckFailure(err error, actions ...fleetapi.Action)
+}
+
 // Acker is a lazy acker which performs HTTP communication on commit.
 type Acker struct {
-	log     *logger.Logger
-	acker   batchAcker
-	queue   []fleetapi.Action
-	retrier retrier
+	log      *logger.Logger
+	acker    batchAcker
+	queue    []fleetapi.Action
+	retrier  retrier
+	recorder AckRecorder
 }
 
 // Option Acker option function
@@ -55,6 +64,13 @@ func WithRetrier(r retrier) Option {
 	}
 }
 
+// WithAckRecorder option allows to specify the AckRecorder recording the acks
+func WithAckRecorder(r AckRecorder) Option {
+	return func(f *Acker) {
+		f.recorder = r
+	}
+}
+
 // Ack acknowledges action.
 func (f *Acker) Ack(ctx context.Context, action fleetapi.Action) (err error) {
 	span, ctx := apm.StartSpan(ctx, "ack", "app.internal")
@@ -86,6 +102,9 @@ func (f *Acker) Commit(ctx context.Context) (err error) {
 
 	// If request failed enqueue all actions with retrier if it is set
 	if err != nil {
+		if f.recorder != nil {
+			f.recorder.RecordAckFailure(err, actions...)
+		}
 		if f.retrier != nil {
 			f.log.Warnf("lazy acker: failed ack batch, enqueue for retry: %s", actions)
 			f.retrier.Enqueue(actions)
@@ -95,6 +114,10 @@ func (f *Acker) Commit(ctx context.Context) (err error) {
 		return err
 	}
 
+	if f.recorder != nil {
+		f.recorder.RecordAckResponse(actions, resp)
+	}
+
 	// If request succeeded check the errors on individual items
 	if f.retrier != nil && resp != nil && resp.Errors {
 		f.log.Error("lazy acker: partially failed ack batch")
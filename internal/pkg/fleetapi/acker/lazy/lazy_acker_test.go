@@ -201,3 +201,71 @@ func TestLazyAcker(t *testing.T) {
 		})
 	}
 }
+
+type testAckRecorder struct {
+	responses []*fleetapi.AckResponse
+	acked     [][]fleetapi.Action
+	failed    []fleetapi.Action
+	errs      []error
+}
+
+func (r *testAckRecorder) RecordAckResponse(actions []fleetapi.Action, resp *fleetapi.AckResponse) {
+	r.acked = append(r.acked, actions)
+	r.responses = append(r.responses, resp)
+}
+
+func (r *testAckRecorder) RecordAckFailure(err error, actions ...fleetapi.Action) {
+	r.errs = append(r.errs, err)
+	r.failed = append(r.failed, actions...)
+}
+
+func TestLazyAckerRecorder(t *testing.T) {
+	log, _ := logger.New("", false)
+	actions := []fleetapi.Action{
+		&fleetapi.ActionSettings{ActionID: "settings-1", ActionType: fleetapi.ActionTypeSettings},
+		&fleetapi.ActionSettings{ActionID: "settings-2", ActionType: fleetapi.ActionTypeSettings},
+	}
+	ackAll := func(lacker *Acker) {
+		for _, a := range actions {
+			if err := lacker.Ack(context.Background(), a); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := lacker.Commit(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the Fleet Server response is recorded for the committed batch
+	resp := &fleetapi.AckResponse{
+		Errors: true,
+		Items: []fleetapi.AckResponseItem{
+			{Status: http.StatusOK},
+			{Status: http.StatusNotFound, Message: "action not found"},
+		},
+	}
+	recorder := &testAckRecorder{}
+	ackAll(NewAcker(&testAcker{ackResponse: resp}, log, WithRetrier(&testRetrier{}), WithAckRecorder(recorder)))
+	if diff := cmp.Diff([][]fleetapi.Action{actions}, recorder.acked, cmp.Comparer(actionsComparer)); diff != "" {
+		t.Fatal(diff)
+	}
+	if recorder.responses[0] != resp {
+		t.Fatalf("expected the ack response to be recorded, got %v", recorder.responses[0])
+	}
+	if len(recorder.failed) != 0 {
+		t.Fatalf("expected no ack failure to be recorded, got %v", recorder.failed)
+	}
+
+	// the failed batch is recorded with the error
+	recorder = &testAckRecorder{}
+	ackAll(NewAcker(&testAcker{errResponse: errFoo}, log, WithRetrier(&testRetrier{}), WithAckRecorder(recorder)))
+	if diff := cmp.Diff(actions, recorder.failed, cmp.Comparer(actionsComparer)); diff != "" {
+		t.Fatal(diff)
+	}
+	if len(recorder.errs) != 1 || !errors.Is(recorder.errs[0], errFoo) {
+		t.Fatalf("expected the ack error to be recorded, got %v", recorder.errs)
+	}
+	if len(recorder.acked) != 0 {
+		t.Fatalf("expected no ack response to be recorded, got %v", recorder.acked)
+	}
+}
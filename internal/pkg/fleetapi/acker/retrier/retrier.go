@@ -6,6 +6,7 @@ package retrier
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"sync"
 	"time"
@@ -27,6 +28,14 @@ type BatchAcker interface {
 	AckBatch(ctx context.Context, actions []fleetapi.Action) (*fleetapi.AckResponse, error)
 }
 
+// AckRecorder records the outcome of the acks of the actions, such as the action journal.
+type AckRecorder interface {
+	// RecordAckResponse records the actions acked in a batch with the Fleet Server response.
+	RecordAckResponse(actions []fleetapi.Action, resp *fleetapi.AckResponse)
+	// RecordAckFailure records the actions which ack failed with err.
+	RecordAckFailure(err error, actions ...fleetapi.Action)
+}
+
 // Option Retrier option function
 type Option func(*Retrier)
 
@@ -44,6 +53,8 @@ type Retrier struct {
 	maxRetries           int           // configurable maxNumber of retries per action
 	initialRetryInterval time.Duration // initial retry interval
 
+	recorder AckRecorder // records the acks, optional
+
 	mx sync.Mutex
 }
 
@@ -86,6 +97,13 @@ func WithMaxAckRetries(maxRetries int) Option {
 	}
 }
 
+// WithAckRecorder configures retrier to record the acks with the AckRecorder provided
+func WithAckRecorder(r AckRecorder) Option {
+	return func(f *Retrier) {
+		f.recorder = r
+	}
+}
+
 // Done signals when retry loop is done, useful for testing
 func (r *Retrier) Done() <-chan struct{} {
 	return r.doneCh
@@ -143,10 +161,15 @@ func (r *Retrier) runRetries(ctx context.Context) {
 			r.log.Errorf("ack retrier: commit failed with error: %v", err)
 			// Commit failed, update retry map from actions
 			failed = r.updateRetriesMap(retries, actions, nil)
-		} else if resp != nil && resp.Errors {
-			// Commit partially failed, update retry map from failed actions
-			failed = r.updateRetriesMap(retries, actions, resp)
-			r.log.Debugf("ack retrier: commit partially failed: %#v", failed)
+		} else {
+			if r.recorder != nil {
+				r.recorder.RecordAckResponse(actions, resp)
+			}
+			if resp != nil && resp.Errors {
+				// Commit partially failed, update retry map from failed actions
+				failed = r.updateRetriesMap(retries, actions, resp)
+				r.log.Debugf("ack retrier: commit partially failed: %#v", failed)
+			}
 		}
 
 		r.log.Debugf("ack retrier: failed actions: %#v", failed)
@@ -199,6 +222,9 @@ func (r *Retrier) updateRetriesMap(retries map[string]int, actions []fleetapi.Ac
 				failed = append(failed, action)
 			} else {
 				delete(retries, action.ID())
+				if r.recorder != nil {
+					r.recorder.RecordAckFailure(errors.New("maximum number of ack retries reached"), action)
+				}
 			}
 		} else {
 			delete(retries, action.ID())
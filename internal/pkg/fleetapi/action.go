@@ -35,12 +35,39 @@ const (
 	ActionTypeCancel = "CANCEL"
 	// ActionTypeDiagnostics specifies a diagnostics action.
 	ActionTypeDiagnostics = "REQUEST_DIAGNOSTICS"
+	// ActionTypeRotateAPIKey specifies a rotation of the access API key of the agent.
+	ActionTypeRotateAPIKey = "ROTATE_API_KEY"
 )
 
+// builtinActionTypes are the action types handled by the elastic-agent itself.
+var builtinActionTypes = []string{
+	ActionTypeUnknown,
+	ActionTypeUpgrade,
+	ActionTypeUnenroll,
+	ActionTypePolicyChange,
+	ActionTypePolicyReassign,
+	ActionTypeSettings,
+	ActionTypeInputAction,
+	ActionTypeCancel,
+	ActionTypeDiagnostics,
+	ActionTypeRotateAPIKey,
+}
+
+// IsBuiltinActionType returns true when actionType is handled by the elastic-agent itself.
+func IsBuiltinActionType(actionType string) bool {
+	for _, t := range builtinActionTypes {
+		if t == actionType {
+			return true
+		}
+	}
+	return false
+}
+
 // Error values that the Action interface can return
 var (
 	ErrNoStartTime  = fmt.Errorf("action has no start time")
 	ErrNoExpiration = fmt.Errorf("action has no expiration")
+	ErrNoSwitchTime = fmt.Errorf("action has no switch time")
 )
 
 // Action base interface for all the implemented action from the fleet API.
@@ -52,7 +79,7 @@ type Action interface {
 }
 
 // ScheduledAction is an Action that may be executed at a later date
-// Only ActionUpgrade implements this at the moment
+// ActionUpgrade and the actions embedding ActionSchedule implement it.
 type ScheduledAction interface {
 	Action
 	// StartTime returns the earliest time an action should start.
@@ -83,6 +110,74 @@ type RetryableAction interface {
 	SetError(error)
 }
 
+// FailableAction is an Action which ack reports the error of the attempt to run it.
+type FailableAction interface {
+	Action
+	// GetError returns the error reported in the ack of the action.
+	GetError() error
+	// SetError sets the error reported in the ack of the action.
+	SetError(error)
+}
+
+// ActionSchedule holds the start time and expiration of an action. The actions embedding it
+// implement ScheduledAction, thus they are queued until their start time and are not run once
+// expired. They are not retried when their handler fails, fleet-server only handles the retries
+// of upgrades, see RetryableAction.
+type ActionSchedule struct {
+	ActionStartTime  string `json:"start_time,omitempty" yaml:"start_time,omitempty"`
+	ActionExpiration string `json:"expiration,omitempty" yaml:"expiration,omitempty"`
+	Err              error  `json:"-" yaml:"-"`
+}
+
+func newActionSchedule(startTime, expiration string) ActionSchedule {
+	return ActionSchedule{
+		ActionStartTime:  startTime,
+		ActionExpiration: expiration,
+	}
+}
+
+// StartTime returns the start_time as a UTC time.Time or ErrNoStartTime if there is no start time
+func (s *ActionSchedule) StartTime() (time.Time, error) {
+	if s.ActionStartTime == "" {
+		return time.Time{}, ErrNoStartTime
+	}
+	ts, err := time.Parse(time.RFC3339, s.ActionStartTime)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return ts.UTC(), nil
+}
+
+// Expiration returns the expiration as a UTC time.Time or ErrNoExpiration if there is no expiration
+func (s *ActionSchedule) Expiration() (time.Time, error) {
+	if s.ActionExpiration == "" {
+		return time.Time{}, ErrNoExpiration
+	}
+	ts, err := time.Parse(time.RFC3339, s.ActionExpiration)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return ts.UTC(), nil
+}
+
+// GetError returns the error associated with the attempt to run the action.
+func (s *ActionSchedule) GetError() error {
+	return s.Err
+}
+
+// SetError sets the error associated with the attempt to run the action, like its expiration.
+func (s *ActionSchedule) SetError(err error) {
+	s.Err = err
+}
+
+// ackError sets the error of the attempt to run the action on event, unless the
+// handler already reported one.
+func (s *ActionSchedule) ackError(event *AckEvent) {
+	if s.Err != nil && event.Error == "" {
+		event.Error = s.Err.Error()
+	}
+}
+
 type Signed struct {
 	Data      string `yaml:"data" json:"data" mapstructure:"data"`
 	Signature string `yaml:"signature" json:"signature" mapstructure:"signature"`
@@ -118,12 +213,16 @@ func newAckEvent(id, aType string) AckEvent {
 // ActionUnknown is an action that is not know by the current version of the Agent and we don't want
 // to return an error at parsing time but at execution time we can report or ignore.
 //
-// NOTE: We only keep the original type and the action id, the payload of the event is dropped, we
-// do this to make sure we do not leak any unwanted information.
+// NOTE: We only expose the original type and the action id, the payload of the event is only kept in
+// memory to hand the actions of a type declared by a component to it, see AsCustom. It is never
+// logged, acked or persisted to make sure we do not leak any unwanted information.
 type ActionUnknown struct {
 	originalType string
 	ActionID     string
 	ActionType   string
+
+	timeout int64
+	data    json.RawMessage
 }
 
 // Type returns the type of the Action.
@@ -153,6 +252,17 @@ func (a *ActionUnknown) OriginalType() string {
 	return a.originalType
 }
 
+// AsCustom returns the action with its payload as an ActionCustom, to be performed by the component
+// declaring its original type in the action_types of its specification.
+func (a *ActionUnknown) AsCustom() *ActionCustom {
+	return &ActionCustom{
+		ActionID:   a.ActionID,
+		ActionType: a.originalType,
+		Timeout:    a.timeout,
+		Data:       a.data,
+	}
+}
+
 func (a *ActionUnknown) AckEvent() AckEvent {
 	return AckEvent{
 		EventType: "ACTION_RESULT", // TODO Discuss EventType/SubType needed - by default only ACTION_RESULT was used - what is (or was) the intended purpose of these attributes? Are they documented? Can we change them to better support acking an error or a retry?
@@ -163,10 +273,64 @@ func (a *ActionUnknown) AckEvent() AckEvent {
 	}
 }
 
+// ActionCustom is an action of a type unknown to the Agent but declared by a component in the
+// action_types of its specification, see ActionUnknown.AsCustom. It's performed by the component
+// and acked with its response.
+type ActionCustom struct {
+	ActionID    string                 `json:"id" mapstructure:"id"`
+	ActionType  string                 `json:"type" mapstructure:"type"`
+	Timeout     int64                  `json:"timeout,omitempty" mapstructure:"timeout,omitempty"`
+	Data        json.RawMessage        `json:"data" mapstructure:"data"`
+	Response    map[string]interface{} `json:"response,omitempty" mapstructure:"response,omitempty"`
+	StartedAt   string                 `json:"started_at,omitempty" mapstructure:"started_at,omitempty"`
+	CompletedAt string                 `json:"completed_at,omitempty" mapstructure:"completed_at,omitempty"`
+	Error       string                 `json:"error,omitempty" mapstructure:"error,omitempty"`
+}
+
+func (a *ActionCustom) String() string {
+	var s strings.Builder
+	s.WriteString("action_id: ")
+	s.WriteString(a.ActionID)
+	s.WriteString(", type: ")
+	s.WriteString(a.ActionType)
+	return s.String()
+}
+
+// ID returns the ID of the Action.
+func (a *ActionCustom) ID() string {
+	return a.ActionID
+}
+
+// Type returns the type of the Action.
+func (a *ActionCustom) Type() string {
+	return a.ActionType
+}
+
+func (a *ActionCustom) AckEvent() AckEvent {
+	return AckEvent{
+		EventType:      "ACTION_RESULT",
+		SubType:        "ACKNOWLEDGED",
+		ActionID:       a.ActionID,
+		Message:        fmt.Sprintf("Action %q of type %q acknowledged.", a.ActionID, a.ActionType),
+		ActionResponse: a.Response,
+		StartedAt:      a.StartedAt,
+		CompletedAt:    a.CompletedAt,
+		Error:          a.Error,
+	}
+}
+
+// MarshalMap marshals ActionCustom into a corresponding map
+func (a *ActionCustom) MarshalMap() (map[string]interface{}, error) {
+	var res map[string]interface{}
+	err := mapstructure.Decode(a, &res)
+	return res, err
+}
+
 // ActionPolicyReassign is a request to apply a new
 type ActionPolicyReassign struct {
-	ActionID   string `yaml:"action_id"`
-	ActionType string `yaml:"type"`
+	ActionID       string `yaml:"action_id"`
+	ActionType     string `yaml:"type"`
+	ActionSchedule `yaml:",inline"`
 }
 
 func (a *ActionPolicyReassign) String() string {
@@ -189,7 +353,9 @@ func (a *ActionPolicyReassign) ID() string {
 }
 
 func (a *ActionPolicyReassign) AckEvent() AckEvent {
-	return newAckEvent(a.ActionID, a.ActionType)
+	event := newAckEvent(a.ActionID, a.ActionType)
+	a.ackError(&event)
+	return event
 }
 
 // ActionPolicyChange is a request to apply a new
@@ -233,6 +399,12 @@ type ActionUpgrade struct {
 	Retry            int     `json:"retry_attempt,omitempty" yaml:"retry_attempt,omitempty" mapstructure:"-"`
 	Signed           *Signed `json:"signed,omitempty" yaml:"signed,omitempty" mapstructure:"signed,omitempty"`
 	Err              error   `json:"-" yaml:"-" mapstructure:"-"`
+	// PrepareOnly requests the artifact to be downloaded, verified and unpacked
+	// without switching the running agent over to it.
+	PrepareOnly bool `json:"prepare_only,omitempty" yaml:"prepare_only,omitempty" mapstructure:"-"`
+	// SwitchAt is the time at which a prepared upgrade is switched to. Only
+	// meaningful together with PrepareOnly.
+	SwitchAt string `json:"switch_at,omitempty" yaml:"switch_at,omitempty" mapstructure:"-"`
 }
 
 func (a *ActionUpgrade) String() string {
@@ -323,6 +495,19 @@ func (a *ActionUpgrade) SetStartTime(t time.Time) {
 	a.ActionStartTime = t.Format(time.RFC3339)
 }
 
+// SwitchTime returns the switch_at time as a UTC time.Time or ErrNoSwitchTime if
+// the prepared upgrade has no scheduled switch.
+func (a *ActionUpgrade) SwitchTime() (time.Time, error) {
+	if a.SwitchAt == "" {
+		return time.Time{}, ErrNoSwitchTime
+	}
+	ts, err := time.Parse(time.RFC3339, a.SwitchAt)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return ts.UTC(), nil
+}
+
 // MarshalMap marshals ActionUpgrade into a corresponding map
 func (a *ActionUpgrade) MarshalMap() (map[string]interface{}, error) {
 	var res map[string]interface{}
@@ -370,9 +555,10 @@ func (a *ActionUnenroll) MarshalMap() (map[string]interface{}, error) {
 
 // ActionSettings is a request to change agent settings.
 type ActionSettings struct {
-	ActionID   string `yaml:"action_id"`
-	ActionType string `yaml:"type"`
-	LogLevel   string `json:"log_level" yaml:"log_level,omitempty"`
+	ActionID       string `yaml:"action_id"`
+	ActionType     string `yaml:"type"`
+	LogLevel       string `json:"log_level" yaml:"log_level,omitempty"`
+	ActionSchedule `yaml:",inline"`
 }
 
 // ID returns the ID of the Action.
@@ -397,7 +583,9 @@ func (a *ActionSettings) String() string {
 }
 
 func (a *ActionSettings) AckEvent() AckEvent {
-	return newAckEvent(a.ActionID, a.ActionType)
+	event := newAckEvent(a.ActionID, a.ActionType)
+	a.ackError(&event)
+	return event
 }
 
 // ActionCancel is a request to cancel an action.
@@ -434,11 +622,11 @@ func (a *ActionCancel) AckEvent() AckEvent {
 
 // ActionDiagnostics is a request to gather and upload a diagnostics bundle.
 type ActionDiagnostics struct {
-	ActionID          string   `json:"action_id"`
-	ActionType        string   `json:"type"`
-	AdditionalMetrics []string `json:"additional_metrics"`
-	UploadID          string   `json:"-"`
-	Err               error    `json:"-"`
+	ActionID          string   `json:"action_id" yaml:"action_id"`
+	ActionType        string   `json:"type" yaml:"type"`
+	AdditionalMetrics []string `json:"additional_metrics" yaml:"additional_metrics,omitempty"`
+	UploadID          string   `json:"-" yaml:"-"`
+	ActionSchedule    `yaml:",inline"`
 }
 
 // ID returns the ID of the action.
@@ -462,9 +650,7 @@ func (a *ActionDiagnostics) String() string {
 
 func (a *ActionDiagnostics) AckEvent() AckEvent {
 	event := newAckEvent(a.ActionID, a.ActionType)
-	if a.Err != nil {
-		event.Error = a.Err.Error()
-	}
+	a.ackError(&event)
 	if a.UploadID != "" {
 		var data struct {
 			UploadID string `json:"upload_id"`
@@ -477,18 +663,63 @@ func (a *ActionDiagnostics) AckEvent() AckEvent {
 	return event
 }
 
+// ActionRotateAPIKey is a request to replace the access API key of the agent with a new one.
+type ActionRotateAPIKey struct {
+	ActionID       string `json:"action_id" yaml:"action_id"`
+	ActionType     string `json:"type" yaml:"type"`
+	APIKeyID       string `json:"-" yaml:"-"`
+	ActionSchedule `yaml:",inline"`
+}
+
+// ID returns the ID of the action.
+func (a *ActionRotateAPIKey) ID() string {
+	return a.ActionID
+}
+
+// Type returns the type of the action.
+func (a *ActionRotateAPIKey) Type() string {
+	return a.ActionType
+}
+
+func (a *ActionRotateAPIKey) String() string {
+	var s strings.Builder
+	s.WriteString("action_id: ")
+	s.WriteString(a.ActionID)
+	s.WriteString(", type: ")
+	s.WriteString(a.ActionType)
+	return s.String()
+}
+
+// AckEvent returns the ack of the action. Once rotated, it holds the ID of the new API key
+// so fleet-server can invalidate the previous one.
+func (a *ActionRotateAPIKey) AckEvent() AckEvent {
+	event := newAckEvent(a.ActionID, a.ActionType)
+	a.ackError(&event)
+	if a.APIKeyID != "" {
+		var data struct {
+			APIKeyID string `json:"api_key_id"`
+		}
+		data.APIKeyID = a.APIKeyID
+		p, _ := json.Marshal(data)
+		event.Data = p
+	}
+
+	return event
+}
+
 // ActionApp is the application action request.
 type ActionApp struct {
-	ActionID    string                 `json:"id" mapstructure:"id"`
-	ActionType  string                 `json:"type" mapstructure:"type"`
-	InputType   string                 `json:"input_type" mapstructure:"input_type"`
-	Timeout     int64                  `json:"timeout,omitempty" mapstructure:"timeout,omitempty"`
-	Data        json.RawMessage        `json:"data" mapstructure:"data"`
-	Response    map[string]interface{} `json:"response,omitempty" mapstructure:"response,omitempty"`
-	StartedAt   string                 `json:"started_at,omitempty" mapstructure:"started_at,omitempty"`
-	CompletedAt string                 `json:"completed_at,omitempty" mapstructure:"completed_at,omitempty"`
-	Signed      *Signed                `json:"signed,omitempty" mapstructure:"signed,omitempty"`
-	Error       string                 `json:"error,omitempty" mapstructure:"error,omitempty"`
+	ActionID       string                 `json:"id" yaml:"action_id" mapstructure:"id"`
+	ActionType     string                 `json:"type" yaml:"type" mapstructure:"type"`
+	InputType      string                 `json:"input_type" yaml:"input_type,omitempty" mapstructure:"input_type"`
+	Timeout        int64                  `json:"timeout,omitempty" yaml:"timeout,omitempty" mapstructure:"timeout,omitempty"`
+	Data           json.RawMessage        `json:"data" yaml:"data,omitempty" mapstructure:"data"`
+	Response       map[string]interface{} `json:"response,omitempty" yaml:"-" mapstructure:"response,omitempty"`
+	StartedAt      string                 `json:"started_at,omitempty" yaml:"-" mapstructure:"started_at,omitempty"`
+	CompletedAt    string                 `json:"completed_at,omitempty" yaml:"-" mapstructure:"completed_at,omitempty"`
+	Signed         *Signed                `json:"signed,omitempty" yaml:"signed,omitempty" mapstructure:"signed,omitempty"`
+	Error          string                 `json:"error,omitempty" yaml:"-" mapstructure:"error,omitempty"`
+	ActionSchedule `json:"-" yaml:",inline" mapstructure:"-"`
 }
 
 func (a *ActionApp) String() string {
@@ -513,7 +744,7 @@ func (a *ActionApp) Type() string {
 }
 
 func (a *ActionApp) AckEvent() AckEvent {
-	return AckEvent{
+	event := AckEvent{
 		EventType:       "ACTION_RESULT",
 		SubType:         "ACKNOWLEDGED",
 		ActionID:        a.ActionID,
@@ -525,6 +756,8 @@ func (a *ActionApp) AckEvent() AckEvent {
 		CompletedAt:     a.CompletedAt,
 		Error:           a.Error,
 	}
+	a.ackError(&event)
+	return event
 }
 
 // MarshalMap marshals ActionApp into a corresponding map
@@ -562,18 +795,20 @@ func (a *Actions) UnmarshalJSON(data []byte) error {
 			}
 		case ActionTypePolicyReassign:
 			action = &ActionPolicyReassign{
-				ActionID:   response.ActionID,
-				ActionType: response.ActionType,
+				ActionID:       response.ActionID,
+				ActionType:     response.ActionType,
+				ActionSchedule: newActionSchedule(response.ActionStartTime, response.ActionExpiration),
 			}
 		case ActionTypeInputAction:
 			// Only INPUT_ACTION type actions could possibly be signed https://github.com/elastic/elastic-agent/pull/2348
 			action = &ActionApp{
-				ActionID:   response.ActionID,
-				ActionType: response.ActionType,
-				InputType:  response.InputType,
-				Timeout:    response.Timeout,
-				Data:       response.Data,
-				Signed:     response.Signed,
+				ActionID:       response.ActionID,
+				ActionType:     response.ActionType,
+				InputType:      response.InputType,
+				Timeout:        response.Timeout,
+				Data:           response.Data,
+				Signed:         response.Signed,
+				ActionSchedule: newActionSchedule(response.ActionStartTime, response.ActionExpiration),
 			}
 		case ActionTypeUnenroll:
 			action = &ActionUnenroll{
@@ -597,8 +832,9 @@ func (a *Actions) UnmarshalJSON(data []byte) error {
 			}
 		case ActionTypeSettings:
 			action = &ActionSettings{
-				ActionID:   response.ActionID,
-				ActionType: response.ActionType,
+				ActionID:       response.ActionID,
+				ActionType:     response.ActionType,
+				ActionSchedule: newActionSchedule(response.ActionStartTime, response.ActionExpiration),
 			}
 
 			if err := json.Unmarshal(response.Data, action); err != nil {
@@ -618,19 +854,28 @@ func (a *Actions) UnmarshalJSON(data []byte) error {
 			}
 		case ActionTypeDiagnostics:
 			action = &ActionDiagnostics{
-				ActionID:   response.ActionID,
-				ActionType: response.ActionType,
+				ActionID:       response.ActionID,
+				ActionType:     response.ActionType,
+				ActionSchedule: newActionSchedule(response.ActionStartTime, response.ActionExpiration),
 			}
 			if err := json.Unmarshal(response.Data, action); err != nil {
 				return errors.New(err,
 					"fail to decode REQUEST_DIAGNOSTICS_ACTION action",
 					errors.TypeConfig)
 			}
+		case ActionTypeRotateAPIKey:
+			action = &ActionRotateAPIKey{
+				ActionID:       response.ActionID,
+				ActionType:     response.ActionType,
+				ActionSchedule: newActionSchedule(response.ActionStartTime, response.ActionExpiration),
+			}
 		default:
 			action = &ActionUnknown{
 				ActionID:     response.ActionID,
 				ActionType:   ActionTypeUnknown,
 				originalType: response.ActionType,
+				timeout:      response.Timeout,
+				data:         response.Data,
 			}
 		}
 		actions = append(actions, action)
@@ -648,6 +893,13 @@ func (a *Actions) UnmarshalYAML(unmarshal func(interface{}) error) error {
 			"fail to decode action",
 			errors.TypeConfig)
 	}
+	// the queued actions are persisted with their payload attributes at the top level
+	var raw []map[string]interface{}
+	if err := unmarshal(&raw); err != nil {
+		return errors.New(err,
+			"fail to decode action",
+			errors.TypeConfig)
+	}
 	actions := make([]Action, 0, len(nodes))
 	for i := range nodes {
 		var action Action
@@ -665,17 +917,19 @@ func (a *Actions) UnmarshalYAML(unmarshal func(interface{}) error) error {
 			}
 		case ActionTypePolicyReassign:
 			action = &ActionPolicyReassign{
-				ActionID:   n.ActionID,
-				ActionType: n.ActionType,
+				ActionID:       n.ActionID,
+				ActionType:     n.ActionType,
+				ActionSchedule: newActionSchedule(n.ActionStartTime, n.ActionExpiration),
 			}
 		case ActionTypeInputAction:
 			action = &ActionApp{
-				ActionID:   n.ActionID,
-				ActionType: n.ActionType,
-				InputType:  n.InputType,
-				Timeout:    n.Timeout,
-				Data:       n.Data,
-				Signed:     n.Signed,
+				ActionID:       n.ActionID,
+				ActionType:     n.ActionType,
+				InputType:      n.InputType,
+				Timeout:        n.Timeout,
+				Data:           n.Data,
+				Signed:         n.Signed,
+				ActionSchedule: newActionSchedule(n.ActionStartTime, n.ActionExpiration),
 			}
 		case ActionTypeUnenroll:
 			action = &ActionUnenroll{
@@ -698,8 +952,9 @@ func (a *Actions) UnmarshalYAML(unmarshal func(interface{}) error) error {
 			}
 		case ActionTypeSettings:
 			action = &ActionSettings{
-				ActionID:   n.ActionID,
-				ActionType: n.ActionType,
+				ActionID:       n.ActionID,
+				ActionType:     n.ActionType,
+				ActionSchedule: newActionSchedule(n.ActionStartTime, n.ActionExpiration),
 			}
 			if err := yaml.Unmarshal(n.Data, action); err != nil {
 				return errors.New(err,
@@ -718,14 +973,21 @@ func (a *Actions) UnmarshalYAML(unmarshal func(interface{}) error) error {
 			}
 		case ActionTypeDiagnostics:
 			action = &ActionDiagnostics{
-				ActionID:   n.ActionID,
-				ActionType: n.ActionType,
+				ActionID:       n.ActionID,
+				ActionType:     n.ActionType,
+				ActionSchedule: newActionSchedule(n.ActionStartTime, n.ActionExpiration),
 			}
 			if err := yaml.Unmarshal(n.Data, action); err != nil {
 				return errors.New(err,
 					"fail to decode REQUEST_DIAGNOSTICS_ACTION action",
 					errors.TypeConfig)
 			}
+		case ActionTypeRotateAPIKey:
+			action = &ActionRotateAPIKey{
+				ActionID:       n.ActionID,
+				ActionType:     n.ActionType,
+				ActionSchedule: newActionSchedule(n.ActionStartTime, n.ActionExpiration),
+			}
 		default:
 			action = &ActionUnknown{
 				ActionID:     n.ActionID,
@@ -733,8 +995,24 @@ func (a *Actions) UnmarshalYAML(unmarshal func(interface{}) error) error {
 				originalType: n.ActionType,
 			}
 		}
+		if _, ok := action.(ScheduledAction); ok && i < len(raw) {
+			if err := decodeYAMLNode(raw[i], action); err != nil {
+				return errors.New(err,
+					fmt.Sprintf("fail to decode queued %s action", n.ActionType),
+					errors.TypeConfig)
+			}
+		}
 		actions = append(actions, action)
 	}
 	*a = actions
 	return nil
 }
+
+// decodeYAMLNode decodes the attributes of the yaml node into action.
+func decodeYAMLNode(node map[string]interface{}, action Action) error {
+	b, err := yaml.Marshal(node)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(b, action)
+}
@@ -175,6 +175,41 @@ func TestActionsUnmarshalJSON(t *testing.T) {
 		require.Len(t, action.AdditionalMetrics, 1)
 		assert.Equal(t, "CPU", action.AdditionalMetrics[0])
 	})
+	t.Run("ActionRotateAPIKey", func(t *testing.T) {
+		p := []byte(`[{"id":"testid","type":"ROTATE_API_KEY","expiration":"2024-01-02T12:00:00Z"}]`)
+		a := &Actions{}
+		err := a.UnmarshalJSON(p)
+		require.Nil(t, err)
+		action, ok := (*a)[0].(*ActionRotateAPIKey)
+		require.True(t, ok, "unable to cast action to specific type")
+		assert.Equal(t, "testid", action.ActionID)
+		assert.Equal(t, ActionTypeRotateAPIKey, action.ActionType)
+		assert.Equal(t, "2024-01-02T12:00:00Z", action.ActionExpiration)
+
+		assert.Nil(t, action.AckEvent().Data)
+		action.APIKeyID = "new-key-id"
+		assert.JSONEq(t, `{"api_key_id":"new-key-id"}`, string(action.AckEvent().Data))
+	})
+	t.Run("ActionUnknown as a custom action", func(t *testing.T) {
+		p := []byte(`[{"id":"testid","type":"CUSTOM_RESTART","timeout":30,"data":{"service":"foo"}}]`)
+		a := &Actions{}
+		err := a.UnmarshalJSON(p)
+		require.Nil(t, err)
+		unknown, ok := (*a)[0].(*ActionUnknown)
+		require.True(t, ok, "unable to cast action to specific type")
+		assert.Equal(t, "testid", unknown.ActionID)
+		assert.Equal(t, "CUSTOM_RESTART", unknown.OriginalType())
+		// the payload is not exposed by the unknown action
+		assert.NotContains(t, unknown.String(), "foo")
+		assert.NotContains(t, unknown.AckEvent().Message+unknown.AckEvent().Error, "foo")
+		assert.Nil(t, unknown.AckEvent().Data)
+
+		action := unknown.AsCustom()
+		assert.Equal(t, "testid", action.ActionID)
+		assert.Equal(t, "CUSTOM_RESTART", action.Type())
+		assert.Equal(t, int64(30), action.Timeout)
+		assert.JSONEq(t, `{"service":"foo"}`, string(action.Data))
+	})
 }
 
 func TestActionUnenrollMarshalMap(t *testing.T) {
@@ -0,0 +1,87 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package fleetapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/errors"
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi/client"
+)
+
+const apiKeyPath = "/api/fleet/agents/%s/api_key"
+
+// APIKeyResponse is the response send back from the server with the new access API key of the agent.
+//
+//	{
+//	  "api_key_id": "...",
+//	  "api_key": "..."
+//	}
+type APIKeyResponse struct {
+	APIKeyID string `json:"api_key_id"`
+	APIKey   string `json:"api_key"`
+}
+
+// Validate validates the response send from the server.
+func (r *APIKeyResponse) Validate() error {
+	if r.APIKeyID == "" {
+		return errors.New("missing API key ID")
+	}
+	if r.APIKey == "" {
+		return errors.New("missing API key")
+	}
+	return nil
+}
+
+// APIKeyCmd is a fleet API command creating a new access API key for the agent.
+// The previous API key stays valid until the agent acknowledges the rotation.
+// POST /agents/{agentId}/api_key
+// Authorization: ApiKey {AgentAccessApiKey}
+type APIKeyCmd struct {
+	client client.Sender
+	info   agentInfo
+}
+
+// NewAPIKeyCmd creates a new api command.
+func NewAPIKeyCmd(info agentInfo, client client.Sender) *APIKeyCmd {
+	return &APIKeyCmd{
+		client: client,
+		info:   info,
+	}
+}
+
+// Execute requests a new access API key to Fleet Server.
+func (e *APIKeyCmd) Execute(ctx context.Context) (*APIKeyResponse, error) {
+	cp := fmt.Sprintf(apiKeyPath, e.info.AgentID())
+	resp, err := e.client.Send(ctx, http.MethodPost, cp, nil, nil, nil)
+	if err != nil {
+		return nil, errors.New(err,
+			"fail to create a new API key",
+			errors.TypeNetwork,
+			errors.M(errors.MetaKeyURI, cp))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, client.ExtractError(resp.Body)
+	}
+
+	apiKeyResponse := &APIKeyResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(apiKeyResponse); err != nil {
+		return nil, errors.New(err,
+			"fail to decode API key response",
+			errors.TypeNetwork,
+			errors.M(errors.MetaKeyURI, cp))
+	}
+
+	if err := apiKeyResponse.Validate(); err != nil {
+		return nil, err
+	}
+
+	return apiKeyResponse, nil
+}
@@ -0,0 +1,56 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package fleetapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi/client"
+)
+
+func TestAPIKey(t *testing.T) {
+	const withAPIKey = "secret"
+	agentInfo := &agentinfo{}
+	path := fmt.Sprintf("/api/fleet/agents/%s/api_key", agentInfo.AgentID())
+
+	t.Run("Test API key roundtrip", withServerWithAuthClient(
+		func(t *testing.T) *http.ServeMux {
+			mux := http.NewServeMux()
+			mux.HandleFunc(path, authHandler(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, http.MethodPost, r.Method)
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprint(w, `{"api_key_id": "new-id", "api_key": "new-secret"}`)
+			}, withAPIKey))
+			return mux
+		}, withAPIKey,
+		func(t *testing.T, client client.Sender) {
+			cmd := NewAPIKeyCmd(agentInfo, client)
+			resp, err := cmd.Execute(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, &APIKeyResponse{APIKeyID: "new-id", APIKey: "new-secret"}, resp)
+		},
+	))
+
+	t.Run("Test missing API key", withServerWithAuthClient(
+		func(t *testing.T) *http.ServeMux {
+			mux := http.NewServeMux()
+			mux.HandleFunc(path, authHandler(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprint(w, `{"api_key_id": "new-id"}`)
+			}, withAPIKey))
+			return mux
+		}, withAPIKey,
+		func(t *testing.T, client client.Sender) {
+			cmd := NewAPIKeyCmd(agentInfo, client)
+			_, err := cmd.Execute(context.Background())
+			require.ErrorContains(t, err, "missing API key")
+		},
+	))
+}
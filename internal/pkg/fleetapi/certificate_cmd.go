@@ -0,0 +1,110 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package fleetapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/errors"
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi/client"
+)
+
+const certificatePath = "/api/fleet/agents/%s/certificate"
+
+// CertificateRequest is the request to renew the client certificate of the agent.
+// POST /agents/{agentId}/certificate
+// Authorization: ApiKey {AgentAccessApiKey}
+//
+//	{
+//	  "csr": "-----BEGIN CERTIFICATE REQUEST-----\n..."
+//	}
+type CertificateRequest struct {
+	CSR string `json:"csr"`
+}
+
+// Validate validates the certificate request before sending it to the API.
+func (r *CertificateRequest) Validate() error {
+	if r.CSR == "" {
+		return errors.New("missing certificate signing request")
+	}
+	return nil
+}
+
+// CertificateResponse is the response send back from the server with the signed client certificate.
+//
+//	{
+//	  "certificate": "-----BEGIN CERTIFICATE-----\n..."
+//	}
+type CertificateResponse struct {
+	Certificate string `json:"certificate"`
+}
+
+// Validate validates the response send from the server.
+func (r *CertificateResponse) Validate() error {
+	if r.Certificate == "" {
+		return errors.New("missing certificate")
+	}
+	return nil
+}
+
+// CertificateCmd is a fleet API command renewing the client certificate of the agent.
+type CertificateCmd struct {
+	client client.Sender
+	info   agentInfo
+}
+
+// NewCertificateCmd creates a new api command.
+func NewCertificateCmd(info agentInfo, client client.Sender) *CertificateCmd {
+	return &CertificateCmd{
+		client: client,
+		info:   info,
+	}
+}
+
+// Execute sends the certificate signing request to Fleet Server and returns the signed certificate.
+func (e *CertificateCmd) Execute(ctx context.Context, r *CertificateRequest) (*CertificateResponse, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, errors.New(err,
+			"fail to encode the certificate request",
+			errors.TypeUnexpected)
+	}
+
+	cp := fmt.Sprintf(certificatePath, e.info.AgentID())
+	resp, err := e.client.Send(ctx, http.MethodPost, cp, nil, nil, bytes.NewBuffer(b))
+	if err != nil {
+		return nil, errors.New(err,
+			"fail to renew the certificate",
+			errors.TypeNetwork,
+			errors.M(errors.MetaKeyURI, cp))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, client.ExtractError(resp.Body)
+	}
+
+	certResponse := &CertificateResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(certResponse); err != nil {
+		return nil, errors.New(err,
+			"fail to decode certificate response",
+			errors.TypeNetwork,
+			errors.M(errors.MetaKeyURI, cp))
+	}
+
+	if err := certResponse.Validate(); err != nil {
+		return nil, err
+	}
+
+	return certResponse, nil
+}
@@ -0,0 +1,67 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package fleetapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi/client"
+)
+
+func TestCertificate(t *testing.T) {
+	const withAPIKey = "secret"
+	agentInfo := &agentinfo{}
+	path := fmt.Sprintf("/api/fleet/agents/%s/certificate", agentInfo.AgentID())
+
+	t.Run("Test certificate roundtrip", withServerWithAuthClient(
+		func(t *testing.T) *http.ServeMux {
+			mux := http.NewServeMux()
+			mux.HandleFunc(path, authHandler(func(w http.ResponseWriter, r *http.Request) {
+				req := &CertificateRequest{}
+				err := json.NewDecoder(r.Body).Decode(req)
+				require.NoError(t, err)
+				require.Equal(t, "my-csr", req.CSR)
+
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprint(w, `{"certificate": "my-certificate"}`)
+			}, withAPIKey))
+			return mux
+		}, withAPIKey,
+		func(t *testing.T, client client.Sender) {
+			cmd := NewCertificateCmd(agentInfo, client)
+			resp, err := cmd.Execute(context.Background(), &CertificateRequest{CSR: "my-csr"})
+			require.NoError(t, err)
+			require.Equal(t, "my-certificate", resp.Certificate)
+		},
+	))
+
+	t.Run("Test certificate not signed", withServerWithAuthClient(
+		func(t *testing.T) *http.ServeMux {
+			mux := http.NewServeMux()
+			mux.HandleFunc(path, authHandler(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"statusCode": 400, "error": "BadRequest", "message": "invalid certificate signing request"}`)
+			}, withAPIKey))
+			return mux
+		}, withAPIKey,
+		func(t *testing.T, client client.Sender) {
+			cmd := NewCertificateCmd(agentInfo, client)
+			_, err := cmd.Execute(context.Background(), &CertificateRequest{CSR: "my-csr"})
+			require.ErrorContains(t, err, "invalid certificate signing request")
+		},
+	))
+
+	t.Run("Test missing CSR", func(t *testing.T) {
+		cmd := NewCertificateCmd(agentInfo, nil)
+		_, err := cmd.Execute(context.Background(), &CertificateRequest{})
+		require.ErrorContains(t, err, "missing certificate signing request")
+	})
+}
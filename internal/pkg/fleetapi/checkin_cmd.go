@@ -17,6 +17,7 @@ import (
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/details"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/errors"
 	"github.com/elastic/elastic-agent/internal/pkg/fleetapi/client"
+	"github.com/elastic/elastic-agent/internal/pkg/remote"
 )
 
 const checkingPath = "/api/fleet/agents/%s/checkin"
@@ -28,6 +29,8 @@ type CheckinUnit struct {
 	Status  string                 `json:"status"`
 	Message string                 `json:"message"`
 	Payload map[string]interface{} `json:"payload,omitempty"`
+	// Removed is only set in a delta checkin for a unit that no longer exists.
+	Removed bool `json:"removed,omitempty"`
 }
 
 // CheckinShipperReference provides information about a component shipper connection during checkin.
@@ -44,6 +47,20 @@ type CheckinComponent struct {
 	Message string                   `json:"message"`
 	Units   []CheckinUnit            `json:"units,omitempty"`
 	Shipper *CheckinShipperReference `json:"shipper,omitempty"`
+	// Removed is only set in a delta checkin for a component that no longer exists.
+	Removed bool `json:"removed,omitempty"`
+}
+
+// CheckinStateTransition is a change of the agent, component or unit status
+// recorded while fleet-server was unreachable. The agent status transitions have
+// no ComponentID, the component status transitions have no UnitID.
+type CheckinStateTransition struct {
+	Timestamp   time.Time `json:"@timestamp"`
+	ComponentID string    `json:"component_id,omitempty"`
+	UnitID      string    `json:"unit_id,omitempty"`
+	UnitType    string    `json:"unit_type,omitempty"`
+	Status      string    `json:"status"`
+	Message     string    `json:"message"`
 }
 
 // CheckinRequest consists of multiple events reported to fleet ui.
@@ -54,6 +71,21 @@ type CheckinRequest struct {
 	Message        string             `json:"message"`    // V2 Agent message
 	Components     []CheckinComponent `json:"components"` // V2 Agent components
 	UpgradeDetails *details.Details   `json:"upgrade_details,omitempty"`
+
+	// StateHistory holds the state transitions that happened since the last
+	// successful checkin, oldest first. It isn't part of the agent state.
+	StateHistory []CheckinStateTransition `json:"state_history,omitempty"`
+
+	// Delta is set when Components and Metadata only hold the changes since the
+	// state identified by BaseStateHash, see NewCheckinDelta.
+	Delta         bool   `json:"delta,omitempty"`
+	BaseStateHash string `json:"base_state_hash,omitempty"`
+	// StateHash is the hash of the full agent state, see CheckinStateHash.
+	StateHash string `json:"state_hash,omitempty"`
+
+	// PollTimeout is how long fleet-server holds the checkin when there are no actions,
+	// parsed with time.ParseDuration. fleet-server uses its configured timeout when empty.
+	PollTimeout string `json:"poll_timeout,omitempty"`
 }
 
 // SerializableEvent is a representation of the event to be send to the Fleet Server API via the checkin
@@ -82,6 +114,11 @@ type CheckinResponse struct {
 	AckToken     string  `json:"ack_token"`
 	Actions      Actions `json:"actions"`
 	FleetWarning string  `json:"-"`
+	// StateHash is the hash of the agent state held by fleet-server after the checkin.
+	// fleet-servers not supporting delta checkins never set it.
+	StateHash string `json:"state_hash,omitempty"`
+	// Resync requests the agent to send its full state on the next checkin.
+	Resync bool `json:"resync,omitempty"`
 }
 
 // Validate validates the response send from the server.
@@ -123,7 +160,8 @@ func (e *CheckinCmd) Execute(ctx context.Context, r *CheckinRequest) (*CheckinRe
 
 	cp := fmt.Sprintf(checkingPath, e.info.AgentID())
 	sendStart := time.Now()
-	resp, err := e.client.Send(ctx, "POST", cp, nil, nil, bytes.NewBuffer(b))
+	// checkin is long-polling, fleet-server holds it until there are actions or it times out
+	resp, err := e.client.Send(remote.ContextWithLongPoll(ctx), "POST", cp, nil, nil, bytes.NewBuffer(b))
 	sendDuration := time.Since(sendStart)
 	if err != nil {
 		return nil, sendDuration, errors.New(err,
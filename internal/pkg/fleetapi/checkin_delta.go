@@ -0,0 +1,164 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package fleetapi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/info"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/details"
+)
+
+// checkinState is the part of a full CheckinRequest describing the agent state.
+// Components and units are sorted so the hash doesn't depend on their order.
+type checkinState struct {
+	Status         string             `json:"status"`
+	Message        string             `json:"message"`
+	Metadata       *info.ECSMeta      `json:"local_metadata"`
+	Components     []CheckinComponent `json:"components,omitempty"`
+	UpgradeDetails *details.Details   `json:"upgrade_details"`
+}
+
+// CheckinStateHash returns the hash of the agent state reported by the full
+// checkin request r. The ack token and delta fields are not part of the state.
+func CheckinStateHash(r *CheckinRequest) (string, error) {
+	state := checkinState{
+		Status:         r.Status,
+		Message:        r.Message,
+		Metadata:       r.Metadata,
+		Components:     sortedComponents(r.Components),
+		UpgradeDetails: r.UpgradeDetails,
+	}
+
+	b, err := json.Marshal(state)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode checkin state: %w", err)
+	}
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// NewCheckinDelta returns a delta checkin request holding the changes from the
+// full checkin request base to the full checkin request current:
+//   - only the added components and the components whose status, message or
+//     units changed, with only their added and changed units,
+//   - the removed components and units with Removed set,
+//   - the metadata, only if it changed.
+//
+// Status, message, ack token and upgrade details are always taken from current.
+// The caller is responsible for setting the state hashes.
+func NewCheckinDelta(base, current *CheckinRequest) *CheckinRequest {
+	delta := &CheckinRequest{
+		Status:         current.Status,
+		AckToken:       current.AckToken,
+		Message:        current.Message,
+		Components:     []CheckinComponent{},
+		UpgradeDetails: current.UpgradeDetails,
+		Delta:          true,
+	}
+	if !reflect.DeepEqual(base.Metadata, current.Metadata) {
+		delta.Metadata = current.Metadata
+	}
+
+	baseComponents := make(map[string]CheckinComponent, len(base.Components))
+	for _, c := range base.Components {
+		baseComponents[c.ID] = c
+	}
+
+	for _, c := range sortedComponents(current.Components) {
+		prev, ok := baseComponents[c.ID]
+		delete(baseComponents, c.ID)
+		if !ok {
+			delta.Components = append(delta.Components, c)
+			continue
+		}
+
+		units := unitsDelta(prev.Units, c.Units)
+		if len(units) == 0 &&
+			c.Status == prev.Status &&
+			c.Message == prev.Message &&
+			c.Type == prev.Type &&
+			reflect.DeepEqual(c.Shipper, prev.Shipper) {
+			continue
+		}
+		c.Units = units
+		delta.Components = append(delta.Components, c)
+	}
+
+	for _, c := range sortedComponents(mapValues(baseComponents)) {
+		delta.Components = append(delta.Components, CheckinComponent{ID: c.ID, Type: c.Type, Removed: true})
+	}
+
+	return delta
+}
+
+func unitsDelta(base, current []CheckinUnit) []CheckinUnit {
+	baseUnits := make(map[string]CheckinUnit, len(base))
+	for _, u := range base {
+		baseUnits[unitKey(u)] = u
+	}
+
+	var delta []CheckinUnit
+	for _, u := range sortedUnits(current) {
+		prev, ok := baseUnits[unitKey(u)]
+		delete(baseUnits, unitKey(u))
+		if ok &&
+			u.Status == prev.Status &&
+			u.Message == prev.Message &&
+			reflect.DeepEqual(u.Payload, prev.Payload) {
+			continue
+		}
+		delta = append(delta, u)
+	}
+
+	for _, u := range sortedUnits(mapValues(baseUnits)) {
+		delta = append(delta, CheckinUnit{ID: u.ID, Type: u.Type, Removed: true})
+	}
+
+	return delta
+}
+
+func unitKey(u CheckinUnit) string {
+	return u.Type + "/" + u.ID
+}
+
+func sortedComponents(components []CheckinComponent) []CheckinComponent {
+	if components == nil {
+		return nil
+	}
+
+	sorted := make([]CheckinComponent, len(components))
+	copy(sorted, components)
+	for i := range sorted {
+		sorted[i].Units = sortedUnits(sorted[i].Units)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	return sorted
+}
+
+func sortedUnits(units []CheckinUnit) []CheckinUnit {
+	if units == nil {
+		return nil
+	}
+
+	sorted := make([]CheckinUnit, len(units))
+	copy(sorted, units)
+	sort.Slice(sorted, func(i, j int) bool { return unitKey(sorted[i]) < unitKey(sorted[j]) })
+	return sorted
+}
+
+func mapValues[T any](m map[string]T) []T {
+	values := make([]T, 0, len(m))
+	for _, v := range m {
+		values = append(values, v)
+	}
+	return values
+}
@@ -0,0 +1,63 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package fleetapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/info"
+)
+
+func TestCheckinDelta(t *testing.T) {
+	base := &CheckinRequest{
+		Status:   "online",
+		Message:  "Running",
+		Metadata: &info.ECSMeta{Host: &info.HostECSMeta{Hostname: "host"}},
+		Components: []CheckinComponent{
+			{ID: "log-default", Type: "log", Status: "HEALTHY", Units: []CheckinUnit{
+				{ID: "log-default", Type: "output", Status: "HEALTHY"},
+				{ID: "log-default-logfile", Type: "input", Status: "HEALTHY"},
+			}},
+			{ID: "system/metrics-default", Type: "system/metrics", Status: "HEALTHY", Units: []CheckinUnit{
+				{ID: "system/metrics-default", Type: "output", Status: "HEALTHY"},
+			}},
+		},
+	}
+	current := &CheckinRequest{
+		Status:   "online",
+		Message:  "Running",
+		Metadata: &info.ECSMeta{Host: &info.HostECSMeta{Hostname: "host"}},
+		Components: []CheckinComponent{
+			{ID: "log-default", Type: "log", Status: "DEGRADED", Units: []CheckinUnit{
+				{ID: "log-default-logfile", Type: "input", Status: "DEGRADED", Message: "file not found"},
+				{ID: "log-default", Type: "output", Status: "HEALTHY"},
+			}},
+			{ID: "filestream-default", Type: "filestream", Status: "STARTING"},
+		},
+	}
+
+	delta := NewCheckinDelta(base, current)
+	assert.True(t, delta.Delta)
+	assert.Nil(t, delta.Metadata, "unchanged metadata is not sent")
+	assert.Equal(t, []CheckinComponent{
+		{ID: "filestream-default", Type: "filestream", Status: "STARTING"},
+		{ID: "log-default", Type: "log", Status: "DEGRADED", Units: []CheckinUnit{
+			{ID: "log-default-logfile", Type: "input", Status: "DEGRADED", Message: "file not found"},
+		}},
+		{ID: "system/metrics-default", Type: "system/metrics", Removed: true},
+	}, delta.Components)
+
+	currentHash, err := CheckinStateHash(current)
+	require.NoError(t, err)
+	baseHash, err := CheckinStateHash(base)
+	require.NoError(t, err)
+	assert.NotEqual(t, baseHash, currentHash)
+
+	unchanged := NewCheckinDelta(current, current)
+	assert.Empty(t, unchanged.Components)
+}
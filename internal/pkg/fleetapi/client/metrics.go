@@ -0,0 +1,65 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package client
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/elastic/elastic-agent-libs/monitoring"
+	"github.com/elastic/elastic-agent/internal/pkg/remote"
+)
+
+var (
+	hostsMetricsOnce   sync.Once
+	hostsMetricsClient atomic.Pointer[remote.Client]
+)
+
+// ReportHostsMetrics reports the health of the fleet-server hosts of c in the
+// fleet_hosts entry of the agent stats, exposed by the monitoring HTTP server.
+// It replaces the client previously reported, as c is the one in use.
+func ReportHostsMetrics(c *remote.Client) {
+	hostsMetricsClient.Store(c)
+	hostsMetricsOnce.Do(func() {
+		monitoring.NewFunc(monitoring.GetNamespace("stats").GetRegistry(), "fleet_hosts", reportHostsMetrics, monitoring.Report)
+	})
+}
+
+// HostsMetricsReporter reports the hosts health of the fleet-server client set
+// with SetClient, see ReportHostsMetrics.
+type HostsMetricsReporter struct{}
+
+// SetClient reports the hosts health of c if it's a fleet-server client.
+func (HostsMetricsReporter) SetClient(c Sender) {
+	if rc, ok := c.(*remote.Client); ok {
+		ReportHostsMetrics(rc)
+	}
+}
+
+func reportHostsMetrics(_ monitoring.Mode, v monitoring.Visitor) {
+	v.OnRegistryStart()
+	defer v.OnRegistryFinished()
+
+	c := hostsMetricsClient.Load()
+	if c == nil {
+		return
+	}
+
+	for _, h := range c.HostsHealth() {
+		monitoring.ReportNamespace(v, h.Host, func() {
+			monitoring.ReportBool(v, "healthy", h.Healthy)
+			monitoring.ReportBool(v, "preferred", h.Preferred)
+			monitoring.ReportInt(v, "latency_ms", h.Latency.Milliseconds())
+			monitoring.ReportFloat(v, "error_rate", h.ErrorRate)
+			monitoring.ReportInt(v, "consecutive_failures", int64(h.ConsecutiveFailures))
+			monitoring.ReportInt(v, "requests", int64(h.Requests))
+			monitoring.ReportInt(v, "failures", int64(h.Failures))
+			monitoring.ReportInt(v, "probes", int64(h.Probes))
+			if h.LastError != "" {
+				monitoring.ReportString(v, "last_error", h.LastError)
+			}
+		})
+	}
+}
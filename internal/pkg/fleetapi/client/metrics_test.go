@@ -0,0 +1,35 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/monitoring"
+	"github.com/elastic/elastic-agent/internal/pkg/remote"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+)
+
+func TestHostsMetricsReporter(t *testing.T) {
+	log, _ := logger.NewTesting(t.Name())
+	cfg := remote.DefaultClientConfig()
+	cfg.Hosts = []string{"http://fleet-1:8220", "http://fleet-2:8220"}
+	c, err := remote.NewWithConfig(log, cfg, nil)
+	require.NoError(t, err)
+
+	HostsMetricsReporter{}.SetClient(c)
+
+	snapshot := monitoring.CollectStructSnapshot(monitoring.GetNamespace("stats").GetRegistry(), monitoring.Full, false)
+	hosts, ok := snapshot["fleet_hosts"].(map[string]interface{})
+	require.True(t, ok, "fleet_hosts missing from stats: %v", snapshot)
+	require.Len(t, hosts, 2)
+	host, ok := hosts["http://fleet-1:8220/"].(map[string]interface{})
+	require.True(t, ok, "fleet-1 missing from fleet_hosts: %v", hosts)
+	assert.Equal(t, true, host["healthy"])
+	assert.Equal(t, int64(0), host["requests"])
+}
@@ -84,12 +84,18 @@ func (p EnrollType) MarshalJSON() ([]byte, error) {
 //	  "metadata": {
 //		  "local": { "os": "macos"},
 //		  "user_provided": { "region": "us-east"}
-//	  }
+//	  },
+//	  "csr": "-----BEGIN CERTIFICATE REQUEST-----\n...",
+//	  "secrets_public_key": "kpwKx1AzL2qjYSqzYjOdVXEI9GvlOq/k4c6J1LCbxl4="
 //	}
 type EnrollRequest struct {
 	EnrollAPIKey string     `json:"-"`
 	Type         EnrollType `json:"type"`
 	Metadata     Metadata   `json:"metadata"`
+	// CSR is the PEM encoded certificate signing request for the client certificate of the agent.
+	CSR string `json:"csr,omitempty"`
+	// SecretsPublicKey is the base64 encoded X25519 public key Fleet encrypts the policy secrets to.
+	SecretsPublicKey string `json:"secrets_public_key,omitempty"`
 }
 
 // Metadata is a all the metadata send or received from the elastic-agent.
@@ -149,6 +155,8 @@ type EnrollItemResponse struct {
 	Actions              []interface{}          `json:"actions"`
 	AccessAPIKey         string                 `json:"access_api_key"`
 	Tags                 []string               `json:"tags"`
+	// Certificate is the PEM encoded client certificate signed from the CSR of the request.
+	Certificate string `json:"certificate,omitempty"`
 }
 
 // Validate validates the response send from the server.
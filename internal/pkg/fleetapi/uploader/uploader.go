@@ -13,9 +13,17 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"math"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/elastic/elastic-agent/internal/pkg/core/backoff"
 	"github.com/elastic/elastic-agent/internal/pkg/core/monitoring/config"
@@ -29,13 +37,27 @@ const (
 	PathFinishUpload = "/api/fleet/uploads/%s"
 )
 
+// The following constants are the compression algorithms of the uploaded chunks.
+const (
+	CompressionNone = "none"
+	CompressionZstd = "zstd"
+)
+
+// errBadRequest is returned when fleet-server rejects the new upload request.
+var errBadRequest = errors.New("new upload request rejected")
+
+// resumeMaxAge is the age after which an interrupted upload is no longer resumed.
+const resumeMaxAge = 24 * time.Hour
+
 // FileData contains metadata about a file.
 type FileData struct {
 	Size      int64  `json:"size"`
 	Name      string `json:"name"`
 	Extension string `json:"ext"`
 	Mime      string `json:"mime_type"`
-	Hash      struct {
+	// Compression is the algorithm the agent proposes to compress the chunks with.
+	Compression string `json:"compression,omitempty"`
+	Hash        struct {
 		SHA256 string `json:"sha256"`
 		MD5    string `json:"md5"`
 	} `json:"hash"`
@@ -54,6 +76,9 @@ type NewUploadRequest struct {
 type NewUploadResponse struct {
 	UploadID  string `json:"upload_id"`
 	ChunkSize int64  `json:"chunk_size"`
+	// Compression is the algorithm accepted by fleet-server, the chunks are sent
+	// uncompressed when it's empty.
+	Compression string `json:"compression,omitempty"`
 }
 
 // FinishRequest is the struct that is used when finalizing an upload.
@@ -64,16 +89,18 @@ type FinishRequest struct {
 }
 
 // retrySender wraps the underlying Sender with retry logic.
+// It's safe for concurrent use, each request has its own backoff.
 type retrySender struct {
-	c    client.Sender
-	max  int
-	wait backoff.Backoff
+	c       client.Sender
+	max     int
+	newWait func() backoff.Backoff
 }
 
 // Send calls the underlying Sender's Send method.
 // If a non context-related error is returned or the 429 status code is returned the request is retried after a backoff period.
 func (r *retrySender) Send(ctx context.Context, method, path string, params url.Values, headers http.Header, body io.Reader) (resp *http.Response, err error) {
-	r.wait.Reset()
+	wait := r.newWait()
+	wait.Reset()
 
 	var b bytes.Buffer
 	tr := io.TeeReader(body, &b)
@@ -84,12 +111,12 @@ func (r *retrySender) Send(ctx context.Context, method, path string, params url.
 				return resp, err
 			}
 			tr = bytes.NewReader(b.Bytes())
-			r.wait.Wait()
+			wait.Wait()
 			continue
 		}
 		if resp.StatusCode == http.StatusTooManyRequests {
 			tr = bytes.NewReader(b.Bytes())
-			r.wait.Wait()
+			wait.Wait()
 			continue
 		}
 		return resp, err
@@ -104,22 +131,64 @@ func (r *retrySender) URI() string {
 
 // Client provides methods to upload a file to ES through fleet-server.
 type Client struct {
-	agentID string
-	c       client.Sender
+	agentID     string
+	mx          sync.Mutex
+	c           client.Sender
+	concurrency int
+	compression string
+	resumeDir   string
+}
+
+// Option is a Client option function.
+type Option func(*Client)
+
+// WithResumeDir keeps the state of the uploads in dir, an upload interrupted by the
+// cancellation of its context is resumed from its last uploaded chunk by the next
+// upload of the same action.
+func WithResumeDir(dir string) Option {
+	return func(c *Client) {
+		c.resumeDir = dir
+	}
 }
 
 // New returns a new Client for the agent identified by the passed id.
 // The sender is wrapped with retry logic specified by the Uploader config.
 // Any request that would return a 429 (too many requests) is retried (up to maxRetries times) with a backoff.
-func New(id string, c client.Sender, cfg config.Uploader) *Client {
-	return &Client{
+// The chunks are uploaded in parallel, up to the concurrency of the Uploader config.
+func New(id string, c client.Sender, cfg config.Uploader, opts ...Option) *Client {
+	u := &Client{
 		agentID: id,
 		c: &retrySender{
-			c:    c,
-			max:  cfg.MaxRetries,
-			wait: backoff.NewEqualJitterBackoff(nil, cfg.InitDur, cfg.MaxDur),
+			c:   c,
+			max: cfg.MaxRetries,
+			newWait: func() backoff.Backoff {
+				return backoff.NewEqualJitterBackoff(nil, cfg.InitDur, cfg.MaxDur)
+			},
 		},
+		concurrency: cfg.Concurrency,
+		compression: cfg.Compression,
+	}
+	for _, opt := range opts {
+		opt(u)
+	}
+	return u
+}
+
+// SetClient sets the client the files are uploaded with, the retries of the previous one are kept.
+func (c *Client) SetClient(sender client.Sender) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if r, ok := c.c.(*retrySender); ok {
+		c.c = &retrySender{c: sender, max: r.max, newWait: r.newWait}
+		return
 	}
+	c.c = sender
+}
+
+func (c *Client) sender() client.Sender {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.c
 }
 
 // New sends a new file upload request to the fleet-server.
@@ -131,12 +200,15 @@ func (c *Client) New(ctx context.Context, r *NewUploadRequest) (*NewUploadRespon
 	if err != nil {
 		return nil, err
 	}
-	resp, err := c.c.Send(ctx, http.MethodPost, PathNewUpload, nil, nil, bytes.NewBuffer(b))
+	resp, err := c.sender().Send(ctx, http.MethodPost, PathNewUpload, nil, nil, bytes.NewBuffer(b))
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusBadRequest {
+		return nil, fmt.Errorf("%w: %w", errBadRequest, client.ExtractError(resp.Body))
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, client.ExtractError(resp.Body)
 	}
@@ -151,8 +223,16 @@ func (c *Client) New(ctx context.Context, r *NewUploadRequest) (*NewUploadRespon
 
 // Chunk uploads a file chunk to fleet-server.
 func (c *Client) Chunk(ctx context.Context, uploadID string, chunkID int, sha256Hash []byte, r io.Reader) error {
+	return c.chunk(ctx, uploadID, chunkID, sha256Hash, "", r)
+}
+
+// chunk uploads a file chunk encoded with encoding, sha256Hash is the hash of the decoded chunk.
+func (c *Client) chunk(ctx context.Context, uploadID string, chunkID int, sha256Hash []byte, encoding string, r io.Reader) error {
 	h := http.Header{"X-Chunk-Sha2": {fmt.Sprintf("%x", sha256Hash)}}
-	resp, err := c.c.Send(ctx, "PUT", fmt.Sprintf(PathChunk, uploadID, chunkID), nil, h, r)
+	if encoding != "" {
+		h.Set("Content-Encoding", encoding)
+	}
+	resp, err := c.sender().Send(ctx, "PUT", fmt.Sprintf(PathChunk, uploadID, chunkID), nil, h, r)
 	if err != nil {
 		return err
 	}
@@ -171,7 +251,7 @@ func (c *Client) Finish(ctx context.Context, id string, r *FinishRequest) error
 		return err
 	}
 
-	resp, err := c.c.Send(ctx, "POST", fmt.Sprintf(PathFinishUpload, id), nil, nil, bytes.NewBuffer(b))
+	resp, err := c.sender().Send(ctx, "POST", fmt.Sprintf(PathFinishUpload, id), nil, nil, bytes.NewBuffer(b))
 	if err != nil {
 		return err
 	}
@@ -184,9 +264,73 @@ func (c *Client) Finish(ctx context.Context, id string, r *FinishRequest) error
 }
 
 // UploadDiagnostics is a wrapper to upload a diagnostics request identified by the passed action id contained in the buffer to fleet-server.
+//
+// The chunks are uploaded in parallel and compressed when fleet-server accepts the proposed
+// compression. When the Client has a resume directory and the previous upload of the action was
+// interrupted, only the chunks not uploaded yet are sent, r must then contain the same bundle.
 func (c *Client) UploadDiagnostics(ctx context.Context, actionId string, timestamp string, size int64, r io.Reader) (string, error) {
+	ra, ok := r.(io.ReaderAt)
+	if !ok {
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return "", err
+		}
+		ra = bytes.NewReader(b)
+	}
+
+	state := c.loadState(actionId, size)
+	if state == nil {
+		upResp, err := c.newDiagnostics(ctx, actionId, timestamp, size)
+		if err != nil {
+			return "", err
+		}
+		state = &uploadState{
+			UploadID:    upResp.UploadID,
+			ChunkSize:   upResp.ChunkSize,
+			Size:        size,
+			Compression: upResp.Compression,
+			Uploaded:    make([]bool, int(math.Ceil(float64(size)/float64(upResp.ChunkSize)))),
+		}
+		c.saveState(actionId, state)
+	}
+
+	transitHash, err := c.uploadChunks(ctx, actionId, state, ra)
+	if err == nil {
+		var fr FinishRequest
+		fr.TransitHash.SHA256 = fmt.Sprintf("%x", transitHash)
+		err = c.Finish(ctx, state.UploadID, &fr)
+	}
+	// an upload interrupted by the cancellation of the context is resumed by the next attempt,
+	// the other failures start a new upload.
+	if err == nil || !(errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+		c.removeState(actionId)
+	}
+	return state.UploadID, err
+}
+
+// BundleFile returns the file the diagnostics bundle of the action is kept in until
+// uploaded, and whether it holds the bundle of an interrupted upload to resume.
+// The path is empty when the Client doesn't resume uploads.
+func (c *Client) BundleFile(actionID string) (string, bool) {
+	if c.resumeDir == "" {
+		return "", false
+	}
+	c.pruneResumeDir()
+
+	path := filepath.Join(c.resumeDir, actionID+".zip")
+	info, err := os.Stat(path)
+	if err != nil {
+		return path, false
+	}
+	state := c.loadState(actionID, info.Size())
+	return path, state != nil
+}
+
+// newDiagnostics requests a new diagnostics upload, proposing the configured compression.
+// fleet-server versions rejecting the proposal are requested an uncompressed upload.
+func (c *Client) newDiagnostics(ctx context.Context, actionID string, timestamp string, size int64) (*NewUploadResponse, error) {
 	upReq := NewUploadRequest{
-		ActionID: actionId,
+		ActionID: actionID,
 		AgentID:  c.agentID,
 		Source:   "agent",
 		File: FileData{
@@ -196,28 +340,159 @@ func (c *Client) UploadDiagnostics(ctx context.Context, actionId string, timesta
 			Mime:      "application/zip",
 		},
 	}
+	if c.compression == CompressionZstd {
+		upReq.File.Compression = CompressionZstd
+	}
+
 	upResp, err := c.New(ctx, &upReq)
+	if upReq.File.Compression != "" && errors.Is(err, errBadRequest) {
+		upReq.File.Compression = ""
+		upResp, err = c.New(ctx, &upReq)
+	}
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	if upResp.Compression != upReq.File.Compression {
+		upResp.Compression = ""
 	}
+	return upResp, nil
+}
 
-	uploadID := upResp.UploadID
-	chunkSize := upResp.ChunkSize
-	totalChunks := int(math.Ceil(float64(size) / float64(chunkSize)))
-	transitHash := sha256.New()
-	for chunk := 0; chunk < totalChunks; chunk++ {
-		var data bytes.Buffer
-		io.CopyN(&data, r, chunkSize) //nolint:errcheck // copy chunkSize bytes to a buffer so we can get the checksum
-		hash := sha256.Sum256(data.Bytes())
-		err := c.Chunk(ctx, uploadID, chunk, hash[:], &data) // hash[:] uses the array as a slice
+// uploadChunks uploads the chunks of state not uploaded yet and returns the transit hash.
+func (c *Client) uploadChunks(ctx context.Context, actionID string, state *uploadState, r io.ReaderAt) ([]byte, error) {
+	var encoder *zstd.Encoder
+	if state.Compression == CompressionZstd {
+		var err error
+		encoder, err = zstd.NewWriter(nil)
 		if err != nil {
-			return uploadID, err
+			return nil, fmt.Errorf("failed to create zstd encoder: %w", err)
 		}
-		transitHash.Write(hash[:]) // used to calculate transit hash, no need to check errors on this write
+		defer encoder.Close()
+	}
+
+	var mx sync.Mutex
+	hashes := make([][]byte, len(state.Uploaded))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(max(c.concurrency, 1))
+	for chunk := range state.Uploaded {
+		chunk := chunk
+		offset := int64(chunk) * state.ChunkSize
+		size := min(state.ChunkSize, state.Size-offset)
+		g.Go(func() error {
+			mx.Lock()
+			uploaded := state.Uploaded[chunk]
+			mx.Unlock()
+			if uploaded {
+				// the chunk is only hashed for the transit hash, without buffering it
+				h := sha256.New()
+				if _, err := io.Copy(h, io.NewSectionReader(r, offset, size)); err != nil {
+					return fmt.Errorf("failed to read chunk %d: %w", chunk, err)
+				}
+				hashes[chunk] = h.Sum(nil)
+				return nil
+			}
+
+			// the chunk is buffered only while it's uploaded, up to the concurrency limit
+			data := make([]byte, size)
+			if _, err := r.ReadAt(data, offset); err != nil && !errors.Is(err, io.EOF) {
+				return fmt.Errorf("failed to read chunk %d: %w", chunk, err)
+			}
+			hash := sha256.Sum256(data)
+			hashes[chunk] = hash[:]
+
+			var encoding string
+			if encoder != nil {
+				data = encoder.EncodeAll(data, nil)
+				encoding = CompressionZstd
+			}
+			if err := c.chunk(gctx, state.UploadID, chunk, hash[:], encoding, bytes.NewReader(data)); err != nil {
+				return fmt.Errorf("failed to upload chunk %d: %w", chunk, err)
+			}
+
+			mx.Lock()
+			defer mx.Unlock()
+			state.Uploaded[chunk] = true
+			c.saveState(actionID, state)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	transitHash := sha256.New()
+	for _, hash := range hashes {
+		transitHash.Write(hash) // used to calculate transit hash, no need to check errors on this write
+	}
+	return transitHash.Sum(nil), nil
+}
+
+// uploadState is the persisted state of an upload, used to resume it.
+type uploadState struct {
+	UploadID    string `json:"upload_id"`
+	ChunkSize   int64  `json:"chunk_size"`
+	Size        int64  `json:"size"`
+	Compression string `json:"compression,omitempty"`
+	Uploaded    []bool `json:"uploaded"`
+}
+
+func (c *Client) statePath(actionID string) string {
+	return filepath.Join(c.resumeDir, actionID+".json")
+}
+
+// loadState returns the state of the interrupted upload of the action, nil if there is none.
+func (c *Client) loadState(actionID string, size int64) *uploadState {
+	if c.resumeDir == "" {
+		return nil
+	}
+	b, err := os.ReadFile(c.statePath(actionID))
+	if err != nil {
+		return nil
+	}
+	var state uploadState
+	if err := json.Unmarshal(b, &state); err != nil || state.Size != size || state.ChunkSize <= 0 ||
+		len(state.Uploaded) != int(math.Ceil(float64(size)/float64(state.ChunkSize))) {
+		return nil
+	}
+	return &state
+}
+
+// saveState persists the state of the upload, failing to do so only prevents resuming the upload.
+func (c *Client) saveState(actionID string, state *uploadState) {
+	if c.resumeDir == "" {
+		return
+	}
+	b, err := json.Marshal(state)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(c.resumeDir, 0o700); err != nil {
+		return
+	}
+	tmp := c.statePath(actionID) + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return
 	}
-	var fr FinishRequest
-	fr.TransitHash.SHA256 = fmt.Sprintf("%x", transitHash.Sum(nil))
+	_ = os.Rename(tmp, c.statePath(actionID))
+}
 
-	err = c.Finish(ctx, uploadID, &fr)
-	return uploadID, err
+func (c *Client) removeState(actionID string) {
+	if c.resumeDir == "" {
+		return
+	}
+	_ = os.Remove(c.statePath(actionID))
+}
+
+// pruneResumeDir removes the bundles and states of the uploads interrupted for too long.
+func (c *Client) pruneResumeDir() {
+	entries, err := os.ReadDir(c.resumeDir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		info, err := e.Info()
+		if errors.Is(err, fs.ErrNotExist) || (err == nil && time.Since(info.ModTime()) > resumeMaxAge) {
+			_ = os.Remove(filepath.Join(c.resumeDir, e.Name()))
+		}
+	}
 }
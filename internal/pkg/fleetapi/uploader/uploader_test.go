@@ -7,17 +7,29 @@ package uploader
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/klauspost/compress/zstd"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/internal/pkg/core/backoff"
+	"github.com/elastic/elastic-agent/internal/pkg/core/monitoring/config"
 )
 
 type mockBackoff struct {
@@ -116,17 +128,17 @@ func Test_retrySender_Send(t *testing.T) {
 		err:    nil,
 	}}
 
-	backoff := &mockBackoff{}
-	backoff.On("Reset").Return()
-	backoff.On("Wait").Return(true)
+	wait := &mockBackoff{}
+	wait.On("Reset").Return()
+	wait.On("Wait").Return(true)
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			sender := tc.sender()
 			c := &retrySender{
-				c:    sender,
-				max:  3,
-				wait: backoff,
+				c:       sender,
+				max:     3,
+				newWait: func() backoff.Backoff { return wait },
 			}
 			resp, err := c.Send(context.Background(), "POST", "/", nil, nil, bytes.NewReader([]byte("abcd")))
 			if err != nil {
@@ -156,14 +168,14 @@ func Test_retrySender_bodyValidation(t *testing.T) {
 		require.NoError(t, err)
 	}).Return(&http.Response{StatusCode: 200}, nil).Once()
 
-	backoff := &mockBackoff{}
-	backoff.On("Reset").Return()
-	backoff.On("Wait").Return(true)
+	wait := &mockBackoff{}
+	wait.On("Reset").Return()
+	wait.On("Wait").Return(true)
 
 	c := &retrySender{
-		c:    sender,
-		max:  3,
-		wait: backoff,
+		c:       sender,
+		max:     3,
+		newWait: func() backoff.Backoff { return wait },
 	}
 	resp, err := c.Send(context.Background(), "POST", "/", nil, nil, bytes.NewReader([]byte("abcd")))
 	require.NoError(t, err)
@@ -173,6 +185,28 @@ func Test_retrySender_bodyValidation(t *testing.T) {
 	sender.AssertExpectations(t)
 }
 
+func Test_Client_SetClient(t *testing.T) {
+	// the client of the previous API key must not be used once rotated
+	prevSender := &mockSender{}
+	sender := &mockSender{}
+	sender.On("Send", mock.Anything, "POST", PathNewUpload, mock.Anything, mock.Anything, mock.Anything).
+		Return(&http.Response{StatusCode: 429, Body: io.NopCloser(bytes.NewReader(nil))}, nil).Once()
+	sender.On("Send", mock.Anything, "POST", PathNewUpload, mock.Anything, mock.Anything, mock.Anything).
+		Return(&http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(strings.NewReader(`{"upload_id":"test-upload","chunk_size":2}`)),
+		}, nil).Once()
+
+	c := New("test-agent", prevSender, config.Uploader{MaxRetries: 2, InitDur: time.Millisecond, MaxDur: time.Millisecond})
+	c.SetClient(sender)
+
+	resp, err := c.New(context.Background(), &NewUploadRequest{ActionID: "test-id"})
+	require.NoError(t, err, "the retries are kept with the new client")
+	assert.Equal(t, "test-upload", resp.UploadID)
+	sender.AssertExpectations(t)
+	prevSender.AssertNotCalled(t, "Send", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
 func Test_Client_UploadDiagnostics(t *testing.T) {
 	var chunk0, chunk1, chunk2 []byte
 	var err error
@@ -225,3 +259,186 @@ func Test_Client_UploadDiagnostics(t *testing.T) {
 	assert.Equal(t, "e", string(chunk2))
 	sender.AssertExpectations(t)
 }
+
+// fakeFleetServer is an in-memory fleet-server upload API.
+type fakeFleetServer struct {
+	t                 *testing.T
+	chunkSize         int64
+	acceptCompression bool
+	rejectCompression bool
+	cancelChunk       int // chunk failing once with context.Canceled, -1 for none
+
+	mx          sync.Mutex
+	newRequests []NewUploadRequest
+	newBodies   [][]byte
+	compression string
+	chunks      map[int][]byte
+	encodings   map[int]string
+	uploads     map[int]int
+	inFlight    int
+	maxInFlight int
+	transitHash string
+}
+
+func (f *fakeFleetServer) Send(ctx context.Context, method, path string, _ url.Values, headers http.Header, body io.Reader) (*http.Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	b, err := io.ReadAll(body)
+	require.NoError(f.t, err)
+
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	switch {
+	case method == http.MethodPost && path == PathNewUpload:
+		var req NewUploadRequest
+		require.NoError(f.t, json.Unmarshal(b, &req))
+		f.newRequests = append(f.newRequests, req)
+		f.newBodies = append(f.newBodies, b)
+		if f.rejectCompression && req.File.Compression != "" {
+			return response(http.StatusBadRequest, `{"statusCode":400,"error":"BadRequest","message":"unsupported compression"}`), nil
+		}
+		if f.acceptCompression {
+			f.compression = req.File.Compression
+		}
+		return response(http.StatusOK, fmt.Sprintf(`{"upload_id":"upload-1","chunk_size":%d,"compression":%q}`, f.chunkSize, f.compression)), nil
+	case method == http.MethodPut:
+		chunk, err := strconv.Atoi(path[strings.LastIndex(path, "/")+1:])
+		require.NoError(f.t, err)
+		f.uploads[chunk]++
+		if chunk == f.cancelChunk {
+			f.cancelChunk = -1
+			return nil, context.Canceled
+		}
+		if enc := headers.Get("Content-Encoding"); enc == CompressionZstd {
+			dec, err := zstd.NewReader(nil)
+			require.NoError(f.t, err)
+			b, err = dec.DecodeAll(b, nil)
+			require.NoError(f.t, err)
+			dec.Close()
+			f.encodings[chunk] = enc
+		}
+		hash := sha256.Sum256(b)
+		assert.Equal(f.t, fmt.Sprintf("%x", hash), headers.Get("X-Chunk-Sha2"))
+		f.chunks[chunk] = b
+
+		f.inFlight++
+		f.maxInFlight = max(f.maxInFlight, f.inFlight)
+		f.mx.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		f.mx.Lock()
+		f.inFlight--
+		return response(http.StatusOK, ""), nil
+	case method == http.MethodPost:
+		var req FinishRequest
+		require.NoError(f.t, json.Unmarshal(b, &req))
+		f.transitHash = req.TransitHash.SHA256
+		return response(http.StatusOK, ""), nil
+	}
+	f.t.Fatalf("unexpected request %s %s", method, path)
+	return nil, nil
+}
+
+func (f *fakeFleetServer) URI() string {
+	return "http://localhost"
+}
+
+// content returns the uploaded file and checks the transit hash.
+func (f *fakeFleetServer) content(t *testing.T) []byte {
+	var content []byte
+	transitHash := sha256.New()
+	for i := 0; i < len(f.chunks); i++ {
+		content = append(content, f.chunks[i]...)
+		hash := sha256.Sum256(f.chunks[i])
+		transitHash.Write(hash[:])
+	}
+	assert.Equal(t, fmt.Sprintf("%x", transitHash.Sum(nil)), f.transitHash)
+	return content
+}
+
+func newFakeFleetServer(t *testing.T, chunkSize int64) *fakeFleetServer {
+	return &fakeFleetServer{
+		t:           t,
+		chunkSize:   chunkSize,
+		cancelChunk: -1,
+		chunks:      map[int][]byte{},
+		encodings:   map[int]string{},
+		uploads:     map[int]int{},
+	}
+}
+
+func response(status int, body string) *http.Response {
+	return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body))}
+}
+
+func Test_Client_UploadDiagnostics_Parallel(t *testing.T) {
+	bundle := []byte("abcdefghijklmnopqrstuvwxyz")
+	cfg := config.Uploader{MaxRetries: 1, InitDur: time.Millisecond, MaxDur: time.Millisecond, Concurrency: 3, Compression: CompressionZstd}
+
+	t.Run("compressed chunks uploaded in parallel", func(t *testing.T) {
+		server := newFakeFleetServer(t, 4)
+		server.acceptCompression = true
+
+		id, err := New("test-agent", server, cfg).UploadDiagnostics(context.Background(), "test-id", "2023-01-30T09-40-02Z-00", int64(len(bundle)), bytes.NewReader(bundle))
+		require.NoError(t, err)
+		assert.Equal(t, "upload-1", id)
+		assert.Equal(t, bundle, server.content(t))
+		assert.Equal(t, CompressionZstd, server.newRequests[0].File.Compression)
+		var body struct {
+			File map[string]interface{} `json:"file"`
+		}
+		require.NoError(t, json.Unmarshal(server.newBodies[0], &body))
+		assert.Equal(t, CompressionZstd, body.File["compression"])
+		assert.Len(t, server.encodings, 7)
+		assert.LessOrEqual(t, server.maxInFlight, 3)
+		assert.Greater(t, server.maxInFlight, 1)
+	})
+
+	t.Run("compression ignored by fleet-server", func(t *testing.T) {
+		server := newFakeFleetServer(t, 4)
+
+		_, err := New("test-agent", server, cfg).UploadDiagnostics(context.Background(), "test-id", "2023-01-30T09-40-02Z-00", int64(len(bundle)), bytes.NewBuffer(bundle))
+		require.NoError(t, err)
+		assert.Equal(t, bundle, server.content(t))
+		assert.Empty(t, server.encodings)
+	})
+
+	t.Run("compression rejected by fleet-server", func(t *testing.T) {
+		server := newFakeFleetServer(t, 4)
+		server.rejectCompression = true
+
+		_, err := New("test-agent", server, cfg).UploadDiagnostics(context.Background(), "test-id", "2023-01-30T09-40-02Z-00", int64(len(bundle)), bytes.NewReader(bundle))
+		require.NoError(t, err)
+		assert.Equal(t, bundle, server.content(t))
+		require.Len(t, server.newRequests, 2)
+		assert.Empty(t, server.newRequests[1].File.Compression)
+		assert.Empty(t, server.encodings)
+	})
+}
+
+func Test_Client_UploadDiagnostics_Resume(t *testing.T) {
+	bundle := []byte("abcdefghijklmnopqrst")
+	dir := t.TempDir()
+	server := newFakeFleetServer(t, 4)
+	server.cancelChunk = 2
+
+	c := New("test-agent", server, config.Uploader{MaxRetries: 1, InitDur: time.Millisecond, MaxDur: time.Millisecond, Concurrency: 1}, WithResumeDir(dir))
+	path, resume := c.BundleFile("test-id")
+	assert.Equal(t, filepath.Join(dir, "test-id.zip"), path)
+	assert.False(t, resume)
+	require.NoError(t, os.WriteFile(path, bundle, 0o600))
+
+	_, err := c.UploadDiagnostics(context.Background(), "test-id", "2023-01-30T09-40-02Z-00", int64(len(bundle)), bytes.NewReader(bundle))
+	require.ErrorIs(t, err, context.Canceled)
+
+	// the agent restarted, the upload is resumed from the chunk that failed
+	_, resume = c.BundleFile("test-id")
+	require.True(t, resume)
+	_, err = c.UploadDiagnostics(context.Background(), "test-id", "2023-01-30T09-40-02Z-00", int64(len(bundle)), bytes.NewReader(bundle))
+	require.NoError(t, err)
+
+	assert.Len(t, server.newRequests, 1)
+	assert.Equal(t, map[int]int{0: 1, 1: 1, 2: 2, 3: 1, 4: 1}, server.uploads)
+	assert.Equal(t, bundle, server.content(t))
+	assert.NoFileExists(t, filepath.Join(dir, "test-id.json"))
+}
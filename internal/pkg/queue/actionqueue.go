@@ -6,6 +6,7 @@ package queue
 
 import (
 	"container/heap"
+	"sync"
 	"time"
 
 	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
@@ -29,9 +30,13 @@ type item struct {
 type queue []*item
 
 // ActionQueue is a priority queue with the ability to persist to disk.
+// It's safe for concurrent use, the actions can be cancelled locally while
+// the dispatcher is running.
 type ActionQueue struct {
-	q *queue
-	s saver
+	mx       sync.Mutex
+	q        *queue
+	s        saver
+	observer func([]fleetapi.Action)
 }
 
 // Len returns the length of the queue
@@ -111,6 +116,8 @@ func NewActionQueue(actions []fleetapi.Action, s saver) (*ActionQueue, error) {
 // The priority is meant to be the start-time of the action as a unix epoch time.
 // Complexity: O(log n)
 func (q *ActionQueue) Add(action fleetapi.ScheduledAction, priority int64) {
+	q.mx.Lock()
+	defer q.mx.Unlock()
 	e := &item{
 		action:   action,
 		priority: priority,
@@ -121,6 +128,8 @@ func (q *ActionQueue) Add(action fleetapi.ScheduledAction, priority int64) {
 // DequeueActions will dequeue all actions that have a priority less then time.Now().
 // Complexity: O(n*log n)
 func (q *ActionQueue) DequeueActions() []fleetapi.ScheduledAction {
+	q.mx.Lock()
+	defer q.mx.Unlock()
 	ts := time.Now().Unix()
 	actions := make([]fleetapi.ScheduledAction, 0)
 	for q.q.Len() != 0 {
@@ -136,6 +145,8 @@ func (q *ActionQueue) DequeueActions() []fleetapi.ScheduledAction {
 // Cancel will remove any actions in the queue with a matching actionID and return the number of entries cancelled.
 // Complexity: O(n*log n)
 func (q *ActionQueue) Cancel(actionID string) int {
+	q.mx.Lock()
+	defer q.mx.Unlock()
 	items := make([]*item, 0)
 	for _, item := range *q.q {
 		if item.action.ID() == actionID {
@@ -150,6 +161,12 @@ func (q *ActionQueue) Cancel(actionID string) int {
 
 // Actions returns all actions in the queue, item 0 is garunteed to be the min, the rest may not be in sorted order.
 func (q *ActionQueue) Actions() []fleetapi.Action {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	return q.actions()
+}
+
+func (q *ActionQueue) actions() []fleetapi.Action {
 	actions := make([]fleetapi.Action, q.q.Len())
 	for i, item := range *q.q {
 		actions[i] = item.action
@@ -159,6 +176,8 @@ func (q *ActionQueue) Actions() []fleetapi.Action {
 
 // CancelType cancels all actions in the queue with a matching action type and returns the number of entries cancelled.
 func (q *ActionQueue) CancelType(actionType string) int {
+	q.mx.Lock()
+	defer q.mx.Unlock()
 	items := make([]*item, 0)
 	for _, item := range *q.q {
 		if item.action.Type() == actionType {
@@ -171,8 +190,25 @@ func (q *ActionQueue) CancelType(actionType string) int {
 	return len(items)
 }
 
+// SetObserver sets the function called with the actions in the queue every time
+// the queue is saved.
+func (q *ActionQueue) SetObserver(observer func([]fleetapi.Action)) {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	q.observer = observer
+}
+
 // Save persists the queue to disk.
 func (q *ActionQueue) Save() error {
-	q.s.SetQueue(q.Actions())
-	return q.s.Save()
+	q.mx.Lock()
+	actions := q.actions()
+	observer := q.observer
+	q.s.SetQueue(actions)
+	err := q.s.Save()
+	q.mx.Unlock()
+
+	if observer != nil {
+		observer(actions)
+	}
+	return err
 }
@@ -243,7 +243,7 @@ func Test_ActionQueue_DequeueActions(t *testing.T) {
 			index:    2,
 		}}
 		heap.Init(q)
-		aq := &ActionQueue{q, &mockSaver{}}
+		aq := &ActionQueue{q: q, s: &mockSaver{}}
 
 		actions := aq.DequeueActions()
 
@@ -277,7 +277,7 @@ func Test_ActionQueue_DequeueActions(t *testing.T) {
 			index:    2,
 		}}
 		heap.Init(q)
-		aq := &ActionQueue{q, &mockSaver{}}
+		aq := &ActionQueue{q: q, s: &mockSaver{}}
 
 		actions := aq.DequeueActions()
 
@@ -309,7 +309,7 @@ func Test_ActionQueue_DequeueActions(t *testing.T) {
 			index:    2,
 		}}
 		heap.Init(q)
-		aq := &ActionQueue{q, &mockSaver{}}
+		aq := &ActionQueue{q: q, s: &mockSaver{}}
 
 		actions := aq.DequeueActions()
 
@@ -337,7 +337,7 @@ func Test_ActionQueue_DequeueActions(t *testing.T) {
 			index:    2,
 		}}
 		heap.Init(q)
-		aq := &ActionQueue{q, &mockSaver{}}
+		aq := &ActionQueue{q: q, s: &mockSaver{}}
 
 		actions := aq.DequeueActions()
 		assert.Empty(t, actions)
@@ -366,7 +366,7 @@ func Test_ActionQueue_Cancel(t *testing.T) {
 
 	t.Run("empty queue", func(t *testing.T) {
 		q := &queue{}
-		aq := &ActionQueue{q, &mockSaver{}}
+		aq := &ActionQueue{q: q, s: &mockSaver{}}
 
 		n := aq.Cancel("test-1")
 		assert.Zero(t, n)
@@ -388,7 +388,7 @@ func Test_ActionQueue_Cancel(t *testing.T) {
 			index:    2,
 		}}
 		heap.Init(q)
-		aq := &ActionQueue{q, &mockSaver{}}
+		aq := &ActionQueue{q: q, s: &mockSaver{}}
 
 		n := aq.Cancel("test-1")
 		assert.Equal(t, 1, n)
@@ -418,7 +418,7 @@ func Test_ActionQueue_Cancel(t *testing.T) {
 			index:    2,
 		}}
 		heap.Init(q)
-		aq := &ActionQueue{q, &mockSaver{}}
+		aq := &ActionQueue{q: q, s: &mockSaver{}}
 
 		n := aq.Cancel("test-1")
 		assert.Equal(t, 2, n)
@@ -445,7 +445,7 @@ func Test_ActionQueue_Cancel(t *testing.T) {
 			index:    2,
 		}}
 		heap.Init(q)
-		aq := &ActionQueue{q, &mockSaver{}}
+		aq := &ActionQueue{q: q, s: &mockSaver{}}
 
 		n := aq.Cancel("test-1")
 		assert.Equal(t, 3, n)
@@ -467,7 +467,7 @@ func Test_ActionQueue_Cancel(t *testing.T) {
 			index:    2,
 		}}
 		heap.Init(q)
-		aq := &ActionQueue{q, &mockSaver{}}
+		aq := &ActionQueue{q: q, s: &mockSaver{}}
 
 		n := aq.Cancel("test-0")
 		assert.Zero(t, n)
@@ -489,7 +489,7 @@ func Test_ActionQueue_Cancel(t *testing.T) {
 func Test_ActionQueue_Actions(t *testing.T) {
 	t.Run("empty queue", func(t *testing.T) {
 		q := &queue{}
-		aq := &ActionQueue{q, &mockSaver{}}
+		aq := &ActionQueue{q: q, s: &mockSaver{}}
 		actions := aq.Actions()
 		assert.Len(t, actions, 0)
 	})
@@ -515,7 +515,7 @@ func Test_ActionQueue_Actions(t *testing.T) {
 			index:    2,
 		}}
 		heap.Init(q)
-		aq := &ActionQueue{q, &mockSaver{}}
+		aq := &ActionQueue{q: q, s: &mockSaver{}}
 
 		actions := aq.Actions()
 		assert.Len(t, actions, 3)
@@ -535,7 +535,7 @@ func Test_ActionQueue_CancelType(t *testing.T) {
 	a3.On("Type").Return("unknown")
 
 	t.Run("empty queue", func(t *testing.T) {
-		aq := &ActionQueue{&queue{}, &mockSaver{}}
+		aq := &ActionQueue{q: &queue{}, s: &mockSaver{}}
 
 		n := aq.CancelType("upgrade")
 		assert.Equal(t, 0, n)
@@ -548,7 +548,7 @@ func Test_ActionQueue_CancelType(t *testing.T) {
 			index:    0,
 		}}
 		heap.Init(q)
-		aq := &ActionQueue{q, &mockSaver{}}
+		aq := &ActionQueue{q: q, s: &mockSaver{}}
 
 		n := aq.CancelType("upgrade")
 		assert.Equal(t, 1, n)
@@ -561,7 +561,7 @@ func Test_ActionQueue_CancelType(t *testing.T) {
 			index:    0,
 		}}
 		heap.Init(q)
-		aq := &ActionQueue{q, &mockSaver{}}
+		aq := &ActionQueue{q: q, s: &mockSaver{}}
 
 		n := aq.CancelType("upgrade")
 		assert.Equal(t, 0, n)
@@ -578,9 +578,35 @@ func Test_ActionQueue_CancelType(t *testing.T) {
 			index:    1,
 		}}
 		heap.Init(q)
-		aq := &ActionQueue{q, &mockSaver{}}
+		aq := &ActionQueue{q: q, s: &mockSaver{}}
 
 		n := aq.CancelType("upgrade")
 		assert.Equal(t, 2, n)
 	})
 }
+
+func Test_ActionQueue_Save(t *testing.T) {
+	a1 := &mockAction{}
+	a1.On("ID").Return("test-1")
+
+	q := &queue{&item{
+		action:   a1,
+		priority: 1,
+		index:    0,
+	}}
+	heap.Init(q)
+	s := &mockSaver{}
+	s.On("SetQueue", []fleetapi.Action{a1}).Once()
+	s.On("Save").Return(nil).Once()
+	aq := &ActionQueue{q: q, s: s}
+
+	var observed []fleetapi.Action
+	aq.SetObserver(func(actions []fleetapi.Action) {
+		observed = actions
+	})
+
+	err := aq.Save()
+	require.NoError(t, err)
+	s.AssertExpectations(t)
+	assert.Equal(t, []fleetapi.Action{a1}, observed)
+}
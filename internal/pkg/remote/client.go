@@ -25,10 +25,6 @@ import (
 	"github.com/elastic/elastic-agent/pkg/core/logger"
 )
 
-const (
-	retryOnBadConnTimeout = 5 * time.Minute
-)
-
 type wrapperFunc func(rt http.RoundTripper) (http.RoundTripper, error)
 
 type requestClient struct {
@@ -37,16 +33,7 @@ type requestClient struct {
 	lastUsed   time.Time
 	lastErr    error
 	lastErrOcc time.Time
-}
-
-func (r *requestClient) SetLastError(err error) {
-	r.lastUsed = time.Now().UTC()
-	r.lastErr = err
-	if err != nil {
-		r.lastErrOcc = r.lastUsed
-	} else {
-		r.lastErrOcc = time.Time{}
-	}
+	health     hostHealth
 }
 
 // Client wraps a http.Client and takes care of making the raw calls, the client should
@@ -57,7 +44,10 @@ type Client struct {
 	log        *logger.Logger
 	clientLock sync.Mutex
 	clients    []*requestClient
-	config     Config
+	// preferred is the host of the last successful request, it is tried first while
+	// healthy so long-polling requests keep going to the same host.
+	preferred *requestClient
+	config    Config
 }
 
 // NewConfigFromURL returns a Config based on a received host.
@@ -204,11 +194,25 @@ func (c *Client) Send(
 			}
 		}
 
+		start := time.Now()
 		resp, err = requester.client.Do(req.WithContext(ctx))
+		latency := time.Since(start)
 
 		// Using the same lock that was used for sorting above
 		c.clientLock.Lock()
-		requester.SetLastError(err)
+		now := time.Now().UTC()
+		switch {
+		case err != nil && ctx.Err() != nil:
+			// the request was cancelled by the caller, it says nothing about the host
+		case err != nil:
+			requester.recordFailure(now, err)
+		case resp.StatusCode >= http.StatusInternalServerError:
+			// the response is still returned, but the host is not doing well
+			requester.recordFailure(now, fmt.Errorf("server responded with %s", resp.Status))
+		default:
+			requester.recordSuccess(now, latency, !isLongPoll(ctx))
+			c.preferred = requester
+		}
 		c.clientLock.Unlock()
 
 		if err != nil {
@@ -274,56 +278,45 @@ func newClient(
 }
 
 // sortClients sort the clients according to the following priority:
-//   - never used
-//   - without errors, last used first when more than one does not have errors
-//   - last errored.
+//   - the preferred host, the last one that succeeded, while healthy
+//   - the healthy hosts, best score first
+//   - the unhealthy hosts, the one that errored the longest ago first.
 //
-// It also removes the last error after retryOnBadConnTimeout has elapsed.
+// Unhealthy hosts are only tried when all the healthy ones failed. It also starts an
+// active health check of the unhealthy hosts not checked for probeInterval, they are
+// healthy again once it succeeds.
 func (c *Client) sortClients() []*requestClient {
 	c.clientLock.Lock()
 	defer c.clientLock.Unlock()
 
 	now := time.Now().UTC()
-
-	sort.Slice(c.clients, func(i, j int) bool {
-		// First, set them good if the timout has elapsed
-		if c.clients[i].lastErr != nil &&
-			now.Sub(c.clients[i].lastErrOcc) > retryOnBadConnTimeout {
-			c.clients[i].lastErr = nil
-			c.clients[i].lastErrOcc = time.Time{}
-		}
-		if c.clients[j].lastErr != nil &&
-			now.Sub(c.clients[j].lastErrOcc) > retryOnBadConnTimeout {
-			c.clients[j].lastErr = nil
-			c.clients[j].lastErrOcc = time.Time{}
-		}
-
-		// Pick not yet used first, but if both haven't been used yet,
-		// we return false to comply with the sort.Interface definition.
-		if c.clients[i].lastUsed.IsZero() &&
-			c.clients[j].lastUsed.IsZero() {
-			return false
+	for _, r := range c.clients {
+		if r.shouldProbe(now) {
+			r.health.probing = true
+			go c.probe(r)
 		}
+	}
 
-		// Pick not yet used first
-		if c.clients[i].lastUsed.IsZero() {
-			return true
+	rank := func(r *requestClient) int {
+		switch {
+		case r.health.unhealthy:
+			return 2
+		case r == c.preferred:
+			return 0
+		default:
+			return 1
 		}
+	}
 
-		// If none has errors, pick the last used
-		// Then, the one without errors
-		if c.clients[i].lastErr == nil &&
-			c.clients[j].lastErr == nil {
-			return c.clients[i].lastUsed.Before(c.clients[j].lastUsed)
+	sort.SliceStable(c.clients, func(i, j int) bool {
+		a, b := c.clients[i], c.clients[j]
+		if rank(a) != rank(b) {
+			return rank(a) < rank(b)
 		}
-
-		// Then, the one without error
-		if c.clients[i].lastErr == nil {
-			return true
+		if a.health.unhealthy {
+			return a.lastErrOcc.Before(b.lastErrOcc)
 		}
-
-		// Lastly, the one that errored last
-		return c.clients[i].lastUsed.Before(c.clients[j].lastUsed)
+		return a.score() < b.score()
 	})
 
 	// return a copy of the slice so we can iterate over it without the lock
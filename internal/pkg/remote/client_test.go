@@ -13,6 +13,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -384,93 +385,176 @@ func TestSortClients(t *testing.T) {
 	})
 
 	t.Run("Picks second requester when first has error", func(t *testing.T) {
-		one := &requestClient{
-			lastUsed:   time.Now().UTC(),
-			lastErr:    fmt.Errorf("fake error"),
-			lastErrOcc: time.Now().UTC(),
-		}
-		two := &requestClient{}
-		client, err := newClient(nil, Config{}, one, two)
-		require.NoError(t, err)
+		one := &requestClient{host: "one"}
+		one.recordFailure(time.Now().UTC(), fmt.Errorf("fake error"))
+		two := &requestClient{host: "two"}
+		client := &Client{clients: []*requestClient{one, two}}
 
 		clients := client.sortClients()
 
 		assert.Equal(t, two, clients[0])
 	})
 
-	t.Run("Picks second requester when first has been used", func(t *testing.T) {
-		one := &requestClient{
-			lastUsed: time.Now().UTC(),
-		}
-		two := &requestClient{}
-		client, err := newClient(nil, Config{}, one, two)
-		require.NoError(t, err)
+	t.Run("Sticks to the preferred requester", func(t *testing.T) {
+		one := &requestClient{host: "one"}
+		one.recordSuccess(time.Now().UTC(), time.Second, true)
+		two := &requestClient{host: "two"}
+		two.recordSuccess(time.Now().UTC(), time.Millisecond, true)
+		three := &requestClient{host: "three"}
+		client := &Client{clients: []*requestClient{three, two, one}, preferred: one}
 
 		clients := client.sortClients()
 
-		assert.Equal(t, two, clients[0])
+		assert.Equal(t, one, clients[0])
+		// then the best scored, not yet used first
+		assert.Equal(t, three, clients[1])
+		assert.Equal(t, two, clients[2])
 	})
 
-	t.Run("Picks second requester when it's the oldest", func(t *testing.T) {
-		one := &requestClient{
-			lastUsed: time.Now().UTC().Add(-time.Minute),
-		}
-		two := &requestClient{
-			lastUsed: time.Now().UTC().Add(-3 * time.Minute),
-		}
-		three := &requestClient{
-			lastUsed: time.Now().UTC().Add(-2 * time.Minute),
-		}
-		client, err := newClient(nil, Config{}, one, two, three)
-		require.NoError(t, err)
+	t.Run("Picks best scored requester when preferred failed", func(t *testing.T) {
+		one := &requestClient{host: "one"}
+		one.recordSuccess(time.Now().UTC(), time.Millisecond, true)
+		one.recordFailure(time.Now().UTC(), fmt.Errorf("fake error"))
+		two := &requestClient{host: "two"}
+		two.recordSuccess(time.Now().UTC(), time.Second, true)
+		three := &requestClient{host: "three"}
+		three.recordSuccess(time.Now().UTC(), 10*time.Millisecond, true)
+		client := &Client{clients: []*requestClient{one, two, three}, preferred: one}
 
 		clients := client.sortClients()
 
-		assert.Equal(t, two, clients[0])
+		assert.Equal(t, three, clients[0])
+		assert.Equal(t, two, clients[1])
+		assert.Equal(t, one, clients[2])
 	})
 
-	t.Run("Picks third requester when second has error and first is last used", func(t *testing.T) {
-		one := &requestClient{
-			lastUsed: time.Now().UTC().Add(-time.Minute),
-		}
-		two := &requestClient{
-			lastUsed:   time.Now().UTC().Add(-3 * time.Minute),
-			lastErr:    fmt.Errorf("fake error"),
-			lastErrOcc: time.Now().Add(-time.Minute),
-		}
-		three := &requestClient{
-			lastUsed: time.Now().UTC().Add(-2 * time.Minute),
-		}
+	t.Run("Picks unhealthy requesters last, oldest error first", func(t *testing.T) {
+		now := time.Now().UTC()
+		one := &requestClient{host: "one"}
+		two := &requestClient{host: "two"}
+		three := &requestClient{host: "three"}
+		one.recordFailure(now.Add(-time.Second), fmt.Errorf("fake error"))
+		two.recordFailure(now.Add(-3*time.Second), fmt.Errorf("fake error"))
 		client := &Client{clients: []*requestClient{one, two, three}}
 
 		clients := client.sortClients()
 
 		assert.Equal(t, three, clients[0])
+		assert.Equal(t, two, clients[1])
+		assert.Equal(t, one, clients[2])
 	})
+}
 
-	t.Run("Picks second requester when its oldest and all have old errors", func(t *testing.T) {
-		one := &requestClient{
-			lastUsed:   time.Now().UTC().Add(-time.Minute),
-			lastErr:    fmt.Errorf("fake error"),
-			lastErrOcc: time.Now().Add(-time.Minute),
-		}
-		two := &requestClient{
-			lastUsed:   time.Now().UTC().Add(-3 * time.Minute),
-			lastErr:    fmt.Errorf("fake error"),
-			lastErrOcc: time.Now().Add(-3 * time.Minute),
+func TestHostHealth(t *testing.T) {
+	l, err := logger.New("", false)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	var healthy atomic.Bool
+	mux := http.NewServeMux()
+	mux.HandleFunc("/echo-hello", func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
 		}
-		three := &requestClient{
-			lastUsed:   time.Now().UTC().Add(-2 * time.Minute),
-			lastErr:    fmt.Errorf("fake error"),
-			lastErrOcc: time.Now().Add(-2 * time.Minute),
+		fmt.Fprint(w, "hello")
+	})
+	mux.HandleFunc("/api/status", func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
 		}
-		client, err := newClient(nil, Config{}, one, two, three)
+		fmt.Fprint(w, `{"status":"HEALTHY"}`)
+	})
+	flaky := httptest.NewServer(mux)
+	defer flaky.Close()
+	stable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "hello")
+	}))
+	defer stable.Close()
+
+	one := &requestClient{host: flaky.URL + "/"}
+	two := &requestClient{host: stable.URL + "/"}
+	c := &Client{clients: []*requestClient{one, two}, log: l}
+
+	send := func() {
+		resp, err := c.Send(ctx, http.MethodGet, "/echo-hello", nil, nil, nil)
 		require.NoError(t, err)
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
 
-		clients := client.sortClients()
+	// the flaky host answers with an error, it's unhealthy
+	send()
+	health := c.HostsHealth()
+	require.Len(t, health, 2)
+	byHost := map[string]HostHealth{}
+	for _, h := range health {
+		byHost[h.Host] = h
+	}
+	assert.False(t, byHost[one.host].Healthy)
+	assert.Equal(t, uint64(1), byHost[one.host].Failures)
+	assert.Contains(t, byHost[one.host].LastError, "503")
+
+	// the flaky host is skipped while unhealthy
+	send()
+	assert.Equal(t, two, c.sortClients()[0])
+	send()
+	assert.Equal(t, two, c.sortClients()[0])
+
+	// once due, the active health check recovers it, but stickiness keeps the stable host first
+	healthy.Store(true)
+	c.clientLock.Lock()
+	one.lastErrOcc = time.Now().Add(-probeInterval)
+	c.clientLock.Unlock()
+	c.sortClients()
+	require.Eventually(t, func() bool {
+		for _, h := range c.HostsHealth() {
+			if h.Host == one.host {
+				return h.Healthy && h.Probes == 1
+			}
+		}
+		return false
+	}, 5*time.Second, 10*time.Millisecond)
+
+	clients := c.sortClients()
+	assert.Equal(t, two, clients[0])
+	assert.Equal(t, one, clients[1])
+	for _, h := range c.HostsHealth() {
+		assert.Equal(t, h.Host == two.host, h.Preferred)
+	}
+}
 
-		assert.Equal(t, two, clients[0])
-	})
+func TestHostHealthCancelledRequest(t *testing.T) {
+	l, err := logger.New("", false)
+	require.NoError(t, err)
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer slow.Close()
+
+	one := &requestClient{host: slow.URL + "/"}
+	c := &Client{clients: []*requestClient{one}, log: l}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = c.Send(ctx, http.MethodGet, "/echo-hello", nil, nil, nil)
+	require.Error(t, err)
+
+	// the caller gave up on the request, the host is still healthy
+	health := c.HostsHealth()
+	require.Len(t, health, 1)
+	assert.True(t, health[0].Healthy)
+	assert.Equal(t, uint64(0), health[0].Failures)
+}
+
+func TestLongPollLatency(t *testing.T) {
+	r := &requestClient{}
+	r.recordSuccess(time.Now(), 100*time.Millisecond, true)
+	r.recordSuccess(time.Now(), 5*time.Minute, !isLongPoll(ContextWithLongPoll(context.Background())))
+	assert.Equal(t, 100*time.Millisecond, r.health.latency)
+	assert.Equal(t, uint64(2), r.health.requests)
 }
 
 func withServer(m func(t *testing.T) *http.ServeMux, test func(t *testing.T, host string)) func(t *testing.T) {
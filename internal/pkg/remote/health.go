@@ -0,0 +1,209 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package remote
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	// healthSmoothing is the weight of the last request in the latency and error rate moving averages.
+	healthSmoothing = 0.3
+	// errorRatePenalty weights the error rate against the latency when scoring healthy hosts.
+	errorRatePenalty = 4
+
+	// probeInterval is the interval between the active health checks of an unhealthy host.
+	probeInterval = 30 * time.Second
+	// probeTimeout is the timeout of an active health check.
+	probeTimeout = 10 * time.Second
+	// probePath is the path checked to decide if an unhealthy host recovered.
+	probePath = "api/status"
+)
+
+type longPollKey struct{}
+
+// ContextWithLongPoll marks the requests sent with ctx as long-polling, their duration
+// depends on the server and is not used to score the host latency.
+func ContextWithLongPoll(ctx context.Context) context.Context {
+	return context.WithValue(ctx, longPollKey{}, true)
+}
+
+func isLongPoll(ctx context.Context) bool {
+	v, _ := ctx.Value(longPollKey{}).(bool)
+	return v
+}
+
+// HostHealth is the health of a host as seen by the client.
+type HostHealth struct {
+	Host string `json:"host"`
+	// Healthy is false once a request to the host failed, it only receives requests when
+	// all the healthy hosts failed until an active health check succeeds.
+	Healthy bool `json:"healthy"`
+	// Preferred is set for the host requests are sent to first, the last one that succeeded.
+	Preferred bool `json:"preferred"`
+	// Latency is the moving average of the requests latency, long-polling requests excluded.
+	Latency time.Duration `json:"latency"`
+	// ErrorRate is the moving average of the failed requests ratio.
+	ErrorRate           float64   `json:"error_rate"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	Requests            uint64    `json:"requests"`
+	Failures            uint64    `json:"failures"`
+	Probes              uint64    `json:"probes"`
+	LastError           string    `json:"last_error,omitempty"`
+	LastErrorTime       time.Time `json:"last_error_time,omitempty"`
+}
+
+// hostHealth is the passive and active health tracking of a host. It is guarded by
+// the Client clientLock.
+type hostHealth struct {
+	latency             time.Duration
+	latencySampled      bool
+	errorRate           float64
+	consecutiveFailures int
+	requests            uint64
+	failures            uint64
+	probes              uint64
+	unhealthy           bool
+	probing             bool
+	lastProbe           time.Time
+}
+
+func (r *requestClient) recordSuccess(now time.Time, latency time.Duration, sampleLatency bool) {
+	r.lastUsed = now
+	r.lastErr = nil
+	r.lastErrOcc = time.Time{}
+
+	h := &r.health
+	h.requests++
+	h.consecutiveFailures = 0
+	h.errorRate *= 1 - healthSmoothing
+	h.unhealthy = false
+	if sampleLatency {
+		if h.latencySampled {
+			h.latency = time.Duration(healthSmoothing*float64(latency) + (1-healthSmoothing)*float64(h.latency))
+		} else {
+			h.latency = latency
+			h.latencySampled = true
+		}
+	}
+}
+
+func (r *requestClient) recordFailure(now time.Time, err error) {
+	r.lastUsed = now
+	r.lastErr = err
+	r.lastErrOcc = now
+
+	h := &r.health
+	h.requests++
+	h.failures++
+	h.consecutiveFailures++
+	h.errorRate = healthSmoothing + (1-healthSmoothing)*h.errorRate
+	// failed hosts only get requests again once an active health check succeeds, or as
+	// a last resort, so a partially failing host doesn't make requests flap between hosts
+	h.unhealthy = true
+}
+
+// score ranks the healthy hosts from their latency, penalized by their recent errors,
+// the lower the better. Hosts without latency samples score 0 so they are tried.
+func (r *requestClient) score() float64 {
+	return float64(r.health.latency) * (1 + errorRatePenalty*r.health.errorRate)
+}
+
+// shouldProbe returns true when an unhealthy host is due for an active health check.
+func (r *requestClient) shouldProbe(now time.Time) bool {
+	h := &r.health
+	if !h.unhealthy || h.probing {
+		return false
+	}
+	last := r.lastErrOcc
+	if h.lastProbe.After(last) {
+		last = h.lastProbe
+	}
+	return now.Sub(last) >= probeInterval
+}
+
+// probe checks if the unhealthy host r recovered, if so it can receive requests again.
+// It must be called without holding the client lock.
+func (c *Client) probe(r *requestClient) {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := r.check(ctx)
+	latency := time.Since(start)
+
+	c.clientLock.Lock()
+	defer c.clientLock.Unlock()
+
+	now := time.Now().UTC()
+	r.health.probing = false
+	r.health.probes++
+	r.health.lastProbe = now
+	if err != nil {
+		if c.log != nil {
+			c.log.Debugw("Health check of unhealthy host failed", "host", r.host, "error.message", err)
+		}
+		return
+	}
+
+	if c.log != nil {
+		c.log.Infow("Host recovered, health check succeeded", "host", r.host)
+	}
+	// the error rate is kept, so the recovered host scores worse than the hosts that didn't fail
+	r.health.unhealthy = false
+	r.health.consecutiveFailures = 0
+	r.health.latency = latency
+	r.health.latencySampled = true
+}
+
+func (r *requestClient) check(ctx context.Context) error {
+	req, err := r.newRequest(http.MethodGet, probePath, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := r.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	// discard body for proper cancellation and connection reuse
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("status check returned %s", resp.Status)
+	}
+	return nil
+}
+
+// HostsHealth returns the health of the client hosts.
+func (c *Client) HostsHealth() []HostHealth {
+	c.clientLock.Lock()
+	defer c.clientLock.Unlock()
+
+	hosts := make([]HostHealth, 0, len(c.clients))
+	for _, r := range c.clients {
+		h := HostHealth{
+			Host:                r.host,
+			Healthy:             !r.health.unhealthy,
+			Preferred:           r == c.preferred,
+			Latency:             r.health.latency,
+			ErrorRate:           r.health.errorRate,
+			ConsecutiveFailures: r.health.consecutiveFailures,
+			Requests:            r.health.requests,
+			Failures:            r.health.failures,
+			Probes:              r.health.probes,
+			LastErrorTime:       r.lastErrOcc,
+		}
+		if r.lastErr != nil {
+			h.LastError = r.lastErr.Error()
+		}
+		hosts = append(hosts, h)
+	}
+	return hosts
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 
 	"github.com/elastic/elastic-agent/internal/pkg/eql"
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
 )
 
 // InputSpec is the specification for an input type.
@@ -19,6 +20,7 @@ type InputSpec struct {
 	Outputs        []string    `config:"outputs,omitempty" yaml:"outputs,omitempty"`
 	ProxiedActions []string    `config:"proxied_actions,omitempty" yaml:"proxied_actions,omitempty"`
 	Shippers       []string    `config:"shippers,omitempty" yaml:"shippers,omitempty"`
+	ActionTypes    []string    `config:"action_types,omitempty" yaml:"action_types,omitempty"`
 	Runtime        RuntimeSpec `config:"runtime,omitempty" yaml:"runtime,omitempty"`
 
 	Command *CommandSpec `config:"command,omitempty" yaml:"command,omitempty"`
@@ -57,6 +59,19 @@ func (s *InputSpec) Validate() error {
 			}
 		}
 	}
+	for i, a := range s.ActionTypes {
+		if a == "" {
+			return fmt.Errorf("input '%s' defines an empty action type", s.Name)
+		}
+		if fleetapi.IsBuiltinActionType(a) {
+			return fmt.Errorf("input '%s' defines the action type '%s' handled by the elastic-agent", s.Name, a)
+		}
+		for j, b := range s.ActionTypes {
+			if i != j && a == b {
+				return fmt.Errorf("input '%s' defines the action type '%s' more than once", s.Name, a)
+			}
+		}
+	}
 	for idx, prevention := range s.Runtime.Preventions {
 		_, err := eql.New(prevention.Condition)
 		if err != nil {
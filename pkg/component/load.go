@@ -330,6 +330,15 @@ func (r *RuntimeSpecs) ServiceSpecs() []InputRuntimeSpec {
 	return services
 }
 
+// ActionTypes returns the action types declared by the input specifications on this platform.
+func (r *RuntimeSpecs) ActionTypes() []string {
+	var actionTypes []string
+	for _, s := range r.inputSpecs {
+		actionTypes = append(actionTypes, s.Spec.ActionTypes...)
+	}
+	return actionTypes
+}
+
 // LoadSpec loads the component specification.
 //
 // Will error in the case that the specification is not valid. Only valid specifications are allowed.
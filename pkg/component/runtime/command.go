@@ -103,6 +103,9 @@ type commandRuntime struct {
 	lastCheckin    time.Time
 	missedCheckins int
 	restartBucket  *rate.Limiter
+
+	// started is set once the process was started, any later start until stopped is a restart.
+	started bool
 }
 
 // newCommandRuntime creates a new command runtime for the provided component.
@@ -156,6 +159,8 @@ func (c *commandRuntime) Run(ctx context.Context, comm Communicator) error {
 				}
 				t.Reset(checkinPeriod)
 			case actionStop, actionTeardown:
+				// starting again after being requested to stop is not a restart
+				c.started = false
 				if err := c.stop(ctx); err != nil {
 					c.forceCompState(client.UnitStateFailed, fmt.Sprintf("Failed: %s", err))
 				}
@@ -164,6 +169,7 @@ func (c *commandRuntime) Run(ctx context.Context, comm Communicator) error {
 			// ignores old processes
 			if ps.proc == c.proc {
 				c.proc = nil
+				c.state.PID = 0
 				if c.handleProc(ps.state) {
 					// start again after restart period
 					t.Reset(restartPeriod)
@@ -383,6 +389,11 @@ func (c *commandRuntime) start(comm Communicator) error {
 	}
 
 	c.proc = proc
+	if c.started {
+		c.state.Restarts++
+	}
+	c.started = true
+	c.state.PID = proc.PID
 	c.forceCompState(client.UnitStateStarting, fmt.Sprintf("Starting: spawned pid '%d'", c.proc.PID))
 	c.startWatcher(proc, comm)
 	return nil
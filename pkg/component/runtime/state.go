@@ -75,6 +75,13 @@ type ComponentState struct {
 
 	VersionInfo ComponentVersionInfo `yaml:"version_info"`
 
+	// Restarts is the number of times the component process was restarted.
+	Restarts int `yaml:"restarts,omitempty"`
+
+	// PID is the process ID of the running component process, zero when not running or when the
+	// component is not run as a sub-process of the agent.
+	PID int `yaml:"pid,omitempty"`
+
 	// internal
 	expectedUnits map[ComponentUnitKey]expectedUnitState
 
@@ -88,6 +88,41 @@ inputs:
 `,
 			Err: "input 'testing' defines the output 'shipper' more than once accessing 'inputs.0'",
 		},
+		{
+			Name: "Duplicate Action Type",
+			Spec: `
+version: 2
+inputs:
+  - name: testing
+    description: Testing Input
+    platforms:
+      - linux/amd64
+    outputs:
+      - shipper
+    action_types:
+      - CUSTOM
+      - CUSTOM
+    command: {}
+`,
+			Err: "input 'testing' defines the action type 'CUSTOM' more than once accessing 'inputs.0'",
+		},
+		{
+			Name: "Builtin Action Type",
+			Spec: `
+version: 2
+inputs:
+  - name: testing
+    description: Testing Input
+    platforms:
+      - linux/amd64
+    outputs:
+      - shipper
+    action_types:
+      - UPGRADE
+    command: {}
+`,
+			Err: "input 'testing' defines the action type 'UPGRADE' handled by the elastic-agent accessing 'inputs.0'",
+		},
 		{
 			Name: "Duplicate Platform Same Input Name",
 			Spec: `
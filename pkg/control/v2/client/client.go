@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/elastic/elastic-agent/internal/pkg/agent/configuration"
 	"github.com/elastic/elastic-agent/pkg/control"
@@ -116,6 +117,8 @@ type AgentState struct {
 	FleetState     State                  `yaml:"fleet_state"`
 	FleetMessage   string                 `yaml:"fleet_message"`
 	UpgradeDetails *cproto.UpgradeDetails `json:"upgrade_details,omitempty" yaml:"upgrade_details,omitempty"`
+	DiskUsage      *cproto.DiskUsage      `json:"disk_usage,omitempty" yaml:"disk_usage,omitempty"`
+	QueuedActions  []*cproto.QueuedAction `json:"queued_actions,omitempty" yaml:"queued_actions,omitempty"`
 }
 
 // DiagnosticFileResult is a diagnostic file result.
@@ -128,6 +131,52 @@ type DiagnosticFileResult struct {
 	Generated   time.Time
 }
 
+// ActionHistoryFilter selects the entries of the action journal, the zero value selects all entries.
+type ActionHistoryFilter struct {
+	ActionID   string
+	ActionType string
+	Status     string
+	Since      time.Time
+	Until      time.Time
+	Limit      int
+}
+
+// ActionHistoryEntry is a step of the lifecycle of a Fleet action recorded in the action journal.
+type ActionHistoryEntry struct {
+	Timestamp    time.Time `json:"timestamp" yaml:"timestamp"`
+	ActionID     string    `json:"action_id" yaml:"action_id"`
+	ActionType   string    `json:"action_type" yaml:"action_type"`
+	Status       string    `json:"status" yaml:"status"`
+	Error        string    `json:"error,omitempty" yaml:"error,omitempty"`
+	RetryAttempt int       `json:"retry_attempt,omitempty" yaml:"retry_attempt,omitempty"`
+}
+
+// ComponentModel is the component model of the running Elastic Agent.
+type ComponentModel struct {
+	// Components run by the Elastic Agent.
+	Components []ModelComponent
+	// BlockedByCapabilities are the components of the policy not run because blocked by the capabilities.
+	BlockedByCapabilities []ModelComponent
+}
+
+// ModelComponent is a component of the component model.
+type ModelComponent struct {
+	ID         string
+	InputType  string
+	OutputType string
+	Error      string
+	Units      []ModelUnit
+}
+
+// ModelUnit is a unit of a component of the component model.
+type ModelUnit struct {
+	ID       string
+	Type     UnitType
+	LogLevel string
+	Config   map[string]interface{}
+	Error    string
+}
+
 // DiagnosticUnitRequest allows a specific unit to be targeted for diagnostics.
 type DiagnosticUnitRequest struct {
 	ComponentID string
@@ -174,6 +223,16 @@ type Client interface {
 	Restart(ctx context.Context) error
 	// Upgrade triggers upgrade of the current running daemon.
 	Upgrade(ctx context.Context, version string, sourceURI string, skipVerify bool, skipDefaultPgp bool, pgpBytes ...string) (string, error)
+	// UpgradeWatch watches the upgrade details of the running agent.
+	UpgradeWatch(ctx context.Context) (ClientUpgradeWatch, error)
+	// Rollback switches the current running daemon back to a previously installed version.
+	Rollback(ctx context.Context, version string) (string, error)
+	// CancelAction cancels a Fleet action waiting in the action queue of the running daemon.
+	CancelAction(ctx context.Context, id string) error
+	// ActionHistory returns the lifecycle of the Fleet actions recorded by the running daemon.
+	ActionHistory(ctx context.Context, filter ActionHistoryFilter) ([]ActionHistoryEntry, error)
+	// ComponentModel returns the component model of the running agent.
+	ComponentModel(ctx context.Context) (ComponentModel, error)
 	// DiagnosticAgent gathers diagnostics information for the running Elastic Agent.
 	DiagnosticAgent(ctx context.Context, additionalDiags []AdditionalMetrics) ([]DiagnosticFileResult, error)
 	// DiagnosticUnits gathers diagnostics information from specific units (or all if non are provided).
@@ -192,6 +251,12 @@ type ClientStateWatch interface {
 	Recv() (*AgentState, error)
 }
 
+// ClientUpgradeWatch allows the upgrade details of the running Elastic Agent to be watched.
+type ClientUpgradeWatch interface {
+	// Recv receives the next upgrade details, nil when no upgrade is in progress or the upgrade completed.
+	Recv() (*cproto.UpgradeDetails, error)
+}
+
 // Option is an option to adjust how the client operates.
 type Option func(c *client)
 
@@ -319,6 +384,89 @@ func (c *client) Upgrade(ctx context.Context, version string, sourceURI string,
 	return res.Version, nil
 }
 
+// UpgradeWatch watches the upgrade details of the running agent.
+func (c *client) UpgradeWatch(ctx context.Context) (ClientUpgradeWatch, error) {
+	cli, err := c.client.UpgradeWatch(ctx, &cproto.Empty{})
+	if err != nil {
+		return nil, err
+	}
+	return &upgradeWatcher{cli}, nil
+}
+
+// Rollback switches the current running daemon back to a previously installed version.
+func (c *client) Rollback(ctx context.Context, version string) (string, error) {
+	res, err := c.client.Rollback(ctx, &cproto.RollbackRequest{
+		Version: version,
+	})
+	if err != nil {
+		return "", err
+	}
+	if res.Status == cproto.ActionStatus_FAILURE {
+		return "", fmt.Errorf(res.Error)
+	}
+	return res.Version, nil
+}
+
+// CancelAction cancels a Fleet action waiting in the action queue of the running daemon.
+func (c *client) CancelAction(ctx context.Context, id string) error {
+	res, err := c.client.CancelAction(ctx, &cproto.CancelActionRequest{
+		Id: id,
+	})
+	if err != nil {
+		return err
+	}
+	if res.Status == cproto.ActionStatus_FAILURE {
+		return errors.New(res.Error)
+	}
+	return nil
+}
+
+// ActionHistory returns the lifecycle of the Fleet actions recorded by the running daemon.
+func (c *client) ActionHistory(ctx context.Context, filter ActionHistoryFilter) ([]ActionHistoryEntry, error) {
+	req := &cproto.ActionHistoryRequest{
+		ActionId:   filter.ActionID,
+		ActionType: filter.ActionType,
+		Status:     filter.Status,
+		Limit:      int32(filter.Limit),
+	}
+	if !filter.Since.IsZero() {
+		req.Since = timestamppb.New(filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		req.Until = timestamppb.New(filter.Until)
+	}
+
+	res, err := c.client.ActionHistory(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("error in ActionHistory RPC call: %w", err)
+	}
+
+	entries := make([]ActionHistoryEntry, 0, len(res.Entries))
+	for _, e := range res.Entries {
+		entries = append(entries, ActionHistoryEntry{
+			Timestamp:    e.Timestamp.AsTime(),
+			ActionID:     e.ActionId,
+			ActionType:   e.ActionType,
+			Status:       e.Status,
+			Error:        e.Error,
+			RetryAttempt: int(e.RetryAttempt),
+		})
+	}
+	return entries, nil
+}
+
+// ComponentModel returns the component model of the running agent.
+func (c *client) ComponentModel(ctx context.Context) (ComponentModel, error) {
+	res, err := c.client.ComponentModel(ctx, &cproto.Empty{})
+	if err != nil {
+		return ComponentModel{}, fmt.Errorf("error in ComponentModel RPC call: %w", err)
+	}
+	return ComponentModel{
+		Components:            modelComponentsFromProto(res.Components),
+		BlockedByCapabilities: modelComponentsFromProto(res.BlockedByCapabilities),
+	}, nil
+}
+
 // DiagnosticAgent gathers diagnostics information for the running Elastic Agent.
 func (c *client) DiagnosticAgent(ctx context.Context, additionalMetrics []AdditionalMetrics) ([]DiagnosticFileResult, error) {
 	resp, err := c.client.DiagnosticAgent(ctx, &cproto.DiagnosticAgentRequest{AdditionalMetrics: additionalMetrics})
@@ -464,6 +612,19 @@ func (sw *stateWatcher) Recv() (*AgentState, error) {
 	return toState(resp)
 }
 
+type upgradeWatcher struct {
+	client cproto.ElasticAgentControl_UpgradeWatchClient
+}
+
+// Recv receives the next upgrade details.
+func (uw *upgradeWatcher) Recv() (*cproto.UpgradeDetails, error) {
+	resp, err := uw.client.Recv()
+	if err != nil {
+		return nil, err
+	}
+	return resp.UpgradeDetails, nil
+}
+
 func toState(res *cproto.StateResponse) (*AgentState, error) {
 	s := &AgentState{
 		Info: AgentStateInfo{
@@ -479,6 +640,8 @@ func toState(res *cproto.StateResponse) (*AgentState, error) {
 		FleetState:     res.FleetState,
 		FleetMessage:   res.FleetMessage,
 		UpgradeDetails: res.UpgradeDetails,
+		DiskUsage:      res.DiskUsage,
+		QueuedActions:  res.QueuedActions,
 
 		Components: make([]ComponentState, 0, len(res.Components)),
 	}
@@ -517,3 +680,27 @@ func toState(res *cproto.StateResponse) (*AgentState, error) {
 	}
 	return s, nil
 }
+
+func modelComponentsFromProto(comps []*cproto.ComponentModelComponent) []ModelComponent {
+	model := make([]ModelComponent, 0, len(comps))
+	for _, c := range comps {
+		mc := ModelComponent{
+			ID:         c.Id,
+			InputType:  c.InputType,
+			OutputType: c.OutputType,
+			Error:      c.Error,
+			Units:      make([]ModelUnit, 0, len(c.Units)),
+		}
+		for _, u := range c.Units {
+			mc.Units = append(mc.Units, ModelUnit{
+				ID:       u.Id,
+				Type:     u.Type,
+				LogLevel: u.LogLevel,
+				Config:   u.Config.AsMap(),
+				Error:    u.Error,
+			})
+		}
+		model = append(model, mc)
+	}
+	return model
+}
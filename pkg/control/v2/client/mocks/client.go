@@ -31,6 +31,156 @@ func (_m *Client) EXPECT() *Client_Expecter {
 	return &Client_Expecter{mock: &_m.Mock}
 }
 
+// ActionHistory provides a mock function with given fields: ctx, filter
+func (_m *Client) ActionHistory(ctx context.Context, filter client.ActionHistoryFilter) ([]client.ActionHistoryEntry, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []client.ActionHistoryEntry
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, client.ActionHistoryFilter) ([]client.ActionHistoryEntry, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, client.ActionHistoryFilter) []client.ActionHistoryEntry); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]client.ActionHistoryEntry)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, client.ActionHistoryFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Client_ActionHistory_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ActionHistory'
+type Client_ActionHistory_Call struct {
+	*mock.Call
+}
+
+// ActionHistory is a helper method to define mock.On call
+//   - ctx context.Context
+//   - filter client.ActionHistoryFilter
+func (_e *Client_Expecter) ActionHistory(ctx interface{}, filter interface{}) *Client_ActionHistory_Call {
+	return &Client_ActionHistory_Call{Call: _e.mock.On("ActionHistory", ctx, filter)}
+}
+
+func (_c *Client_ActionHistory_Call) Run(run func(ctx context.Context, filter client.ActionHistoryFilter)) *Client_ActionHistory_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(client.ActionHistoryFilter))
+	})
+	return _c
+}
+
+func (_c *Client_ActionHistory_Call) Return(_a0 []client.ActionHistoryEntry, _a1 error) *Client_ActionHistory_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Client_ActionHistory_Call) RunAndReturn(run func(context.Context, client.ActionHistoryFilter) ([]client.ActionHistoryEntry, error)) *Client_ActionHistory_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CancelAction provides a mock function with given fields: ctx, id
+func (_m *Client) CancelAction(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Client_CancelAction_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CancelAction'
+type Client_CancelAction_Call struct {
+	*mock.Call
+}
+
+// CancelAction is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+func (_e *Client_Expecter) CancelAction(ctx interface{}, id interface{}) *Client_CancelAction_Call {
+	return &Client_CancelAction_Call{Call: _e.mock.On("CancelAction", ctx, id)}
+}
+
+func (_c *Client_CancelAction_Call) Run(run func(ctx context.Context, id string)) *Client_CancelAction_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Client_CancelAction_Call) Return(_a0 error) *Client_CancelAction_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Client_CancelAction_Call) RunAndReturn(run func(context.Context, string) error) *Client_CancelAction_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ComponentModel provides a mock function with given fields: ctx
+func (_m *Client) ComponentModel(ctx context.Context) (client.ComponentModel, error) {
+	ret := _m.Called(ctx)
+
+	var r0 client.ComponentModel
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (client.ComponentModel, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) client.ComponentModel); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(client.ComponentModel)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Client_ComponentModel_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ComponentModel'
+type Client_ComponentModel_Call struct {
+	*mock.Call
+}
+
+// ComponentModel is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *Client_Expecter) ComponentModel(ctx interface{}) *Client_ComponentModel_Call {
+	return &Client_ComponentModel_Call{Call: _e.mock.On("ComponentModel", ctx)}
+}
+
+func (_c *Client_ComponentModel_Call) Run(run func(ctx context.Context)) *Client_ComponentModel_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *Client_ComponentModel_Call) Return(_a0 client.ComponentModel, _a1 error) *Client_ComponentModel_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Client_ComponentModel_Call) RunAndReturn(run func(context.Context) (client.ComponentModel, error)) *Client_ComponentModel_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Configure provides a mock function with given fields: ctx, config
 func (_m *Client) Configure(ctx context.Context, config string) error {
 	ret := _m.Called(ctx, config)
@@ -399,6 +549,59 @@ func (_c *Client_Restart_Call) RunAndReturn(run func(context.Context) error) *Cl
 	return _c
 }
 
+// Rollback provides a mock function with given fields: ctx, version
+func (_m *Client) Rollback(ctx context.Context, version string) (string, error) {
+	ret := _m.Called(ctx, version)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+		return rf(ctx, version)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, version)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, version)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Client_Rollback_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Rollback'
+type Client_Rollback_Call struct {
+	*mock.Call
+}
+
+// Rollback is a helper method to define mock.On call
+//   - ctx context.Context
+//   - version string
+func (_e *Client_Expecter) Rollback(ctx interface{}, version interface{}) *Client_Rollback_Call {
+	return &Client_Rollback_Call{Call: _e.mock.On("Rollback", ctx, version)}
+}
+
+func (_c *Client_Rollback_Call) Run(run func(ctx context.Context, version string)) *Client_Rollback_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Client_Rollback_Call) Return(_a0 string, _a1 error) *Client_Rollback_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Client_Rollback_Call) RunAndReturn(run func(context.Context, string) (string, error)) *Client_Rollback_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // State provides a mock function with given fields: ctx
 func (_m *Client) State(ctx context.Context) (*client.AgentState, error) {
 	ret := _m.Called(ctx)
@@ -578,6 +781,60 @@ func (_c *Client_Upgrade_Call) RunAndReturn(run func(context.Context, string, st
 	return _c
 }
 
+// UpgradeWatch provides a mock function with given fields: ctx
+func (_m *Client) UpgradeWatch(ctx context.Context) (client.ClientUpgradeWatch, error) {
+	ret := _m.Called(ctx)
+
+	var r0 client.ClientUpgradeWatch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (client.ClientUpgradeWatch, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) client.ClientUpgradeWatch); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(client.ClientUpgradeWatch)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Client_UpgradeWatch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpgradeWatch'
+type Client_UpgradeWatch_Call struct {
+	*mock.Call
+}
+
+// UpgradeWatch is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *Client_Expecter) UpgradeWatch(ctx interface{}) *Client_UpgradeWatch_Call {
+	return &Client_UpgradeWatch_Call{Call: _e.mock.On("UpgradeWatch", ctx)}
+}
+
+func (_c *Client_UpgradeWatch_Call) Run(run func(ctx context.Context)) *Client_UpgradeWatch_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *Client_UpgradeWatch_Call) Return(_a0 client.ClientUpgradeWatch, _a1 error) *Client_UpgradeWatch_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Client_UpgradeWatch_Call) RunAndReturn(run func(context.Context) (client.ClientUpgradeWatch, error)) *Client_UpgradeWatch_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Version provides a mock function with given fields: ctx
 func (_m *Client) Version(ctx context.Context) (client.Version, error) {
 	ret := _m.Called(ctx)
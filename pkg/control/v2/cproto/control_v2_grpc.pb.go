@@ -36,6 +36,12 @@ type ElasticAgentControlClient interface {
 	// Client will continue to get updated StateResponse when any state
 	// of the Elastic Agent has changed.
 	StateWatch(ctx context.Context, in *Empty, opts ...grpc.CallOption) (ElasticAgentControl_StateWatchClient, error)
+	// UpgradeWatch streams the upgrade details of the Elastic Agent.
+	//
+	// The current upgrade details are sent first, then every transition of
+	// the upgrade details, including download progress, is sent until the
+	// client disconnects.
+	UpgradeWatch(ctx context.Context, in *Empty, opts ...grpc.CallOption) (ElasticAgentControl_UpgradeWatchClient, error)
 	// Restart restarts the current running Elastic Agent.
 	Restart(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*RestartResponse, error)
 	// Upgrade starts the upgrade process of Elastic Agent.
@@ -53,6 +59,15 @@ type ElasticAgentControlClient interface {
 	// on any Elastic Agent that is not in TESTING_MODE will result in an error being
 	// returned and nothing occurring.
 	Configure(ctx context.Context, in *ConfigureRequest, opts ...grpc.CallOption) (*Empty, error)
+	// Rollback switches the Elastic Agent back to a previously installed version.
+	Rollback(ctx context.Context, in *RollbackRequest, opts ...grpc.CallOption) (*RollbackResponse, error)
+	// CancelAction cancels a Fleet action waiting in the action queue. The action is
+	// acked to Fleet as failed.
+	CancelAction(ctx context.Context, in *CancelActionRequest, opts ...grpc.CallOption) (*CancelActionResponse, error)
+	// ActionHistory returns the lifecycle of the Fleet actions recorded in the action journal.
+	ActionHistory(ctx context.Context, in *ActionHistoryRequest, opts ...grpc.CallOption) (*ActionHistoryResponse, error)
+	// ComponentModel returns the component model the running Elastic Agent computed from its policy.
+	ComponentModel(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ComponentModelResponse, error)
 }
 
 type elasticAgentControlClient struct {
@@ -113,6 +128,38 @@ func (x *elasticAgentControlStateWatchClient) Recv() (*StateResponse, error) {
 	return m, nil
 }
 
+func (c *elasticAgentControlClient) UpgradeWatch(ctx context.Context, in *Empty, opts ...grpc.CallOption) (ElasticAgentControl_UpgradeWatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ElasticAgentControl_ServiceDesc.Streams[1], "/cproto.ElasticAgentControl/UpgradeWatch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &elasticAgentControlUpgradeWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ElasticAgentControl_UpgradeWatchClient interface {
+	Recv() (*UpgradeWatchResponse, error)
+	grpc.ClientStream
+}
+
+type elasticAgentControlUpgradeWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *elasticAgentControlUpgradeWatchClient) Recv() (*UpgradeWatchResponse, error) {
+	m := new(UpgradeWatchResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *elasticAgentControlClient) Restart(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*RestartResponse, error) {
 	out := new(RestartResponse)
 	err := c.cc.Invoke(ctx, "/cproto.ElasticAgentControl/Restart", in, out, opts...)
@@ -141,7 +188,7 @@ func (c *elasticAgentControlClient) DiagnosticAgent(ctx context.Context, in *Dia
 }
 
 func (c *elasticAgentControlClient) DiagnosticUnits(ctx context.Context, in *DiagnosticUnitsRequest, opts ...grpc.CallOption) (ElasticAgentControl_DiagnosticUnitsClient, error) {
-	stream, err := c.cc.NewStream(ctx, &ElasticAgentControl_ServiceDesc.Streams[1], "/cproto.ElasticAgentControl/DiagnosticUnits", opts...)
+	stream, err := c.cc.NewStream(ctx, &ElasticAgentControl_ServiceDesc.Streams[2], "/cproto.ElasticAgentControl/DiagnosticUnits", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -173,7 +220,7 @@ func (x *elasticAgentControlDiagnosticUnitsClient) Recv() (*DiagnosticUnitRespon
 }
 
 func (c *elasticAgentControlClient) DiagnosticComponents(ctx context.Context, in *DiagnosticComponentsRequest, opts ...grpc.CallOption) (ElasticAgentControl_DiagnosticComponentsClient, error) {
-	stream, err := c.cc.NewStream(ctx, &ElasticAgentControl_ServiceDesc.Streams[2], "/cproto.ElasticAgentControl/DiagnosticComponents", opts...)
+	stream, err := c.cc.NewStream(ctx, &ElasticAgentControl_ServiceDesc.Streams[3], "/cproto.ElasticAgentControl/DiagnosticComponents", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -213,6 +260,42 @@ func (c *elasticAgentControlClient) Configure(ctx context.Context, in *Configure
 	return out, nil
 }
 
+func (c *elasticAgentControlClient) Rollback(ctx context.Context, in *RollbackRequest, opts ...grpc.CallOption) (*RollbackResponse, error) {
+	out := new(RollbackResponse)
+	err := c.cc.Invoke(ctx, "/cproto.ElasticAgentControl/Rollback", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *elasticAgentControlClient) CancelAction(ctx context.Context, in *CancelActionRequest, opts ...grpc.CallOption) (*CancelActionResponse, error) {
+	out := new(CancelActionResponse)
+	err := c.cc.Invoke(ctx, "/cproto.ElasticAgentControl/CancelAction", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *elasticAgentControlClient) ActionHistory(ctx context.Context, in *ActionHistoryRequest, opts ...grpc.CallOption) (*ActionHistoryResponse, error) {
+	out := new(ActionHistoryResponse)
+	err := c.cc.Invoke(ctx, "/cproto.ElasticAgentControl/ActionHistory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *elasticAgentControlClient) ComponentModel(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ComponentModelResponse, error) {
+	out := new(ComponentModelResponse)
+	err := c.cc.Invoke(ctx, "/cproto.ElasticAgentControl/ComponentModel", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ElasticAgentControlServer is the server API for ElasticAgentControl service.
 // All implementations must embed UnimplementedElasticAgentControlServer
 // for forward compatibility
@@ -226,6 +309,12 @@ type ElasticAgentControlServer interface {
 	// Client will continue to get updated StateResponse when any state
 	// of the Elastic Agent has changed.
 	StateWatch(*Empty, ElasticAgentControl_StateWatchServer) error
+	// UpgradeWatch streams the upgrade details of the Elastic Agent.
+	//
+	// The current upgrade details are sent first, then every transition of
+	// the upgrade details, including download progress, is sent until the
+	// client disconnects.
+	UpgradeWatch(*Empty, ElasticAgentControl_UpgradeWatchServer) error
 	// Restart restarts the current running Elastic Agent.
 	Restart(context.Context, *Empty) (*RestartResponse, error)
 	// Upgrade starts the upgrade process of Elastic Agent.
@@ -243,6 +332,15 @@ type ElasticAgentControlServer interface {
 	// on any Elastic Agent that is not in TESTING_MODE will result in an error being
 	// returned and nothing occurring.
 	Configure(context.Context, *ConfigureRequest) (*Empty, error)
+	// Rollback switches the Elastic Agent back to a previously installed version.
+	Rollback(context.Context, *RollbackRequest) (*RollbackResponse, error)
+	// CancelAction cancels a Fleet action waiting in the action queue. The action is
+	// acked to Fleet as failed.
+	CancelAction(context.Context, *CancelActionRequest) (*CancelActionResponse, error)
+	// ActionHistory returns the lifecycle of the Fleet actions recorded in the action journal.
+	ActionHistory(context.Context, *ActionHistoryRequest) (*ActionHistoryResponse, error)
+	// ComponentModel returns the component model the running Elastic Agent computed from its policy.
+	ComponentModel(context.Context, *Empty) (*ComponentModelResponse, error)
 	mustEmbedUnimplementedElasticAgentControlServer()
 }
 
@@ -259,6 +357,9 @@ func (UnimplementedElasticAgentControlServer) State(context.Context, *Empty) (*S
 func (UnimplementedElasticAgentControlServer) StateWatch(*Empty, ElasticAgentControl_StateWatchServer) error {
 	return status.Errorf(codes.Unimplemented, "method StateWatch not implemented")
 }
+func (UnimplementedElasticAgentControlServer) UpgradeWatch(*Empty, ElasticAgentControl_UpgradeWatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method UpgradeWatch not implemented")
+}
 func (UnimplementedElasticAgentControlServer) Restart(context.Context, *Empty) (*RestartResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Restart not implemented")
 }
@@ -277,6 +378,18 @@ func (UnimplementedElasticAgentControlServer) DiagnosticComponents(*DiagnosticCo
 func (UnimplementedElasticAgentControlServer) Configure(context.Context, *ConfigureRequest) (*Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Configure not implemented")
 }
+func (UnimplementedElasticAgentControlServer) Rollback(context.Context, *RollbackRequest) (*RollbackResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Rollback not implemented")
+}
+func (UnimplementedElasticAgentControlServer) CancelAction(context.Context, *CancelActionRequest) (*CancelActionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelAction not implemented")
+}
+func (UnimplementedElasticAgentControlServer) ActionHistory(context.Context, *ActionHistoryRequest) (*ActionHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ActionHistory not implemented")
+}
+func (UnimplementedElasticAgentControlServer) ComponentModel(context.Context, *Empty) (*ComponentModelResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ComponentModel not implemented")
+}
 func (UnimplementedElasticAgentControlServer) mustEmbedUnimplementedElasticAgentControlServer() {}
 
 // UnsafeElasticAgentControlServer may be embedded to opt out of forward compatibility for this service.
@@ -347,6 +460,27 @@ func (x *elasticAgentControlStateWatchServer) Send(m *StateResponse) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _ElasticAgentControl_UpgradeWatch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ElasticAgentControlServer).UpgradeWatch(m, &elasticAgentControlUpgradeWatchServer{stream})
+}
+
+type ElasticAgentControl_UpgradeWatchServer interface {
+	Send(*UpgradeWatchResponse) error
+	grpc.ServerStream
+}
+
+type elasticAgentControlUpgradeWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *elasticAgentControlUpgradeWatchServer) Send(m *UpgradeWatchResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _ElasticAgentControl_Restart_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(Empty)
 	if err := dec(in); err != nil {
@@ -461,6 +595,78 @@ func _ElasticAgentControl_Configure_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ElasticAgentControl_Rollback_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RollbackRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ElasticAgentControlServer).Rollback(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cproto.ElasticAgentControl/Rollback",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ElasticAgentControlServer).Rollback(ctx, req.(*RollbackRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ElasticAgentControl_CancelAction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelActionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ElasticAgentControlServer).CancelAction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cproto.ElasticAgentControl/CancelAction",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ElasticAgentControlServer).CancelAction(ctx, req.(*CancelActionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ElasticAgentControl_ActionHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ActionHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ElasticAgentControlServer).ActionHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cproto.ElasticAgentControl/ActionHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ElasticAgentControlServer).ActionHistory(ctx, req.(*ActionHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ElasticAgentControl_ComponentModel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ElasticAgentControlServer).ComponentModel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cproto.ElasticAgentControl/ComponentModel",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ElasticAgentControlServer).ComponentModel(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ElasticAgentControl_ServiceDesc is the grpc.ServiceDesc for ElasticAgentControl service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -492,6 +698,22 @@ var ElasticAgentControl_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Configure",
 			Handler:    _ElasticAgentControl_Configure_Handler,
 		},
+		{
+			MethodName: "Rollback",
+			Handler:    _ElasticAgentControl_Rollback_Handler,
+		},
+		{
+			MethodName: "CancelAction",
+			Handler:    _ElasticAgentControl_CancelAction_Handler,
+		},
+		{
+			MethodName: "ActionHistory",
+			Handler:    _ElasticAgentControl_ActionHistory_Handler,
+		},
+		{
+			MethodName: "ComponentModel",
+			Handler:    _ElasticAgentControl_ComponentModel_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -499,6 +721,11 @@ var ElasticAgentControl_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _ElasticAgentControl_StateWatch_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "UpgradeWatch",
+			Handler:       _ElasticAgentControl_UpgradeWatch_Handler,
+			ServerStreams: true,
+		},
 		{
 			StreamName:    "DiagnosticUnits",
 			Handler:       _ElasticAgentControl_DiagnosticUnits_Handler,
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/elastic/elastic-agent/pkg/control"
@@ -21,11 +22,16 @@ import (
 	"go.elastic.co/apm"
 	"go.elastic.co/apm/module/apmgrpc"
 	"google.golang.org/grpc"
+	protobuf "google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/elastic/elastic-agent-client/v7/pkg/client"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/actions/journal"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/coordinator"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/info"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/details"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/configuration"
 	"github.com/elastic/elastic-agent/internal/pkg/diagnostics"
 	"github.com/elastic/elastic-agent/internal/pkg/release"
@@ -34,6 +40,9 @@ import (
 	"github.com/elastic/elastic-agent/pkg/core/logger"
 )
 
+// diskUsageCacheTTL is how long the computed disk usage is reused before walking the directories again.
+const diskUsageCacheTTL = time.Minute
+
 // TestModeConfigSetter is used only for testing mode.
 type TestModeConfigSetter interface {
 	// SetConfig sets the configuration.
@@ -54,6 +63,10 @@ type Server struct {
 	grpcConfig *configuration.GRPCConfig
 
 	tmSetter TestModeConfigSetter
+
+	diskUsageMx sync.Mutex
+	diskUsage   *cproto.DiskUsage
+	diskUsageAt time.Time
 }
 
 // New creates a new control protocol server.
@@ -132,7 +145,32 @@ func (s *Server) Version(_ context.Context, _ *cproto.Empty) (*cproto.VersionRes
 // State returns the overall state of the agent.
 func (s *Server) State(_ context.Context, _ *cproto.Empty) (*cproto.StateResponse, error) {
 	state := s.coord.State()
-	return stateToProto(&state, s.agentInfo)
+	resp, err := stateToProto(&state, s.agentInfo)
+	if err != nil {
+		return nil, err
+	}
+	resp.DiskUsage = s.getDiskUsage()
+	return resp, nil
+}
+
+// getDiskUsage returns the disk usage of the installed versions and downloads, nil if it cannot be computed.
+func (s *Server) getDiskUsage() *cproto.DiskUsage {
+	s.diskUsageMx.Lock()
+	defer s.diskUsageMx.Unlock()
+
+	if s.diskUsage != nil && time.Since(s.diskUsageAt) < diskUsageCacheTTL {
+		return s.diskUsage
+	}
+
+	usage, err := upgrade.GetDiskUsage(paths.Top(), paths.Downloads())
+	if err != nil {
+		s.logger.Debugw("Unable to compute disk usage", "error.message", err)
+		return nil
+	}
+
+	s.diskUsage = diskUsageToProto(usage)
+	s.diskUsageAt = time.Now()
+	return s.diskUsage
 }
 
 // StateWatch streams the current state of the Elastic Agent to the client.
@@ -160,6 +198,35 @@ func (s *Server) StateWatch(_ *cproto.Empty, srv cproto.ElasticAgentControl_Stat
 	}
 }
 
+// UpgradeWatch streams the upgrade details of the Elastic Agent to the client, sending only the transitions.
+func (s *Server) UpgradeWatch(_ *cproto.Empty, srv cproto.ElasticAgentControl_UpgradeWatchServer) error {
+	ctx := srv.Context()
+	subChan := s.coord.StateSubscribe(ctx, 32)
+	var last *cproto.UpgradeDetails
+	first := true
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case state, ok := <-subChan:
+			if !ok {
+				// the coordinator shut down, the client reconnects once the Elastic Agent is restarted
+				return nil
+			}
+			upgradeDetails := upgradeDetailsToProto(state.UpgradeDetails)
+			if !first && protobuf.Equal(last, upgradeDetails) {
+				continue
+			}
+			first = false
+			last = upgradeDetails
+			err := srv.Send(&cproto.UpgradeWatchResponse{UpgradeDetails: upgradeDetails})
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
 // Restart performs re-exec.
 func (s *Server) Restart(_ context.Context, _ *cproto.Empty) (*cproto.RestartResponse, error) {
 	s.coord.ReExec(nil)
@@ -184,6 +251,68 @@ func (s *Server) Upgrade(ctx context.Context, request *cproto.UpgradeRequest) (*
 	}, nil
 }
 
+// Rollback switches the running Elastic Agent back to a previously installed version.
+func (s *Server) Rollback(ctx context.Context, request *cproto.RollbackRequest) (*cproto.RollbackResponse, error) {
+	version, err := s.coord.Rollback(ctx, request.Version)
+	if err != nil {
+		//nolint:nilerr // ignore the error, return a failure rollback response
+		return &cproto.RollbackResponse{
+			Status: cproto.ActionStatus_FAILURE,
+			Error:  err.Error(),
+		}, nil
+	}
+	return &cproto.RollbackResponse{
+		Status:  cproto.ActionStatus_SUCCESS,
+		Version: version,
+	}, nil
+}
+
+// CancelAction cancels a Fleet action waiting in the action queue.
+func (s *Server) CancelAction(ctx context.Context, request *cproto.CancelActionRequest) (*cproto.CancelActionResponse, error) {
+	if err := s.coord.CancelQueuedAction(ctx, request.Id); err != nil {
+		//nolint:nilerr // ignore the error, return a failure cancel action response
+		return &cproto.CancelActionResponse{
+			Status: cproto.ActionStatus_FAILURE,
+			Error:  err.Error(),
+		}, nil
+	}
+	return &cproto.CancelActionResponse{
+		Status: cproto.ActionStatus_SUCCESS,
+	}, nil
+}
+
+// ActionHistory returns the lifecycle of the Fleet actions recorded in the action journal.
+func (s *Server) ActionHistory(_ context.Context, request *cproto.ActionHistoryRequest) (*cproto.ActionHistoryResponse, error) {
+	filter := journal.Filter{
+		ActionID:   request.ActionId,
+		ActionType: request.ActionType,
+		Status:     journal.Status(request.Status),
+		Limit:      int(request.Limit),
+	}
+	if request.Since != nil {
+		filter.Since = request.Since.AsTime()
+	}
+	if request.Until != nil {
+		filter.Until = request.Until.AsTime()
+	}
+
+	entries, err := s.coord.ActionHistory(filter)
+	if err != nil {
+		return nil, err
+	}
+	return &cproto.ActionHistoryResponse{Entries: actionHistoryToProto(entries)}, nil
+}
+
+// ComponentModel returns the component model of the running Elastic Agent, and the components
+// blocked by the capabilities.
+func (s *Server) ComponentModel(_ context.Context, _ *cproto.Empty) (*cproto.ComponentModelResponse, error) {
+	comps, blocked := s.coord.ComponentModel()
+	return &cproto.ComponentModelResponse{
+		Components:            componentModelToProto(comps),
+		BlockedByCapabilities: componentModelToProto(blocked),
+	}, nil
+}
+
 // DiagnosticAgent returns diagnostic information for this running Elastic Agent.
 func (s *Server) DiagnosticAgent(ctx context.Context, req *cproto.DiagnosticAgentRequest) (*cproto.DiagnosticAgentResponse, error) {
 	res := make([]*cproto.DiagnosticFileResult, 0, len(s.diagHooks))
@@ -363,33 +492,6 @@ func stateToProto(state *coordinator.State, agentInfo info.Agent) (*cproto.State
 		})
 	}
 
-	var upgradeDetails *cproto.UpgradeDetails
-	if state.UpgradeDetails != nil {
-		upgradeDetails = &cproto.UpgradeDetails{
-			TargetVersion: state.UpgradeDetails.TargetVersion,
-			State:         string(state.UpgradeDetails.State),
-			ActionId:      state.UpgradeDetails.ActionID,
-			Metadata: &cproto.UpgradeDetailsMetadata{
-				DownloadPercent: float32(state.UpgradeDetails.Metadata.DownloadPercent),
-				FailedState:     string(state.UpgradeDetails.Metadata.FailedState),
-				ErrorMsg:        state.UpgradeDetails.Metadata.ErrorMsg,
-				RetryErrorMsg:   state.UpgradeDetails.Metadata.RetryErrorMsg,
-			},
-		}
-
-		if state.UpgradeDetails.Metadata.ScheduledAt != nil &&
-			!state.UpgradeDetails.Metadata.ScheduledAt.IsZero() {
-			upgradeDetails.Metadata.ScheduledAt = state.UpgradeDetails.Metadata.ScheduledAt.Format(control.TimeFormat())
-
-		}
-
-		if state.UpgradeDetails.Metadata.RetryUntil != nil &&
-			!state.UpgradeDetails.Metadata.RetryUntil.IsZero() {
-			upgradeDetails.Metadata.RetryUntil = state.UpgradeDetails.Metadata.RetryUntil.Format(control.TimeFormat())
-
-		}
-	}
-
 	return &cproto.StateResponse{
 		Info: &cproto.StateAgentInfo{
 			Id:        agentInfo.AgentID(),
@@ -404,6 +506,124 @@ func stateToProto(state *coordinator.State, agentInfo info.Agent) (*cproto.State
 		FleetState:     state.FleetState,
 		FleetMessage:   state.FleetMessage,
 		Components:     components,
-		UpgradeDetails: upgradeDetails,
+		UpgradeDetails: upgradeDetailsToProto(state.UpgradeDetails),
+		QueuedActions:  queuedActionsToProto(state.QueuedActions),
 	}, nil
 }
+
+func actionHistoryToProto(entries []journal.Entry) []*cproto.ActionHistoryEntry {
+	history := make([]*cproto.ActionHistoryEntry, 0, len(entries))
+	for _, e := range entries {
+		history = append(history, &cproto.ActionHistoryEntry{
+			Timestamp:    timestamppb.New(e.Timestamp),
+			ActionId:     e.ActionID,
+			ActionType:   e.ActionType,
+			Status:       string(e.Status),
+			Error:        e.Error,
+			RetryAttempt: int32(e.RetryAttempt),
+		})
+	}
+	return history
+}
+
+func componentModelToProto(comps []component.Component) []*cproto.ComponentModelComponent {
+	model := make([]*cproto.ComponentModelComponent, 0, len(comps))
+	for _, c := range comps {
+		pc := &cproto.ComponentModelComponent{
+			Id:         c.ID,
+			InputType:  c.InputType,
+			OutputType: c.OutputType,
+			Error:      c.ErrMsg,
+			Units:      make([]*cproto.ComponentModelUnit, 0, len(c.Units)),
+		}
+		if c.Err != nil {
+			pc.Error = c.Err.Error()
+		}
+		for _, u := range c.Units {
+			pu := &cproto.ComponentModelUnit{
+				Id:       u.ID,
+				Type:     cproto.UnitType(u.Type),
+				LogLevel: u.LogLevel.String(),
+			}
+			if u.Config != nil {
+				pu.Config = u.Config.GetSource()
+			}
+			if u.Err != nil {
+				pu.Error = u.Err.Error()
+			}
+			pc.Units = append(pc.Units, pu)
+		}
+		model = append(model, pc)
+	}
+	return model
+}
+
+func queuedActionsToProto(actions []coordinator.QueuedAction) []*cproto.QueuedAction {
+	if len(actions) == 0 {
+		return nil
+	}
+
+	queued := make([]*cproto.QueuedAction, 0, len(actions))
+	for _, a := range actions {
+		q := &cproto.QueuedAction{
+			Id:           a.ID,
+			Type:         a.Type,
+			StartTime:    a.StartTime.Format(control.TimeFormat()),
+			RetryAttempt: int32(a.RetryAttempt),
+		}
+		if !a.Expiration.IsZero() {
+			q.Expiration = a.Expiration.Format(control.TimeFormat())
+		}
+		queued = append(queued, q)
+	}
+	return queued
+}
+
+func upgradeDetailsToProto(det *details.Details) *cproto.UpgradeDetails {
+	if det == nil {
+		return nil
+	}
+
+	upgradeDetails := &cproto.UpgradeDetails{
+		TargetVersion: det.TargetVersion,
+		State:         string(det.State),
+		ActionId:      det.ActionID,
+		Metadata: &cproto.UpgradeDetailsMetadata{
+			DownloadPercent: float32(det.Metadata.DownloadPercent),
+			DownloadRate:    float64(det.Metadata.DownloadRate),
+			FailedState:     string(det.Metadata.FailedState),
+			ErrorMsg:        det.Metadata.ErrorMsg,
+			RetryErrorMsg:   det.Metadata.RetryErrorMsg,
+		},
+	}
+
+	if det.Metadata.ScheduledAt != nil &&
+		!det.Metadata.ScheduledAt.IsZero() {
+		upgradeDetails.Metadata.ScheduledAt = det.Metadata.ScheduledAt.Format(control.TimeFormat())
+	}
+
+	if det.Metadata.RetryUntil != nil &&
+		!det.Metadata.RetryUntil.IsZero() {
+		upgradeDetails.Metadata.RetryUntil = det.Metadata.RetryUntil.Format(control.TimeFormat())
+	}
+
+	return upgradeDetails
+}
+
+func diskUsageToProto(usage *upgrade.DiskUsage) *cproto.DiskUsage {
+	versions := make([]*cproto.VersionDiskUsage, 0, len(usage.Versions))
+	for _, v := range usage.Versions {
+		versions = append(versions, &cproto.VersionDiskUsage{
+			Version:       v.Version,
+			Hash:          v.Hash,
+			VersionedHome: v.VersionedHome,
+			Current:       v.Current,
+			Size:          v.Size,
+		})
+	}
+	return &cproto.DiskUsage{
+		Versions:  versions,
+		Downloads: usage.Downloads,
+		Total:     usage.Total,
+	}
+}
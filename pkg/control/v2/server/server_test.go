@@ -5,14 +5,18 @@
 package server
 
 import (
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
 
 	"github.com/elastic/elastic-agent-client/v7/pkg/client"
+	"github.com/elastic/elastic-agent-client/v7/pkg/proto"
 	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/actions/journal"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/coordinator"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/info"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/details"
@@ -191,3 +195,63 @@ func TestStateMapping(t *testing.T) {
 		})
 	}
 }
+
+func TestQueuedActionsToProto(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+	actions := []coordinator.QueuedAction{
+		{ID: "diagnostics", Type: "REQUEST_DIAGNOSTICS", StartTime: start},
+		{ID: "upgrade", Type: "UPGRADE", StartTime: start, Expiration: start.Add(time.Hour), RetryAttempt: 2},
+	}
+
+	assert.Nil(t, queuedActionsToProto(nil))
+	assert.Equal(t, []*cproto.QueuedAction{
+		{Id: "diagnostics", Type: "REQUEST_DIAGNOSTICS", StartTime: start.Format(control.TimeFormat())},
+		{Id: "upgrade", Type: "UPGRADE", StartTime: start.Format(control.TimeFormat()), Expiration: start.Add(time.Hour).Format(control.TimeFormat()), RetryAttempt: 2},
+	}, queuedActionsToProto(actions))
+}
+
+func TestActionHistoryToProto(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+	history := actionHistoryToProto([]journal.Entry{
+		{Timestamp: ts, ActionID: "upgrade", ActionType: "UPGRADE", Status: journal.StatusFailed, Error: "download failed", RetryAttempt: 1},
+	})
+
+	require.Len(t, history, 1)
+	assert.Equal(t, ts, history[0].Timestamp.AsTime())
+	assert.Equal(t, "upgrade", history[0].ActionId)
+	assert.Equal(t, "UPGRADE", history[0].ActionType)
+	assert.Equal(t, "failed", history[0].Status)
+	assert.Equal(t, "download failed", history[0].Error)
+	assert.Equal(t, int32(1), history[0].RetryAttempt)
+}
+
+func TestComponentModelToProto(t *testing.T) {
+	source, err := structpb.NewStruct(map[string]interface{}{"paths": []interface{}{"/var/log/*.log"}})
+	require.NoError(t, err)
+	model := componentModelToProto([]component.Component{
+		{
+			ID:         "filestream-default",
+			InputType:  "filestream",
+			OutputType: "elasticsearch",
+			Units: []component.Unit{
+				{ID: "filestream-default-logs", Type: client.UnitTypeInput, LogLevel: client.UnitLogLevelDebug, Config: &proto.UnitExpectedConfig{Source: source}},
+				{ID: "filestream-default", Type: client.UnitTypeOutput, LogLevel: client.UnitLogLevelInfo, Err: errors.New("invalid output")},
+			},
+		},
+		{ID: "endpoint-default", Err: errors.New("unsupported platform")},
+	})
+
+	require.Len(t, model, 2)
+	assert.Equal(t, "filestream-default", model[0].Id)
+	assert.Equal(t, "filestream", model[0].InputType)
+	assert.Equal(t, "elasticsearch", model[0].OutputType)
+	require.Len(t, model[0].Units, 2)
+	assert.Equal(t, cproto.UnitType_INPUT, model[0].Units[0].Type)
+	assert.Equal(t, "debug", model[0].Units[0].LogLevel)
+	assert.Equal(t, map[string]interface{}{"paths": []interface{}{"/var/log/*.log"}}, model[0].Units[0].Config.AsMap())
+	assert.Equal(t, cproto.UnitType_OUTPUT, model[0].Units[1].Type)
+	assert.Nil(t, model[0].Units[1].Config)
+	assert.Equal(t, "invalid output", model[0].Units[1].Error)
+	assert.Equal(t, "unsupported platform", model[1].Error)
+	assert.Empty(t, model[1].Units)
+}
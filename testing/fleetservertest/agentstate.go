@@ -0,0 +1,117 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package fleetservertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
+)
+
+// AgentState keeps the state the agent reports on checkin, applying
+// delta-encoded checkins on top of the last full state. Use it with
+// NewHandlerCheckinWithState.
+type AgentState struct {
+	// mu is the mutex for any read or write operation on any of the
+	// AgentState properties.
+	mu sync.Mutex
+
+	state  *fleetapi.CheckinRequest
+	hash   string
+	resync bool
+
+	fullCheckins  int
+	deltaCheckins int
+}
+
+// NewAgentState returns a new AgentState without any state, thus the first
+// delta checkin will be answered with a resync request.
+func NewAgentState() *AgentState {
+	return &AgentState{}
+}
+
+// State returns the last full state reported by the agent and its hash, or
+// nil if the agent hasn't reported its full state yet.
+func (s *AgentState) State() (*fleetapi.CheckinRequest, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.state, s.hash
+}
+
+// Checkins returns how many full and delta checkins were received.
+func (s *AgentState) Checkins() (full int, delta int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.fullCheckins, s.deltaCheckins
+}
+
+// RequestResync makes the next checkin response request the agent to send
+// its full state.
+func (s *AgentState) RequestResync() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.resync = true
+}
+
+// Checkin updates the state with checkinRequest. It returns the hash of the
+// state held after the checkin and whether the agent must send its full state
+// on the next checkin. A delta checkin not applying to the state held, or
+// resulting in a state with a different hash than the agent's, drops the state
+// and requests a resync.
+func (s *AgentState) Checkin(checkinRequest CheckinRequest) (string, bool, *HTTPError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	raw, err := json.Marshal(checkinRequest)
+	if err != nil {
+		return "", false, &HTTPError{
+			StatusCode: http.StatusInternalServerError,
+			Message:    fmt.Sprintf("failed to encode CheckinRequest: %v", err),
+		}
+	}
+	req := &fleetapi.CheckinRequest{}
+	if err := json.Unmarshal(raw, req); err != nil {
+		return "", false, &HTTPError{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("invalid checkin state: %v", err),
+		}
+	}
+
+	resync := s.resync
+	s.resync = false
+
+	delta := req.Delta
+	if delta {
+		s.deltaCheckins++
+		if s.state == nil || req.BaseStateHash != s.hash {
+			s.state, s.hash = nil, ""
+			return "", true, nil
+		}
+		req = applyCheckinDelta(s.state, req)
+	} else {
+		s.fullCheckins++
+	}
+
+	hash, err := fleetapi.CheckinStateHash(req)
+	if err != nil {
+		return "", false, &HTTPError{
+			StatusCode: http.StatusInternalServerError,
+			Message:    fmt.Sprintf("failed to hash checkin state: %v", err),
+		}
+	}
+	if delta && hash != req.StateHash {
+		s.state, s.hash = nil, ""
+		return "", true, nil
+	}
+
+	s.state, s.hash = req, hash
+	return hash, resync, nil
+}
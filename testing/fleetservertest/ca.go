@@ -0,0 +1,171 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package fleetservertest
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"sync"
+	"time"
+)
+
+type ctxClientCertificateKey struct{}
+
+// CA is a fake certificate authority. It signs the certificate of the mock
+// Fleet Server when using mutual TLS and the client certificates of the agents
+// from their certificate signing requests on enroll and certificate renewal.
+type CA struct {
+	// Certificate is the CA certificate.
+	Certificate *x509.Certificate
+	// CertificatePEM is the PEM encoded CA certificate, ready to be used as the
+	// Fleet Server CA by the agent.
+	CertificatePEM []byte
+	// Validity is the validity of the client certificates signed by the CA.
+	// Defaults to 24h.
+	Validity time.Duration
+
+	key *ecdsa.PrivateKey
+
+	mu     sync.Mutex
+	serial int64
+}
+
+// NewCA returns a new self-signed CA.
+func NewCA() (*CA, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate CA key: %w", err)
+	}
+
+	now := timeNow()
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "fleet-server-test CA"},
+		NotBefore:             now.Add(-time.Minute),
+		NotAfter:              now.Add(24 * time.Hour * 365),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("could not create CA certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse CA certificate: %w", err)
+	}
+
+	return &CA{
+		Certificate:    cert,
+		CertificatePEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		Validity:       24 * time.Hour,
+		key:            key,
+		serial:         1,
+	}, nil
+}
+
+// CertPool returns a pool containing the CA certificate.
+func (ca *CA) CertPool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Certificate)
+	return pool
+}
+
+// SignCSR signs the PEM encoded certificate signing request and returns the
+// PEM encoded client certificate, valid for ca.Validity.
+func (ca *CA) SignCSR(csrPEM string) (string, error) {
+	block, _ := pem.Decode([]byte(csrPEM))
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return "", errors.New("invalid certificate signing request: no PEM CERTIFICATE REQUEST block")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return "", fmt.Errorf("invalid certificate signing request: %w", err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return "", fmt.Errorf("invalid certificate signing request signature: %w", err)
+	}
+
+	now := timeNow()
+	template := &x509.Certificate{
+		SerialNumber: ca.nextSerial(),
+		Subject:      csr.Subject,
+		NotBefore:    now.Add(-time.Minute),
+		NotAfter:     now.Add(ca.Validity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.Certificate, csr.PublicKey, ca.key)
+	if err != nil {
+		return "", fmt.Errorf("could not sign certificate: %w", err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})), nil
+}
+
+// ServerTLSConfig returns a TLS configuration for the mock Fleet Server with a
+// certificate valid for localhost and the loopback addresses. The client
+// certificates signed by the CA are verified if given, as the agent enrolls
+// without client certificate.
+func (ca *CA) ServerTLSConfig() (*tls.Config, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate server key: %w", err)
+	}
+
+	now := timeNow()
+	template := &x509.Certificate{
+		SerialNumber: ca.nextSerial(),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+		NotBefore:    now.Add(-time.Minute),
+		NotAfter:     now.Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.Certificate, &key.PublicKey, ca.key)
+	if err != nil {
+		return nil, fmt.Errorf("could not create server certificate: %w", err)
+	}
+
+	return &tls.Config{ //nolint:gosec // it's a test
+		Certificates: []tls.Certificate{{
+			Certificate: [][]byte{der},
+			PrivateKey:  key,
+		}},
+		ClientAuth: tls.VerifyClientCertIfGiven,
+		ClientCAs:  ca.CertPool(),
+	}, nil
+}
+
+func (ca *CA) nextSerial() *big.Int {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	ca.serial++
+	return big.NewInt(ca.serial)
+}
+
+// ClientCertificateFromCtx returns the verified client certificate the agent
+// sent with the request or nil if none was sent.
+func ClientCertificateFromCtx(ctx context.Context) *x509.Certificate {
+	cert, _ := ctx.Value(ctxClientCertificateKey{}).(*x509.Certificate)
+	return cert
+}
+
+func clientCertificateWithCtx(ctx context.Context, cert *x509.Certificate) context.Context {
+	return context.WithValue(ctx, ctxClientCertificateKey{}, cert)
+}
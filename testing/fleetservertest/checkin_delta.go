@@ -0,0 +1,94 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package fleetservertest
+
+import (
+	"sort"
+
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
+)
+
+// applyCheckinDelta returns the full checkin request resulting from applying
+// the delta checkin request to the full checkin request base, as fleet-server
+// does. It is the reverse of fleetapi.NewCheckinDelta.
+func applyCheckinDelta(base, delta *fleetapi.CheckinRequest) *fleetapi.CheckinRequest {
+	full := &fleetapi.CheckinRequest{
+		Status:         delta.Status,
+		AckToken:       delta.AckToken,
+		Metadata:       base.Metadata,
+		Message:        delta.Message,
+		UpgradeDetails: delta.UpgradeDetails,
+		StateHash:      delta.StateHash,
+	}
+	if delta.Metadata != nil {
+		full.Metadata = delta.Metadata
+	}
+
+	changed := make(map[string]fleetapi.CheckinComponent, len(delta.Components))
+	for _, c := range delta.Components {
+		changed[c.ID] = c
+	}
+
+	for _, c := range base.Components {
+		d, ok := changed[c.ID]
+		if !ok {
+			full.Components = append(full.Components, c)
+			continue
+		}
+		delete(changed, c.ID)
+		if d.Removed {
+			continue
+		}
+		d.Units = applyUnitsDelta(c.Units, d.Units)
+		full.Components = append(full.Components, d)
+	}
+
+	// remaining components were added
+	added := make([]fleetapi.CheckinComponent, 0, len(changed))
+	for _, c := range changed {
+		if !c.Removed {
+			added = append(added, c)
+		}
+	}
+	sort.Slice(added, func(i, j int) bool { return added[i].ID < added[j].ID })
+	full.Components = append(full.Components, added...)
+
+	return full
+}
+
+func applyUnitsDelta(base, delta []fleetapi.CheckinUnit) []fleetapi.CheckinUnit {
+	changed := make(map[string]fleetapi.CheckinUnit, len(delta))
+	for _, u := range delta {
+		changed[unitKey(u)] = u
+	}
+
+	var units []fleetapi.CheckinUnit
+	for _, u := range base {
+		d, ok := changed[unitKey(u)]
+		if !ok {
+			units = append(units, u)
+			continue
+		}
+		delete(changed, unitKey(u))
+		if !d.Removed {
+			units = append(units, d)
+		}
+	}
+
+	added := make([]fleetapi.CheckinUnit, 0, len(changed))
+	for _, u := range changed {
+		if !u.Removed {
+			added = append(added, u)
+		}
+	}
+	sort.Slice(added, func(i, j int) bool { return unitKey(added[i]) < unitKey(added[j]) })
+	units = append(units, added...)
+
+	return units
+}
+
+func unitKey(u fleetapi.CheckinUnit) string {
+	return u.Type + "/" + u.ID
+}
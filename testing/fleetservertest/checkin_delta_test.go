@@ -0,0 +1,50 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package fleetservertest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/info"
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
+)
+
+func TestApplyCheckinDelta(t *testing.T) {
+	base := &fleetapi.CheckinRequest{
+		Status:   "online",
+		Message:  "Running",
+		Metadata: &info.ECSMeta{Host: &info.HostECSMeta{Hostname: "host"}},
+		Components: []fleetapi.CheckinComponent{
+			{ID: "log-default", Type: "log", Status: "HEALTHY", Units: []fleetapi.CheckinUnit{
+				{ID: "log-default", Type: "output", Status: "HEALTHY"},
+				{ID: "log-default-logfile", Type: "input", Status: "HEALTHY"},
+			}},
+			{ID: "system/metrics-default", Type: "system/metrics", Status: "HEALTHY", Units: []fleetapi.CheckinUnit{
+				{ID: "system/metrics-default", Type: "output", Status: "HEALTHY"},
+			}},
+		},
+	}
+	current := &fleetapi.CheckinRequest{
+		Status:   "online",
+		Message:  "Running",
+		Metadata: &info.ECSMeta{Host: &info.HostECSMeta{Hostname: "host"}},
+		Components: []fleetapi.CheckinComponent{
+			{ID: "log-default", Type: "log", Status: "DEGRADED", Units: []fleetapi.CheckinUnit{
+				{ID: "log-default-logfile", Type: "input", Status: "DEGRADED", Message: "file not found"},
+				{ID: "log-default", Type: "output", Status: "HEALTHY"},
+			}},
+			{ID: "filestream-default", Type: "filestream", Status: "STARTING"},
+		},
+	}
+
+	currentHash, err := fleetapi.CheckinStateHash(current)
+	require.NoError(t, err)
+	appliedHash, err := fleetapi.CheckinStateHash(applyCheckinDelta(base, fleetapi.NewCheckinDelta(base, current)))
+	require.NoError(t, err)
+	assert.Equal(t, currentHash, appliedHash, "applying the delta must give back the current state")
+}
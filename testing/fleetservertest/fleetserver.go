@@ -31,6 +31,7 @@ type options struct {
 	address string
 	logFn   func(format string, a ...any)
 	agentID string
+	ca      *CA
 }
 
 // NewServerWithHandlers returns a Fleet Server ready for use to Agent's
@@ -69,6 +70,7 @@ func NewServerWithHandlers(
 // By default, it binds to all network interfaces, thus Server.URL is in the form
 // of http://[::]:PORT, not valid to be used directly. Use Server.LocalhostURL
 // for a URL using localhost or Server.Port to get the port it is listening on.
+// With WithMutualTLS the server uses HTTPS instead.
 func NewServer(h *Handlers, opts ...Option) *Server {
 	if h.logFn == nil {
 		h.logFn = func(format string, a ...any) {}
@@ -85,6 +87,9 @@ func NewServer(h *Handlers, opts ...Option) *Server {
 	if optns.agentID != "" {
 		h.AgentID = optns.agentID
 	}
+	if optns.ca != nil {
+		h.CA = optns.ca
+	}
 
 	mux := NewRouter(h)
 
@@ -103,7 +108,18 @@ func NewServer(h *Handlers, opts ...Option) *Server {
 			Listener: l,
 			Config:   &http.Server{Handler: mux}}, //nolint:gosec // it's a test
 	}
-	s.Start()
+	scheme := "http"
+	if optns.ca != nil {
+		tlsCfg, err := optns.ca.ServerTLSConfig()
+		if err != nil {
+			panic(fmt.Sprintf("NewServer failed to create the TLS config: %v", err))
+		}
+		s.TLS = tlsCfg
+		s.StartTLS()
+		scheme = "https"
+	} else {
+		s.Start()
+	}
 
 	u, err := url.Parse(s.URL)
 	if err != nil {
@@ -111,7 +127,7 @@ func NewServer(h *Handlers, opts ...Option) *Server {
 	}
 
 	s.Port = u.Port()
-	s.LocalhostURL = "http://localhost:" + s.Port
+	s.LocalhostURL = scheme + "://localhost:" + s.Port
 
 	return &s
 }
@@ -140,3 +156,12 @@ func WithAgentID(id string) Option {
 		o.agentID = id
 	}
 }
+
+// WithMutualTLS sets the server to use HTTPS with a certificate signed by ca and
+// to verify the client certificates signed by ca. It also sets Handlers.CA, so
+// the enroll and certificate handlers sign the agent certificates with ca.
+func WithMutualTLS(ca *CA) Option {
+	return func(o *options) {
+		o.ca = ca
+	}
+}
@@ -14,9 +14,10 @@ import (
 )
 
 const (
-	PathAgentAcks    = "/api/fleet/agents/{id}/acks"
-	PathAgentCheckin = "/api/fleet/agents/{id}/checkin"
-	PathAgentEnroll  = "/api/fleet/agents/enroll"
+	PathAgentAcks        = "/api/fleet/agents/{id}/acks"
+	PathAgentCertificate = "/api/fleet/agents/{id}/certificate"
+	PathAgentCheckin     = "/api/fleet/agents/{id}/checkin"
+	PathAgentEnroll      = "/api/fleet/agents/enroll"
 
 	PathArtifact = "/api/fleet/artifacts/{id}/{sha2}"
 	PathStatus   = "/api/status"
@@ -30,6 +31,10 @@ func NewPathAgentAcks(agentID string) string {
 	return strings.Replace(PathAgentAcks, "{id}", agentID, 1)
 }
 
+func NewPathAgentCertificate(agentID string) string {
+	return strings.Replace(PathAgentCertificate, "{id}", agentID, 1)
+}
+
 func NewPathCheckin(agentID string) string {
 	return strings.Replace(PathAgentCheckin, "{id}", agentID, 1)
 }
@@ -121,7 +126,8 @@ func NewHandlerAckWithAcker(acker func(actionID string) (AckResponseItem, bool))
 // NewHandlerEnroll returns an enrol handler ready to be used. It ignores the
 // enrolment token. Its repose will use the provided agentID, policyID and apiKey
 // when building the EnrollResponse. It'll also set the agentID on Handlers, which
-// is accessible by the other handlers.
+// is accessible by the other handlers. If Handlers.CA is set, the certificate
+// signing request of the agent is signed and returned as its client certificate.
 func NewHandlerEnroll(agentID, policyID string, apiKey APIKey) func(
 	ctx context.Context,
 	h *Handlers,
@@ -155,6 +161,17 @@ func NewHandlerEnroll(agentID, policyID string, apiKey APIKey) func(
 			}
 		}
 
+		var certificate string
+		if h.CA != nil && enrollRequest.CSR != "" {
+			certificate, err = h.CA.SignCSR(enrollRequest.CSR)
+			if err != nil {
+				return nil, &HTTPError{
+					StatusCode: http.StatusBadRequest,
+					Message:    fmt.Sprintf("could not sign certificate: %v", err),
+				}
+			}
+		}
+
 		return &EnrollResponse{
 			Action: "created",
 			Item: EnrollResponseItem{
@@ -170,11 +187,50 @@ func NewHandlerEnroll(agentID, policyID string, apiKey APIKey) func(
 				AccessApiKey:         apiKey.Key,
 				Status:               "online",
 				Tags:                 enrollRequest.Metadata.Tags,
+				Certificate:          certificate,
 			},
 		}, nil
 	}
 }
 
+// NewHandlerCertificate returns a certificate renewal handler signing the
+// certificate signing request of the agent with Handlers.CA.
+func NewHandlerCertificate() func(
+	ctx context.Context,
+	h *Handlers,
+	agentID string,
+	certificateRequest CertificateRequest) (*CertificateResponse, *HTTPError) {
+	return func(
+		ctx context.Context,
+		h *Handlers,
+		agentID string,
+		certificateRequest CertificateRequest) (*CertificateResponse, *HTTPError) {
+
+		if agentID != h.AgentID {
+			return nil, &HTTPError{
+				StatusCode: http.StatusNotFound,
+				Message:    fmt.Sprintf("agent %q not found", agentID),
+			}
+		}
+		if h.CA == nil {
+			return nil, &HTTPError{
+				StatusCode: http.StatusNotImplemented,
+				Message:    "no CA configured to sign certificates",
+			}
+		}
+
+		certificate, err := h.CA.SignCSR(certificateRequest.CSR)
+		if err != nil {
+			return nil, &HTTPError{
+				StatusCode: http.StatusBadRequest,
+				Message:    fmt.Sprintf("could not sign certificate: %v", err),
+			}
+		}
+
+		return &CertificateResponse{Certificate: certificate}, nil
+	}
+}
+
 // CheckinAction is the actions to be sent on next checkin and the delay, how
 // long the handler will wait before sending the response.
 type CheckinAction struct {
@@ -240,6 +296,47 @@ func NewHandlerCheckin(next ActionsGenerator) func(
 	}
 }
 
+// NewHandlerCheckinWithState is like NewHandlerCheckin, but also keeps the
+// agent state reported on checkin in state, supporting delta-encoded checkins.
+func NewHandlerCheckinWithState(next ActionsGenerator, state *AgentState) func(
+	ctx context.Context,
+	h *Handlers,
+	agentID string,
+	userAgent string,
+	acceptEncoding string,
+	checkinRequest CheckinRequest) (*CheckinResponse, *HTTPError) {
+
+	checkin := NewHandlerCheckin(next)
+	return func(
+		ctx context.Context,
+		h *Handlers,
+		agentID string,
+		userAgent string,
+		acceptEncoding string,
+		checkinRequest CheckinRequest) (*CheckinResponse, *HTTPError) {
+		if agentID != h.AgentID {
+			return nil, &HTTPError{
+				StatusCode: http.StatusNotFound,
+				Message:    fmt.Sprintf("agent %q not found", agentID),
+			}
+		}
+
+		hash, resync, hErr := state.Checkin(checkinRequest)
+		if hErr != nil {
+			return nil, hErr
+		}
+
+		resp, hErr := checkin(ctx, h, agentID, userAgent, acceptEncoding, checkinRequest)
+		if hErr != nil {
+			return nil, hErr
+		}
+		resp.StateHash = hash
+		resp.Resync = resync
+
+		return resp, nil
+	}
+}
+
 func NewHandlerStatusHealthy() func(ctx context.Context, _ *Handlers) (*StatusResponse, *HTTPError) {
 	return func(ctx context.Context, _ *Handlers) (*StatusResponse, *HTTPError) {
 		return &StatusResponse{
@@ -117,6 +117,21 @@ type CheckinRequest struct {
 
 	// An optional timeout value that informs fleet-server of when a client will time out on it's checkin request. If not specified fleet-server will use the timeout values specified in the config (defaults to 5m polling and a 10m write timeout). The value, if specified is expected to be a string that is parsable by [time.ParseDuration](https://pkg.go.dev/time#ParseDuration). If specified fleet-server will set its poll timeout to `max(1m, poll_timeout-2m)` and its write timeout to `max(2m, poll_timout-1m)`.
 	PollTimeout string `json:"poll_timeout,omitempty"`
+
+	// An embedded JSON object with the details of an ongoing upgrade.
+	UpgradeDetails json.RawMessage `json:"upgrade_details,omitempty"`
+
+	// The agent, component and unit state transitions recorded while fleet-server was unreachable, oldest first.
+	StateHistory json.RawMessage `json:"state_history,omitempty"`
+
+	// Set when the components and local_metadata only hold the changes since the state identified by base_state_hash. Removed components and units have `removed` set.
+	Delta bool `json:"delta,omitempty"`
+
+	// The hash of the agent state the delta applies to.
+	BaseStateHash string `json:"base_state_hash,omitempty"`
+
+	// The hash of the full agent state after this checkin.
+	StateHash string `json:"state_hash,omitempty"`
 }
 type CheckinResponse struct {
 
@@ -128,6 +143,12 @@ type CheckinResponse struct {
 
 	// A list of actions that the agent must execute.
 	Actions []Action `json:"actions,omitempty"`
+
+	// The hash of the agent state fleet-server holds after the checkin.
+	StateHash string `json:"state_hash,omitempty"`
+
+	// Requests the agent to send its full state on the next checkin.
+	Resync bool `json:"resync,omitempty"`
 }
 
 // Action - An action for an elastic-agent. The actions are defined in generic terms on the fleet-server. The elastic-agent will have additional details for what is expected when a specific action-type is received. Many attributes in this schema also contain yaml tags so the elastic-agent may serialize them. The structure of the `data` attribute will vary between action types.  An additional consideration is Scheduled Actions. Scheduled actions are currently defined as actions that have non-empty values for both the `start_time` and `expiration` attributes.
@@ -190,6 +211,9 @@ type EnrollRequest struct {
 	SharedId string `json:"shared_id"`
 
 	Metadata EnrollMetadata `json:"metadata"`
+
+	// The PEM encoded certificate signing request for the client certificate of the agent.
+	CSR string `json:"csr,omitempty"`
 }
 
 // EnrollResponse - The enrollment action response.
@@ -239,6 +263,9 @@ type EnrollResponseItem struct {
 
 	// A copy of the tags that were sent with the enrollment request.
 	Tags []string `json:"tags"`
+
+	// The PEM encoded client certificate signed from the certificate signing request.
+	Certificate string `json:"certificate,omitempty"`
 }
 
 // EnrollMetadata - Metadata associated with the agent that is enrolling to fleet.
@@ -254,6 +281,24 @@ type EnrollMetadata struct {
 	Tags []string `json:"tags"`
 }
 
+// =============================================================================
+// ================================ Certificate ================================
+// =============================================================================
+
+// CertificateRequest - A request to renew the client certificate of the agent.
+type CertificateRequest struct {
+
+	// The PEM encoded certificate signing request for the new client certificate.
+	CSR string `json:"csr"`
+}
+
+// CertificateResponse - The renewed client certificate.
+type CertificateResponse struct {
+
+	// The PEM encoded client certificate signed from the certificate signing request.
+	Certificate string `json:"certificate"`
+}
+
 // =============================================================================
 // ================================== Status ===================================
 // =============================================================================
@@ -313,7 +358,7 @@ type UploadBeginResponse struct {
 type UploadBeginRequestFile struct {
 
 	// The algorithm used to compress the file. Valid values: br,gzip,deflate,none
-	Compression string `json:"Compression,omitempty"`
+	Compression string `json:"compression,omitempty"`
 
 	Hash Hash `json:"hash,omitempty"`
 
@@ -35,6 +35,10 @@ type Handlers struct {
 	APIKey          string
 	EnrollmentToken string
 
+	// CA, if set, signs the client certificates requested by the agent on
+	// enroll and certificate renewal.
+	CA *CA
+
 	// logFn if set will be used to log every request.
 	logFn func(format string, a ...any)
 
@@ -60,6 +64,12 @@ type Handlers struct {
 		enrollmentToken string,
 		enrollRequest EnrollRequest) (*EnrollResponse, *HTTPError)
 
+	CertificateFn func(
+		ctx context.Context,
+		h *Handlers,
+		agentID string,
+		certificateRequest CertificateRequest) (*CertificateResponse, *HTTPError)
+
 	ArtifactFn func(
 		ctx context.Context,
 		h *Handlers,
@@ -121,6 +131,10 @@ func NewRouter(handlers *Handlers) *mux.Router {
 
 					ww := &statusResponseWriter{w: w}
 
+					if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+						r = r.WithContext(clientCertificateWithCtx(r.Context(), r.TLS.PeerCertificates[0]))
+					}
+
 					requestID := uuid.New().String()
 					handlers.logFn("[%s] STARTING - %s %s %s %s\n",
 						requestID, r.Method, r.URL, r.Proto, r.RemoteAddr)
@@ -151,6 +165,13 @@ func (h *Handlers) Routes() []Route {
 			AuthKey: h.APIKey,
 			Handler: http.HandlerFunc(h.AgentCheckin),
 		},
+		{
+			Name:    "AgentCertificate",
+			Method:  http.MethodPost,
+			Pattern: PathAgentCertificate,
+			AuthKey: h.APIKey,
+			Handler: http.HandlerFunc(h.AgentCertificate),
+		},
 		{
 			Name:    "AgentEnroll",
 			Method:  http.MethodPost,
@@ -227,6 +248,37 @@ func (h *Handlers) AgentAcks(w http.ResponseWriter, r *http.Request) {
 	respondAsJSON(http.StatusOK, result, w)
 }
 
+// AgentCertificate -
+func (h *Handlers) AgentCertificate(w http.ResponseWriter, r *http.Request) {
+	if h.CertificateFn == nil {
+		err := &HTTPError{StatusCode: http.StatusNotImplemented,
+			Message: "agent certificate Handlers not implemented"}
+		respondAsJSON(err.StatusCode, err, w)
+		return
+	}
+
+	params := mux.Vars(r)
+	agentID := params["id"]
+
+	certificateRequestParam := CertificateRequest{}
+	d := json.NewDecoder(r.Body)
+	if err := d.Decode(&certificateRequestParam); err != nil {
+		respondAsJSON(http.StatusBadRequest, HTTPError{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("could not decode certificate request: %v", err),
+		}, w)
+		return
+	}
+
+	result, err := h.CertificateFn(r.Context(), h, agentID, certificateRequestParam)
+	if err != nil {
+		respondAsJSON(err.StatusCode, err, w)
+		return
+	}
+
+	respondAsJSON(http.StatusOK, result, w)
+}
+
 // AgentCheckin -
 func (h *Handlers) AgentCheckin(w http.ResponseWriter, r *http.Request) {
 	if h.CheckinFn == nil {
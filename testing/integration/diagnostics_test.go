@@ -31,6 +31,7 @@ const diagnosticsArchiveGlobPattern = "elastic-agent-diagnostics-*.zip"
 
 var diagnosticsFiles = []string{
 	"package.version",
+	"action-journal.yaml",
 	"allocs.pprof.gz",
 	"block.pprof.gz",
 	"components-actual.yaml",
@@ -41,6 +42,7 @@ var diagnosticsFiles = []string{
 	"local-config.yaml",
 	"mutex.pprof.gz",
 	"pre-config.yaml",
+	"redaction-manifest.yaml",
 	"local-config.yaml",
 	"state.yaml",
 	"threadcreate.pprof.gz",
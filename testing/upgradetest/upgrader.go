@@ -323,6 +323,11 @@ func PerformUpgrade(
 		upgradeCmdArgs = append(upgradeCmdArgs, "--skip-default-pgp")
 	}
 
+	if !startParsedVersion.Less(*Version_8_14_0_SNAPSHOT) {
+		// the upgrade watcher is checked below, do not wait for it in the upgrade command
+		upgradeCmdArgs = append(upgradeCmdArgs, "--detach")
+	}
+
 	upgradeOutput, err := startFixture.Exec(ctx, upgradeCmdArgs)
 	if err != nil {
 		return fmt.Errorf("failed to start agent upgrade to version %q: %w\n%s", endVersionInfo.Binary.Version, err, upgradeOutput)
@@ -38,6 +38,9 @@ var (
 	Version_8_13_0_SNAPSHOT = version.NewParsedSemVer(8, 13, 0, "SNAPSHOT", "")
 	// Version_8_13_0 is the minimum version for proper unprivileged execution
 	Version_8_13_0 = version.NewParsedSemVer(8, 13, 0, "", "")
+	// Version_8_14_0_SNAPSHOT is the minimum version for the upgrade command to wait for the upgrade to complete
+	// unless --detach is set
+	Version_8_14_0_SNAPSHOT = version.NewParsedSemVer(8, 14, 0, "SNAPSHOT", "")
 )
 
 // VersionRequirements is to set requirements for upgradable versions while fetching them.